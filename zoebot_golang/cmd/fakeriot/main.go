@@ -0,0 +1,70 @@
+// fakeriot serves a fake Riot API and a stub AI for running ZoeBot
+// offline. Point the bot at it with:
+//
+//	RIOT_API_KEY=fake
+//	RIOT_BASE_URL_ACCOUNT=http://localhost:8090
+//	RIOT_BASE_URL_MATCH=http://localhost:8090
+//	RIOT_BASE_URL_PLATFORM=http://localhost:8090
+//	CLIPROXY_API_KEY=fake
+//	CLIPROXY_API_URL=http://localhost:8090/v1/chat/completions
+//
+// and a local Redis (REDIS_URL=redis://localhost:6379). Then make a
+// tracked player finish a match and watch the next poll pick it up:
+//
+//	curl -X POST localhost:8090/_fake/matches -d '{"puuid": "...", "win": true}'
+//
+// See package fakeriot for the fixtures layout and the other /_fake/ routes.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/zoebot/internal/fakeriot"
+	"github.com/zoebot/internal/logging"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8090", "Listen address")
+	fixtures := flag.String("fixtures", "internal/fakeriot/testdata", "Fixtures directory (empty: start with no data)")
+	token := flag.String("token", "", "Required X-Riot-Token (default: any non-empty token)")
+	logLevel := flag.String("log-level", "info", "Log level; debug logs every request")
+	flag.Parse()
+
+	if _, err := logging.Setup(os.Stderr, *logLevel, "text"); err != nil {
+		fmt.Fprintln(os.Stderr, "logging:", err)
+		os.Exit(1)
+	}
+
+	server, err := fakeriot.New(*fixtures)
+	if err != nil {
+		slog.Error("Loading fixtures failed", "dir", *fixtures, "error", err)
+		os.Exit(1)
+	}
+	server.RequireToken(*token)
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           logRequests(server.Handler()),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	slog.Info("Fake Riot API listening", "addr", *addr, "fixtures", *fixtures)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// logRequests logs each request at debug level.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		slog.Debug("Request", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/services/riot"
+)
+
+const analyzeUsage = `Usage: zoebot analyze -match match.json -puuid PUUID [flags]
+
+Parses a saved match-v5 match (and optional timeline) exactly like the bot
+does and prints the AI user prompt. No Discord token is needed; -score ai
+needs CLIPROXY_API_KEY.
+
+Flags:
+`
+
+// analyzeOutput is the -format json document.
+type analyzeOutput struct {
+	Match  *riot.ParsedMatchData     `json:"match"`
+	Prompt string                    `json:"prompt"`
+	System string                    `json:"system_prompt,omitempty"`
+	Result *ai.AnalysisResult        `json:"result,omitempty"`
+	Embeds []*discordgo.MessageEmbed `json:"embeds,omitempty"`
+}
+
+// runAnalyze implements the "zoebot analyze" subcommand and returns the
+// exit code.
+func runAnalyze(args []string) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, analyzeUsage)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Config file (data.dir and ai settings are read from it)")
+	matchFile := fs.String("match", "", "match-v5 match JSON file")
+	timelineFile := fs.String("timeline", "", "match-v5 timeline JSON file (optional)")
+	puuid := fs.String("puuid", "", "PUUID of the player the analysis is for")
+	score := fs.String("score", "none", "Scorer to run: none, rules or ai")
+	format := fs.String("format", "text", "Output format: text or json")
+	showSystem := fs.Bool("system", false, "Also print the system prompt")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *matchFile == "" || *puuid == "" {
+		fs.Usage()
+		return 2
+	}
+	if *score != "none" && *score != "rules" && *score != "ai" {
+		fmt.Fprintf(os.Stderr, "analyze: unknown scorer %q\n", *score)
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "analyze: unknown format %q\n", *format)
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		return 1
+	}
+
+	// Champion tags and names come from the local game data
+	gameData := data.NewStore()
+	dataset, err := data.ResolveDataset(cfg.Data.Dir, cfg.Data.DDragonVersion)
+	if err == nil {
+		_, err = gameData.Load(dataset)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: game data:", err)
+	}
+	if _, err := ai.LoadPrompts(cfg.Data.PromptsDir); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: prompts:", err)
+	}
+
+	var match riot.MatchResponse
+	if err := readJSON(*matchFile, &match); err != nil {
+		fmt.Fprintln(os.Stderr, "analyze:", err)
+		return 1
+	}
+	var timeline *riot.TimelineResponse
+	if *timelineFile != "" {
+		timeline = &riot.TimelineResponse{}
+		if err := readJSON(*timelineFile, timeline); err != nil {
+			fmt.Fprintln(os.Stderr, "analyze:", err)
+			return 1
+		}
+	}
+
+	riotClient := riot.NewClient(cfg, nil, gameData)
+	matchData := riotClient.ParseMatchData(&match, *puuid, timeline)
+	if matchData == nil {
+		fmt.Fprintf(os.Stderr, "analyze: player %s is not in match %s\n", *puuid, match.Metadata.MatchID)
+		return 1
+	}
+
+	aiClient := ai.NewClient(cfg)
+	out := analyzeOutput{Match: matchData, Prompt: aiClient.UserPrompt(matchData)}
+	if *showSystem {
+		out.System = ai.ActiveSystemPrompt()
+	}
+
+	switch *score {
+	case "rules":
+		out.Result = ai.ScoreMatch(matchData)
+	case "ai":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		out.Result, err = aiClient.AnalyzeMatch(ctx, matchData)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "analyze: AI:", err)
+			return 1
+		}
+	}
+	if out.Result != nil {
+		// The same embeds the bot posts for the match and the detail button
+		out.Embeds = append(out.Embeds, embeds.CompactAnalysis(out.Result.Players, matchData))
+		for _, p := range out.Result.Players {
+			out.Embeds = append(out.Embeds, embeds.PlayerAnalysisEmbed(gameData, p, matchData))
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintln(os.Stderr, "analyze:", err)
+			return 1
+		}
+		return 0
+	}
+
+	writeAnalysisText(os.Stdout, out)
+	return 0
+}
+
+// writeAnalysisText prints the analysis for a terminal.
+func writeAnalysisText(w io.Writer, out analyzeOutput) {
+	m := out.Match
+	result := "THUA"
+	if m.Win {
+		result = "THẮNG"
+	}
+	fmt.Fprintf(w, "Match %s | %s | %.1f phút | %s | %s\n", m.MatchID, m.GameMode, m.GameDurationMinutes, result, m.TargetPlayerName)
+
+	if out.System != "" {
+		fmt.Fprintf(w, "\n===== SYSTEM PROMPT =====\n%s\n", out.System)
+	}
+	fmt.Fprintf(w, "\n===== USER PROMPT =====\n%s\n", out.Prompt)
+
+	for _, e := range out.Embeds {
+		fmt.Fprintln(w)
+		writeEmbedText(w, e)
+	}
+}
+
+// writeEmbedText prints an embed roughly as Discord lays it out.
+func writeEmbedText(w io.Writer, e *discordgo.MessageEmbed) {
+	fmt.Fprintf(w, "===== %s =====\n", e.Title)
+	if e.Description != "" {
+		fmt.Fprintln(w, e.Description)
+	}
+	if e.Thumbnail != nil {
+		fmt.Fprintf(w, "[thumbnail] %s\n", e.Thumbnail.URL)
+	}
+	for _, f := range e.Fields {
+		fmt.Fprintf(w, "\n## %s\n", f.Name)
+		fmt.Fprintln(w, strings.TrimSpace(f.Value))
+	}
+	if e.Footer != nil {
+		fmt.Fprintf(w, "\n-- %s\n", e.Footer.Text)
+	}
+}
+
+// readJSON decodes the JSON file at path into v.
+func readJSON(path string, v interface{}) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
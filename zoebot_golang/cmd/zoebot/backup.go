@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/storage"
+)
+
+const backupUsage = `Usage: zoebot backup [-o file] [-config file]
+       zoebot restore [-dry-run] [-config file] file
+
+backup writes the tracked players, match history and stored analyses to a
+JSON archive (default zoebot-backup-<time>.json, "-" for stdout). restore
+reads one back into the configured storage, on the same or a different
+backend, and migrates it to this build's schema. Stop the bot before
+restoring; the bolt backend can't be opened while it runs.
+
+Flags:
+`
+
+// openStorage opens the configured storage for a subcommand, refusing the
+// memory fallback of an unreachable Redis.
+func openStorage(configPath string) (*config.Config, *storage.Store, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: %w", err)
+	}
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("storage: %w", err)
+	}
+	if err := store.Ping(); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("storage: %w", err)
+	}
+	return cfg, store, nil
+}
+
+// runBackup implements the "zoebot backup" subcommand and returns the exit
+// code.
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, backupUsage)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Config file (storage and redis settings are read from it)")
+	output := fs.String("o", "", `Archive file ("-" for stdout)`)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, store, err := openStorage(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	archive, err := storage.Backup(store, cfg.Redis)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backup:", err)
+		return 1
+	}
+
+	path := *output
+	if path == "" {
+		path = "zoebot-backup-" + archive.CreatedAt.Format("20060102-150405") + ".json"
+	}
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "backup:", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(archive); err != nil {
+		fmt.Fprintln(os.Stderr, "backup:", err)
+		return 1
+	}
+	if path != "-" {
+		fmt.Printf("Wrote %s (%s)\n", path, archiveSummary(archive))
+	}
+	return 0
+}
+
+// runRestore implements the "zoebot restore" subcommand and returns the
+// exit code.
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, backupUsage)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Config file (storage and redis settings are read from it)")
+	dryRun := fs.Bool("dry-run", false, "Check the archive and show what would be restored")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	raw, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restore:", err)
+		return 1
+	}
+	var archive storage.Archive
+	if err := json.Unmarshal(raw, &archive); err != nil {
+		fmt.Fprintln(os.Stderr, "restore: invalid archive:", err)
+		return 1
+	}
+	fmt.Printf("Archive from %s (%s, schema %d): %s\n",
+		archive.CreatedAt.Local().Format(time.DateTime), archive.Backend, archive.SchemaVersion, archiveSummary(&archive))
+
+	cfg, store, err := openStorage(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	summary, err := storage.Restore(store, cfg.Redis, &archive, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restore:", err)
+		return 1
+	}
+	for _, m := range summary.Migrations {
+		fmt.Printf("Applied migration %d (%s)\n", m.Version, m.Name)
+	}
+	if *dryRun {
+		fmt.Printf("Dry run, nothing was written to %s\n", store.Name())
+		return 0
+	}
+	fmt.Printf("Restored into %s\n", store.Name())
+	return 0
+}
+
+// archiveSummary counts the entries of each section, e.g. "players 12,
+// analysis 3".
+func archiveSummary(archive *storage.Archive) string {
+	var parts []string
+	for section, records := range archive.Records {
+		parts = append(parts, fmt.Sprintf("%s %d", section, len(records)))
+	}
+	for family, values := range archive.Values {
+		parts = append(parts, fmt.Sprintf("%s %d", family, len(values)))
+	}
+	sort.Strings(parts)
+	if len(parts) == 0 {
+		return "empty"
+	}
+	return strings.Join(parts, ", ")
+}
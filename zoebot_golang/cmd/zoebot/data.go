@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
+)
+
+const dataUsage = `Usage: zoebot data <command> [flags]
+
+Commands:
+  sync [-version latest] [-locale vi_VN] [-force] [-use]
+        download a patch's game data into <data.dir>/versions/<version>
+  list  show synced versions and the active one
+  use <version>
+        make a synced version active on the next start
+        (or at runtime via /admin data use / POST /admin/data/activate)
+
+Common flags:
+  -config file   config file (data.dir is read from it)
+`
+
+// runData implements the "zoebot data" subcommand and returns the exit code.
+func runData(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, dataUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("data "+args[0], flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, dataUsage) }
+	configPath := fs.String("config", "", "Config file")
+	version := fs.String("version", "latest", "Data Dragon version to sync")
+	locale := fs.String("locale", data.DefaultLocale, "Locale of names and descriptions")
+	force := fs.Bool("force", false, "Replace an already synced version")
+	use := fs.Bool("use", false, "Make the synced version active")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		return 1
+	}
+	root := cfg.Data.Dir
+
+	switch args[0] {
+	case "sync":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Printf("Syncing %s (%s) into %s...\n", *version, *locale, root)
+		manifest, err := data.Sync(ctx, root, data.SyncOptions{Version: *version, Locale: *locale, Force: *force})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "sync:", err)
+			return 1
+		}
+		for name, file := range manifest.Files {
+			fmt.Printf("  %-22s %8d bytes  %s\n", name, file.Size, file.SHA256[:12])
+		}
+		fmt.Printf("Synced %s\n", manifest.Version)
+
+		if *use {
+			return useVersion(root, manifest.Version)
+		}
+		fmt.Printf("Run `zoebot data use %s` or /admin data use to activate it\n", manifest.Version)
+		return 0
+
+	case "list":
+		versions, err := data.Versions(root)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "list:", err)
+			return 1
+		}
+		current, err := data.CurrentVersion(root)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "list:", err)
+			return 1
+		}
+		if len(versions) == 0 {
+			fmt.Println("No synced versions; using the data files in", root)
+			return 0
+		}
+		for _, v := range versions {
+			marker := " "
+			if v == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, v)
+		}
+		return 0
+
+	case "use":
+		if fs.NArg() != 1 {
+			fmt.Fprint(os.Stderr, dataUsage)
+			return 2
+		}
+		return useVersion(root, fs.Arg(0))
+	}
+
+	fmt.Fprint(os.Stderr, dataUsage)
+	return 2
+}
+
+// useVersion marks a synced version as current.
+func useVersion(root, version string) int {
+	if _, err := data.OpenDataset(root, version); err != nil {
+		fmt.Fprintf(os.Stderr, "use: version %s is not synced: %v\n", version, err)
+		return 1
+	}
+	if err := data.SetCurrent(root, version); err != nil {
+		fmt.Fprintln(os.Stderr, "use:", err)
+		return 1
+	}
+	fmt.Printf("Active version is now %s\n", version)
+	return 0
+}
@@ -4,22 +4,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"syscall"
 	"time"
 
+	"github.com/zoebot/internal/admin"
 	"github.com/zoebot/internal/bot"
 	"github.com/zoebot/internal/config"
 	"github.com/zoebot/internal/data"
+	"github.com/zoebot/internal/logging"
+	"github.com/zoebot/internal/services/ai"
 	"github.com/zoebot/pkg/healthcheck"
+	"github.com/zoebot/pkg/metrics"
 )
 
 func init() {
@@ -35,104 +40,123 @@ func init() {
 }
 
 func main() {
-	// Health check flag for Docker
-	healthFlag := flag.Bool("health", false, "Run health check")
-	flag.Parse()
-
-	if *healthFlag {
-		if err := runHealthCheck(); err != nil {
-			os.Exit(1)
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "data":
+			os.Exit(runData(os.Args[2:]))
+		case "analyze":
+			os.Exit(runAnalyze(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "backup":
+			os.Exit(runBackup(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
 		}
-		os.Exit(0)
 	}
 
-	// Minimal logging - write directly to stdout for Docker
-	log.SetFlags(log.Ltime)
-	log.SetOutput(os.Stdout)
-	log.Println("Starting ZoeBot...")
-	log.Println("VERSION DEBUG: WITH REDIS LOGS AND FMT FIX")
+	// Health check flag for Docker (checks readiness, not just the process)
+	healthFlag := flag.Bool("health", false, "Run readiness check")
+	configPath := flag.String("config", "", "Config file (default $CONFIG_FILE or ./"+config.DefaultFile+" if present)")
+	flag.Parse()
 
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Fatalf("Config error: %v", err)
+		fatal("Config error", err)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		log.Fatalf("Config invalid: %v", err)
+	if *healthFlag {
+		if err := runHealthCheck(cfg.Health.Addr); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
-	// Load game data (items, perks, perk styles, and champions)
-	execPath, _ := os.Executable()
-	dataDir := filepath.Join(filepath.Dir(execPath), "data")
-	itemPath := filepath.Join(dataDir, "item.json")
-	perkPath := filepath.Join(dataDir, "perk.json")
-	perkStylePath := filepath.Join(dataDir, "perk-style.json")
-	championPath := filepath.Join(dataDir, "champion-summary.json")
-
-	// Try multiple paths for data files
-	if _, err := os.Stat(itemPath); os.IsNotExist(err) {
-		// Try relative to working directory
-		itemPath = "data/item.json"
-	}
-	if _, err := os.Stat(perkPath); os.IsNotExist(err) {
-		perkPath = "data/perk.json"
-	}
-	if _, err := os.Stat(perkStylePath); os.IsNotExist(err) {
-		perkStylePath = "data/perk-style.json"
-	}
-	if _, err := os.Stat(championPath); os.IsNotExist(err) {
-		championPath = "data/champion-summary.json"
+	if err := cfg.Validate(); err != nil {
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			for _, problem := range verr.Problems {
+				slog.Error("Config invalid", "problem", problem)
+			}
+			os.Exit(1)
+		}
+		fatal("Config invalid", err)
 	}
 
-	if items, err := data.LoadItems(itemPath); err != nil {
-		log.Printf("Warning: Could not load item data: %v", err)
-	} else {
-		log.Printf("Loaded %d items from %s", len(items), itemPath)
+	// Structured logging - write directly to stdout for Docker
+	if _, err := logging.Setup(os.Stdout, cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		fatal("Logging config invalid", err)
 	}
-	if perks, err := data.LoadPerks(perkPath); err != nil {
-		log.Printf("Warning: Could not load perk data: %v", err)
-	} else {
-		log.Printf("Loaded %d perks from %s", len(perks), perkPath)
+	slog.Info("Starting ZoeBot", "config_file", cfg.Path())
+
+	// Pick the local game data set; never touches the network
+	dataset, err := data.ResolveDataset(cfg.Data.Dir, cfg.Data.DDragonVersion)
+	if err != nil {
+		fatal("Game data error", err)
 	}
-	if styles, err := data.LoadPerkStyles(perkStylePath); err != nil {
-		log.Printf("Warning: Could not load perk style data: %v", err)
-	} else {
-		log.Printf("Loaded %d perk styles from %s", len(styles), perkStylePath)
+
+	// Load game data (items, perks, perk styles, champions and champion stats)
+	gameData := data.NewStore()
+	summary, err := gameData.Load(dataset)
+	if err != nil {
+		// The files that parsed are still used; /admin reload retries the rest
+		slog.Warn("Could not load all game data", "version", dataset.Version, "dir", dataset.Dir, "error", err)
 	}
-	if champions, err := data.LoadChampions(championPath); err != nil {
-		log.Printf("Warning: Could not load champion data: %v", err)
-	} else {
-		log.Printf("Loaded %d champions from %s", len(champions), championPath)
+	slog.Info("Loaded game data", "version", summary.Version, "dir", dataset.Dir,
+		"items", summary.Items, "perks", summary.Perks, "perk_styles", summary.PerkStyles,
+		"champions", summary.Champions, "champion_stats", summary.ChampionStats)
+
+	// Load AI prompt overrides, if any
+	if overridden, err := ai.LoadPrompts(cfg.Data.PromptsDir); err != nil {
+		slog.Warn("Could not load prompt overrides, using built-in prompts", "error", err)
+	} else if len(overridden) > 0 {
+		slog.Info("Loaded prompt overrides", "dir", cfg.Data.PromptsDir, "files", overridden)
 	}
 
 	// Create bot
-	discordBot, err := bot.New(cfg)
+	discordBot, err := bot.New(cfg, gameData)
 	if err != nil {
-		log.Fatalf("Bot error: %v", err)
+		fatal("Bot error", err)
 	}
 
 	// Start health check server (lightweight)
-	healthServer := healthcheck.New(":8080")
+	healthServer := healthcheck.New(cfg.Health.Addr)
+	discordBot.RegisterHealthChecks(healthServer)
+	healthServer.Handle("/metrics", metrics.Handler())
+	healthServer.Handle("/admin/", admin.Handler(discordBot, cfg.Admin.Token))
+	if cfg.Admin.Token != "" {
+		slog.Info("Admin API enabled", "path", "/admin/")
+	}
 	go func() {
 		if err := healthServer.Start(); err != nil && err != http.ErrServerClosed {
-			log.Printf("Health server error: %v", err)
+			slog.Error("Health server error", "error", err)
 		}
 	}()
 
 	// Start bot
 	if err := discordBot.Start(); err != nil {
-		log.Fatalf("Start error: %v", err)
+		fatal("Start error", err)
 	}
 
-	log.Println("ZoeBot running")
+	slog.Info("ZoeBot running")
+
+	// Reload safe settings on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			discordBot.ReloadConfig(logging.NewCorrelationID(context.Background(), "sighup"))
+		}
+	}()
 
 	// Wait for interrupt signal
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
-	log.Println("Shutting down...")
+	slog.Info("Shutting down")
 
 	// Graceful shutdown with short timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -141,13 +165,27 @@ func main() {
 	healthServer.Stop(ctx)
 	discordBot.Stop()
 
-	log.Println("Stopped")
+	slog.Info("Stopped")
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
-// runHealthCheck performs a quick health check
-func runHealthCheck() error {
+// runHealthCheck performs a quick readiness check
+func runHealthCheck(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
 	client := &http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get("http://localhost:8080/health")
+	resp, err := client.Get("http://" + net.JoinHostPort(host, port) + "/readyz")
 	if err != nil {
 		return err
 	}
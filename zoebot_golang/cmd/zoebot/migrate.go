@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zoebot/internal/storage"
+)
+
+const migrateUsage = `Usage: zoebot migrate [-dry-run] [-config file]
+
+Brings the stored data up to the schema of this build. The bot does this
+on startup too; run it by hand to check what an upgrade will change. The
+bolt backend can't be migrated while the bot is running.
+
+Flags:
+`
+
+// runMigrate implements the "zoebot migrate" subcommand and returns the
+// exit code.
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "Config file (storage and redis settings are read from it)")
+	dryRun := fs.Bool("dry-run", false, "Show what would change without writing anything")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, store, err := openStorage(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	current, err := storage.SchemaVersion(store.Backend())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	fmt.Printf("Storage %s at schema %d, this build uses %d\n", store.Name(), current, storage.LatestSchema())
+
+	results, err := storage.Migrate(store, cfg.Redis, *dryRun)
+	for _, res := range results {
+		fmt.Printf("%d. %s\n", res.Version, res.Name)
+		if len(res.Changes) == 0 {
+			fmt.Println("   (nothing to change)")
+		}
+		for _, change := range res.Changes {
+			fmt.Println("   -", change)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+
+	switch {
+	case len(results) == 0:
+		fmt.Println("Up to date")
+	case *dryRun:
+		fmt.Println("Dry run, nothing was written")
+	default:
+		fmt.Printf("Migrated to schema %d\n", storage.LatestSchema())
+	}
+	return 0
+}
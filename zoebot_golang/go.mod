@@ -7,6 +7,9 @@ require (
 	github.com/bwmarrin/discordgo v0.28.1
 	github.com/joho/godotenv v1.5.1
 	github.com/redis/go-redis/v9 v9.17.2
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/image v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,4 +20,5 @@ require (
 	golang.org/x/crypto v0.44.0 // indirect
 	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
 )
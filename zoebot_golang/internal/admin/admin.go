@@ -0,0 +1,366 @@
+// Package admin provides the token-protected operator HTTP API.
+//
+// It is mounted under /admin/ on the healthcheck server and calls the
+// same Bot operations as the slash commands:
+//
+//	GET    /admin/subscriptions               list tracked players
+//	POST   /admin/subscriptions               track {"riot_id", "channel_id"}
+//	PATCH  /admin/subscriptions/{puuid}       move {"channel_id"}
+//	DELETE /admin/subscriptions/{puuid}       untrack
+//	POST   /admin/subscriptions/{puuid}/poll  check for a new match now
+//	POST   /admin/analyze                     re-run {"match_id", "puuid"}
+//	GET    /admin/history/{puuid}             recorded matches [?from, to, queue, limit]
+//	DELETE /admin/cache/{family}[?key=...]    flush a cache family or key
+//	GET    /admin/queue                       poll loop and queue state
+//	POST   /admin/reload                      reload static data and prompts
+//	POST   /admin/config/reload               reload safe config settings
+//	GET    /admin/data                        active and synced game data versions
+//	POST   /admin/data/activate               switch game data {"version"}
+//	POST   /admin/broadcast                   notify channels {"message"}
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zoebot/internal/bot"
+	"github.com/zoebot/internal/logging"
+	"github.com/zoebot/internal/storage"
+)
+
+// slowTimeout is the write deadline for requests that call Riot or the AI
+// API, which can take far longer than the healthcheck server allows.
+const slowTimeout = 3 * time.Minute
+
+// Handler returns the admin API, authenticated with a bearer token.
+// An empty token disables the API: every request gets 404.
+func Handler(b *bot.Bot, token string) http.Handler {
+	if token == "" {
+		return http.NotFoundHandler()
+	}
+
+	a := &api{bot: b}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/subscriptions", a.listSubscriptions)
+	mux.HandleFunc("POST /admin/subscriptions", a.slow(a.track))
+	mux.HandleFunc("PATCH /admin/subscriptions/{puuid}", a.move)
+	mux.HandleFunc("DELETE /admin/subscriptions/{puuid}", a.untrack)
+	mux.HandleFunc("POST /admin/subscriptions/{puuid}/poll", a.slow(a.poll))
+	mux.HandleFunc("POST /admin/analyze", a.slow(a.analyze))
+	mux.HandleFunc("GET /admin/history/{puuid}", a.history)
+	mux.HandleFunc("DELETE /admin/cache/{family}", a.flushCache)
+	mux.HandleFunc("GET /admin/queue", a.queue)
+	mux.HandleFunc("POST /admin/reload", a.reload)
+	mux.HandleFunc("POST /admin/config/reload", a.reloadConfig)
+	mux.HandleFunc("GET /admin/data", a.listData)
+	mux.HandleFunc("POST /admin/data/activate", a.activateData)
+	mux.HandleFunc("POST /admin/broadcast", a.slow(a.broadcast))
+
+	return requireToken(token, mux)
+}
+
+// requireToken rejects requests without the expected bearer token.
+func requireToken(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type api struct {
+	bot *bot.Bot
+}
+
+// slow extends the write deadline for long-running requests.
+func (a *api) slow(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(slowTimeout))
+		h(w, r)
+	}
+}
+
+// requestContext returns a context correlated to this admin request.
+func requestContext(r *http.Request) context.Context {
+	return logging.NewCorrelationID(r.Context(), "admin")
+}
+
+func (a *api) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.bot.Subscriptions())
+}
+
+func (a *api) track(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RiotID    string `json:"riot_id"`
+		ChannelID string `json:"channel_id"`
+	}
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !isSnowflake(req.ChannelID) {
+		writeError(w, http.StatusBadRequest, errors.New("channel_id must be a Discord channel ID"))
+		return
+	}
+
+	player, err := a.bot.TrackPlayer(requestContext(r), req.RiotID, req.ChannelID)
+	if errors.Is(err, bot.ErrAlreadyTracked) {
+		writeJSON(w, http.StatusOK, player)
+		return
+	}
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, player)
+}
+
+func (a *api) move(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChannelID string `json:"channel_id"`
+	}
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !isSnowflake(req.ChannelID) {
+		writeError(w, http.StatusBadRequest, errors.New("channel_id must be a Discord channel ID"))
+		return
+	}
+
+	player, err := a.bot.MoveSubscription(requestContext(r), r.PathValue("puuid"), req.ChannelID)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, player)
+}
+
+func (a *api) untrack(w http.ResponseWriter, r *http.Request) {
+	player, err := a.bot.Unsubscribe(requestContext(r), r.PathValue("puuid"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, player)
+}
+
+func (a *api) poll(w http.ResponseWriter, r *http.Request) {
+	found, err := a.bot.ForcePoll(requestContext(r), r.PathValue("puuid"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"new_match": found})
+}
+
+func (a *api) analyze(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MatchID string `json:"match_id"`
+		PUUID   string `json:"puuid"`
+	}
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.MatchID == "" {
+		writeError(w, http.StatusBadRequest, errors.New("match_id is required"))
+		return
+	}
+
+	result, matchData, err := a.bot.AnalyzeMatch(requestContext(r), req.MatchID, req.PUUID)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"match_id":  req.MatchID,
+		"win":       matchData.Win,
+		"game_mode": matchData.GameMode,
+		"duration":  matchData.GameDurationMinutes,
+		"players":   result.Players,
+	})
+}
+
+// history lists a player's recorded matches, newest first. from and to are
+// dates (2006-01-02) or RFC 3339 times; limit defaults to 20.
+func (a *api) history(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := storage.HistoryQuery{Limit: 20}
+	var err error
+	if q.From, err = parseTime(query.Get("from")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("from: %w", err))
+		return
+	}
+	if q.To, err = parseTime(query.Get("to")); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("to: %w", err))
+		return
+	}
+	for name, dst := range map[string]*int{"queue": &q.QueueID, "limit": &q.Limit} {
+		if v := query.Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil || *dst < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("%s must be a non-negative number", name))
+				return
+			}
+		}
+	}
+
+	matches, err := a.bot.MatchHistory(r.PathValue("puuid"), q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if matches == nil {
+		matches = []storage.MatchRecord{}
+	}
+	writeJSON(w, http.StatusOK, matches)
+}
+
+// parseTime reads a date or an RFC 3339 time; "" is the zero time.
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func (a *api) flushCache(w http.ResponseWriter, r *http.Request) {
+	family := r.PathValue("family")
+	deleted, err := a.bot.FlushCache(requestContext(r), family, r.URL.Query().Get("key"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"family": family, "deleted": deleted})
+}
+
+func (a *api) queue(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.bot.QueueState())
+}
+
+func (a *api) reload(w http.ResponseWriter, r *http.Request) {
+	report, err := a.bot.ReloadStaticData(requestContext(r))
+	if err != nil {
+		// Partial reloads still apply; report what loaded alongside the error
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error":  err.Error(),
+			"report": report,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (a *api) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	res, err := a.bot.ReloadConfig(requestContext(r))
+	if err != nil {
+		// The running config is untouched when the new one is rejected
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (a *api) listData(w http.ResponseWriter, r *http.Request) {
+	status, err := a.bot.Datasets()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (a *api) activateData(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Version string `json:"version"`
+	}
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Version == "" {
+		writeError(w, http.StatusBadRequest, errors.New("version is required"))
+		return
+	}
+
+	report, err := a.bot.ActivateDataset(requestContext(r), req.Version)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (a *api) broadcast(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := a.bot.Broadcast(requestContext(r), req.Message)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// statusOf maps a Bot operation error to an HTTP status.
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, bot.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, bot.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// decode reads a small JSON request body.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.New("invalid JSON body: " + err.Error())
+	}
+	return nil
+}
+
+// isSnowflake reports whether id looks like a Discord ID.
+func isSnowflake(id string) bool {
+	if len(id) < 15 || len(id) > 21 {
+		return false
+	}
+	return strings.Trim(id, "0123456789") == ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Writing admin response failed", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": logging.RedactText(err.Error())})
+}
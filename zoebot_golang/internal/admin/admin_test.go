@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zoebot/internal/bot"
+	"github.com/zoebot/internal/bot/bottest"
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
+	"github.com/zoebot/internal/storage"
+)
+
+const (
+	testToken    = "s3cret"
+	testChannel  = "123456789012345678"
+	otherChannel = "876543210987654321"
+)
+
+// newTestAPI serves the admin API of a bot wired to fakes.
+func newTestAPI(t *testing.T, token string) (*httptest.Server, *bottest.Riot, *storage.Store) {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.Redis.URL = ""
+
+	riot := bottest.NewRiot()
+	store := storage.NewStore(storage.NewMemoryBackend())
+	t.Cleanup(func() { store.Close() })
+	b := bot.NewWithDeps(cfg, data.NewStore(), bot.Deps{
+		Session: bottest.NewSession(),
+		Riot:    riot,
+		AI:      &bottest.AI{},
+		Scraper: &bottest.Scraper{},
+		Store:   store,
+	})
+
+	srv := httptest.NewServer(Handler(b, token))
+	t.Cleanup(srv.Close)
+	return srv, riot, store
+}
+
+// call sends an admin request and decodes the JSON response into out.
+func call(t *testing.T, srv *httptest.Server, token, method, path, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAuth(t *testing.T) {
+	disabled, _, _ := newTestAPI(t, "")
+	if status := call(t, disabled, "anything", "GET", "/admin/subscriptions", "", nil); status != http.StatusNotFound {
+		t.Errorf("without a configured token: status %d, want 404", status)
+	}
+
+	srv, _, _ := newTestAPI(t, testToken)
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"token prefix", "Bearer s3cre", http.StatusUnauthorized},
+		{"not bearer", testToken, http.StatusUnauthorized},
+		{"valid", "Bearer " + testToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL+"/admin/subscriptions", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	srv, riot, _ := newTestAPI(t, testToken)
+	riot.PUUIDs["Zoe#VN2"] = "p1"
+	riot.MatchIDs["p1"] = []string{"VN2_1"}
+
+	var players []storage.TrackedPlayer
+	if status := call(t, srv, testToken, "GET", "/admin/subscriptions", "", &players); status != http.StatusOK || len(players) != 0 {
+		t.Fatalf("list: status %d, players %v", status, players)
+	}
+
+	var player storage.TrackedPlayer
+	track := `{"riot_id": "Zoe#VN2", "channel_id": "` + testChannel + `"}`
+	if status := call(t, srv, testToken, "POST", "/admin/subscriptions", track, &player); status != http.StatusCreated {
+		t.Fatalf("track: status %d", status)
+	}
+	if player.PUUID != "p1" || player.ChannelID != testChannel || player.LastMatchID != "VN2_1" {
+		t.Errorf("tracked %+v", player)
+	}
+	if status := call(t, srv, testToken, "POST", "/admin/subscriptions", track, nil); status != http.StatusOK {
+		t.Errorf("track again: status %d, want 200", status)
+	}
+
+	var failed map[string]string
+	for body, want := range map[string]int{
+		`{"riot_id": "Nobody#VN2", "channel_id": "` + testChannel + `"}`: http.StatusNotFound,
+		`{"riot_id": "Zoe", "channel_id": "` + testChannel + `"}`:        http.StatusBadRequest,
+		`{"riot_id": "Zoe#VN2", "channel_id": "general"}`:                http.StatusBadRequest,
+		`{"riot_id": "Zoe#VN2", "extra": true}`:                          http.StatusBadRequest,
+	} {
+		if status := call(t, srv, testToken, "POST", "/admin/subscriptions", body, &failed); status != want || failed["error"] == "" {
+			t.Errorf("track %s: status %d %v, want %d with an error", body, status, failed, want)
+		}
+	}
+
+	move := `{"channel_id": "` + otherChannel + `"}`
+	if status := call(t, srv, testToken, "PATCH", "/admin/subscriptions/p1", move, &player); status != http.StatusOK || player.ChannelID != otherChannel {
+		t.Errorf("move: status %d, player %+v", status, player)
+	}
+	if status := call(t, srv, testToken, "PATCH", "/admin/subscriptions/p2", move, nil); status != http.StatusNotFound {
+		t.Errorf("move untracked: status %d, want 404", status)
+	}
+
+	call(t, srv, testToken, "GET", "/admin/subscriptions", "", &players)
+	if len(players) != 1 || players[0].ChannelID != otherChannel {
+		t.Errorf("list after move = %+v", players)
+	}
+
+	// Polling runs the same match check as the poll loop
+	riot.MatchIDs["p1"] = []string{"VN2_2", "VN2_1"}
+	riot.AddMatch("VN2_2", true, "p1")
+	var polled map[string]bool
+	if status := call(t, srv, testToken, "POST", "/admin/subscriptions/p1/poll", "", &polled); status != http.StatusOK || !polled["new_match"] {
+		t.Errorf("poll: status %d, %v", status, polled)
+	}
+	if status := call(t, srv, testToken, "POST", "/admin/subscriptions/p1/poll", "", &polled); status != http.StatusOK || polled["new_match"] {
+		t.Errorf("poll again: status %d, %v", status, polled)
+	}
+
+	if status := call(t, srv, testToken, "DELETE", "/admin/subscriptions/p1", "", &player); status != http.StatusOK || player.PUUID != "p1" {
+		t.Errorf("untrack: status %d, player %+v", status, player)
+	}
+	if status := call(t, srv, testToken, "DELETE", "/admin/subscriptions/p1", "", nil); status != http.StatusNotFound {
+		t.Errorf("untrack again: status %d, want 404", status)
+	}
+	call(t, srv, testToken, "GET", "/admin/subscriptions", "", &players)
+	if len(players) != 0 {
+		t.Errorf("list after untrack = %+v", players)
+	}
+}
+
+func TestFlushCache(t *testing.T) {
+	srv, _, store := newTestAPI(t, testToken)
+	for _, key := range []string{"puuid:zoe#vn2", "puuid:ga#vn2", "counter:zoe:mid"} {
+		store.CacheSet("test", key, "cached")
+	}
+
+	var res struct {
+		Family  string `json:"family"`
+		Deleted int    `json:"deleted"`
+	}
+	if status := call(t, srv, testToken, "DELETE", "/admin/cache/puuid?key=puuid:zoe%23vn2", "", &res); status != http.StatusOK || res.Deleted != 1 {
+		t.Errorf("flush key: status %d, %+v", status, res)
+	}
+	if status := call(t, srv, testToken, "DELETE", "/admin/cache/puuid", "", &res); status != http.StatusOK || res.Family != "puuid" || res.Deleted != 1 {
+		t.Errorf("flush family: status %d, %+v", status, res)
+	}
+	if _, ok := store.CacheGet("counter", "counter:zoe:mid"); !ok {
+		t.Error("flushing puuid deleted a counter entry")
+	}
+
+	for _, path := range []string{"/admin/cache/nope", "/admin/cache/puuid?key=counter:zoe:mid"} {
+		if status := call(t, srv, testToken, "DELETE", path, "", nil); status != http.StatusBadRequest {
+			t.Errorf("DELETE %s: status %d, want 400", path, status)
+		}
+	}
+}
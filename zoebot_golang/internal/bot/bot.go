@@ -3,16 +3,23 @@ package bot
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 
 	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
 	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/logging"
 	"github.com/zoebot/internal/services/ai"
 	"github.com/zoebot/internal/services/riot"
 	"github.com/zoebot/internal/services/scraper"
@@ -21,38 +28,48 @@ import (
 
 // AnalysisCache stores analysis results for button interactions.
 type AnalysisCache struct {
-	Players   []ai.PlayerAnalysis
-	MatchData *riot.ParsedMatchData
+	Players   []ai.PlayerAnalysis   `json:"players"`
+	MatchData *riot.ParsedMatchData `json:"match_data"`
 }
 
 // MessageContext stores context for AI chat replies.
 type MessageContext struct {
-	Type      string                 // "analysis", "build", "counter"
-	Data      map[string]interface{} // Context data
-	CreatedAt time.Time
+	Type      string                 `json:"type"` // "analysis", "build", "counter", "recap", "session"
+	Data      map[string]interface{} `json:"data"` // Context data
+	CreatedAt time.Time              `json:"created_at"`
 }
 
 // Bot represents the Discord bot.
 type Bot struct {
-	session         *discordgo.Session
-	cfg             *config.Config
-	riotClient      *riot.Client
-	aiClient        *ai.Client
-	scraperClient   *scraper.Client
+	dg              *discordgo.Session // gateway connection; nil in tests
+	session         Session
+	cfg             atomic.Pointer[config.Config] // swapped on config reload
+	gameData        *data.Store                   // static game data, swapped by ActivateDataset
+	riotClient      RiotAPI
+	aiClient        AIClient
+	scraperClient   scraper.Scraper
+	store           *storage.Store
 	trackedPlayers  *storage.TrackedPlayersStore
-	analyzedMatches map[string][]string        // matchID -> []channelID
-	analysisCache   map[string]*AnalysisCache  // matchID -> analysis result
-	messageContext  map[string]*MessageContext // messageID -> context for AI chat
+	history         *storage.HistoryStore
+	guildSettings   *storage.GuildStore
+	guilds          sync.Map            // channel ID -> guild ID
+	analyzedMatches map[string][]string // matchID -> []channelID
 	analyzesMu      sync.RWMutex
-	cacheMu         sync.RWMutex
-	contextMu       sync.RWMutex
 	stopPolling     chan struct{}
 	commands        []*discordgo.ApplicationCommand
+	httpClient      *http.Client // downloads /import attachments
+
+	// Health state, read by the healthcheck server
+	connected    atomic.Bool
+	lastPollAt   atomic.Int64 // unix nanos of the last completed poll
+	lastPollTook atomic.Int64 // duration of the last poll in nanos
+	polling      atomic.Bool  // a poll is running
+	pollInFlight atomic.Int64 // player checks currently running
 }
 
-// New creates a new Bot instance.
-func New(cfg *config.Config) (*Bot, error) {
-	session, err := discordgo.New("Bot " + cfg.DiscordToken)
+// New creates a new Bot instance using the loaded game data in gameData.
+func New(cfg *config.Config, gameData *data.Store) (*Bot, error) {
+	session, err := discordgo.New("Bot " + cfg.Discord.Token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Discord session: %w", err)
 	}
@@ -62,54 +79,81 @@ func New(cfg *config.Config) (*Bot, error) {
 		discordgo.IntentsGuildMessages |
 		discordgo.IntentsMessageContent
 
-	// Create Redis client and tracked players store
-	redisClient := storage.NewRedisClient(cfg)
-	trackedPlayers := storage.NewTrackedPlayersStore(redisClient, cfg.RedisKeyTrackedPlayers)
+	store, err := storage.Open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+	if _, err := storage.Migrate(store, cfg.Redis, false); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to migrate storage: %w", err)
+	}
+	bot := NewWithDeps(cfg, gameData, Deps{
+		Session: session,
+		Riot:    riot.NewClient(cfg, store, gameData),
+		AI:      ai.NewClient(cfg),
+		Scraper: scraper.NewClient(store, gameData),
+		Store:   store,
+	})
+	bot.dg = session
+
+	// Register handlers
+	session.AddHandler(bot.onReady)
+	session.AddHandler(bot.onConnect)
+	session.AddHandler(bot.onDisconnect)
+	session.AddHandler(bot.onInteractionCreate)
+	session.AddHandler(bot.onMessageCreate)
 
-	// Load tracked players
+	return bot, nil
+}
+
+// NewWithDeps builds a Bot around d and loads the tracked players. The Bot
+// has no gateway connection, so Start can't be used; New is for that.
+func NewWithDeps(cfg *config.Config, gameData *data.Store, d Deps) *Bot {
+	trackedPlayers := storage.NewTrackedPlayersStore(d.Store, cfg.Redis)
 	if err := trackedPlayers.Load(); err != nil {
-		log.Printf("Load players failed: %v", err)
+		slog.Error("Loading tracked players failed", "error", err)
 	}
 
 	bot := &Bot{
-		session:         session,
-		cfg:             cfg,
-		riotClient:      riot.NewClient(cfg, redisClient),
-		aiClient:        ai.NewClient(cfg),
-		scraperClient:   scraper.NewClient(redisClient),
+		session:         d.Session,
+		gameData:        gameData,
+		riotClient:      d.Riot,
+		aiClient:        d.AI,
+		scraperClient:   d.Scraper,
+		store:           d.Store,
 		trackedPlayers:  trackedPlayers,
+		history:         storage.NewHistoryStore(d.Store, cfg.Redis, cfg.History),
+		guildSettings:   storage.NewGuildStore(d.Store, cfg.Redis),
 		analyzedMatches: make(map[string][]string),
-		analysisCache:   make(map[string]*AnalysisCache),
-		messageContext:  make(map[string]*MessageContext),
 		stopPolling:     make(chan struct{}),
+		httpClient:      &http.Client{Timeout: 15 * time.Second},
 	}
+	bot.cfg.Store(cfg)
+	return bot
+}
 
-	// Register handlers
-	session.AddHandler(bot.onReady)
-	session.AddHandler(bot.onInteractionCreate)
-	session.AddHandler(bot.onMessageCreate)
-
-	return bot, nil
+// config returns the current configuration.
+func (b *Bot) config() *config.Config {
+	return b.cfg.Load()
 }
 
 // Start connects to Discord and starts the bot.
 func (b *Bot) Start() error {
-	if err := b.session.Open(); err != nil {
+	if err := b.dg.Open(); err != nil {
 		return fmt.Errorf("failed to open Discord session: %w", err)
 	}
 
-	log.Println("Connected to Discord")
+	slog.Info("Connected to Discord")
 
 	// Register slash commands
 	if err := b.registerCommands(); err != nil {
-		log.Printf("Register commands failed: %v", err)
+		slog.Error("Registering commands failed", "error", err)
 	}
 
 	// Start polling task
 	go b.pollMatches()
-
-	// Start context cleanup task
-	go b.startContextCleanup()
+	go b.runRecaps()
+	go b.sweepHistory()
 
 	return nil
 }
@@ -117,13 +161,28 @@ func (b *Bot) Start() error {
 // Stop gracefully shuts down the bot.
 func (b *Bot) Stop() error {
 	close(b.stopPolling)
-	b.trackedPlayers.Save()
-	return b.session.Close()
+	err := b.dg.Close()
+	if cerr := b.store.Close(); cerr != nil {
+		slog.Warn("Closing storage failed", "error", cerr)
+	}
+	return err
 }
 
 // onReady is called when the bot is ready.
 func (b *Bot) onReady(s *discordgo.Session, event *discordgo.Ready) {
-	log.Printf("Bot ready: %s", event.User.Username)
+	slog.Info("Bot ready", "user", event.User.Username)
+	b.connected.Store(true)
+}
+
+// onConnect is called when the gateway websocket connects (or reconnects).
+func (b *Bot) onConnect(s *discordgo.Session, event *discordgo.Connect) {
+	b.connected.Store(true)
+}
+
+// onDisconnect is called when the gateway websocket drops.
+func (b *Bot) onDisconnect(s *discordgo.Session, event *discordgo.Disconnect) {
+	slog.Warn("Disconnected from Discord gateway")
+	b.connected.Store(false)
 }
 
 // registerCommands registers all slash commands.
@@ -191,10 +250,7 @@ func (b *Bot) registerCommands() error {
 				},
 			},
 		},
-		{
-			Name:        "leaderboard",
-			Description: "Xem bảng xếp hạng người chơi đang theo dõi",
-		},
+		leaderboardCommand(),
 		{
 			Name:        "build",
 			Description: "Xem build tướng (runes, items) từ OP.GG",
@@ -220,58 +276,104 @@ func (b *Bot) registerCommands() error {
 				},
 			},
 		},
+		historyCommand(),
+		profileCommand(),
+		lpCommand(),
+		milestonesCommand(),
+		recapCommand(),
+		notifyCommand(),
+		tiltCommand(),
+		exportCommand(),
+		importCommand(),
+		adminCommand(),
 	}
 
 	registeredCommands := make([]*discordgo.ApplicationCommand, len(commands))
 	for i, cmd := range commands {
-		registered, err := b.session.ApplicationCommandCreate(b.session.State.User.ID, "", cmd)
+		registered, err := b.dg.ApplicationCommandCreate(b.dg.State.User.ID, "", cmd)
 		if err != nil {
-			log.Printf("Command %s failed: %v", cmd.Name, err)
+			slog.Error("Registering command failed", "command", cmd.Name, "error", err)
 			continue
 		}
 		registeredCommands[i] = registered
 	}
 
 	b.commands = registeredCommands
-	log.Printf("Registered %d commands", len(registeredCommands))
+	slog.Info("Registered commands", "count", len(registeredCommands))
 	return nil
 }
 
 // onInteractionCreate handles slash command interactions.
 func (b *Bot) onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	b.handleInteraction(s, i)
+}
+
+// handleInteraction routes an interaction to its command or component
+// handler.
+func (b *Bot) handleInteraction(s Session, i *discordgo.InteractionCreate) {
+	// Correlate every log line of this interaction, including riot/ai/scraper calls
+	ctx := logging.WithCorrelationID(context.Background(), "i-"+i.ID)
+
 	if i.Type == discordgo.InteractionApplicationCommand {
-		switch i.ApplicationCommandData().Name {
+		name := i.ApplicationCommandData().Name
+		var handler func(context.Context, Session, *discordgo.InteractionCreate) error
+		switch name {
 		case "ping":
-			b.handlePing(s, i)
+			handler = b.handlePing
 		case "track":
-			b.handleTrack(s, i)
+			handler = b.handleTrack
 		case "untrack":
-			b.handleUntrack(s, i)
+			handler = b.handleUntrack
 		case "list":
-			b.handleList(s, i)
+			handler = b.handleList
 		case "analyze":
-			b.handleAnalyze(s, i)
+			handler = b.handleAnalyze
 		case "counter":
-			b.handleCounter(s, i)
+			handler = b.handleCounter
 		case "leaderboard":
-			b.handleLeaderboard(s, i)
+			handler = b.handleLeaderboard
 		case "build":
-			b.handleBuild(s, i)
+			handler = b.handleBuild
+		case "admin":
+			handler = b.handleAdmin
+		case "history":
+			handler = b.handleHistory
+		case "profile":
+			handler = b.handleProfile
+		case "lp":
+			handler = b.handleLP
+		case "milestones":
+			handler = b.handleMilestones
+		case "recap":
+			handler = b.handleRecap
+		case "notify":
+			handler = b.handleNotify
+		case "tilt":
+			handler = b.handleTilt
+		case "export":
+			handler = b.handleExport
+		case "import":
+			handler = b.handleImport
+		default:
+			return
 		}
+		b.runCommand(ctx, name, func() error { return handler(ctx, s, i) })
 	} else if i.Type == discordgo.InteractionMessageComponent {
-		b.handleComponentInteraction(s, i)
+		b.runCommand(ctx, componentName(i.MessageComponentData().CustomID), func() error {
+			return b.handleComponentInteraction(ctx, s, i)
+		})
 	}
 }
 
 // handlePing handles the /ping command.
-func (b *Bot) handlePing(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handlePing(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	latency := s.HeartbeatLatency().Milliseconds()
 	embed := embeds.Success(
 		fmt.Sprintf("🏓 Pong! Độ trễ: **%dms**", latency),
 		"✅ Bot đang hoạt động",
 	)
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{embed},
@@ -280,20 +382,13 @@ func (b *Bot) handlePing(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 // handleTrack handles the /track command.
-func (b *Bot) handleTrack(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleTrack(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	riotID := i.ApplicationCommandData().Options[0].StringValue()
 
 	// Validate format
-	if !strings.Contains(riotID, "#") {
-		embed := embeds.Error("Sai định dạng! Vui lòng dùng: `Name#Tag` (VD: Faker#KR1)", "")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Embeds: []*discordgo.MessageEmbed{embed},
-				Flags:  discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+	if _, _, err := parseRiotID(riotID); err != nil {
+		b.respondInvalidRiotID(s, i)
+		return err
 	}
 
 	// Send searching status
@@ -305,84 +400,47 @@ func (b *Bot) handleTrack(s *discordgo.Session, i *discordgo.InteractionCreate)
 		},
 	})
 
-	parts := strings.SplitN(riotID, "#", 2)
-	gameName, tagLine := parts[0], parts[1]
-
-	// Get PUUID
-	puuid, err := b.riotClient.GetPUUIDByRiotID(gameName, tagLine)
-	if err != nil || puuid == "" {
+	_, err := b.TrackPlayer(ctx, riotID, i.ChannelID)
+	switch {
+	case errors.Is(err, ErrAlreadyTracked):
+		embed := embeds.Warning(fmt.Sprintf("**%s** đã được theo dõi trong kênh này rồi.", riotID), "")
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds: &[]*discordgo.MessageEmbed{embed},
+		})
+		return nil
+	case err != nil:
 		embed := embeds.Error(fmt.Sprintf("Không tìm thấy người chơi **%s**. Kiểm tra lại tên và tag.", riotID), "")
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
-		return
-	}
-
-	// Check if already tracking
-	if existing, ok := b.trackedPlayers.Get(puuid); ok {
-		if existing.ChannelID == i.ChannelID {
-			embed := embeds.Warning(fmt.Sprintf("**%s** đã được theo dõi trong kênh này rồi.", riotID), "")
-			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-				Embeds: &[]*discordgo.MessageEmbed{embed},
-			})
-			return
-		}
-	}
-
-	// Get latest match to initialize
-	matches, _ := b.riotClient.GetMatchIDsByPUUID(puuid, 1)
-	var lastMatchID string
-	if len(matches) > 0 {
-		lastMatchID = matches[0]
+		return err
 	}
 
-	// Add to tracking
-	b.trackedPlayers.Set(puuid, &storage.TrackedPlayer{
-		PUUID:       puuid,
-		LastMatchID: lastMatchID,
-		ChannelID:   i.ChannelID,
-		Name:        riotID,
-	})
-	b.trackedPlayers.Save()
-
 	embed = embeds.Success(
 		fmt.Sprintf("Đã thêm **%s** vào danh sách theo dõi!\nBot sẽ thông báo khi có trận mới.", riotID),
 		"✅ Đã theo dõi",
 	)
 	embed.Thumbnail = &discordgo.MessageEmbedThumbnail{
-		URL: embeds.GetChampionIcon("Zoe"),
+		URL: embeds.GetChampionIcon(b.gameData, "Zoe"),
 	}
 
 	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 		Embeds: &[]*discordgo.MessageEmbed{embed},
 	})
-
-	log.Printf("Tracked: %s", riotID)
+	return nil
 }
 
 // handleUntrack handles the /untrack command.
-func (b *Bot) handleUntrack(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleUntrack(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	riotID := i.ApplicationCommandData().Options[0].StringValue()
 
 	// Validate format
-	if !strings.Contains(riotID, "#") {
-		embed := embeds.Error("Sai định dạng! Vui lòng dùng: `Name#Tag` (VD: Faker#KR1)", "")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Embeds: []*discordgo.MessageEmbed{embed},
-				Flags:  discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+	if _, _, err := parseRiotID(riotID); err != nil {
+		b.respondInvalidRiotID(s, i)
+		return err
 	}
 
-	parts := strings.SplitN(riotID, "#", 2)
-	gameName, tagLine := parts[0], parts[1]
-
-	// Get PUUID
-	puuid, err := b.riotClient.GetPUUIDByRiotID(gameName, tagLine)
-	if err != nil || puuid == "" {
+	if _, err := b.UntrackPlayer(ctx, riotID); err != nil {
 		embed := embeds.Error(fmt.Sprintf("Không tìm thấy **%s** trong danh sách đang theo dõi.", riotID), "")
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -390,34 +448,32 @@ func (b *Bot) handleUntrack(s *discordgo.Session, i *discordgo.InteractionCreate
 				Embeds: []*discordgo.MessageEmbed{embed},
 			},
 		})
-		return
+		return err
 	}
 
-	if _, ok := b.trackedPlayers.Get(puuid); ok {
-		b.trackedPlayers.Delete(puuid)
-		b.trackedPlayers.Save()
+	embed := embeds.Success(fmt.Sprintf("Đã huỷ theo dõi **%s**.", riotID), "")
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
 
-		embed := embeds.Success(fmt.Sprintf("Đã huỷ theo dõi **%s**.", riotID), "")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Embeds: []*discordgo.MessageEmbed{embed},
-			},
-		})
-		log.Printf("Untracked: %s", riotID)
-	} else {
-		embed := embeds.Error(fmt.Sprintf("Không tìm thấy **%s** trong danh sách đang theo dõi.", riotID), "")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Embeds: []*discordgo.MessageEmbed{embed},
-			},
-		})
-	}
+// respondInvalidRiotID tells the user how a Riot ID must be written.
+func (b *Bot) respondInvalidRiotID(s Session, i *discordgo.InteractionCreate) {
+	embed := embeds.Error("Sai định dạng! Vui lòng dùng: `Name#Tag` (VD: Faker#KR1)", "")
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
 }
 
 // handleList handles the /list command.
-func (b *Bot) handleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleList(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	channelPlayers := b.trackedPlayers.GetByChannel(i.ChannelID)
 
 	var playerNames []string
@@ -431,7 +487,7 @@ func (b *Bot) handleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	embed := embeds.TrackingList(playerNames, channelName)
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{embed},
@@ -440,20 +496,14 @@ func (b *Bot) handleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 // handleAnalyze handles the /analyze command.
-func (b *Bot) handleAnalyze(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleAnalyze(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	riotID := i.ApplicationCommandData().Options[0].StringValue()
 
 	// Validate format
-	if !strings.Contains(riotID, "#") {
-		embed := embeds.Error("Sai định dạng! Vui lòng dùng: `Name#Tag` (VD: Faker#KR1)", "")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Embeds: []*discordgo.MessageEmbed{embed},
-				Flags:  discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+	gameName, tagLine, err := parseRiotID(riotID)
+	if err != nil {
+		b.respondInvalidRiotID(s, i)
+		return err
 	}
 
 	// Send searching status
@@ -465,27 +515,24 @@ func (b *Bot) handleAnalyze(s *discordgo.Session, i *discordgo.InteractionCreate
 		},
 	})
 
-	parts := strings.SplitN(riotID, "#", 2)
-	gameName, tagLine := parts[0], parts[1]
-
 	// Get PUUID
-	puuid, err := b.riotClient.GetPUUIDByRiotID(gameName, tagLine)
+	puuid, err := b.riotClient.GetPUUIDByRiotID(ctx, gameName, tagLine)
 	if err != nil || puuid == "" {
 		embed := embeds.Error(fmt.Sprintf("Không tìm thấy người chơi **%s**. Kiểm tra lại tên và tag.", riotID), "")
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
-		return
+		return fmt.Errorf("%w: player %s: %v", ErrNotFound, riotID, err)
 	}
 
 	// Get latest match
-	matches, err := b.riotClient.GetMatchIDsByPUUID(puuid, 1)
+	matches, err := b.riotClient.GetMatchIDsByPUUID(ctx, puuid, 1)
 	if err != nil || len(matches) == 0 {
 		embed := embeds.Error("Người chơi này chưa đánh trận nào gần đây.", "")
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
-		return
+		return fmt.Errorf("%w: no recent matches for %s", ErrNotFound, riotID)
 	}
 
 	matchID := matches[0]
@@ -496,43 +543,24 @@ func (b *Bot) handleAnalyze(s *discordgo.Session, i *discordgo.InteractionCreate
 		Embeds: &[]*discordgo.MessageEmbed{embed},
 	})
 
-	// Get match details and timeline
-	matchDetails, err := b.riotClient.GetMatchDetails(matchID)
-	if err != nil {
-		embed := embeds.Error("Không thể lấy dữ liệu chi tiết của trận đấu.", "")
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Embeds: &[]*discordgo.MessageEmbed{embed},
-		})
-		return
-	}
-
-	timeline, _ := b.riotClient.GetMatchTimeline(matchID)
-
-	// Parse match data
-	matchData := b.riotClient.ParseMatchData(matchDetails, puuid, timeline)
-	if matchData == nil {
-		embed := embeds.Error("Không thể xử lý dữ liệu trận đấu.", "")
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Embeds: &[]*discordgo.MessageEmbed{embed},
-		})
-		return
-	}
+	return b.editAnalysis(ctx, s, i, riotID, puuid, matchID)
+}
 
-	// Get AI analysis
-	analysisResult, err := b.aiClient.AnalyzeMatch(matchData)
+// editAnalysis analyzes a match and replaces the interaction response with
+// the result and its buttons. The response must already have been sent.
+func (b *Bot) editAnalysis(ctx context.Context, s Session, i *discordgo.InteractionCreate, riotID, puuid, matchID string) error {
+	// Fetch, parse and analyze the match
+	analysisResult, matchData, err := b.AnalyzeMatch(ctx, matchID, puuid)
 	if err != nil {
-		embed := embeds.Error(fmt.Sprintf("Lỗi AI: %s", err.Error()[:min(200, len(err.Error()))]), "")
+		embed := analysisErrorEmbed(err, "Không thể lấy dữ liệu chi tiết của trận đấu.")
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
-		return
+		return err
 	}
 
-	// Cache analysis result for button interactions
-	b.cacheAnalysis(matchID, analysisResult.Players, matchData)
-
 	// Create embed
-	embed = embeds.CompactAnalysis(analysisResult.Players, matchData)
+	embed := embeds.CompactAnalysis(analysisResult.Players, matchData)
 
 	// Create buttons
 	buttons := []discordgo.MessageComponent{
@@ -579,19 +607,26 @@ func (b *Bot) handleAnalyze(s *discordgo.Session, i *discordgo.InteractionCreate
 		}
 		b.saveMessageContext(msg.ID, "analysis", contextData)
 	}
+	return nil
 }
 
 // handleComponentInteraction handles button/component interactions.
-func (b *Bot) handleComponentInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleComponentInteraction(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	customID := i.MessageComponentData().CustomID
 
 	switch {
 	case strings.HasPrefix(customID, "detail_"), strings.HasPrefix(customID, "full_"):
-		b.handleDetailButton(s, i, customID)
+		return b.handleDetailButton(ctx, s, i, customID)
+
+	case strings.HasPrefix(customID, "histpage_"):
+		return b.handleHistoryButton(ctx, s, i, customID)
+
+	case strings.HasPrefix(customID, "histpick_"):
+		return b.handleHistorySelect(ctx, s, i, customID)
 
 	case strings.HasPrefix(customID, "copy_"):
 		matchID := strings.TrimPrefix(customID, "copy_")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
 				Content: fmt.Sprintf("```\n%s\n```", matchID),
@@ -602,37 +637,17 @@ func (b *Bot) handleComponentInteraction(s *discordgo.Session, i *discordgo.Inte
 	case strings.HasPrefix(customID, "track_"):
 		parts := strings.SplitN(strings.TrimPrefix(customID, "track_"), "_", 2)
 		if len(parts) < 2 {
-			return
+			return fmt.Errorf("%w: custom id %q", ErrInvalidInput, customID)
 		}
 		riotID := parts[0]
 		channelID := parts[1]
 
-		riotParts := strings.SplitN(riotID, "#", 2)
-		if len(riotParts) < 2 {
-			return
-		}
-
-		puuid, err := b.riotClient.GetPUUIDByRiotID(riotParts[0], riotParts[1])
-		if err != nil || puuid == "" {
-			return
+		if _, err := b.TrackPlayer(ctx, riotID, channelID); err != nil && !errors.Is(err, ErrAlreadyTracked) {
+			return err
 		}
 
-		matches, _ := b.riotClient.GetMatchIDsByPUUID(puuid, 1)
-		var lastMatchID string
-		if len(matches) > 0 {
-			lastMatchID = matches[0]
-		}
-
-		b.trackedPlayers.Set(puuid, &storage.TrackedPlayer{
-			PUUID:       puuid,
-			LastMatchID: lastMatchID,
-			ChannelID:   channelID,
-			Name:        riotID,
-		})
-		b.trackedPlayers.Save()
-
 		embed := embeds.Success(fmt.Sprintf("Đã thêm **%s** vào danh sách theo dõi!", riotID), "")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
 				Embeds: []*discordgo.MessageEmbed{embed},
@@ -640,10 +655,11 @@ func (b *Bot) handleComponentInteraction(s *discordgo.Session, i *discordgo.Inte
 			},
 		})
 	}
+	return nil
 }
 
 // handleDetailButton handles detail/full analysis button clicks.
-func (b *Bot) handleDetailButton(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+func (b *Bot) handleDetailButton(ctx context.Context, s Session, i *discordgo.InteractionCreate, customID string) error {
 	// Parse customID: detail_matchID_puuid or full_matchID_puuid
 	var remainder string
 	if strings.HasPrefix(customID, "detail_") {
@@ -675,15 +691,15 @@ func (b *Bot) handleDetailButton(s *discordgo.Session, i *discordgo.InteractionC
 			},
 		})
 		if err != nil {
-			log.Printf("Error responding to interaction: %v", err)
+			slog.WarnContext(ctx, "Responding to interaction failed", "error", err)
 		}
-		return
+		return fmt.Errorf("%w: analysis for %s expired", ErrNotFound, matchID)
 	}
 
 	// Create embeds for each player
 	var playerEmbeds []*discordgo.MessageEmbed
 	for _, p := range cache.Players {
-		playerEmbeds = append(playerEmbeds, embeds.PlayerAnalysisEmbed(p, cache.MatchData))
+		playerEmbeds = append(playerEmbeds, embeds.PlayerAnalysisEmbed(b.gameData, p, cache.MatchData))
 	}
 
 	// Discord allows max 10 embeds per message, split if needed
@@ -699,24 +715,77 @@ func (b *Bot) handleDetailButton(s *discordgo.Session, i *discordgo.InteractionC
 		},
 	})
 	if err != nil {
-		log.Printf("Error responding with player embeds: %v", err)
+		slog.WarnContext(ctx, "Responding with player embeds failed", "error", err)
 	}
+	return err
 }
 
 // pollMatches runs the background task to check for new matches.
 func (b *Bot) pollMatches() {
-	ticker := time.NewTicker(1 * time.Minute)
+	interval := b.config().Poll.Interval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	log.Println("Polling started (1min interval)")
+	// Count lag from startup so the first tick isn't reported as overdue
+	b.lastPollAt.Store(time.Now().UnixNano())
+
+	slog.Info("Polling started", "interval", interval)
 
 	for {
 		select {
 		case <-b.stopPolling:
-			log.Println("Polling stopped")
+			slog.Info("Polling stopped")
 			return
 		case <-ticker.C:
+			start := time.Now()
+			b.polling.Store(true)
 			b.checkMatches()
+			b.postDueSessions(time.Now())
+			b.postTiltAlerts(time.Now())
+			b.polling.Store(false)
+			took := time.Since(start)
+			pollDuration.Observe(took.Seconds())
+			b.lastPollTook.Store(int64(took))
+			b.lastPollAt.Store(time.Now().UnixNano())
+
+			// Pick up a reloaded interval
+			if next := b.config().Poll.Interval; next != interval {
+				interval = next
+				ticker.Reset(interval)
+				slog.Info("Poll interval changed", "interval", interval)
+			}
+		}
+	}
+}
+
+// historySweepInterval is how often match history past the retention
+// limit is deleted.
+const historySweepInterval = time.Hour
+
+// sweepHistory deletes match history past the retention limit until the
+// bot stops. With several instances sharing storage, one sweeps per round.
+func (b *Bot) sweepHistory() {
+	ticker := time.NewTicker(historySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopPolling:
+			return
+		case now := <-ticker.C:
+			// Never unlocked: the lock expires before the next round
+			if _, err := b.store.Backend().Lock("history:sweep", historySweepInterval/2); err != nil {
+				if !errors.Is(err, storage.ErrLocked) {
+					slog.Warn("Taking history sweep lock failed", "error", err)
+				}
+				continue
+			}
+			deleted, err := b.history.Sweep(now)
+			if err != nil {
+				slog.Warn("Sweeping match history failed", "deleted", deleted, "error", err)
+			} else if deleted > 0 {
+				slog.Info("Swept match history", "deleted", deleted)
+			}
 		}
 	}
 }
@@ -729,20 +798,22 @@ func (b *Bot) checkMatches() {
 		return
 	}
 
+	pollCfg := b.config().Poll
+
 	var wg sync.WaitGroup
 	// Semaphore to limit concurrent API requests (Riot rate limit friendly)
-	sem := make(chan struct{}, 5) // Max 5 concurrent requests
-	// Rate limiter: 1 request per 100ms = 10 req/sec (well under Riot's 20/sec)
-	ticker := time.NewTicker(100 * time.Millisecond)
+	sem := make(chan struct{}, pollCfg.Concurrency)
+	// Rate limiter: space out player checks to stay under Riot's 20 req/sec
+	ticker := time.NewTicker(pollCfg.RequestInterval)
 	defer ticker.Stop()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), pollCfg.Timeout)
 	defer cancel()
 
 	for puuid, data := range players {
 		select {
 		case <-ctx.Done():
-			log.Println("Polling timeout, waiting for remaining goroutines...")
+			slog.Warn("Polling timeout, waiting for remaining goroutines")
 			wg.Wait()
 			return
 		case <-ticker.C:
@@ -764,44 +835,66 @@ func (b *Bot) checkMatches() {
 				return
 			}
 
-			b.checkPlayerMatch(p, d)
+			b.pollInFlight.Add(1)
+			defer b.pollInFlight.Add(-1)
+
+			// Each player check is its own job with its own correlation ID
+			jobCtx := logging.NewCorrelationID(context.Background(), "poll")
+			b.checkPlayerMatch(jobCtx, p, d)
 		}(puuid, &playerCopy)
 	}
 
 	wg.Wait()
 }
 
-// checkPlayerMatch checks for new match for a single player.
-func (b *Bot) checkPlayerMatch(puuid string, data *storage.TrackedPlayer) {
+// checkPlayerMatch checks for new match for a single player and reports
+// whether one was found.
+func (b *Bot) checkPlayerMatch(ctx context.Context, puuid string, data *storage.TrackedPlayer) bool {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("Panic checking %s: %v", data.Name, r)
+			slog.ErrorContext(ctx, "Panic checking player", "player", data.Name, "panic", r)
 		}
 	}()
 
-	matches, err := b.riotClient.GetMatchIDsByPUUID(puuid, 1)
+	playersChecked.Inc()
+
+	matches, err := b.riotClient.GetMatchIDsByPUUID(ctx, puuid, 1)
 	if err != nil || len(matches) == 0 {
-		return
+		return false
 	}
 
 	latestMatchID := matches[0]
 	oldMatchID := data.LastMatchID
 
+	// Earlier games whose league entry lagged go before anything newer
+	b.retryRankSnapshots(ctx, puuid, data)
+
 	// No new match
 	if latestMatchID == oldMatchID {
-		return
+		return false
 	}
 
 	// Update last match ID
-	b.trackedPlayers.UpdateLastMatch(puuid, latestMatchID)
-	b.trackedPlayers.Save()
+	if err := b.trackedPlayers.UpdateLastMatch(puuid, latestMatchID); err != nil {
+		slog.WarnContext(ctx, "Saving last match failed", "player", data.Name, "error", err)
+	}
 
 	// First time tracking, just initialize
 	if oldMatchID == "" {
-		return
+		return false
 	}
 
-	log.Printf("New match: %s", data.Name)
+	slog.InfoContext(ctx, "New match", "player", data.Name, "match_id", latestMatchID)
+
+	// Snapshot LP before the per-channel dedup, so every tracked player in
+	// the game gets one. Milestones follow the match notification, and a
+	// game Riot hasn't counted yet is retried on the next poll.
+	switch change, result := b.snapshotRank(ctx, puuid, latestMatchID, false); result {
+	case rankTaken:
+		defer b.announceMilestones(ctx, data, change)
+	case rankPending:
+		b.addPendingRank(ctx, puuid, data, latestMatchID)
+	}
 
 	// Check if already analyzed for this channel
 	b.analyzesMu.RLock()
@@ -811,19 +904,33 @@ func (b *Bot) checkPlayerMatch(puuid string, data *storage.TrackedPlayer) {
 	if exists {
 		for _, ch := range channels {
 			if ch == data.ChannelID {
-				return
+				return true
 			}
 		}
 	}
 
+	// The guild only wants session summaries: record the match quietly
+	if b.notifyMode(ctx, data.ChannelID) == storage.NotifySummaries {
+		if _, _, err := b.AnalyzeMatch(ctx, latestMatchID, puuid); err != nil {
+			slog.WarnContext(ctx, "Analyzing match failed", "match_id", latestMatchID, "error", err)
+		}
+		b.markAnalyzed(latestMatchID, data.ChannelID)
+		return true
+	}
+
 	// Find all tracked players in this match for this channel
 	allPlayers := b.trackedPlayers.GetAll()
 	var playersInMatch []string
-	for _, p := range allPlayers {
+	var rankLines []string
+	for id, p := range allPlayers {
 		if p.ChannelID == data.ChannelID && p.LastMatchID == latestMatchID {
 			playersInMatch = append(playersInMatch, fmt.Sprintf("**%s**", p.Name))
+			if change, ok, err := b.history.RankChangeAt(id, latestMatchID); err == nil && ok {
+				rankLines = append(rankLines, rankChangeLine(p.Name, change))
+			}
 		}
 	}
+	sort.Strings(rankLines)
 
 	playersMention := strings.Join(playersInMatch, ", ")
 
@@ -835,38 +942,27 @@ func (b *Bot) checkPlayerMatch(puuid string, data *storage.TrackedPlayer) {
 
 	msg, err := b.session.ChannelMessageSendEmbed(data.ChannelID, embed)
 	if err != nil {
-		return
+		return true
 	}
+	notificationsSent.Inc()
 
 	// Get match details and analyze
-	matchDetails, err := b.riotClient.GetMatchDetails(latestMatchID)
+	analysisResult, matchData, err := b.AnalyzeMatch(ctx, latestMatchID, puuid)
 	if err != nil {
-		embed := embeds.Error("Không thể lấy dữ liệu trận đấu từ Riot API.", "")
+		slog.WarnContext(ctx, "Analyzing match failed", "match_id", latestMatchID, "error", err)
+		embed := analysisErrorEmbed(err, "Không thể lấy dữ liệu trận đấu từ Riot API.")
 		b.session.ChannelMessageEditEmbed(data.ChannelID, msg.ID, embed)
-		return
+		return true
 	}
 
-	timeline, _ := b.riotClient.GetMatchTimeline(latestMatchID)
-	matchData := b.riotClient.ParseMatchData(matchDetails, puuid, timeline)
-
-	if matchData == nil {
-		embed := embeds.Error("Không thể xử lý dữ liệu trận đấu.", "")
-		b.session.ChannelMessageEditEmbed(data.ChannelID, msg.ID, embed)
-		return
-	}
-
-	analysisResult, err := b.aiClient.AnalyzeMatch(matchData)
-	if err != nil {
-		embed := embeds.Error(fmt.Sprintf("Lỗi AI: %s", err.Error()[:min(200, len(err.Error()))]), "")
-		b.session.ChannelMessageEditEmbed(data.ChannelID, msg.ID, embed)
-		return
-	}
-
-	// Cache analysis result for button interactions
-	b.cacheAnalysis(latestMatchID, analysisResult.Players, matchData)
-
 	// Create embed with analysis
 	embed = embeds.CompactAnalysis(analysisResult.Players, matchData)
+	if len(rankLines) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "📈 LP",
+			Value: strings.Join(rankLines, "\n"),
+		})
+	}
 
 	// Create buttons
 	components := []discordgo.MessageComponent{
@@ -906,9 +1002,18 @@ func (b *Bot) checkPlayerMatch(puuid string, data *storage.TrackedPlayer) {
 	}
 	b.saveMessageContext(msg.ID, "analysis", contextData)
 
-	// Mark as analyzed
+	b.markAnalyzed(latestMatchID, data.ChannelID)
+
+	slog.InfoContext(ctx, "Analyzed match", "match_id", latestMatchID, "players", playersMention)
+	return true
+}
+
+// markAnalyzed records that a match was posted to a channel, so the other
+// tracked players in it don't post it again.
+func (b *Bot) markAnalyzed(matchID, channelID string) {
 	b.analyzesMu.Lock()
-	b.analyzedMatches[latestMatchID] = append(b.analyzedMatches[latestMatchID], data.ChannelID)
+	defer b.analyzesMu.Unlock()
+	b.analyzedMatches[matchID] = append(b.analyzedMatches[matchID], channelID)
 
 	// Cleanup old entries
 	if len(b.analyzedMatches) > 50 {
@@ -917,9 +1022,19 @@ func (b *Bot) checkPlayerMatch(puuid string, data *storage.TrackedPlayer) {
 			break
 		}
 	}
-	b.analyzesMu.Unlock()
+}
 
-	log.Printf("Analyzed: %s", playersMention)
+// analysisErrorEmbed describes an AnalyzeMatch failure. fetchMsg is shown
+// when Riot had no match details.
+func analysisErrorEmbed(err error, fetchMsg string) *discordgo.MessageEmbed {
+	switch {
+	case errors.Is(err, errMatchUnavailable):
+		return embeds.Error(fetchMsg, "")
+	case errors.Is(err, ErrNotFound):
+		return embeds.Error("Không thể xử lý dữ liệu trận đấu.", "")
+	}
+	msg := err.Error()
+	return embeds.Error(fmt.Sprintf("Lỗi AI: %s", msg[:min(200, len(msg))]), "")
 }
 
 func min(a, b int) int {
@@ -930,113 +1045,74 @@ func min(a, b int) int {
 }
 
 // cacheAnalysis stores analysis result for later button interactions.
+// Entries expire after the cache.analysis TTL.
 func (b *Bot) cacheAnalysis(matchID string, players []ai.PlayerAnalysis, matchData *riot.ParsedMatchData) {
-	b.cacheMu.Lock()
-	defer b.cacheMu.Unlock()
-
-	b.analysisCache[matchID] = &AnalysisCache{
+	raw, err := json.Marshal(&AnalysisCache{
 		Players:   players,
 		MatchData: matchData,
+	})
+	if err == nil {
+		err = b.store.CacheSet("analysis", "analysis:"+matchID, string(raw))
 	}
-
-	// Cleanup old entries (keep max 20)
-	if len(b.analysisCache) > 20 {
-		for k := range b.analysisCache {
-			delete(b.analysisCache, k)
-			break
-		}
+	if err != nil {
+		slog.Warn("Caching analysis failed", "match_id", matchID, "error", err)
 	}
 }
 
 // getAnalysisCache retrieves cached analysis result.
 func (b *Bot) getAnalysisCache(matchID string) *AnalysisCache {
-	b.cacheMu.RLock()
-	defer b.cacheMu.RUnlock()
-	return b.analysisCache[matchID]
+	raw, ok := b.store.CacheGet("analysis", "analysis:"+matchID)
+	if !ok {
+		return nil
+	}
+	var cache AnalysisCache
+	if err := json.Unmarshal([]byte(raw), &cache); err != nil {
+		slog.Warn("Decoding cached analysis failed", "match_id", matchID, "error", err)
+		return nil
+	}
+	return &cache
 }
 
 // saveMessageContext saves context for a bot message (for AI chat replies).
+// Contexts expire after the cache.context TTL.
 func (b *Bot) saveMessageContext(messageID string, contextType string, data map[string]interface{}) {
-	b.contextMu.Lock()
-	defer b.contextMu.Unlock()
-
-	b.messageContext[messageID] = &MessageContext{
+	raw, err := json.Marshal(&MessageContext{
 		Type:      contextType,
 		Data:      data,
 		CreatedAt: time.Now(),
+	})
+	if err == nil {
+		err = b.store.CacheSet("context", "context:"+messageID, string(raw))
 	}
-
-	// Cleanup old entries (keep max 100, remove entries older than 24 hours)
-	if len(b.messageContext) > 100 {
-		ttlAgo := time.Now().Add(-24 * time.Hour)
-		for id, ctx := range b.messageContext {
-			if ctx.CreatedAt.Before(ttlAgo) {
-				delete(b.messageContext, id)
-			}
-		}
-		// If still too many, remove oldest
-		if len(b.messageContext) > 100 {
-			for id := range b.messageContext {
-				delete(b.messageContext, id)
-				break
-			}
-		}
+	if err != nil {
+		slog.Warn("Saving message context failed", "message_id", messageID, "error", err)
 	}
 }
 
 // getMessageContext retrieves context for a bot message.
 func (b *Bot) getMessageContext(messageID string) *MessageContext {
-	b.contextMu.RLock()
-	defer b.contextMu.RUnlock()
-	return b.messageContext[messageID]
-}
-
-// startContextCleanup starts background goroutine to cleanup expired contexts.
-func (b *Bot) startContextCleanup() {
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
-
-	log.Println("Context cleanup started (10min interval)")
-
-	for {
-		select {
-		case <-b.stopPolling:
-			log.Println("Context cleanup stopped")
-			return
-		case <-ticker.C:
-			b.cleanupOldContexts()
-		}
-	}
-}
-
-// cleanupOldContexts removes contexts older than 24 hours.
-func (b *Bot) cleanupOldContexts() {
-	b.contextMu.Lock()
-	defer b.contextMu.Unlock()
-
-	if len(b.messageContext) == 0 {
-		return
-	}
-
-	ttlAgo := time.Now().Add(-24 * time.Hour)
-	deleted := 0
-
-	for id, ctx := range b.messageContext {
-		if ctx.CreatedAt.Before(ttlAgo) {
-			delete(b.messageContext, id)
-			deleted++
-		}
+	raw, ok := b.store.CacheGet("context", "context:"+messageID)
+	if !ok {
+		return nil
 	}
-
-	if deleted > 0 {
-		log.Printf("Cleaned up %d expired contexts, %d remaining", deleted, len(b.messageContext))
+	var msgCtx MessageContext
+	if err := json.Unmarshal([]byte(raw), &msgCtx); err != nil {
+		slog.Warn("Decoding message context failed", "message_id", messageID, "error", err)
+		return nil
 	}
+	return &msgCtx
 }
 
 // onMessageCreate handles message create events (for reply-based AI chat).
 func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	b.handleReply(s, s.State.User.ID, m)
+}
+
+// handleReply answers a reply to one of the bot's messages (botID) with
+// the AI, using the context saved for that message.
+func (b *Bot) handleReply(s Session, botID string, m *discordgo.MessageCreate) {
 	// Ignore bot messages
-	if m.Author.ID == s.State.User.ID {
+	if m.Author.ID == botID {
 		return
 	}
 
@@ -1052,13 +1128,13 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 	}
 
 	// Check if referenced message is from the bot
-	if refMsg.Author.ID != s.State.User.ID {
+	if refMsg.Author.ID != botID {
 		return
 	}
 
 	// Get context for the referenced message
-	ctx := b.getMessageContext(refMsg.ID)
-	if ctx == nil {
+	msgCtx := b.getMessageContext(refMsg.ID)
+	if msgCtx == nil {
 		// No context found, ignore
 		return
 	}
@@ -1072,10 +1148,12 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 	// Show typing indicator
 	s.ChannelTyping(m.ChannelID)
 
+	ctx := logging.WithCorrelationID(context.Background(), "m-"+m.ID)
+
 	// Call AI with context
-	response, err := b.aiClient.ChatWithContext(ctx.Type, ctx.Data, question)
+	response, err := b.aiClient.ChatWithContext(ctx, msgCtx.Type, msgCtx.Data, question)
 	if err != nil {
-		log.Printf("AI chat error: %v", err)
+		slog.WarnContext(ctx, "AI chat failed", "error", err)
 		embed := embeds.Error("Không thể trả lời lúc này. Thử lại sau nhé!", "")
 		s.ChannelMessageSendEmbedReply(m.ChannelID, embed, m.Reference())
 		return
@@ -0,0 +1,1474 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/services/scraper"
+	"github.com/zoebot/internal/storage"
+)
+
+func TestCheckPlayerMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		lastMatch string
+		matchIDs  []string
+		idsErr    error
+		aiErr     error
+		noDetails bool // Riot has no details for the new match
+		analyzed  bool // the match was already posted in the channel
+		sendErr   error
+
+		wantNew      bool
+		wantLast     string
+		wantSent     int
+		wantAnalysis bool   // the notification was replaced by the analysis
+		wantError    string // the notification was replaced by an error containing this
+	}{
+		{
+			name:      "riot error",
+			lastMatch: "VN2_1",
+			idsErr:    errors.New("API error: 503"),
+			wantLast:  "VN2_1",
+		},
+		{
+			name:      "no matches",
+			lastMatch: "VN2_1",
+			wantLast:  "VN2_1",
+		},
+		{
+			name:      "same match",
+			lastMatch: "VN2_1",
+			matchIDs:  []string{"VN2_1"},
+			wantLast:  "VN2_1",
+		},
+		{
+			name:     "first poll only records the match",
+			matchIDs: []string{"VN2_2"},
+			wantLast: "VN2_2",
+		},
+		{
+			name:         "new match is analyzed",
+			lastMatch:    "VN2_1",
+			matchIDs:     []string{"VN2_2"},
+			wantNew:      true,
+			wantLast:     "VN2_2",
+			wantSent:     1,
+			wantAnalysis: true,
+		},
+		{
+			name:      "AI failure shows the error",
+			lastMatch: "VN2_1",
+			matchIDs:  []string{"VN2_2"},
+			aiErr:     errors.New("AI API error: 500"),
+			wantNew:   true,
+			wantLast:  "VN2_2",
+			wantSent:  1,
+			wantError: "Lỗi AI",
+		},
+		{
+			name:      "missing match details",
+			lastMatch: "VN2_1",
+			matchIDs:  []string{"VN2_2"},
+			noDetails: true,
+			wantNew:   true,
+			wantLast:  "VN2_2",
+			wantSent:  1,
+			wantError: "Riot API",
+		},
+		{
+			name:      "already posted in the channel",
+			lastMatch: "VN2_1",
+			matchIDs:  []string{"VN2_2"},
+			analyzed:  true,
+			wantNew:   true,
+			wantLast:  "VN2_2",
+		},
+		{
+			name:      "notification not delivered",
+			lastMatch: "VN2_1",
+			matchIDs:  []string{"VN2_2"},
+			sendErr:   errors.New("HTTP 403 Forbidden"),
+			wantNew:   true,
+			wantLast:  "VN2_2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBot(t)
+			tb.track("p1", "Zoe#VN2", tt.lastMatch)
+			tb.riot.MatchIDs["p1"] = tt.matchIDs
+			tb.riot.IDsErr = tt.idsErr
+			if !tt.noDetails {
+				tb.riot.AddMatch("VN2_2", true, "p1", "p2")
+			}
+			tb.ai.Err = tt.aiErr
+			tb.session.SendErr = tt.sendErr
+			if tt.analyzed {
+				tb.analyzedMatches["VN2_2"] = []string{testChannel}
+			}
+
+			player, _ := tb.trackedPlayers.Get("p1")
+			got := tb.checkPlayerMatch(context.Background(), "p1", player)
+
+			if got != tt.wantNew {
+				t.Errorf("checkPlayerMatch() = %v, want %v", got, tt.wantNew)
+			}
+			if p, _ := tb.trackedPlayers.Get("p1"); p.LastMatchID != tt.wantLast {
+				t.Errorf("LastMatchID = %q, want %q", p.LastMatchID, tt.wantLast)
+			}
+			if len(tb.session.Sent) != tt.wantSent {
+				t.Fatalf("sent %d messages, want %d", len(tb.session.Sent), tt.wantSent)
+			}
+			if tt.wantSent > 0 && tb.session.Sent[0].ChannelID != testChannel {
+				t.Errorf("notified channel %q, want %q", tb.session.Sent[0].ChannelID, testChannel)
+			}
+
+			if got := len(tb.session.Edited) == 1; got != tt.wantAnalysis {
+				t.Errorf("analysis posted = %v, want %v", got, tt.wantAnalysis)
+			}
+			if tt.wantAnalysis {
+				if tb.getAnalysisCache("VN2_2") == nil {
+					t.Error("analysis not cached for the detail button")
+				}
+				if tb.getMessageContext("msg-1") == nil {
+					t.Error("no chat context saved for the analysis message")
+				}
+			}
+
+			if tt.wantError == "" {
+				if len(tb.session.EmbedEdits) != 0 {
+					t.Errorf("unexpected error edit: %q", tb.session.EmbedEdits[0].Description)
+				}
+				return
+			}
+			if len(tb.session.EmbedEdits) != 1 {
+				t.Fatalf("got %d error edits, want 1", len(tb.session.EmbedEdits))
+			}
+			if desc := tb.session.EmbedEdits[0].Description; !strings.Contains(desc, tt.wantError) {
+				t.Errorf("error embed %q does not mention %q", desc, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCheckPlayerMatchMentionsTeammates(t *testing.T) {
+	tb := newTestBot(t)
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tb.track("p2", "Lux#VN2", "VN2_2") // already saw the match
+	tb.riot.MatchIDs["p1"] = []string{"VN2_2"}
+	tb.riot.AddMatch("VN2_2", true, "p1", "p2")
+
+	player, _ := tb.trackedPlayers.Get("p1")
+	tb.checkPlayerMatch(context.Background(), "p1", player)
+
+	if len(tb.session.Sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(tb.session.Sent))
+	}
+	desc := tb.session.Sent[0].Embed.Description
+	for _, name := range []string{"Zoe#VN2", "Lux#VN2"} {
+		if !strings.Contains(desc, name) {
+			t.Errorf("notification %q does not mention %s", desc, name)
+		}
+	}
+}
+
+func TestCheckPlayerMatchLP(t *testing.T) {
+	tb := newTestBot(t)
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tb.history.AddRank(&storage.RankSnapshot{
+		PUUID: "p1", QueueType: storage.QueueSolo, MatchID: "VN2_1", TakenAt: time.Now().Add(-time.Hour),
+		Tier: "GOLD", Rank: "I", LP: 85, Wins: 10, Losses: 10,
+	})
+	tb.riot.Leagues["p1"] = []riot.LeagueEntryDTO{{QueueType: storage.QueueSolo, Tier: "PLATINUM", Rank: "IV", LeaguePoints: 6, Wins: 11, Losses: 10}}
+	tb.riot.MatchIDs["p1"] = []string{"VN2_2"}
+	tb.riot.AddMatch("VN2_2", true, "p1")
+
+	player, _ := tb.trackedPlayers.Get("p1")
+	tb.checkPlayerMatch(context.Background(), "p1", player)
+
+	if len(tb.session.Edited) != 1 {
+		t.Fatalf("edited %d messages, want 1", len(tb.session.Edited))
+	}
+	fields := (*tb.session.Edited[0].Embeds)[0].Fields
+	lp := fields[len(fields)-1]
+	if lp.Name != "📈 LP" || lp.Value != "**Zoe#VN2**: +21 LP → Platinum IV 6LP 🎉 Lên **Platinum**!" {
+		t.Errorf("LP field = %q: %q", lp.Name, lp.Value)
+	}
+
+	// The promotion is also announced as a milestone, after the match
+	last := tb.session.Sent[len(tb.session.Sent)-1]
+	if last.Embed.Title != "🎖️ CỘT MỐC RANK" || !strings.Contains(last.Embed.Description, "lên **Platinum**") || !strings.Contains(last.Embed.Description, "đỉnh mới") {
+		t.Errorf("milestone embed = %+v", last.Embed)
+	}
+
+	// A second check of the same game doesn't take another snapshot
+	if change, result := tb.snapshotRank(context.Background(), "p1", "VN2_2", false); change == nil || result != rankExisting || change.LPDelta() != 21 {
+		t.Errorf("snapshotRank again = %+v, %v; want the stored +21 change", change, result)
+	}
+
+	tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+	tb.handleInteraction(tb.session, commandInteraction("lp", stringOption("riot_id", "Zoe#VN2")))
+	embed := tb.session.LastEmbed()
+	if !strings.Contains(embed.Description, "Platinum IV 6LP") || !strings.Contains(embed.Description, "**+21 LP** (1T - 0B)") {
+		t.Errorf("/lp description = %q", embed.Description)
+	}
+	assertChart := func(command string) {
+		t.Helper()
+		edit := tb.session.Edits[len(tb.session.Edits)-1]
+		if len(edit.Files) != 1 || edit.Files[0].Name != "lp.png" || edit.Files[0].ContentType != "image/png" {
+			t.Fatalf("%s files = %+v, want the LP chart", command, edit.Files)
+		}
+		if image := (*edit.Embeds)[0].Image; image == nil || image.URL != "attachment://lp.png" {
+			t.Errorf("%s embed image = %+v", command, image)
+		}
+	}
+	assertChart("/lp")
+
+	tb.handleInteraction(tb.session, commandInteraction("leaderboard"))
+	assertChart("/leaderboard")
+
+	// The league entry lags behind the next game: no LP yet, and the game
+	// waits for the next poll instead of being snapshotted with no change.
+	// Snapshots are stored to the second, so move the last one back a poll.
+	previous, _, _ := tb.history.RankAt("p1", "VN2_2")
+	previous.TakenAt = previous.TakenAt.Add(-time.Minute)
+	tb.history.AddRank(previous)
+	// Polled like the admin API does, through the stored player
+	poll := func() {
+		t.Helper()
+		if _, err := tb.ForcePoll(context.Background(), "p1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tb.riot.MatchIDs["p1"] = []string{"VN2_3"}
+	tb.riot.AddMatch("VN2_3", true, "p1")
+	poll()
+	fields = (*tb.session.Edited[len(tb.session.Edited)-1].Embeds)[0].Fields
+	if lp := fields[len(fields)-1]; lp.Name == "📈 LP" {
+		t.Errorf("LP field %q before Riot counted the game", lp.Value)
+	}
+	if _, ok, _ := tb.history.RankChangeAt("p1", "VN2_3"); ok {
+		t.Error("snapshot taken before Riot counted the game")
+	}
+	poll()
+	if player, _ := tb.trackedPlayers.Get("p1"); !slices.Equal(player.PendingRanks, []string{"VN2_3"}) {
+		t.Fatalf("pending = %v, want the lagging game", player.PendingRanks)
+	}
+
+	sent := len(tb.session.Sent)
+	tb.riot.Leagues["p1"] = []riot.LeagueEntryDTO{{QueueType: storage.QueueSolo, Tier: "PLATINUM", Rank: "IV", LeaguePoints: 25, Wins: 12, Losses: 10}}
+	poll()
+	if change, ok, _ := tb.history.RankChangeAt("p1", "VN2_3"); !ok || change.LPDelta() != 19 {
+		t.Errorf("retried change = %+v, %v; want +19 LP", change, ok)
+	}
+	if player, _ := tb.trackedPlayers.Get("p1"); len(player.PendingRanks) != 0 {
+		t.Errorf("pending = %v after the retry", player.PendingRanks)
+	}
+	if len(tb.session.Sent) != sent {
+		t.Errorf("sent %d messages after the retry, want the match left as posted", len(tb.session.Sent)-sent)
+	}
+}
+
+func TestWindowStart(t *testing.T) {
+	saigon, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	tb := newTestBot(t)
+	if loc := tb.channelLocation(context.Background(), testChannel); loc.String() != tb.config().Recap.Timezone {
+		t.Errorf("location without guild settings = %v, want the configured %s", loc, tb.config().Recap.Timezone)
+	}
+	tb.guildSettings.Put(&storage.GuildSettings{GuildID: testGuild, Timezone: "Asia/Tokyo"})
+	if loc := tb.channelLocation(context.Background(), testChannel); loc.String() != "Asia/Tokyo" {
+		t.Errorf("location = %v, want the guild's Asia/Tokyo", loc)
+	}
+
+	// Monday 03:00 in Saigon is still Sunday in UTC
+	now := time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		window string
+		loc    *time.Location
+		want   time.Time
+	}{
+		{windowWeek, saigon, time.Date(2026, 10, 19, 0, 0, 0, 0, saigon)},
+		{windowWeek, time.UTC, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{windowMonth, saigon, time.Date(2026, 10, 1, 0, 0, 0, 0, saigon)},
+		{windowAll, saigon, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := windowStart(tt.window, now.In(tt.loc)); !got.Equal(tt.want) {
+			t.Errorf("windowStart(%s) in %v = %v, want %v", tt.window, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	tb := newTestBot(t)
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tb.track("p2", "Ga#VN2", "VN2_1")
+	tb.track("p3", "New#VN2", "VN2_1")
+
+	now := time.Now()
+	// Zoe: 2 of 3 recently, Ga: 3 of 4 but mostly months ago
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_10", PUUID: "p1", PlayedAt: now, Win: true, Kills: 10, Deaths: 2, Assists: 8, Score: 8.5})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_11", PUUID: "p1", PlayedAt: now, Win: true, Kills: 7, Deaths: 3, Assists: 5, Score: 7.5})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_12", PUUID: "p1", PlayedAt: now, Kills: 2, Deaths: 6, Assists: 3, Score: 4.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_20", PUUID: "p2", PlayedAt: now, Kills: 1, Deaths: 9, Assists: 2, Score: 2.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_21", PUUID: "p2", PlayedAt: now.AddDate(0, 0, -90), Win: true, Kills: 5, Deaths: 5, Assists: 5, Score: 6.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_22", PUUID: "p2", PlayedAt: now.AddDate(0, 0, -90), Win: true, Kills: 5, Deaths: 5, Assists: 5, Score: 6.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_23", PUUID: "p2", PlayedAt: now.AddDate(0, 0, -90), Win: true, Kills: 5, Deaths: 5, Assists: 5, Score: 6.0})
+
+	snap := func(puuid, matchID string, daysAgo int, tier, rank string, lp, wins, losses int) {
+		t.Helper()
+		err := tb.history.AddRank(&storage.RankSnapshot{
+			PUUID: puuid, QueueType: storage.QueueSolo, MatchID: matchID, TakenAt: now.AddDate(0, 0, -daysAgo),
+			Tier: tier, Rank: rank, LP: lp, Wins: wins, Losses: losses,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	snap("p1", "VN2_9", 90, "GOLD", "IV", 10, 10, 10)
+	snap("p1", "VN2_12", 0, "GOLD", "II", 30, 12, 11)
+	snap("p2", "VN2_23", 90, "PLATINUM", "IV", 50, 30, 20)
+	snap("p2", "VN2_20", 0, "PLATINUM", "IV", 30, 30, 21)
+	// New has no snapshot yet, so /leaderboard falls back to the league entry
+	tb.riot.Leagues["p3"] = []riot.LeagueEntryDTO{{QueueType: storage.QueueSolo, Tier: "EMERALD", Rank: "III", LeaguePoints: 1, Wins: 3, Losses: 2}}
+
+	tests := []struct {
+		options []*discordgo.ApplicationCommandInteractionDataOption
+		want    []string // players in order
+		wantIn  string
+	}{
+		{want: []string{"New#VN2", "Ga#VN2", "Zoe#VN2"}, wantIn: "Emerald III 1LP"},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("metric", "winrate")}, want: []string{"Ga#VN2", "Zoe#VN2"}, wantIn: "`75.0%` • 3T - 1B"},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("metric", "winrate"), stringOption("window", "week")}, want: []string{"Zoe#VN2"}},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("metric", "kda"), stringOption("window", "month")}, want: []string{"Zoe#VN2", "Ga#VN2"}, wantIn: "KDA **3.18**"},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("metric", "score")}, want: []string{"Zoe#VN2", "Ga#VN2"}, wantIn: "Điểm TB **6.7** (3 trận)"},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("metric", "lp")}, want: []string{"Zoe#VN2", "Ga#VN2"}, wantIn: "**+220 LP** → Gold II 30LP"},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("queue", storage.QueueFlex)}},
+	}
+	for _, tt := range tests {
+		tb.handleInteraction(tb.session, commandInteraction("leaderboard", tt.options...))
+		embed := tb.session.LastEmbed()
+		if len(tt.want) == 0 {
+			if embed.Color != embeds.ColorWarning {
+				t.Errorf("%v: embed = %+v, want a no data warning", tt.options, embed)
+			}
+			continue
+		}
+		last := -1
+		for _, name := range tt.want {
+			at := strings.Index(embed.Description, "**"+name+"**")
+			if at <= last {
+				t.Errorf("%v: %s missing or out of order in %q", tt.options, name, embed.Description)
+			}
+			last = at
+		}
+		if strings.Count(embed.Description, "┗") != len(tt.want) {
+			t.Errorf("%v: listed %d players, want %d", tt.options, strings.Count(embed.Description, "┗"), len(tt.want))
+		}
+		if !strings.Contains(embed.Description, tt.wantIn) {
+			t.Errorf("%v: description = %q, want %q", tt.options, embed.Description, tt.wantIn)
+		}
+	}
+}
+
+func TestRankMilestones(t *testing.T) {
+	snap := func(tier, rank string, lp, wins, losses int) storage.RankSnapshot {
+		return storage.RankSnapshot{Tier: tier, Rank: rank, LP: lp, Wins: wins, Losses: losses}
+	}
+	// streak builds the snapshots before a game, newest first, from the
+	// results of the games before it, oldest first
+	streak := func(results string) []storage.RankSnapshot {
+		wins, losses := 20, 20
+		earlier := []storage.RankSnapshot{snap("GOLD", "II", 50, wins, losses)}
+		for _, r := range results {
+			if r == 'W' {
+				wins++
+			} else {
+				losses++
+			}
+			earlier = append([]storage.RankSnapshot{snap("GOLD", "II", 50, wins, losses)}, earlier...)
+		}
+		return earlier
+	}
+
+	tests := []struct {
+		name    string
+		after   storage.RankSnapshot
+		earlier []storage.RankSnapshot
+		want    []string // milestone kinds
+		wantIn  string   // in the first line
+	}{
+		{
+			name:    "division promotion",
+			after:   snap("GOLD", "I", 10, 31, 20),
+			earlier: []storage.RankSnapshot{snap("GOLD", "II", 90, 30, 20), snap("GOLD", "I", 20, 28, 20)},
+			want:    []string{storage.MilestonePromotion},
+			wantIn:  "thăng lên **Gold I 10LP**",
+		},
+		{
+			name:    "tier demotion",
+			after:   snap("SILVER", "I", 75, 30, 21),
+			earlier: []storage.RankSnapshot{snap("GOLD", "IV", 0, 30, 20)},
+			want:    []string{storage.MilestonePromotion},
+			wantIn:  "rớt khỏi Gold",
+		},
+		{
+			name:   "placements",
+			after:  snap("DIAMOND", "III", 0, 4, 1),
+			want:   []string{storage.MilestonePlacements},
+			wantIn: "tôn trọng",
+		},
+		{
+			name:  "first snapshot mid season",
+			after: snap("GOLD", "II", 50, 40, 40),
+		},
+		{
+			name:    "season reset is placements, not a demotion",
+			after:   snap("SILVER", "I", 0, 3, 2),
+			earlier: []storage.RankSnapshot{snap("PLATINUM", "IV", 30, 100, 90)},
+			want:    []string{storage.MilestonePlacements},
+			wantIn:  "nông nỗi",
+		},
+		{
+			name:    "new peak",
+			after:   snap("GOLD", "I", 10, 33, 20),
+			earlier: []storage.RankSnapshot{snap("GOLD", "II", 90, 32, 20), snap("GOLD", "II", 70, 31, 20), snap("GOLD", "III", 50, 28, 20)},
+			want:    []string{storage.MilestonePromotion, storage.MilestonePeak},
+		},
+		{
+			name:    "five wins in a row",
+			after:   snap("GOLD", "II", 50, 25, 20),
+			earlier: streak("WWWW"),
+			want:    []string{storage.MilestoneStreak},
+			wantIn:  "thắng **5 trận liên tiếp**",
+		},
+		{
+			name:    "sixth win isn't announced again",
+			after:   snap("GOLD", "II", 50, 26, 20),
+			earlier: streak("WWWWW"),
+		},
+		{
+			name:    "loss streak ends",
+			after:   snap("GOLD", "II", 50, 21, 26),
+			earlier: streak("LLLLLL"),
+			want:    []string{storage.MilestoneStreak},
+			wantIn:  "sau **6 trận thua**",
+		},
+		{
+			name:    "missed games break the streak",
+			after:   snap("GOLD", "II", 50, 26, 20),
+			earlier: []storage.RankSnapshot{snap("GOLD", "II", 50, 24, 20), snap("GOLD", "II", 50, 23, 20), snap("GOLD", "II", 50, 22, 20), snap("GOLD", "II", 50, 21, 20)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := rankMilestones("Zoe#VN2", &storage.RankChange{After: &tt.after}, tt.earlier)
+			var kinds []string
+			for _, m := range found {
+				kinds = append(kinds, m.Kind)
+			}
+			if fmt.Sprint(kinds) != fmt.Sprint(tt.want) {
+				t.Fatalf("milestones = %v, want %v", kinds, tt.want)
+			}
+			if tt.wantIn != "" && !strings.Contains(found[0].Line, tt.wantIn) {
+				t.Errorf("line = %q, want it to contain %q", found[0].Line, tt.wantIn)
+			}
+		})
+	}
+}
+
+func TestMilestonesCommand(t *testing.T) {
+	tb := newTestBot(t)
+	set := func(kind string, enabled bool) *discordgo.InteractionCreate {
+		i := commandInteraction("milestones", &discordgo.ApplicationCommandInteractionDataOption{
+			Name: "set",
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				stringOption("type", kind),
+				{Name: "enabled", Type: discordgo.ApplicationCommandOptionBoolean, Value: enabled},
+			},
+		})
+		i.GuildID = testGuild
+		return i
+	}
+
+	if err := tb.handleMilestones(context.Background(), tb.session, set(storage.MilestonePromotion, false)); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("set without permission: err = %v, want ErrForbidden", err)
+	}
+
+	i := set(storage.MilestonePromotion, false)
+	i.Member = &discordgo.Member{Permissions: discordgo.PermissionManageServer}
+	if err := tb.handleMilestones(context.Background(), tb.session, i); err != nil {
+		t.Fatal(err)
+	}
+	if embed := tb.session.LastEmbed(); !strings.Contains(embed.Description, "❌ Thăng/rớt hạng") || !strings.Contains(embed.Description, "✅ Đỉnh cao") {
+		t.Errorf("/milestones set reply = %q", embed.Description)
+	}
+
+	// With promotions off, only the new peak of the same game is announced
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tb.history.AddRank(&storage.RankSnapshot{
+		PUUID: "p1", QueueType: storage.QueueSolo, MatchID: "VN2_1", TakenAt: time.Now().Add(-time.Hour),
+		Tier: "GOLD", Rank: "I", LP: 85, Wins: 10, Losses: 10,
+	})
+	tb.riot.Leagues["p1"] = []riot.LeagueEntryDTO{{QueueType: storage.QueueSolo, Tier: "PLATINUM", Rank: "IV", LeaguePoints: 6, Wins: 11, Losses: 10}}
+	tb.riot.MatchIDs["p1"] = []string{"VN2_2"}
+	tb.riot.AddMatch("VN2_2", true, "p1")
+	player, _ := tb.trackedPlayers.Get("p1")
+	tb.checkPlayerMatch(context.Background(), "p1", player)
+
+	last := tb.session.Sent[len(tb.session.Sent)-1].Embed
+	if last.Title != "🎖️ CỘT MỐC RANK" || strings.Contains(last.Description, "lên **Platinum**") || !strings.Contains(last.Description, "đỉnh mới") {
+		t.Errorf("milestone embed = %q", last.Description)
+	}
+}
+
+func TestRecap(t *testing.T) {
+	tb := newTestBot(t)
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tb.track("p2", "Ga#VN2", "VN2_1")
+	tb.track("p3", "Afk#VN2", "VN2_1")
+
+	saigon, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	// The default schedule fires Sundays at 20:00
+	fire := time.Date(2026, 10, 18, 20, 0, 0, 0, saigon)
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_10", PUUID: "p1", RiotID: "p1", PlayedAt: fire.AddDate(0, 0, -6), Champion: "Zoe", Win: true, Kills: 5, Deaths: 5, Assists: 5, Score: 8.5})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_11", PUUID: "p1", RiotID: "p1", PlayedAt: fire.AddDate(0, 0, -5), Champion: "Zoe", Win: true, Kills: 5, Deaths: 5, Assists: 5, Score: 7.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_12", PUUID: "p1", RiotID: "p1", PlayedAt: fire.AddDate(0, 0, -4), Champion: "Ahri", Win: true, Kills: 5, Deaths: 5, Assists: 5, Score: 7.5})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_20", PUUID: "p2", RiotID: "p2", PlayedAt: fire.AddDate(0, 0, -3), Champion: "Garen", Kills: 5, Deaths: 5, Assists: 5, Score: 2.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_21", PUUID: "p2", RiotID: "p2", PlayedAt: fire.AddDate(0, 0, -2), Champion: "Zoe", Kills: 5, Deaths: 5, Assists: 5, Score: 4.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_22", PUUID: "p2", RiotID: "p2", PlayedAt: fire.AddDate(0, 0, -10), Champion: "Garen", Win: true, Kills: 5, Deaths: 5, Assists: 5, Score: 9.9}) // last week
+	for n, lp := range []int{20, 41, 62} {
+		tb.history.AddRank(&storage.RankSnapshot{
+			PUUID: "p1", QueueType: storage.QueueSolo, MatchID: fmt.Sprintf("VN2_1%d", n), TakenAt: fire.AddDate(0, 0, n-6),
+			Tier: "GOLD", Rank: "IV", LP: lp, Wins: 10 + n, Losses: 10,
+		})
+	}
+
+	tb.postDueRecaps(fire.Add(-30*time.Second), fire.Add(-time.Second))
+	if len(tb.session.Sent) != 0 {
+		t.Fatalf("posted %d messages before the schedule fired", len(tb.session.Sent))
+	}
+	tb.postDueRecaps(fire.Add(-time.Second), fire.Add(29*time.Second))
+	tb.postDueRecaps(fire.Add(-time.Second), fire.Add(29*time.Second)) // another instance
+	if len(tb.session.Sent) != 1 {
+		t.Fatalf("posted %d messages, want one recap", len(tb.session.Sent))
+	}
+
+	sent := tb.session.Sent[0]
+	if sent.ChannelID != testChannel || sent.Embed.Title != "📅 TỔNG KẾT - #general" || sent.Embed.Description != tb.ai.Answer {
+		t.Errorf("recap = %+v", sent.Embed)
+	}
+	if len(sent.Files) != 1 || sent.Embed.Image == nil {
+		t.Error("recap has no LP chart")
+	}
+	fields := make(map[string]string)
+	for _, f := range sent.Embed.Fields {
+		fields[f.Name] = f.Value
+	}
+	for name, want := range map[string]string{
+		"🎮 Người chơi":            "**Zoe#VN2** • 3 trận (3T - 0B) • +42 LP\n**Ga#VN2** • 2 trận (0T - 2B)\n",
+		"⭐ Trận hay nhất":         "**p1** - Zoe **8.5** điểm",
+		"💀 Trận tệ nhất":          "**p2** - Garen **2.0** điểm",
+		"🦸 Tướng được chơi nhiều": "Zoe (3) • Ahri (1) • Garen (1)",
+		"📈 Chuỗi dài nhất":        "🔥 **Zoe#VN2** thắng **3 trận liên tiếp**",
+	} {
+		if !strings.HasPrefix(fields[name], want) {
+			t.Errorf("%s = %q, want %q", name, fields[name], want)
+		}
+	}
+	if ctx := tb.getMessageContext("msg-1"); ctx == nil || ctx.Type != "recap" {
+		t.Errorf("recap context = %+v", ctx)
+	}
+
+	// A channel can turn its recaps off
+	recap := func(sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) error {
+		i := commandInteraction("recap", &discordgo.ApplicationCommandInteractionDataOption{
+			Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options,
+		})
+		i.GuildID = testGuild
+		i.Member = &discordgo.Member{Permissions: discordgo.PermissionManageServer}
+		return tb.handleRecap(context.Background(), tb.session, i)
+	}
+	if err := recap("schedule", stringOption("cron", "0 25 * * *")); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("invalid schedule: err = %v, want ErrInvalidInput", err)
+	}
+	if err := recap("timezone", stringOption("name", "Mars/Olympus")); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("invalid time zone: err = %v, want ErrInvalidInput", err)
+	}
+	if err := recap("timezone", stringOption("name", "Asia/Tokyo")); err != nil {
+		t.Fatal(err)
+	}
+	if err := recap("schedule", stringOption("cron", "0 9 * * mon")); err != nil {
+		t.Fatal(err)
+	}
+	if embed := tb.session.LastEmbed(); !strings.Contains(embed.Description, "`0 9 * * mon` (riêng của kênh)") || !strings.Contains(embed.Description, "Asia/Tokyo") {
+		t.Errorf("/recap schedule reply = %q", embed.Description)
+	}
+	if err := recap("schedule", stringOption("cron", "OFF")); err != nil {
+		t.Fatal(err)
+	}
+	next := fire.AddDate(0, 0, 7)
+	tb.postDueRecaps(next.Add(-time.Second), next.Add(time.Second))
+	if len(tb.session.Sent) != 1 {
+		t.Error("posted a recap with recaps off")
+	}
+}
+
+func TestSessions(t *testing.T) {
+	tb := newTestBot(t)
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tb.track("p2", "Ga#VN2", "VN2_1")
+
+	now := time.Now()
+	// Zoe: an earlier game, then four in a row ending on three losses
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_10", PUUID: "p1", PlayedAt: now.Add(-5 * time.Hour), Duration: 30 * time.Minute, Champion: "Zoe", Win: true, Deaths: 2, Score: 9.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_11", PUUID: "p1", PlayedAt: now.Add(-3 * time.Hour), Duration: 30 * time.Minute, Champion: "Zoe", Win: true, Deaths: 3, Score: 8.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_12", PUUID: "p1", PlayedAt: now.Add(-150 * time.Minute), Duration: 30 * time.Minute, Champion: "Ahri", Deaths: 9, Score: 7.5})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_13", PUUID: "p1", PlayedAt: now.Add(-110 * time.Minute), Duration: 30 * time.Minute, Champion: "Zoe", Deaths: 10, Score: 5.0})
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_14", PUUID: "p1", PlayedAt: now.Add(-70 * time.Minute), Duration: 30 * time.Minute, Champion: "Zoe", Deaths: 12, Score: 4.0})
+	// Ga: one game only
+	tb.addGame(t, storage.MatchRecord{MatchID: "VN2_20", PUUID: "p2", PlayedAt: now.Add(-2 * time.Hour), Duration: 30 * time.Minute, Champion: "Garen", Win: true, Deaths: 1, Score: 6.0})
+
+	records, _ := tb.history.ByPlayer("p1", storage.HistoryQuery{})
+	if got := lastSession(records, 45*time.Minute); len(got) != 4 || got[0].MatchID != "VN2_11" || got[3].MatchID != "VN2_14" {
+		t.Errorf("lastSession = %v, want VN2_11 to VN2_14", got)
+	}
+
+	// Still playing: nothing yet
+	tb.postDueSessions(now.Add(-40 * time.Minute))
+	if len(tb.session.Sent) != 0 {
+		t.Fatalf("posted %d summaries while Zoe was still playing", len(tb.session.Sent))
+	}
+
+	tb.postDueSessions(now)
+	tb.postDueSessions(now.Add(time.Minute))
+	if len(tb.session.Sent) != 1 {
+		t.Fatalf("posted %d messages, want one summary", len(tb.session.Sent))
+	}
+	embed := tb.session.Sent[0].Embed
+	if embed.Title != "🎮 TỔNG KẾT PHIÊN - Zoe#VN2" || !strings.Contains(embed.Description, "4 trận") || !strings.Contains(embed.Description, "✅❌❌❌") {
+		t.Errorf("summary = %q %q", embed.Title, embed.Description)
+	}
+	fields := make(map[string]string)
+	for _, f := range embed.Fields {
+		fields[f.Name] = f.Value
+	}
+	if fields["📊 Thành tích"] != "1T - 3B (25%)" || fields["⭐ Điểm TB"] != "6.1" || fields["🦸 Tướng"] != "Zoe ×3 • Ahri ×1" {
+		t.Errorf("summary fields = %v", fields)
+	}
+	for _, want := range []string{"Thua **3 trận cuối**", "Điểm tụt dần: **7.8** → **4.5**", "Chết trung bình **8.5**"} {
+		if !strings.Contains(fields["⚠️ Dấu hiệu tilt"], want) {
+			t.Errorf("tilt = %q, want %q", fields["⚠️ Dấu hiệu tilt"], want)
+		}
+	}
+
+	// In summary only mode a single game is a session too, and games
+	// aren't posted one by one
+	tb.guildSettings.Put(&storage.GuildSettings{GuildID: testGuild, Notify: storage.NotifySummaries})
+	tb.postDueSessions(now)
+	if len(tb.session.Sent) != 2 || tb.session.Sent[1].Embed.Title != "🎮 TỔNG KẾT PHIÊN - Ga#VN2" {
+		t.Fatalf("sent %d messages, want Ga's one game summed up", len(tb.session.Sent))
+	}
+
+	tb.riot.MatchIDs["p2"] = []string{"VN2_2"}
+	tb.riot.AddMatch("VN2_2", true, "p2")
+	player, _ := tb.trackedPlayers.Get("p2")
+	if !tb.checkPlayerMatch(context.Background(), "p2", player) {
+		t.Fatal("new match not found")
+	}
+	if len(tb.session.Sent) != 2 {
+		t.Errorf("posted the game in summary only mode")
+	}
+	if _, ok, _ := tb.history.Get("p2", "VN2_2"); !ok {
+		t.Error("game not recorded in summary only mode")
+	}
+}
+
+func TestNotifyCommand(t *testing.T) {
+	tb := newTestBot(t)
+	i := commandInteraction("notify", stringOption("mode", storage.NotifySummaries))
+	i.GuildID = testGuild
+	if err := tb.handleNotify(context.Background(), tb.session, i); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("without permission: err = %v, want ErrForbidden", err)
+	}
+
+	i.Member = &discordgo.Member{Permissions: discordgo.PermissionManageServer}
+	if err := tb.handleNotify(context.Background(), tb.session, i); err != nil {
+		t.Fatal(err)
+	}
+	if embed := tb.session.LastEmbed(); !strings.Contains(embed.Description, "🔘 Chỉ tổng kết phiên") || !strings.Contains(embed.Description, "**45 phút**") {
+		t.Errorf("/notify reply = %q", embed.Description)
+	}
+	if settings, _ := tb.guildSettings.Get(testGuild); settings.NotifyMode() != storage.NotifySummaries {
+		t.Errorf("notify mode = %q, want %q", settings.NotifyMode(), storage.NotifySummaries)
+	}
+}
+
+func TestTiltAlerts(t *testing.T) {
+	tb := newTestBot(t)
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tb.track("p2", "Ga#VN2", "VN2_1")
+	tb.track("p3", "Lux#VN2", "VN2_1")
+	tb.track("p4", "Ahri#VN2", "VN2_1")
+	tb.trackedPlayers.UpdateTilt("p1", "user-1", false)
+	tb.trackedPlayers.UpdateTilt("p3", "", true)
+	tb.trackedPlayers.UpdateTilt("p4", "missing-user", false)
+
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	now := time.Date(2026, 10, 17, 20, 0, 0, 0, loc)
+	// Zoe, Lux and Ahri: three losses, each worse than the last
+	for _, puuid := range []string{"p1", "p3", "p4"} {
+		tb.addGame(t, storage.MatchRecord{MatchID: puuid + "_1", PUUID: puuid, PlayedAt: now.Add(-90 * time.Minute), Duration: 30 * time.Minute, Champion: "Zoe", Deaths: 4, Score: 7.0})
+		tb.addGame(t, storage.MatchRecord{MatchID: puuid + "_2", PUUID: puuid, PlayedAt: now.Add(-50 * time.Minute), Duration: 30 * time.Minute, Champion: "Zoe", Deaths: 7, Score: 5.5})
+		tb.addGame(t, storage.MatchRecord{MatchID: puuid + "_3", PUUID: puuid, PlayedAt: now.Add(-10 * time.Minute), Duration: 30 * time.Minute, Champion: "Zoe", Deaths: 9, Score: 4.0})
+	}
+	// Ga: doing fine
+	tb.addGame(t, storage.MatchRecord{MatchID: "p2_1", PUUID: "p2", PlayedAt: now.Add(-10 * time.Minute), Duration: 30 * time.Minute, Champion: "Zoe", Win: true, Deaths: 1, Score: 8.0})
+
+	tb.postTiltAlerts(now)
+	tb.postTiltAlerts(now.Add(time.Minute))
+	if len(tb.session.Sent) != 2 {
+		t.Fatalf("sent %d alerts, want Zoe's and Ahri's once each", len(tb.session.Sent))
+	}
+	sentTo := make(map[string]*discordgo.MessageEmbed)
+	for _, m := range tb.session.Sent {
+		sentTo[m.ChannelID] = m.Embed
+	}
+	embed := sentTo["dm-user-1"]
+	if embed == nil || embed.Title != "🌱 ĐI CHẠM CỎ ĐI" {
+		t.Fatalf("Zoe's alert not sent by DM: %v", sentTo)
+	}
+	for _, want := range []string{"**Zoe#VN2**", "Thua **3 trận liên tiếp**", "Chết ngày càng nhiều: 4 → 7 → 9", "Điểm tụt dần: 7.0 → 5.5 → 4.0"} {
+		if !strings.Contains(embed.Description, want) {
+			t.Errorf("alert = %q, want %q", embed.Description, want)
+		}
+	}
+	if embed := sentTo[testChannel]; embed == nil || !strings.Contains(embed.Description, "**Ahri#VN2**") {
+		t.Errorf("Ahri's alert not posted in the channel after the DM failed: %v", sentTo)
+	}
+
+	// Games long over don't get a nudge
+	tb.trackedPlayers.UpdateTilt("p3", "", false)
+	tb.postTiltAlerts(now.Add(time.Hour))
+	if len(tb.session.Sent) != 2 {
+		t.Errorf("nudged a player who stopped playing an hour ago")
+	}
+
+	late := []storage.MatchRecord{{MatchID: "VN2_1", Win: true, PlayedAt: time.Date(2026, 10, 17, 19, 30, 0, 0, time.UTC)}}
+	if signs := tiltSigns(late, loc); len(signs) != 1 || signs[0].Kind != "late" || !strings.Contains(signs[0].Text, "02:30") {
+		t.Errorf("tiltSigns at 02:30 = %v, want a late night sign", signs)
+	}
+	if signs := tiltSigns(late, time.UTC); len(signs) != 0 {
+		t.Errorf("tiltSigns at 19:30 = %v, want none", signs)
+	}
+}
+
+func TestTiltCommand(t *testing.T) {
+	tb := newTestBot(t)
+	tb.track("p1", "Zoe#VN2", "VN2_1")
+	tilt := func(userID, sub, riotID string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+		i := commandInteraction("tilt", &discordgo.ApplicationCommandInteractionDataOption{
+			Name:    sub,
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: append([]*discordgo.ApplicationCommandInteractionDataOption{stringOption("riot_id", riotID)}, options...),
+		})
+		i.GuildID = testGuild
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: userID}}
+		return i
+	}
+	disable := &discordgo.ApplicationCommandInteractionDataOption{Name: "enabled", Type: discordgo.ApplicationCommandOptionBoolean, Value: false}
+
+	if err := tb.handleTilt(context.Background(), tb.session, tilt("user-1", "link", "Faker#KR1")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("link untracked player: err = %v, want ErrNotFound", err)
+	}
+	// Players can't be claimed: only managers link them, to themselves or
+	// to someone else
+	if err := tb.handleTilt(context.Background(), tb.session, tilt("user-1", "link", "zoe#vn2")); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("link by a member: err = %v, want ErrForbidden", err)
+	}
+	if player, _ := tb.trackedPlayers.Get("p1"); player.DiscordID != "" {
+		t.Fatalf("a member linked the player to %s", player.DiscordID)
+	}
+	link := tilt("user-3", "link", "zoe#vn2", &discordgo.ApplicationCommandInteractionDataOption{Name: "user", Type: discordgo.ApplicationCommandOptionUser, Value: "user-1"})
+	link.Member.Permissions = discordgo.PermissionManageServer
+	if err := tb.handleTilt(context.Background(), tb.session, link); err != nil {
+		t.Fatal(err)
+	}
+	if embed := tb.session.LastEmbed(); !strings.Contains(embed.Description, "tin nhắn riêng của <@user-1>") {
+		t.Errorf("/tilt link reply = %q", embed.Description)
+	}
+
+	if err := tb.handleTilt(context.Background(), tb.session, tilt("user-2", "alerts", "Zoe#VN2", disable)); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("alerts by another user: err = %v, want ErrForbidden", err)
+	}
+	if err := tb.handleTilt(context.Background(), tb.session, tilt("user-1", "alerts", "Zoe#VN2", disable)); err != nil {
+		t.Fatal(err)
+	}
+	if player, _ := tb.trackedPlayers.Get("p1"); player.DiscordID != "user-1" || !player.TiltOff {
+		t.Errorf("player = %+v, want linked to user-1 with alerts off", player)
+	}
+
+	manager := tilt("user-2", "unlink", "Zoe#VN2")
+	manager.Member.Permissions = discordgo.PermissionManageServer
+	if err := tb.handleTilt(context.Background(), tb.session, manager); err != nil {
+		t.Fatal(err)
+	}
+	if player, _ := tb.trackedPlayers.Get("p1"); player.DiscordID != "" || !player.TiltOff {
+		t.Errorf("player = %+v, want unlinked with alerts still off", player)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		setup       func(tb *testBot)
+
+		wantColor   int
+		wantText    string // in the final embed's description
+		wantTracked map[string]bool
+	}{
+		{
+			name:        "ping",
+			interaction: commandInteraction("ping"),
+			wantColor:   embeds.ColorWin,
+			wantText:    "42ms",
+		},
+		{
+			name:        "track",
+			interaction: commandInteraction("track", stringOption("riot_id", "Zoe#VN2")),
+			setup: func(tb *testBot) {
+				tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+				tb.riot.MatchIDs["p1"] = []string{"VN2_1"}
+			},
+			wantColor:   embeds.ColorWin,
+			wantText:    "Zoe#VN2",
+			wantTracked: map[string]bool{"p1": true},
+		},
+		{
+			name:        "track invalid riot id",
+			interaction: commandInteraction("track", stringOption("riot_id", "Zoe")),
+			wantColor:   embeds.ColorLose,
+			wantText:    "Name#Tag",
+		},
+		{
+			name:        "track unknown player",
+			interaction: commandInteraction("track", stringOption("riot_id", "Nobody#VN2")),
+			wantColor:   embeds.ColorLose,
+			wantText:    "Không tìm thấy",
+		},
+		{
+			name:        "track twice",
+			interaction: commandInteraction("track", stringOption("riot_id", "Zoe#VN2")),
+			setup: func(tb *testBot) {
+				tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+				tb.track("p1", "Zoe#VN2", "VN2_1")
+			},
+			wantColor:   embeds.ColorWarning,
+			wantText:    "đã được theo dõi",
+			wantTracked: map[string]bool{"p1": true},
+		},
+		{
+			name:        "untrack",
+			interaction: commandInteraction("untrack", stringOption("riot_id", "Zoe#VN2")),
+			setup: func(tb *testBot) {
+				tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+				tb.track("p1", "Zoe#VN2", "VN2_1")
+			},
+			wantColor:   embeds.ColorWin,
+			wantText:    "Đã huỷ theo dõi",
+			wantTracked: map[string]bool{"p1": false},
+		},
+		{
+			name:        "untrack not tracked",
+			interaction: commandInteraction("untrack", stringOption("riot_id", "Zoe#VN2")),
+			setup: func(tb *testBot) {
+				tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+			},
+			wantColor: embeds.ColorLose,
+			wantText:  "Không tìm thấy",
+		},
+		{
+			name:        "list",
+			interaction: commandInteraction("list"),
+			setup: func(tb *testBot) {
+				tb.track("p1", "Zoe#VN2", "VN2_1")
+			},
+			wantText: "Zoe#VN2",
+		},
+		{
+			name:        "analyze without recent matches",
+			interaction: commandInteraction("analyze", stringOption("riot_id", "Zoe#VN2")),
+			setup: func(tb *testBot) {
+				tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+			},
+			wantColor: embeds.ColorLose,
+			wantText:  "chưa đánh trận nào",
+		},
+		{
+			name:        "counter scraper failure",
+			interaction: commandInteraction("counter", stringOption("champion", "Yasuo")),
+			setup: func(tb *testBot) {
+				tb.scraper.Err = errors.New("status 503")
+			},
+			wantColor: embeds.ColorLose,
+			wantText:  "Không tìm thấy dữ liệu khắc chế",
+		},
+		{
+			name:        "counter without data",
+			interaction: commandInteraction("counter", stringOption("champion", "Yasuo"), stringOption("lane", "mid")),
+			setup: func(tb *testBot) {
+				tb.scraper.Counters = &scraper.CounterData{}
+			},
+			wantColor: embeds.ColorLose,
+			wantText:  "Không có dữ liệu",
+		},
+		{
+			name:        "admin needs an owner",
+			interaction: commandInteraction("admin", &discordgo.ApplicationCommandInteractionDataOption{Name: "status", Type: discordgo.ApplicationCommandOptionSubCommand}),
+			wantColor:   embeds.ColorLose,
+			wantText:    "owner",
+		},
+		{
+			name:        "copy match id",
+			interaction: componentInteraction("copy_VN2_7"),
+		},
+		{
+			name:        "detail after the cache expired",
+			interaction: componentInteraction("detail_VN2_7_p1"),
+			wantColor:   embeds.ColorLose,
+			wantText:    "hết hạn",
+		},
+		{
+			name:        "track button",
+			interaction: componentInteraction("track_Zoe#VN2_" + testChannel),
+			setup: func(tb *testBot) {
+				tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+			},
+			wantColor:   embeds.ColorWin,
+			wantText:    "Zoe#VN2",
+			wantTracked: map[string]bool{"p1": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBot(t)
+			if tt.setup != nil {
+				tt.setup(tb)
+			}
+
+			tb.handleInteraction(tb.session, tt.interaction)
+
+			embed := tb.session.LastEmbed()
+			if tt.wantText == "" && tt.wantColor == 0 {
+				if embed != nil {
+					t.Errorf("unexpected embed %q", embed.Description)
+				}
+			} else {
+				if embed == nil {
+					t.Fatal("no embed was shown")
+				}
+				if tt.wantColor != 0 && embed.Color != tt.wantColor {
+					t.Errorf("embed color = %#x, want %#x (%q)", embed.Color, tt.wantColor, embed.Description)
+				}
+				if !strings.Contains(embed.Description, tt.wantText) {
+					t.Errorf("embed %q does not contain %q", embed.Description, tt.wantText)
+				}
+			}
+
+			for puuid, want := range tt.wantTracked {
+				if _, got := tb.trackedPlayers.Get(puuid); got != want {
+					t.Errorf("tracked(%s) = %v, want %v", puuid, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeCommand(t *testing.T) {
+	tb := newTestBot(t)
+	tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+	tb.riot.MatchIDs["p1"] = []string{"VN2_9"}
+	tb.riot.AddMatch("VN2_9", false, "p1", "p2", "p3")
+
+	tb.handleInteraction(tb.session, commandInteraction("analyze", stringOption("riot_id", "Zoe#VN2")))
+
+	if len(tb.ai.Analyzed) != 1 || tb.ai.Analyzed[0] != "VN2_9" {
+		t.Fatalf("analyzed %v, want [VN2_9]", tb.ai.Analyzed)
+	}
+
+	last := tb.session.Edits[len(tb.session.Edits)-1]
+	if last.Components == nil {
+		t.Fatal("analysis has no buttons")
+	}
+	row := (*last.Components)[0].(discordgo.ActionsRow)
+	var ids []string
+	for _, c := range row.Components {
+		ids = append(ids, c.(discordgo.Button).CustomID)
+	}
+	want := []string{"detail_VN2_9_p1", "copy_VN2_9", "track_Zoe#VN2_" + testChannel}
+	if strings.Join(ids, " ") != strings.Join(want, " ") {
+		t.Errorf("buttons = %v, want %v", ids, want)
+	}
+	if tb.getMessageContext("resp-i-1") == nil {
+		t.Error("no chat context saved for the analysis")
+	}
+
+	// The detail button now shows one embed per player
+	tb.session.Responds = nil
+	tb.handleInteraction(tb.session, componentInteraction("detail_VN2_9_p1"))
+	if len(tb.session.Responds) != 1 || len(tb.session.Responds[0].Data.Embeds) != 3 {
+		t.Errorf("detail button responded %+v, want 3 player embeds", tb.session.Responds)
+	}
+}
+
+func TestHistoryCommand(t *testing.T) {
+	tb := newTestBot(t)
+	tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+	for n := 7; n >= 1; n-- {
+		matchID := fmt.Sprintf("VN2_%d", n)
+		tb.riot.MatchIDs["p1"] = append(tb.riot.MatchIDs["p1"], matchID)
+		tb.riot.AddMatch(matchID, n%2 == 0, "p1", "p2")
+	}
+	tb.riot.Matches["VN2_7"].Info.QueueID = 450
+
+	count := &discordgo.ApplicationCommandInteractionDataOption{Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(7)}
+	tb.handleInteraction(tb.session, commandInteraction("history", stringOption("riot_id", "Zoe#VN2"), count))
+
+	last := tb.session.Edits[len(tb.session.Edits)-1]
+	embed := (*last.Embeds)[0]
+	if len(embed.Fields) != 5 || embed.Footer.Text != "Trang 1/2" {
+		t.Fatalf("first page has %d matches, footer %q; want 5, Trang 1/2", len(embed.Fields), embed.Footer.Text)
+	}
+	rows := *last.Components
+	buttons := rows[0].(discordgo.ActionsRow).Components
+	if prev := buttons[0].(discordgo.Button); !prev.Disabled {
+		t.Error("previous button enabled on the first page")
+	}
+	if next := buttons[1].(discordgo.Button); next.CustomID != "histpage_i-1_1" || next.Disabled {
+		t.Errorf("next button = %+v, want enabled histpage_i-1_1", next)
+	}
+	menu := rows[1].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu)
+	if menu.CustomID != "histpick_i-1" || len(menu.Options) != 5 || menu.Options[0].Value != "VN2_7" {
+		t.Errorf("select menu = %+v, want 5 matches starting at VN2_7", menu)
+	}
+
+	// Next page updates the message in place
+	tb.handleInteraction(tb.session, componentInteraction("histpage_i-1_1"))
+	resp := tb.session.Responds[len(tb.session.Responds)-1]
+	if resp.Type != discordgo.InteractionResponseUpdateMessage || len(resp.Data.Embeds[0].Fields) != 2 {
+		t.Errorf("page 2 = %+v, want an update with 2 matches", resp)
+	}
+
+	// Picking a match runs the full analysis
+	pick := componentInteraction("histpick_i-1")
+	pick.Data = discordgo.MessageComponentInteractionData{CustomID: "histpick_i-1", Values: []string{"VN2_3"}}
+	tb.handleInteraction(tb.session, pick)
+	if len(tb.ai.Analyzed) != 1 || tb.ai.Analyzed[0] != "VN2_3" {
+		t.Errorf("analyzed %v, want [VN2_3]", tb.ai.Analyzed)
+	}
+
+	// Buttons of an expired /history only explain themselves
+	tb.handleInteraction(tb.session, componentInteraction("histpage_i-2_1"))
+	resp = tb.session.Responds[len(tb.session.Responds)-1]
+	if resp.Data.Flags != discordgo.MessageFlagsEphemeral || !strings.Contains(resp.Data.Embeds[0].Description, "hết hạn") {
+		t.Errorf("expired page responded %+v, want an ephemeral expiry notice", resp.Data)
+	}
+
+	// The queue filter is passed to Riot
+	queue := &discordgo.ApplicationCommandInteractionDataOption{Name: "queue", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(450)}
+	tb.handleInteraction(tb.session, commandInteraction("history", stringOption("riot_id", "Zoe#VN2"), queue))
+	embed = tb.session.LastEmbed()
+	if len(embed.Fields) != 1 || embed.Description != "ARAM" {
+		t.Errorf("ARAM history = %d matches, %q; want 1, ARAM", len(embed.Fields), embed.Description)
+	}
+}
+
+func TestProfileCommand(t *testing.T) {
+	tb := newTestBot(t)
+	tb.riot.PUUIDs["Zoe#VN2"] = "p1"
+	tb.riot.Leagues["p1"] = []riot.LeagueEntryDTO{{QueueType: "RANKED_SOLO_5x5", Tier: "EMERALD", Rank: "II", LeaguePoints: 47, Wins: 58, Losses: 51}}
+	tb.riot.Masteries["p1"] = []riot.ChampionMasteryDTO{{ChampionID: 142, ChampionLevel: 42, ChampionPoints: 512340}}
+	for n, win := range []bool{true, true, false} {
+		matchID := fmt.Sprintf("VN2_%d", n)
+		tb.riot.MatchIDs["p1"] = append(tb.riot.MatchIDs["p1"], matchID)
+		tb.riot.AddMatch(matchID, win, "p1")
+		tb.riot.Matches[matchID].Info.Participants[0].Kills = 4
+		tb.riot.Matches[matchID].Info.Participants[0].Deaths = 2
+	}
+
+	tb.handleInteraction(tb.session, commandInteraction("profile", stringOption("riot_id", "Zoe#VN2")))
+
+	embed := tb.session.LastEmbed()
+	if embed.Description != "Cấp **321**" {
+		t.Errorf("description = %q, want the summoner level", embed.Description)
+	}
+	fields := make(map[string]string)
+	for _, f := range embed.Fields {
+		fields[f.Name] = f.Value
+	}
+	if got := fields["🏆 Đơn/Đôi"]; !strings.HasPrefix(got, "Emerald II 47LP\n58T - 51B") {
+		t.Errorf("solo = %q", got)
+	}
+	if got := fields["👥 Linh hoạt"]; got != "Unranked" {
+		t.Errorf("flex = %q, want Unranked", got)
+	}
+	if got := fields["⭐ Thông thạo (tổng cấp 42)"]; !strings.Contains(got, "Tướng #142") || !strings.Contains(got, "512.3K") {
+		t.Errorf("mastery = %q", got)
+	}
+	if got := fields["📈 Phong độ 3 trận gần nhất"]; !strings.HasPrefix(got, "`WWL`\n2T - 1B (67%) • KDA **2.00**") || !strings.HasSuffix(got, "Hay chơi: Zoe (3)") {
+		t.Errorf("form = %q", got)
+	}
+}
+
+func TestHandleReply(t *testing.T) {
+	const botID = "bot"
+	question := func(author, refID, content string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:               "m-1",
+			ChannelID:        testChannel,
+			Content:          content,
+			Author:           &discordgo.User{ID: author},
+			MessageReference: &discordgo.MessageReference{MessageID: refID, ChannelID: testChannel},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		message   *discordgo.MessageCreate
+		aiErr     error
+		wantAsked bool
+		wantReply string
+	}{
+		{
+			name:      "question about an analysis",
+			message:   question("user", "analysis-msg", "  Ai chơi tệ nhất?  "),
+			wantAsked: true,
+			wantReply: "Zoe nói",
+		},
+		{
+			name:    "bot's own message",
+			message: question(botID, "analysis-msg", "hi"),
+		},
+		{
+			name:    "reply to a message without context",
+			message: question("user", "other-msg", "hi"),
+		},
+		{
+			name:    "reply to someone else",
+			message: question("user", "user-msg", "hi"),
+		},
+		{
+			name:    "empty question",
+			message: question("user", "analysis-msg", "   "),
+		},
+		{
+			name:      "AI failure",
+			message:   question("user", "analysis-msg", "Ai chơi tệ nhất?"),
+			aiErr:     errors.New("AI API error: 500"),
+			wantAsked: true,
+			wantReply: "Không thể trả lời",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBot(t)
+			tb.ai.Err = tt.aiErr
+			tb.session.Messages["analysis-msg"] = &discordgo.Message{ID: "analysis-msg", Author: &discordgo.User{ID: botID}}
+			tb.session.Messages["other-msg"] = &discordgo.Message{ID: "other-msg", Author: &discordgo.User{ID: botID}}
+			tb.session.Messages["user-msg"] = &discordgo.Message{ID: "user-msg", Author: &discordgo.User{ID: "user"}}
+			tb.saveMessageContext("analysis-msg", "analysis", map[string]interface{}{"match_id": "VN2_1"})
+			tb.saveMessageContext("user-msg", "analysis", map[string]interface{}{"match_id": "VN2_1"})
+
+			tb.handleReply(tb.session, botID, tt.message)
+
+			if asked := len(tb.ai.Asked) > 0; asked != tt.wantAsked {
+				t.Fatalf("asked AI = %v, want %v", asked, tt.wantAsked)
+			}
+			if tt.wantAsked && tb.ai.Asked[0] != strings.TrimSpace(tt.message.Content) {
+				t.Errorf("question = %q, want it trimmed", tb.ai.Asked[0])
+			}
+			if tt.wantReply == "" {
+				if len(tb.session.Replies) != 0 {
+					t.Errorf("unexpected reply %q", tb.session.Replies[0])
+				}
+				return
+			}
+			if len(tb.session.Replies) != 1 || !strings.Contains(tb.session.Replies[0], tt.wantReply) {
+				t.Errorf("replies = %q, want one containing %q", tb.session.Replies, tt.wantReply)
+			}
+		})
+	}
+}
+
+func TestMoveSubscription(t *testing.T) {
+	tests := []struct {
+		name     string
+		puuid    string
+		channel  string
+		wantErr  error
+		wantChan string
+	}{
+		{name: "moves", puuid: "p1", channel: "chan-2", wantChan: "chan-2"},
+		{name: "not tracked", puuid: "p9", channel: "chan-2", wantErr: ErrNotFound, wantChan: testChannel},
+		{name: "unknown channel", puuid: "p1", channel: "missing-1", wantErr: ErrInvalidInput, wantChan: testChannel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBot(t)
+			tb.track("p1", "Zoe#VN2", "VN2_1")
+
+			tb.trackedPlayers.UpdateLastMatch("p1", "VN2_2")
+
+			moved, err := tb.MoveSubscription(context.Background(), tt.puuid, tt.channel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MoveSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if p, _ := tb.trackedPlayers.Get("p1"); p.ChannelID != tt.wantChan {
+				t.Errorf("channel = %q, want %q", p.ChannelID, tt.wantChan)
+			}
+
+			// Only the channel is written: the stored record keeps the
+			// fields updated since
+			reloaded := storage.NewTrackedPlayersStore(tb.store, tb.config().Redis)
+			if err := reloaded.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if p, _ := reloaded.Get("p1"); p.ChannelID != tt.wantChan || p.LastMatchID != "VN2_2" {
+				t.Errorf("stored player = %+v, want channel %q at VN2_2", p, tt.wantChan)
+			}
+			if moved != nil {
+				moved.ChannelID = "elsewhere"
+				if p, _ := tb.trackedPlayers.Get("p1"); p.ChannelID != tt.wantChan {
+					t.Error("MoveSubscription returned the stored player instead of a copy")
+				}
+			}
+		})
+	}
+}
+
+func TestParseRiotID(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantName  string
+		wantTag   string
+		wantError bool
+	}{
+		{in: "Faker#KR1", wantName: "Faker", wantTag: "KR1"},
+		{in: "Zoe Main#VN2", wantName: "Zoe Main", wantTag: "VN2"},
+		{in: "a#b#c", wantName: "a", wantTag: "b#c"},
+		{in: "Faker", wantError: true},
+		{in: "#KR1", wantError: true},
+		{in: "Faker#", wantError: true},
+		{in: "", wantError: true},
+	}
+
+	for _, tt := range tests {
+		name, tag, err := parseRiotID(tt.in)
+		if tt.wantError {
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("parseRiotID(%q) error = %v, want ErrInvalidInput", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || name != tt.wantName || tag != tt.wantTag {
+			t.Errorf("parseRiotID(%q) = %q, %q, %v; want %q, %q", tt.in, name, tag, err, tt.wantName, tt.wantTag)
+		}
+	}
+}
+
+func TestSubscriptionsSorted(t *testing.T) {
+	tb := newTestBot(t)
+	for puuid, name := range map[string]string{"p1": "Zoe#VN2", "p2": "Ahri#VN2", "p3": "Lux#VN2"} {
+		tb.trackedPlayers.Set(puuid, &storage.TrackedPlayer{PUUID: puuid, Name: name, ChannelID: testChannel})
+	}
+
+	var names []string
+	for _, p := range tb.Subscriptions() {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "Ahri#VN2,Lux#VN2,Zoe#VN2" {
+		t.Errorf("Subscriptions() = %s, want sorted by name", got)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	manager := &discordgo.Member{Permissions: discordgo.PermissionManageServer}
+
+	src := newTestBot(t)
+	src.track("p1", "Zoe#VN2", "VN2_1")
+	src.track("p2", "Lux#VN2", "VN2_1")
+	src.trackedPlayers.Set("p3", &storage.TrackedPlayer{PUUID: "p3", Name: "Ahri#VN2", ChannelID: "chan-2"})
+
+	export := commandInteraction("export")
+	if err := src.handleExport(context.Background(), src.session, export); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("export without permission: err = %v, want ErrForbidden", err)
+	}
+	export.Member = manager
+	if err := src.handleExport(context.Background(), src.session, export); err != nil {
+		t.Fatal(err)
+	}
+	files := src.session.Responds[len(src.session.Responds)-1].Data.Files
+	if len(files) != 1 {
+		t.Fatalf("export response has %d files, want 1", len(files))
+	}
+	raw, _ := io.ReadAll(files[0].Reader)
+	var exported ChannelExport
+	if err := json.Unmarshal(raw, &exported); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.Players) != 2 || exported.Players[0].RiotID != "Lux#VN2" || exported.Players[1].RiotID != "Zoe#VN2" {
+		t.Fatalf("exported players = %+v, want the channel's players sorted", exported.Players)
+	}
+
+	tests := []struct {
+		name      string
+		file      string
+		wantColor int
+		wantText  string
+		wantCount int
+	}{
+		{name: "export file", file: string(raw), wantColor: embeds.ColorWin, wantText: "Đã thêm: **2**", wantCount: 2},
+		{name: "riot id list", file: `["Zoe#VN2", "zoe#vn2", "Nobody#VN2", "bad"]`, wantColor: embeds.ColorWarning, wantText: "Lỗi: **2**", wantCount: 1},
+		{name: "invalid file", file: `{"players": 1}`, wantColor: embeds.ColorLose, wantText: "không hợp lệ"},
+		{name: "too many", file: `["` + strings.Repeat(`A#1","`, maxImportPlayers) + `A#1"]`, wantColor: embeds.ColorLose, wantText: "tối đa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.file)
+			}))
+			defer server.Close()
+
+			dst := newTestBot(t)
+			dst.riot.PUUIDs["Zoe#VN2"] = "p1"
+			dst.riot.PUUIDs["Lux#VN2"] = "p2"
+
+			i := commandInteraction("import", &discordgo.ApplicationCommandInteractionDataOption{
+				Name:  "file",
+				Type:  discordgo.ApplicationCommandOptionAttachment,
+				Value: "att-1",
+			})
+			i.Member = manager
+			data := i.ApplicationCommandData()
+			data.Resolved = &discordgo.ApplicationCommandInteractionDataResolved{
+				Attachments: map[string]*discordgo.MessageAttachment{
+					"att-1": {ID: "att-1", URL: server.URL, Size: len(tt.file)},
+				},
+			}
+			i.Data = data
+
+			dst.handleImport(context.Background(), dst.session, i)
+
+			embed := dst.session.LastEmbed()
+			if embed == nil || embed.Color != tt.wantColor || !strings.Contains(embed.Description, tt.wantText) {
+				t.Fatalf("embed = %+v, want color %d containing %q", embed, tt.wantColor, tt.wantText)
+			}
+			if got := len(dst.trackedPlayers.GetByChannel(testChannel)); got != tt.wantCount {
+				t.Errorf("tracked in channel = %d, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRecordMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		aiErr     error
+		aram      bool
+		wantScore float64
+		wantSrc   string
+	}{
+		{name: "scored", wantScore: 7, wantSrc: "ai"},
+		{name: "analysis failed", aiErr: errors.New("AI down")},
+		{name: "no positions", aram: true, wantScore: 7, wantSrc: "ai"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := newTestBot(t)
+			tb.ai.Err = tt.aiErr
+			tb.track("p1", "Zoe#VN2", "VN2_1")
+			tb.track("p2", "Garen#VN2", "VN2_1")
+			tb.track("p4", "Jinx#VN2", "VN2_1")
+			tb.track("p5", "Thresh#VN2", "VN2_1")
+			tb.riot.MatchIDs["p1"] = []string{"VN2_2"}
+			tb.riot.MatchIDs["p4"] = []string{"VN2_2"}
+			tb.riot.AddMatch("VN2_2", true, "p1", "p2", "p3", "p4", "p5")
+
+			// p4 and p5 are tracked enemies in lanes nobody on p1's team
+			// plays, or in no lane at all
+			match := tb.riot.Matches["VN2_2"]
+			for n := range match.Info.Participants {
+				p := &match.Info.Participants[n]
+				if p.PUUID == "p4" || p.PUUID == "p5" {
+					p.TeamID, p.Win = 200, false
+				}
+				if tt.aram {
+					p.TeamPosition = ""
+				}
+			}
+			if tt.aram {
+				match.Info.QueueID, match.Info.GameMode = 450, "ARAM"
+			}
+
+			tb.checkPlayerMatch(context.Background(), "p1", &storage.TrackedPlayer{PUUID: "p1", Name: "Zoe#VN2", ChannelID: testChannel, LastMatchID: "VN2_1"})
+			// The enemy's own poll finds the game already posted in the channel
+			tb.checkPlayerMatch(context.Background(), "p4", &storage.TrackedPlayer{PUUID: "p4", Name: "Jinx#VN2", ChannelID: testChannel, LastMatchID: "VN2_1"})
+
+			for _, puuid := range []string{"p1", "p2", "p4", "p5"} {
+				r, ok, err := tb.history.Get(puuid, "VN2_2")
+				if err != nil || !ok {
+					t.Fatalf("history of %s: ok = %v, err = %v", puuid, ok, err)
+				}
+				enemy := puuid == "p4" || puuid == "p5"
+				if r.GuildID != testGuild || r.ChannelID != testChannel || r.Patch != "15.20" || r.Win == enemy {
+					t.Errorf("record of %s = %+v", puuid, r)
+				}
+				if !enemy && (r.Score != tt.wantScore || r.ScoreSource != tt.wantSrc) {
+					t.Errorf("score of %s = %v (%q), want %v (%q)", puuid, r.Score, r.ScoreSource, tt.wantScore, tt.wantSrc)
+				}
+			}
+			if r, _, _ := tb.history.Get("p1", "VN2_2"); r.RiotID != "Zoe#VN2" || r.Champion != "Zoe" {
+				t.Errorf("p1 record = %+v, want the tracked Riot ID and champion", r)
+			}
+			if _, ok, _ := tb.history.Get("p3", "VN2_2"); ok {
+				t.Error("untracked teammate recorded")
+			}
+			if matches, _ := tb.history.ByGuild(testGuild, storage.HistoryQuery{}); len(matches) != 4 {
+				t.Errorf("guild history has %d matches, want 4", len(matches))
+			}
+			if tt.aiErr == nil && len(tb.session.Sent) != 1 {
+				t.Errorf("sent %d notifications, want the game posted once", len(tb.session.Sent))
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+// Package bottest provides in-memory fakes of the services a bot.Bot talks
+// to, for tests of the bot and of the packages built on it. Build a Bot
+// around them with bot.NewWithDeps.
+package bottest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/services/scraper"
+	"github.com/zoebot/pkg/healthcheck"
+)
+
+// The channel and guild fake interactions come from. Session puts every
+// channel in Guild.
+const (
+	Channel = "chan-1"
+	Guild   = "guild-1"
+)
+
+// SentMessage is a channel message posted through Session.
+type SentMessage struct {
+	ChannelID string
+	Embed     *discordgo.MessageEmbed
+	Files     []*discordgo.File
+}
+
+// Session records every Discord call instead of making it.
+type Session struct {
+	mu         sync.Mutex
+	Responds   []*discordgo.InteractionResponse
+	Edits      []*discordgo.WebhookEdit
+	Sent       []SentMessage
+	EmbedEdits []*discordgo.MessageEmbed
+	Edited     []*discordgo.MessageEdit
+	Replies    []string
+	Messages   map[string]*discordgo.Message // message ID -> message, for ChannelMessage
+	SendErr    error
+}
+
+// NewSession creates a session with no messages.
+func NewSession() *Session {
+	return &Session{Messages: make(map[string]*discordgo.Message)}
+}
+
+func (f *Session) Channel(channelID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if channelID == "" || strings.HasPrefix(channelID, "missing") {
+		return nil, errors.New("HTTP 404 Not Found")
+	}
+	return &discordgo.Channel{ID: channelID, GuildID: Guild, Name: "general"}, nil
+}
+
+func (f *Session) ChannelMessage(_, messageID string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if m, ok := f.Messages[messageID]; ok {
+		return m, nil
+	}
+	return nil, errors.New("HTTP 404 Not Found")
+}
+
+func (f *Session) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.SendErr != nil {
+		return nil, f.SendErr
+	}
+	sent := SentMessage{ChannelID: channelID, Files: data.Files}
+	if len(data.Embeds) > 0 {
+		sent.Embed = data.Embeds[0]
+	}
+	f.Sent = append(f.Sent, sent)
+	return &discordgo.Message{ID: fmt.Sprintf("msg-%d", len(f.Sent)), ChannelID: channelID}, nil
+}
+
+func (f *Session) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.SendErr != nil {
+		return nil, f.SendErr
+	}
+	f.Sent = append(f.Sent, SentMessage{ChannelID: channelID, Embed: embed})
+	return &discordgo.Message{ID: fmt.Sprintf("msg-%d", len(f.Sent)), ChannelID: channelID}, nil
+}
+
+func (f *Session) ChannelMessageSendReply(channelID, content string, _ *discordgo.MessageReference, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Replies = append(f.Replies, content)
+	return &discordgo.Message{ChannelID: channelID, Content: content}, nil
+}
+
+func (f *Session) ChannelMessageSendEmbedReply(channelID string, embed *discordgo.MessageEmbed, _ *discordgo.MessageReference, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Replies = append(f.Replies, embed.Description)
+	return &discordgo.Message{ChannelID: channelID}, nil
+}
+
+func (f *Session) ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.EmbedEdits = append(f.EmbedEdits, embed)
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func (f *Session) ChannelMessageEditComplex(m *discordgo.MessageEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Edited = append(f.Edited, m)
+	return &discordgo.Message{ID: m.ID, ChannelID: m.Channel}, nil
+}
+
+func (f *Session) ChannelTyping(string, ...discordgo.RequestOption) error { return nil }
+
+func (f *Session) UserChannelCreate(recipientID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if strings.HasPrefix(recipientID, "missing") {
+		return nil, errors.New("HTTP 403 Forbidden")
+	}
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func (f *Session) InteractionRespond(_ *discordgo.Interaction, resp *discordgo.InteractionResponse, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Responds = append(f.Responds, resp)
+	return nil
+}
+
+func (f *Session) InteractionResponse(interaction *discordgo.Interaction, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return &discordgo.Message{ID: "resp-" + interaction.ID}, nil
+}
+
+func (f *Session) InteractionResponseEdit(_ *discordgo.Interaction, edit *discordgo.WebhookEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Edits = append(f.Edits, edit)
+	return &discordgo.Message{}, nil
+}
+
+func (f *Session) HeartbeatLatency() time.Duration { return 42 * time.Millisecond }
+
+// LastEmbed returns the embed the user ends up seeing for an interaction:
+// the last edit, or else the last response.
+func (f *Session) LastEmbed() *discordgo.MessageEmbed {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.Edits) - 1; i >= 0; i-- {
+		if e := f.Edits[i].Embeds; e != nil && len(*e) > 0 {
+			return (*e)[0]
+		}
+	}
+	for i := len(f.Responds) - 1; i >= 0; i-- {
+		if d := f.Responds[i].Data; d != nil && len(d.Embeds) > 0 {
+			return d.Embeds[0]
+		}
+	}
+	return nil
+}
+
+// Riot serves players and matches from maps.
+type Riot struct {
+	mu        sync.Mutex
+	PUUIDs    map[string]string // "name#tag" -> puuid
+	MatchIDs  map[string][]string
+	Matches   map[string]*riot.MatchResponse
+	IDsErr    error
+	Leagues   map[string][]riot.LeagueEntryDTO
+	Masteries map[string][]riot.ChampionMasteryDTO
+}
+
+// NewRiot creates a Riot API without players or matches.
+func NewRiot() *Riot {
+	return &Riot{
+		PUUIDs:    make(map[string]string),
+		MatchIDs:  make(map[string][]string),
+		Matches:   make(map[string]*riot.MatchResponse),
+		Leagues:   make(map[string][]riot.LeagueEntryDTO),
+		Masteries: make(map[string][]riot.ChampionMasteryDTO),
+	}
+}
+
+func (f *Riot) GetPUUIDByRiotID(_ context.Context, gameName, tagLine string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if puuid, ok := f.PUUIDs[gameName+"#"+tagLine]; ok {
+		return puuid, nil
+	}
+	return "", errors.New("API error: 404")
+}
+
+func (f *Riot) GetMatchIDsByPUUID(_ context.Context, puuid string, count int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.IDsErr != nil {
+		return nil, f.IDsErr
+	}
+	ids := f.MatchIDs[puuid]
+	return ids[:min(count, len(ids))], nil
+}
+
+func (f *Riot) GetMatchIDs(_ context.Context, puuid string, start, count, queueID int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.IDsErr != nil {
+		return nil, f.IDsErr
+	}
+	var ids []string
+	for _, id := range f.MatchIDs[puuid] {
+		if queueID == 0 || f.Matches[id] == nil || f.Matches[id].Info.QueueID == queueID {
+			ids = append(ids, id)
+		}
+	}
+	ids = ids[min(start, len(ids)):]
+	return ids[:min(count, len(ids))], nil
+}
+
+func (f *Riot) GetMatchDetails(_ context.Context, matchID string) (*riot.MatchResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if m, ok := f.Matches[matchID]; ok {
+		return m, nil
+	}
+	return nil, errors.New("API error: 404")
+}
+
+func (f *Riot) GetMatchTimeline(context.Context, string) (*riot.TimelineResponse, error) {
+	return nil, errors.New("API error: 404")
+}
+
+// ParseMatchData keeps only what the bot reads: the target's result and
+// one lane matchup per teammate.
+func (f *Riot) ParseMatchData(match *riot.MatchResponse, targetPUUID string, _ *riot.TimelineResponse) *riot.ParsedMatchData {
+	var target *riot.Participant
+	for i, p := range match.Info.Participants {
+		if p.PUUID == targetPUUID {
+			target = &match.Info.Participants[i]
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	parsed := &riot.ParsedMatchData{
+		MatchID:             match.Metadata.MatchID,
+		GameDuration:        match.Info.GameDuration,
+		GameDurationMinutes: float64(match.Info.GameDuration) / 60,
+		GameMode:            match.Info.GameMode,
+		QueueID:             match.Info.QueueID,
+		Patch:               riot.Patch(match.Info.GameVersion),
+		GameEndTimestamp:    match.Info.GameEndTimestamp,
+		Win:                 target.Win,
+		TargetPlayerName:    target.RiotIDGameName,
+	}
+	for _, p := range match.Info.Participants {
+		if p.TeamID == target.TeamID {
+			pd := riot.PlayerData{PUUID: p.PUUID, ChampionName: p.ChampionName, RiotIDGameName: p.RiotIDGameName, TeamPosition: p.TeamPosition, Win: p.Win, Kills: p.Kills, Deaths: p.Deaths, Assists: p.Assists}
+			parsed.Teammates = append(parsed.Teammates, pd)
+			parsed.LaneMatchups = append(parsed.LaneMatchups, riot.LaneMatchup{Player: &pd})
+		}
+	}
+	return parsed
+}
+
+func (f *Riot) GetSummonerByPUUID(_ context.Context, puuid string) (*riot.SummonerDTO, error) {
+	return &riot.SummonerDTO{PUUID: puuid, ProfileIconID: 29, SummonerLevel: 321}, nil
+}
+
+func (f *Riot) GetLeagueEntriesByPUUID(_ context.Context, puuid string) ([]riot.LeagueEntryDTO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Leagues[puuid], nil
+}
+
+func (f *Riot) RefreshLeagueEntries(ctx context.Context, puuid string) ([]riot.LeagueEntryDTO, error) {
+	return f.GetLeagueEntriesByPUUID(ctx, puuid)
+}
+
+func (f *Riot) GetTopMasteries(_ context.Context, puuid string, count int) ([]riot.ChampionMasteryDTO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	masteries := f.Masteries[puuid]
+	return masteries[:min(count, len(masteries))], nil
+}
+
+func (f *Riot) GetMasteryScore(_ context.Context, puuid string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	score := 0
+	for _, m := range f.Masteries[puuid] {
+		score += m.ChampionLevel
+	}
+	return score, nil
+}
+
+func (f *Riot) CallStats() healthcheck.CallSnapshot { return healthcheck.CallSnapshot{} }
+
+// AddMatch stores a match where puuids play on team 100, in that order.
+func (f *Riot) AddMatch(matchID string, win bool, puuids ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m := &riot.MatchResponse{}
+	m.Metadata.MatchID = matchID
+	m.Info.GameDuration = 1800
+	m.Info.GameEndTimestamp = time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC).UnixMilli()
+	m.Info.GameMode = "CLASSIC"
+	m.Info.GameVersion = "15.20.712.2345"
+	m.Info.QueueID = 420
+	for i, puuid := range puuids {
+		m.Info.Participants = append(m.Info.Participants, riot.Participant{
+			PUUID:          puuid,
+			RiotIDGameName: "Player" + puuid,
+			ChampionName:   []string{"Zoe", "Garen", "LeeSin", "Jinx", "Thresh"}[i%5],
+			TeamID:         100,
+			TeamPosition:   []string{"MIDDLE", "TOP", "JUNGLE", "BOTTOM", "UTILITY"}[i%5],
+			Win:            win,
+		})
+	}
+	f.Matches[matchID] = m
+}
+
+// AI returns a canned analysis.
+type AI struct {
+	mu       sync.Mutex
+	Err      error
+	Answer   string
+	Analyzed []string // match IDs, in call order
+	Asked    []string // chat questions
+}
+
+func (f *AI) AnalyzeMatch(_ context.Context, matchData *riot.ParsedMatchData) (*ai.AnalysisResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Analyzed = append(f.Analyzed, matchData.MatchID)
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	result := &ai.AnalysisResult{}
+	for _, m := range matchData.LaneMatchups {
+		result.Players = append(result.Players, ai.PlayerAnalysis{
+			Champion:   m.Player.ChampionName,
+			PlayerName: m.Player.RiotIDGameName,
+			Score:      7,
+		})
+	}
+	return result, nil
+}
+
+func (f *AI) ChatWithContext(_ context.Context, _ string, _ map[string]interface{}, question string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Asked = append(f.Asked, question)
+	if f.Err != nil {
+		return "", f.Err
+	}
+	return f.Answer, nil
+}
+
+func (f *AI) Apply(config.AIConfig) {}
+
+func (f *AI) CallStats() healthcheck.CallSnapshot { return healthcheck.CallSnapshot{} }
+
+// Scraper returns canned counters and builds.
+type Scraper struct {
+	Counters *scraper.CounterData
+	Build    *scraper.BuildData
+	Err      error
+}
+
+func (f *Scraper) GetCounters(context.Context, string, string) (*scraper.CounterData, error) {
+	return f.Counters, f.Err
+}
+
+func (f *Scraper) GetBuild(context.Context, string, string) (*scraper.BuildData, error) {
+	return f.Build, f.Err
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/services/scraper"
+	"github.com/zoebot/internal/storage"
+	"github.com/zoebot/pkg/healthcheck"
+)
+
+// Session is the part of *discordgo.Session the bot calls after connecting.
+// Gateway lifecycle and command registration stay on the concrete session.
+type Session interface {
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendReply(channelID, content string, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendEmbedReply(channelID string, embed *discordgo.MessageEmbed, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelTyping(channelID string, options ...discordgo.RequestOption) error
+	UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+	InteractionResponse(interaction *discordgo.Interaction, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	HeartbeatLatency() time.Duration
+}
+
+// RiotAPI is the part of riot.Client the bot uses.
+type RiotAPI interface {
+	GetPUUIDByRiotID(ctx context.Context, gameName, tagLine string) (string, error)
+	GetMatchIDsByPUUID(ctx context.Context, puuid string, count int) ([]string, error)
+	GetMatchIDs(ctx context.Context, puuid string, start, count, queueID int) ([]string, error)
+	GetMatchDetails(ctx context.Context, matchID string) (*riot.MatchResponse, error)
+	GetMatchTimeline(ctx context.Context, matchID string) (*riot.TimelineResponse, error)
+	ParseMatchData(match *riot.MatchResponse, targetPUUID string, timeline *riot.TimelineResponse) *riot.ParsedMatchData
+	GetSummonerByPUUID(ctx context.Context, puuid string) (*riot.SummonerDTO, error)
+	GetLeagueEntriesByPUUID(ctx context.Context, puuid string) ([]riot.LeagueEntryDTO, error)
+	RefreshLeagueEntries(ctx context.Context, puuid string) ([]riot.LeagueEntryDTO, error)
+	GetTopMasteries(ctx context.Context, puuid string, count int) ([]riot.ChampionMasteryDTO, error)
+	GetMasteryScore(ctx context.Context, puuid string) (int, error)
+	CallStats() healthcheck.CallSnapshot
+}
+
+// AIClient is the part of ai.Client the bot uses.
+type AIClient interface {
+	AnalyzeMatch(ctx context.Context, matchData *riot.ParsedMatchData) (*ai.AnalysisResult, error)
+	ChatWithContext(ctx context.Context, contextType string, contextData map[string]interface{}, question string) (string, error)
+	Apply(settings config.AIConfig)
+	CallStats() healthcheck.CallSnapshot
+}
+
+// Deps are the external services a Bot talks to. New wires the real
+// clients; tests pass the in-memory fakes of package bottest.
+type Deps struct {
+	Session Session
+	Riot    RiotAPI
+	AI      AIClient
+	Scraper scraper.Scraper
+	Store   *storage.Store
+}
+
+// Compile-time checks that the real clients satisfy the interfaces.
+var (
+	_ Session         = (*discordgo.Session)(nil)
+	_ RiotAPI         = (*riot.Client)(nil)
+	_ AIClient        = (*ai.Client)(nil)
+	_ scraper.Scraper = (*scraper.Client)(nil)
+)
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/zoebot/internal/bot/bottest"
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
+	"github.com/zoebot/internal/services/scraper"
+	"github.com/zoebot/internal/storage"
+)
+
+const (
+	testChannel = bottest.Channel
+	testGuild   = bottest.Guild
+)
+
+// The fakes satisfy the interfaces the bot uses.
+var (
+	_ Session         = (*bottest.Session)(nil)
+	_ RiotAPI         = (*bottest.Riot)(nil)
+	_ AIClient        = (*bottest.AI)(nil)
+	_ scraper.Scraper = (*bottest.Scraper)(nil)
+)
+
+// testBot is a Bot wired to fakes, with Redis disabled.
+type testBot struct {
+	*Bot
+	session *bottest.Session
+	riot    *bottest.Riot
+	ai      *bottest.AI
+	scraper *bottest.Scraper
+}
+
+func newTestBot(t *testing.T) *testBot {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.Redis.URL = ""
+
+	tb := &testBot{
+		session: bottest.NewSession(),
+		riot:    bottest.NewRiot(),
+		ai:      &bottest.AI{Answer: "Zoe nói: chơi tốt lắm"},
+		scraper: &bottest.Scraper{},
+	}
+	store := storage.NewStore(storage.NewMemoryBackend())
+	t.Cleanup(func() { store.Close() })
+	tb.Bot = NewWithDeps(cfg, data.NewStore(), Deps{
+		Session: tb.session,
+		Riot:    tb.riot,
+		AI:      tb.ai,
+		Scraper: tb.scraper,
+		Store:   store,
+	})
+	return tb
+}
+
+// track adds a tracked player directly.
+func (tb *testBot) track(puuid, name, lastMatchID string) {
+	tb.trackedPlayers.Set(puuid, &storage.TrackedPlayer{
+		PUUID:       puuid,
+		Name:        name,
+		ChannelID:   testChannel,
+		LastMatchID: lastMatchID,
+	})
+}
+
+// addGame stores a match in the history. Games are ranked solo queue and
+// scored by the AI unless r says otherwise.
+func (tb *testBot) addGame(t *testing.T, r storage.MatchRecord) {
+	t.Helper()
+	if r.QueueID == 0 {
+		r.QueueID = 420
+	}
+	if r.Score != 0 && r.ScoreSource == "" {
+		r.ScoreSource = "ai"
+	}
+	if err := tb.history.Add(&r); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commandInteraction(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "i-1",
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: testChannel,
+		Data:      discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+	}}
+}
+
+func componentInteraction(customID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "i-1",
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: testChannel,
+		Data:      discordgo.MessageComponentInteractionData{CustomID: customID},
+	}}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: value,
+	}
+}
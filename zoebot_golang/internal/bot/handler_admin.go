@@ -0,0 +1,418 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/storage"
+)
+
+// errNotOwner is returned when a non-owner invokes /admin.
+var errNotOwner = fmt.Errorf("%w: not a bot owner", ErrForbidden)
+
+// adminCommand defines the owner-only /admin command group. Default member
+// permissions of 0 hide it from everyone but server administrators; the
+// owner check in handleAdmin is what actually restricts it.
+func adminCommand() *discordgo.ApplicationCommand {
+	var noPermissions int64
+	dmAllowed := false
+
+	families := make([]string, 0, len(storage.CacheFamilies))
+	for family := range storage.CacheFamilies {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	familyChoices := make([]*discordgo.ApplicationCommandOptionChoice, len(families))
+	for i, family := range families {
+		familyChoices[i] = &discordgo.ApplicationCommandOptionChoice{Name: family, Value: family}
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:                     "admin",
+		Description:              "Lệnh quản trị bot (chỉ owner)",
+		DefaultMemberPermissions: &noPermissions,
+		DMPermission:             &dmAllowed,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Xem trạng thái bot",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "cache",
+				Description: "Quản lý cache",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "flush",
+						Description: "Xoá cache theo nhóm hoặc theo key",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "family",
+								Description: "Nhóm cache",
+								Required:    true,
+								Choices:     familyChoices,
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "key",
+								Description: "Key cụ thể (VD: counter:yasuo:mid)",
+								Required:    false,
+							},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reload",
+				Description: "Tải lại dữ liệu game và prompt",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "data",
+				Description: "Quản lý dữ liệu game theo phiên bản",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "list",
+						Description: "Xem phiên bản dữ liệu đang dùng và đã tải",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "use",
+						Description: "Chuyển sang phiên bản dữ liệu đã tải",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "version",
+								Description: "Phiên bản Data Dragon (VD: 16.1.1)",
+								Required:    true,
+							},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reload-config",
+				Description: "Tải lại file cấu hình",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "broadcast",
+				Description: "Gửi thông báo tới mọi kênh đang theo dõi",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Nội dung thông báo",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "untrack-anywhere",
+				Description: "Huỷ theo dõi người chơi ở bất kỳ kênh nào",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "riot_id",
+						Description: "Tên người chơi (VD: Faker#KR1)",
+						Required:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// interactionUserID returns the invoking user, in guilds or DMs.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// handleAdmin handles the /admin command group.
+func (b *Bot) handleAdmin(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	if !b.config().IsOwner(interactionUserID(i)) {
+		respondEphemeral(s, i, embeds.Error("Lệnh này chỉ dành cho owner của bot.", ""))
+		return errNotOwner
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	switch sub.Name {
+	case "status":
+		return b.handleAdminStatus(s, i)
+	case "cache":
+		return b.handleAdminCacheFlush(ctx, s, i, sub.Options[0].Options)
+	case "reload":
+		return b.handleAdminReload(ctx, s, i)
+	case "data":
+		action := sub.Options[0]
+		if action.Name == "use" {
+			return b.handleAdminDataUse(ctx, s, i, action.Options[0].StringValue())
+		}
+		return b.handleAdminDataList(s, i)
+	case "reload-config":
+		return b.handleAdminReloadConfig(ctx, s, i)
+	case "broadcast":
+		return b.handleAdminBroadcast(ctx, s, i, sub.Options[0].StringValue())
+	case "untrack-anywhere":
+		return b.handleAdminUntrack(ctx, s, i, sub.Options[0].StringValue())
+	}
+	return fmt.Errorf("%w: unknown subcommand %q", ErrInvalidInput, sub.Name)
+}
+
+// guildCount returns how many servers the bot is in.
+func (b *Bot) guildCount() int {
+	if b.dg == nil || b.dg.State == nil {
+		return 0
+	}
+	b.dg.State.RLock()
+	defer b.dg.State.RUnlock()
+	return len(b.dg.State.Guilds)
+}
+
+// handleAdminStatus shows guilds, tracked players and the poll state.
+func (b *Bot) handleAdminStatus(s Session, i *discordgo.InteractionCreate) error {
+	state := b.QueueState()
+
+	storageStatus := "✅ " + b.store.Name()
+	switch {
+	case b.store.Ping() != nil:
+		storageStatus = "❌ " + b.store.Name() + " (lỗi)"
+	case b.store.Name() == "memory":
+		storageStatus = "⚪ memory (mất khi khởi động lại)"
+	}
+
+	lastPoll := "Chưa chạy"
+	if !state.LastPollAt.IsZero() {
+		lastPoll = fmt.Sprintf("<t:%d:R> (%s)", state.LastPollAt.Unix(), state.LastPollTook)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🛠️ Trạng thái ZoeBot",
+		Color: embeds.ColorInfo,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Servers", Value: fmt.Sprintf("%d", b.guildCount()), Inline: true},
+			{Name: "Người chơi", Value: fmt.Sprintf("%d", state.TrackedPlayers), Inline: true},
+			{Name: "Kênh thông báo", Value: fmt.Sprintf("%d", len(b.NotificationChannels())), Inline: true},
+			{Name: "Lưu trữ", Value: storageStatus, Inline: true},
+			{Name: "Độ trễ", Value: fmt.Sprintf("%dms", s.HeartbeatLatency().Milliseconds()), Inline: true},
+			{Name: "Poll gần nhất", Value: lastPoll, Inline: true},
+			{Name: "Đang kiểm tra", Value: fmt.Sprintf("%d", state.InFlight), Inline: true},
+		},
+	}
+	return respondEphemeral(s, i, embed)
+}
+
+// handleAdminCacheFlush handles /admin cache flush.
+func (b *Bot) handleAdminCacheFlush(ctx context.Context, s Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) error {
+	var family, key string
+	for _, opt := range options {
+		switch opt.Name {
+		case "family":
+			family = opt.StringValue()
+		case "key":
+			key = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	deleted, err := b.FlushCache(ctx, family, key)
+	if err != nil {
+		respondEphemeral(s, i, embeds.Error(fmt.Sprintf("Không thể xoá cache: %v", err), ""))
+		return err
+	}
+
+	target := fmt.Sprintf("nhóm `%s`", family)
+	if key != "" {
+		target = fmt.Sprintf("key `%s`", key)
+	}
+	return respondEphemeral(s, i, embeds.Success(fmt.Sprintf("Đã xoá **%d** key của %s.", deleted, target), ""))
+}
+
+// handleAdminReload handles /admin reload.
+func (b *Bot) handleAdminReload(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	deferEphemeral(s, i)
+
+	report, err := b.ReloadStaticData(ctx)
+	summary := fmt.Sprintf("Phiên bản: `%s`\n%s", report.Data.Version, dataSummaryText(report.Data))
+	if len(report.Prompts) > 0 {
+		summary += fmt.Sprintf("\nPrompt tuỳ chỉnh: `%s`", strings.Join(report.Prompts, "`, `"))
+	}
+
+	embed := embeds.Success(summary, "✅ Đã tải lại dữ liệu")
+	if err != nil {
+		embed = embeds.Warning(fmt.Sprintf("%s\n\n⚠️ Lỗi: %v", summary, err), "Tải lại chưa hoàn chỉnh")
+	}
+	editEmbed(s, i, embed)
+	return err
+}
+
+// handleAdminDataList handles /admin data list.
+func (b *Bot) handleAdminDataList(s Session, i *discordgo.InteractionCreate) error {
+	status, err := b.Datasets()
+	if err != nil {
+		respondEphemeral(s, i, embeds.Error(fmt.Sprintf("Không thể đọc danh sách dữ liệu: %v", err), ""))
+		return err
+	}
+
+	synced := "Chưa tải phiên bản nào (dùng `zoebot data sync`)."
+	if len(status.Synced) > 0 {
+		lines := make([]string, len(status.Synced))
+		for n, v := range status.Synced {
+			lines[n] = "• `" + v + "`"
+			if status.Dataset.Manifest != nil && v == status.Dataset.Version {
+				lines[n] += " ← đang dùng"
+			}
+		}
+		synced = strings.Join(lines, "\n")
+	}
+
+	source := "dữ liệu có sẵn"
+	if status.Dataset.Manifest != nil {
+		source = fmt.Sprintf("tải lúc <t:%d:f>", status.Dataset.Manifest.SyncedAt.Unix())
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "📦 Dữ liệu game",
+		Color: embeds.ColorInfo,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Đang dùng", Value: fmt.Sprintf("`%s` (%s)\n%s", status.Dataset.Version, source, dataSummaryText(status.Summary))},
+			{Name: "Đã tải", Value: synced},
+		},
+	}
+	if status.LastError != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "⚠️ Lần tải gần nhất thất bại",
+			Value: fmt.Sprintf("<t:%d:R>: %s", status.LastLoad.Unix(), status.LastError),
+		})
+	}
+	return respondEphemeral(s, i, embed)
+}
+
+// handleAdminDataUse handles /admin data use.
+func (b *Bot) handleAdminDataUse(ctx context.Context, s Session, i *discordgo.InteractionCreate, version string) error {
+	deferEphemeral(s, i)
+
+	summary, err := b.ActivateDataset(ctx, strings.TrimSpace(version))
+	if err != nil {
+		editEmbed(s, i, embeds.Error(fmt.Sprintf("Không thể chuyển dữ liệu, vẫn giữ phiên bản cũ: %v", err), ""))
+		return err
+	}
+
+	editEmbed(s, i, embeds.Success(dataSummaryText(summary), fmt.Sprintf("✅ Đang dùng dữ liệu %s", summary.Version)))
+	return nil
+}
+
+// dataSummaryText formats loaded game data counts.
+func dataSummaryText(sum data.Summary) string {
+	return fmt.Sprintf("Items: **%d** • Runes: **%d** • Cây ngọc: **%d** • Tướng: **%d**\nChỉ số tướng: **%d**",
+		sum.Items, sum.Perks, sum.PerkStyles, sum.Champions, sum.ChampionStats)
+}
+
+// handleAdminReloadConfig handles /admin reload-config.
+func (b *Bot) handleAdminReloadConfig(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	deferEphemeral(s, i)
+
+	res, err := b.ReloadConfig(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("Cấu hình mới không hợp lệ, vẫn giữ cấu hình cũ.\n```%v```", err)
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			msg = fmt.Sprintf("Cấu hình mới không hợp lệ, vẫn giữ cấu hình cũ:\n• %s", strings.Join(verr.Problems, "\n• "))
+		}
+		editEmbed(s, i, embeds.Error(msg, ""))
+		return err
+	}
+
+	applied := "Không có thay đổi."
+	if len(res.Applied) > 0 {
+		applied = "`" + strings.Join(res.Applied, "`, `") + "`"
+	}
+	text := fmt.Sprintf("Đã áp dụng: %s", applied)
+	if len(res.Restart) > 0 {
+		text += fmt.Sprintf("\n⚠️ Cần khởi động lại: `%s`", strings.Join(res.Restart, "`, `"))
+	}
+	editEmbed(s, i, embeds.Success(text, "⚙️ Đã tải lại cấu hình"))
+	return nil
+}
+
+// handleAdminBroadcast handles /admin broadcast.
+func (b *Bot) handleAdminBroadcast(ctx context.Context, s Session, i *discordgo.InteractionCreate, message string) error {
+	deferEphemeral(s, i)
+
+	res, err := b.Broadcast(ctx, message)
+	if err != nil {
+		editEmbed(s, i, embeds.Error(fmt.Sprintf("Không thể gửi thông báo: %v", err), ""))
+		return err
+	}
+
+	text := fmt.Sprintf("Đã gửi tới **%d** kênh.", res.Sent)
+	if len(res.Failed) > 0 {
+		text += fmt.Sprintf("\nLỗi ở %d kênh: %s", len(res.Failed), strings.Join(res.Failed, ", "))
+	}
+	editEmbed(s, i, embeds.Success(text, "📢 Broadcast"))
+	return nil
+}
+
+// handleAdminUntrack handles /admin untrack-anywhere.
+func (b *Bot) handleAdminUntrack(ctx context.Context, s Session, i *discordgo.InteractionCreate, riotID string) error {
+	deferEphemeral(s, i)
+
+	player, err := b.UntrackPlayer(ctx, riotID)
+	if err != nil {
+		msg := fmt.Sprintf("Không tìm thấy **%s** trong danh sách đang theo dõi.", riotID)
+		if errors.Is(err, ErrInvalidInput) {
+			msg = "Sai định dạng! Vui lòng dùng: `Name#Tag` (VD: Faker#KR1)"
+		}
+		editEmbed(s, i, embeds.Error(msg, ""))
+		return err
+	}
+
+	editEmbed(s, i, embeds.Success(fmt.Sprintf("Đã huỷ theo dõi **%s** (kênh <#%s>).", player.Name, player.ChannelID), ""))
+	return nil
+}
+
+// respondEphemeral replies with an embed only the invoking user can see.
+func respondEphemeral(s Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// deferEphemeral acknowledges a slow command with a private loading state.
+func deferEphemeral(s Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// editEmbed replaces the (deferred) interaction response with an embed.
+func editEmbed(s Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+	})
+}
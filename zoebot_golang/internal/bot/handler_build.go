@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -11,7 +12,7 @@ import (
 )
 
 // handleBuild handles the /build command.
-func (b *Bot) handleBuild(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleBuild(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	options := i.ApplicationCommandData().Options
 	champion := options[0].StringValue()
 	role := options[1].StringValue()
@@ -29,7 +30,7 @@ func (b *Bot) handleBuild(s *discordgo.Session, i *discordgo.InteractionCreate)
 	})
 
 	// Get build data
-	buildData, err := b.scraperClient.GetBuild(champion, role)
+	buildData, err := b.scraperClient.GetBuild(ctx, champion, role)
 	if err != nil {
 		embed := embeds.Error(
 			fmt.Sprintf("Không tìm thấy build cho **%s %s**.\n\n%s", champion, role, err.Error()),
@@ -38,11 +39,11 @@ func (b *Bot) handleBuild(s *discordgo.Session, i *discordgo.InteractionCreate)
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
-		return
+		return fmt.Errorf("get build for %s %s: %w", champion, role, err)
 	}
 
 	// Create build embed
-	embed = createBuildEmbed(buildData)
+	embed = b.createBuildEmbed(buildData)
 	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 		Embeds: &[]*discordgo.MessageEmbed{embed},
 	})
@@ -66,10 +67,11 @@ func (b *Bot) handleBuild(s *discordgo.Session, i *discordgo.InteractionCreate)
 		}
 		b.saveMessageContext(msg.ID, "build", contextData)
 	}
+	return nil
 }
 
 // createBuildEmbed creates a Discord embed for build data.
-func createBuildEmbed(data *scraper.BuildData) *discordgo.MessageEmbed {
+func (b *Bot) createBuildEmbed(data *scraper.BuildData) *discordgo.MessageEmbed {
 	// Role display names (keep English, capitalize)
 	roleDisplayNames := map[string]string{
 		"top":     "TOP",
@@ -108,7 +110,7 @@ func createBuildEmbed(data *scraper.BuildData) *discordgo.MessageEmbed {
 		Title: title,
 		Color: 0x3498DB, // Blue
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: embeds.GetChampionIcon(data.Champion),
+			URL: embeds.GetChampionIcon(b.gameData, data.Champion),
 		},
 		Fields: make([]*discordgo.MessageEmbedField, 0),
 	}
@@ -130,7 +132,7 @@ func createBuildEmbed(data *scraper.BuildData) *discordgo.MessageEmbed {
 
 	// Items Section
 	if len(data.CoreItems) > 0 || data.Boots != "" {
-		itemValue := buildItemDisplay(data)
+		itemValue := b.buildItemDisplay(data)
 		itemTitle := "🗡️ TRANG BỊ CỐT LÕI"
 		if data.ItemWinRate != "" {
 			itemTitle = fmt.Sprintf("🗡️ TRANG BỊ CỐT LÕI ─ %s Tỉ lệ thắng", data.ItemWinRate)
@@ -201,25 +203,25 @@ func buildRuneDisplay(data *scraper.BuildData) string {
 }
 
 // buildItemDisplay creates the item display string.
-func buildItemDisplay(data *scraper.BuildData) string {
+func (b *Bot) buildItemDisplay(data *scraper.BuildData) string {
 	var lines []string
 
 	// Starter Items (use + since bought together)
 	if len(data.StarterItems) > 0 {
-		starters := formatStarterItems(data.StarterItems, data.PatchVersion)
+		starters := b.formatStarterItems(data.StarterItems, data.PatchVersion)
 		lines = append(lines, fmt.Sprintf("**Khởi đầu:** %s", starters))
 	}
 
 	// Boots
 	if data.Boots != "" {
-		bootName := getItemName(data.Boots)
+		bootName := b.itemName(data.Boots)
 		bootURL := gamedata.GetItemIconURL(data.Boots, data.PatchVersion)
 		lines = append(lines, fmt.Sprintf("**Giày:** [%s](%s)", bootName, bootURL))
 	}
 
 	// Core Items (use → since built in sequence)
 	if len(data.CoreItems) > 0 {
-		coreNames := formatItemIDsWithLinks(data.CoreItems, data.PatchVersion)
+		coreNames := b.formatItemIDsWithLinks(data.CoreItems, data.PatchVersion)
 		lines = append(lines, fmt.Sprintf("**Cốt lõi:** %s", coreNames))
 	}
 
@@ -236,10 +238,10 @@ func buildItemDisplay(data *scraper.BuildData) string {
 }
 
 // formatStarterItems formats starter items with + separator (bought together).
-func formatStarterItems(itemIDs []string, patchVersion string) string {
+func (b *Bot) formatStarterItems(itemIDs []string, patchVersion string) string {
 	var names []string
 	for _, id := range itemIDs {
-		name := getItemName(id)
+		name := b.itemName(id)
 		url := gamedata.GetItemIconURL(id, patchVersion)
 		names = append(names, fmt.Sprintf("[%s](%s)", name, url))
 	}
@@ -247,10 +249,10 @@ func formatStarterItems(itemIDs []string, patchVersion string) string {
 }
 
 // formatItemIDsWithLinks converts item IDs to display names with image links.
-func formatItemIDsWithLinks(itemIDs []string, patchVersion string) string {
+func (b *Bot) formatItemIDsWithLinks(itemIDs []string, patchVersion string) string {
 	var names []string
 	for _, id := range itemIDs {
-		name := getItemName(id)
+		name := b.itemName(id)
 		url := gamedata.GetItemIconURL(id, patchVersion)
 		names = append(names, fmt.Sprintf("[%s](%s)", name, url))
 	}
@@ -258,16 +260,16 @@ func formatItemIDsWithLinks(itemIDs []string, patchVersion string) string {
 }
 
 // formatItemIDs converts item IDs to display names.
-func formatItemIDs(itemIDs []string) string {
+func (b *Bot) formatItemIDs(itemIDs []string) string {
 	var names []string
 	for _, id := range itemIDs {
-		name := getItemName(id)
+		name := b.itemName(id)
 		names = append(names, name)
 	}
 	return strings.Join(names, " → ")
 }
 
-// getItemName returns item name from ID using loaded data.
-func getItemName(id string) string {
-	return gamedata.GetItemName(id)
+// itemName returns item name from ID using loaded data.
+func (b *Bot) itemName(id string) string {
+	return b.gameData.ItemName(id)
 }
@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -9,7 +10,7 @@ import (
 )
 
 // handleCounter handles the /counter command.
-func (b *Bot) handleCounter(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleCounter(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
 	options := i.ApplicationCommandData().Options
 	champion := options[0].StringValue()
 	var lane string
@@ -23,13 +24,13 @@ func (b *Bot) handleCounter(s *discordgo.Session, i *discordgo.InteractionCreate
 	})
 
 	// Call scraper
-	data, err := b.scraperClient.GetCounters(champion, lane)
+	data, err := b.scraperClient.GetCounters(ctx, champion, lane)
 	if err != nil {
 		embed := embeds.Error("Không tìm thấy dữ liệu khắc chế! Hãy kiểm tra lại tên tướng.", fmt.Sprintf("Lỗi: %v", err))
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
-		return
+		return fmt.Errorf("get counters for %s: %w", champion, err)
 	}
 
 	if len(data.BestPicks) == 0 && len(data.WorstPicks) == 0 {
@@ -37,7 +38,7 @@ func (b *Bot) handleCounter(s *discordgo.Session, i *discordgo.InteractionCreate
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
-		return
+		return fmt.Errorf("%w: no counters for %s %s", ErrNotFound, champion, lane)
 	}
 
 	// Build Embed
@@ -51,7 +52,7 @@ func (b *Bot) handleCounter(s *discordgo.Session, i *discordgo.InteractionCreate
 		Title: title,
 		Color: 0xE74C3C,
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: embeds.GetChampionIcon(champion),
+			URL: embeds.GetChampionIcon(b.gameData, champion),
 		},
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: "📊 CounterStats.net",
@@ -124,6 +125,7 @@ func (b *Bot) handleCounter(s *discordgo.Session, i *discordgo.InteractionCreate
 		}
 		b.saveMessageContext(msg.ID, "counter", contextData)
 	}
+	return nil
 }
 
 func getMedal(index int) string {
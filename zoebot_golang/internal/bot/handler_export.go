@@ -0,0 +1,241 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+)
+
+// channelExportFormat is the version of ChannelExport.
+const channelExportFormat = 1
+
+// maxImportSize caps the size of an /import attachment.
+const maxImportSize = 1 << 20
+
+// errNotGuildManager is returned when /export, /import or /milestones is
+// used without the Manage Server permission.
+var errNotGuildManager = fmt.Errorf("%w: missing Manage Server permission", ErrForbidden)
+
+// ChannelExport is the file /export produces and /import reads back.
+type ChannelExport struct {
+	Format     int              `json:"format"`
+	ChannelID  string           `json:"channel_id"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Players    []ExportedPlayer `json:"players"`
+}
+
+// ExportedPlayer is one tracked player in a ChannelExport.
+type ExportedPlayer struct {
+	RiotID string `json:"riot_id"`
+	PUUID  string `json:"puuid,omitempty"`
+}
+
+// guildManagerPermissions hides /export, /import and /milestones from
+// members who can't manage the server; handlers check the permission again.
+func guildManagerPermissions() (*int64, *bool) {
+	permissions := int64(discordgo.PermissionManageServer)
+	dmAllowed := false
+	return &permissions, &dmAllowed
+}
+
+// exportCommand defines /export.
+func exportCommand() *discordgo.ApplicationCommand {
+	permissions, dmAllowed := guildManagerPermissions()
+	return &discordgo.ApplicationCommand{
+		Name:                     "export",
+		Description:              "Tải danh sách người chơi đang theo dõi trong kênh (JSON)",
+		DefaultMemberPermissions: permissions,
+		DMPermission:             dmAllowed,
+	}
+}
+
+// importCommand defines /import.
+func importCommand() *discordgo.ApplicationCommand {
+	permissions, dmAllowed := guildManagerPermissions()
+	return &discordgo.ApplicationCommand{
+		Name:                     "import",
+		Description:              "Theo dõi hàng loạt người chơi từ file JSON",
+		DefaultMemberPermissions: permissions,
+		DMPermission:             dmAllowed,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Name:        "file",
+				Description: "File từ /export, hoặc mảng JSON các Riot ID (VD: [\"Faker#KR1\"])",
+				Required:    true,
+			},
+		},
+	}
+}
+
+// isGuildManager reports whether the invoking member can manage the server.
+func isGuildManager(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+// handleExport handles the /export command.
+func (b *Bot) handleExport(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	if !isGuildManager(i) {
+		respondEphemeral(s, i, embeds.Error("Bạn cần quyền **Quản lý máy chủ** để dùng lệnh này.", ""))
+		return errNotGuildManager
+	}
+
+	players := b.trackedPlayers.GetByChannel(i.ChannelID)
+	if len(players) == 0 {
+		return respondEphemeral(s, i, embeds.Warning("Chưa có người chơi nào được theo dõi trong kênh này.", ""))
+	}
+	sort.Slice(players, func(a, c int) bool { return players[a].Name < players[c].Name })
+
+	export := ChannelExport{
+		Format:     channelExportFormat,
+		ChannelID:  i.ChannelID,
+		ExportedAt: time.Now().UTC(),
+		Players:    make([]ExportedPlayer, len(players)),
+	}
+	for n, p := range players {
+		export.Players[n] = ExportedPlayer{RiotID: p.Name, PUUID: p.PUUID}
+	}
+	raw, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embeds.Success(fmt.Sprintf("Đã xuất **%d** người chơi. Dùng `/import` để nhập lại file này.", len(players)), "")},
+			Files: []*discordgo.File{{
+				Name:        fmt.Sprintf("zoebot-%s.json", i.ChannelID),
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(raw),
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleImport handles the /import command.
+func (b *Bot) handleImport(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	if !isGuildManager(i) {
+		respondEphemeral(s, i, embeds.Error("Bạn cần quyền **Quản lý máy chủ** để dùng lệnh này.", ""))
+		return errNotGuildManager
+	}
+
+	data := i.ApplicationCommandData()
+	var attachment *discordgo.MessageAttachment
+	if len(data.Options) > 0 && data.Resolved != nil {
+		if id, ok := data.Options[0].Value.(string); ok {
+			attachment = data.Resolved.Attachments[id]
+		}
+	}
+	if attachment == nil {
+		respondEphemeral(s, i, embeds.Error("Không tìm thấy file đính kèm.", ""))
+		return fmt.Errorf("%w: missing attachment", ErrInvalidInput)
+	}
+	if attachment.Size > maxImportSize {
+		respondEphemeral(s, i, embeds.Error("File quá lớn (tối đa 1MB).", ""))
+		return fmt.Errorf("%w: attachment of %d bytes", ErrInvalidInput, attachment.Size)
+	}
+
+	deferEphemeral(s, i)
+
+	riotIDs, err := b.downloadImport(ctx, attachment.URL)
+	if err != nil {
+		editEmbed(s, i, embeds.Error("File không hợp lệ. Dùng file từ `/export` hoặc một mảng JSON các Riot ID.", fmt.Sprintf("Lỗi: %v", err)))
+		return err
+	}
+
+	result, err := b.ImportPlayers(ctx, riotIDs, i.ChannelID)
+	if err != nil {
+		editEmbed(s, i, embeds.Error(fmt.Sprintf("Mỗi lần chỉ nhập được tối đa **%d** người chơi.", maxImportPlayers), ""))
+		return err
+	}
+
+	editEmbed(s, i, importResultEmbed(result))
+	return nil
+}
+
+// downloadImport fetches an /import attachment and returns the Riot IDs in
+// it. Both a ChannelExport and a plain JSON array of Riot IDs are accepted.
+func (b *Bot) downloadImport(ctx context.Context, url string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download: %s", resp.Status)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxImportSize {
+		return nil, fmt.Errorf("%w: file larger than %d bytes", ErrInvalidInput, maxImportSize)
+	}
+	return parseImport(raw)
+}
+
+// parseImport reads the Riot IDs from an /import file.
+func parseImport(raw []byte) ([]string, error) {
+	raw = bytes.TrimSpace(raw)
+	if bytes.HasPrefix(raw, []byte("[")) {
+		var riotIDs []string
+		if err := json.Unmarshal(raw, &riotIDs); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		return riotIDs, nil
+	}
+
+	var export ChannelExport
+	if err := json.Unmarshal(raw, &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if export.Format < 1 || export.Format > channelExportFormat {
+		return nil, fmt.Errorf("%w: unsupported export format %d", ErrInvalidInput, export.Format)
+	}
+	riotIDs := make([]string, len(export.Players))
+	for n, p := range export.Players {
+		riotIDs[n] = p.RiotID
+	}
+	return riotIDs, nil
+}
+
+// importResultEmbed summarises an import.
+func importResultEmbed(result *ImportResult) *discordgo.MessageEmbed {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ Đã thêm: **%d**\n", len(result.Added))
+	fmt.Fprintf(&sb, "📌 Đã theo dõi sẵn: **%d**\n", len(result.AlreadyTracked))
+	fmt.Fprintf(&sb, "❌ Lỗi: **%d**", len(result.Failed))
+
+	failed := make([]string, 0, len(result.Failed))
+	for riotID := range result.Failed {
+		failed = append(failed, riotID)
+	}
+	sort.Strings(failed)
+	for n, riotID := range failed {
+		if n == 10 {
+			fmt.Fprintf(&sb, "\n… và %d người khác", len(failed)-n)
+			break
+		}
+		fmt.Fprintf(&sb, "\n• %s", riotID)
+	}
+
+	if len(result.Failed) > 0 {
+		return embeds.Warning(sb.String(), "Nhập danh sách chưa hoàn chỉnh")
+	}
+	return embeds.Success(sb.String(), "Đã nhập danh sách")
+}
@@ -0,0 +1,264 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/storage"
+)
+
+// historyPageSize is the number of matches on one /history page.
+const historyPageSize = 5
+
+// historyState is what the /history buttons need to render another page.
+// It is stored under the interaction ID and expires after the cache.pages
+// TTL.
+type historyState struct {
+	RiotID   string   `json:"riot_id"`
+	PUUID    string   `json:"puuid"`
+	QueueID  int      `json:"queue_id"`
+	MatchIDs []string `json:"match_ids"`
+}
+
+// historyCommand defines /history.
+func historyCommand() *discordgo.ApplicationCommand {
+	minCount := float64(historyPageSize)
+	return &discordgo.ApplicationCommand{
+		Name:        "history",
+		Description: "Xem lịch sử đấu gần đây của người chơi",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "riot_id",
+				Description: "Tên người chơi (VD: Faker#KR1)",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "Số trận (5-20, mặc định 10)",
+				MinValue:    &minCount,
+				MaxValue:    20,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "queue",
+				Description: "Chỉ xem một chế độ",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Xếp hạng đơn/đôi", Value: 420},
+					{Name: "Xếp hạng linh hoạt", Value: 440},
+					{Name: "Thường", Value: 400},
+					{Name: "ARAM", Value: 450},
+				},
+			},
+		},
+	}
+}
+
+// handleHistory handles the /history command.
+func (b *Bot) handleHistory(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	var riotID string
+	count, queueID := 10, 0
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "riot_id":
+			riotID = opt.StringValue()
+		case "count":
+			count = int(opt.IntValue())
+		case "queue":
+			queueID = int(opt.IntValue())
+		}
+	}
+
+	gameName, tagLine, err := parseRiotID(riotID)
+	if err != nil {
+		b.respondInvalidRiotID(s, i)
+		return err
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	puuid, err := b.riotClient.GetPUUIDByRiotID(ctx, gameName, tagLine)
+	if err != nil || puuid == "" {
+		editEmbed(s, i, embeds.Error(fmt.Sprintf("Không tìm thấy người chơi **%s**. Kiểm tra lại tên và tag.", riotID), ""))
+		return fmt.Errorf("%w: player %s: %v", ErrNotFound, riotID, err)
+	}
+
+	matchIDs, err := b.riotClient.GetMatchIDs(ctx, puuid, 0, count, queueID)
+	if err != nil || len(matchIDs) == 0 {
+		editEmbed(s, i, embeds.Error("Người chơi này chưa đánh trận nào gần đây.", ""))
+		return fmt.Errorf("%w: no recent matches for %s", ErrNotFound, riotID)
+	}
+
+	state := &historyState{RiotID: riotID, PUUID: puuid, QueueID: queueID, MatchIDs: matchIDs}
+	raw, err := json.Marshal(state)
+	if err == nil {
+		err = b.store.CacheSet("pages", "pages:history:"+i.ID, string(raw))
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Saving history pages failed", "error", err)
+	}
+
+	embed, components := b.historyPage(ctx, i.ID, state, 0)
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+	return nil
+}
+
+// handleHistoryButton handles the /history previous/next buttons:
+// histpage_<token>_<page>.
+func (b *Bot) handleHistoryButton(ctx context.Context, s Session, i *discordgo.InteractionCreate, customID string) error {
+	token, rawPage, _ := strings.Cut(strings.TrimPrefix(customID, "histpage_"), "_")
+	page, err := strconv.Atoi(rawPage)
+	if err != nil || page < 0 {
+		return fmt.Errorf("%w: custom id %q", ErrInvalidInput, customID)
+	}
+
+	state := b.getHistoryState(token)
+	if state == nil {
+		respondEphemeral(s, i, embeds.Error("Lịch sử đấu đã hết hạn. Vui lòng dùng `/history` để xem lại.", ""))
+		return fmt.Errorf("%w: history pages %s expired", ErrNotFound, token)
+	}
+
+	embed, components := b.historyPage(ctx, token, state, page)
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+// handleHistorySelect runs the full analysis of the match picked in a
+// /history select menu: histpick_<token>.
+func (b *Bot) handleHistorySelect(ctx context.Context, s Session, i *discordgo.InteractionCreate, customID string) error {
+	token := strings.TrimPrefix(customID, "histpick_")
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return fmt.Errorf("%w: no match selected", ErrInvalidInput)
+	}
+	matchID := values[0]
+
+	state := b.getHistoryState(token)
+	if state == nil {
+		respondEphemeral(s, i, embeds.Error("Lịch sử đấu đã hết hạn. Vui lòng dùng `/history` để xem lại.", ""))
+		return fmt.Errorf("%w: history pages %s expired", ErrNotFound, token)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embeds.Analyzing(state.RiotID, matchID)},
+		},
+	})
+	return b.editAnalysis(ctx, s, i, state.RiotID, state.PUUID, matchID)
+}
+
+// getHistoryState loads the state behind a /history message, or nil once it
+// has expired.
+func (b *Bot) getHistoryState(token string) *historyState {
+	raw, ok := b.store.CacheGet("pages", "pages:history:"+token)
+	if !ok {
+		return nil
+	}
+	var state historyState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		slog.Warn("Decoding history pages failed", "token", token, "error", err)
+		return nil
+	}
+	return &state
+}
+
+// historyPage renders one page of a /history message and its components.
+// Pages past the end are clamped to the last page.
+func (b *Bot) historyPage(ctx context.Context, token string, state *historyState, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	pages := (len(state.MatchIDs) + historyPageSize - 1) / historyPageSize
+	page = max(0, min(page, pages-1))
+	matchIDs := state.MatchIDs[page*historyPageSize : min((page+1)*historyPageSize, len(state.MatchIDs))]
+
+	now := time.Now()
+	records := b.historyRecords(ctx, state.PUUID, matchIDs)
+	embed := embeds.MatchHistory(state.RiotID, state.QueueID, records, page, pages, now)
+
+	var components []discordgo.MessageComponent
+	if pages > 1 {
+		components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "◀ Trước",
+				Style:    discordgo.SecondaryButton,
+				CustomID: fmt.Sprintf("histpage_%s_%d", token, page-1),
+				Disabled: page == 0,
+			},
+			discordgo.Button{
+				Label:    "Sau ▶",
+				Style:    discordgo.SecondaryButton,
+				CustomID: fmt.Sprintf("histpage_%s_%d", token, page+1),
+				Disabled: page == pages-1,
+			},
+		}})
+	}
+
+	if len(records) > 0 {
+		options := make([]discordgo.SelectMenuOption, len(records))
+		for n, r := range records {
+			result := "Thua"
+			if r.Win {
+				result = "Thắng"
+			}
+			options[n] = discordgo.SelectMenuOption{
+				Label:       fmt.Sprintf("%s - %s %d/%d/%d", result, r.Champion, r.Kills, r.Deaths, r.Assists),
+				Value:       r.MatchID,
+				Description: fmt.Sprintf("%s • %s", embeds.QueueName(r.QueueID, r.GameMode), embeds.TimeAgo(r.PlayedAt, now)),
+			}
+		}
+		components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				CustomID:    "histpick_" + token,
+				Placeholder: "🔍 Chọn trận để phân tích chi tiết",
+				Options:     options,
+			},
+		}})
+	}
+
+	return embed, components
+}
+
+// historyRecords returns the player's result in each match, preferring the
+// stored history and falling back to the (cached) match details. Matches
+// that can't be fetched are left out.
+func (b *Bot) historyRecords(ctx context.Context, puuid string, matchIDs []string) []storage.MatchRecord {
+	stored, err := b.history.GetMany(puuid, matchIDs)
+	if err != nil {
+		slog.WarnContext(ctx, "Reading match history failed", "puuid", puuid, "error", err)
+	}
+
+	records := make([]storage.MatchRecord, 0, len(matchIDs))
+	for _, matchID := range matchIDs {
+		if record, ok := stored[matchID]; ok {
+			records = append(records, *record)
+			continue
+		}
+
+		match, err := b.riotClient.GetMatchDetails(ctx, matchID)
+		if err != nil {
+			slog.WarnContext(ctx, "Fetching history match failed", "match_id", matchID, "error", err)
+			continue
+		}
+		if record := participantRecord(match, puuid); record != nil {
+			records = append(records, *record)
+		}
+	}
+	return records
+}
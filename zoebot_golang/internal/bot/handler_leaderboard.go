@@ -1,19 +1,205 @@
 package bot
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/charts"
 	"github.com/zoebot/internal/embeds"
-	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/storage"
 )
 
+// Leaderboard metrics.
+const (
+	metricRank    = "rank"
+	metricWinRate = "winrate"
+	metricGames   = "games"
+	metricScore   = "score"
+	metricKDA     = "kda"
+	metricLP      = "lp"
+)
+
+// leaderboardMetricNames are the Vietnamese names of the metrics.
+var leaderboardMetricNames = map[string]string{
+	metricRank:    "Rank hiện tại",
+	metricWinRate: "Tỉ lệ thắng",
+	metricGames:   "Số trận",
+	metricScore:   "Điểm AI trung bình",
+	metricKDA:     "KDA",
+	metricLP:      "LP kiếm được",
+}
+
+// Leaderboard time windows.
+const (
+	windowAll   = "all"
+	windowWeek  = "week"
+	windowMonth = "month"
+)
+
+// leaderboardWindowNames are the Vietnamese names of the windows.
+var leaderboardWindowNames = map[string]string{
+	windowAll:   "Toàn bộ",
+	windowWeek:  "Tuần này",
+	windowMonth: "Tháng này",
+}
+
+const (
+	leaderboardSize          = 10 // players listed
+	leaderboardMinWinRate    = 3  // games needed to rank by win rate
+	leaderboardChartPlayers  = 5  // top players drawn on the /leaderboard chart
+	leaderboardChartFallback = 30 // days drawn when the window is all time
+)
+
+// leaderboardCommand defines /leaderboard.
+func leaderboardCommand() *discordgo.ApplicationCommand {
+	choices := func(names map[string]string, order ...string) []*discordgo.ApplicationCommandOptionChoice {
+		out := make([]*discordgo.ApplicationCommandOptionChoice, len(order))
+		for n, value := range order {
+			out[n] = &discordgo.ApplicationCommandOptionChoice{Name: names[value], Value: value}
+		}
+		return out
+	}
+	return &discordgo.ApplicationCommand{
+		Name:        "leaderboard",
+		Description: "Xem bảng xếp hạng người chơi đang theo dõi",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "metric",
+				Description: "Xếp theo (mặc định rank hiện tại)",
+				Choices:     choices(leaderboardMetricNames, metricRank, metricWinRate, metricGames, metricScore, metricKDA, metricLP),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "queue",
+				Description: "Hàng chờ (mặc định đơn/đôi)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Xếp hạng đơn/đôi", Value: storage.QueueSolo},
+					{Name: "Xếp hạng linh hoạt", Value: storage.QueueFlex},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "window",
+				Description: "Khoảng thời gian (mặc định toàn bộ)",
+				Choices:     choices(leaderboardWindowNames, windowAll, windowWeek, windowMonth),
+			},
+		},
+	}
+}
+
+// leaderboardEntry is one player's standing, read from the stored history
+// and rank snapshots.
+type leaderboardEntry struct {
+	Name  string
+	PUUID string
+	Rank  *storage.RankSnapshot // latest in the queue; nil if unranked
+
+	Games, Wins            int
+	Kills, Deaths, Assists int
+	ScoreSum               float64
+	Scored                 int // games with a score
+
+	LPGained int
+	HasLP    bool // snapshots in the window to measure LP with
+}
+
+// WinRate returns the win rate in the window, in percent.
+func (e *leaderboardEntry) WinRate() float64 {
+	return float64(e.Wins) / float64(max(e.Games, 1)) * 100
+}
+
+// KDA returns (kills + assists) / deaths in the window.
+func (e *leaderboardEntry) KDA() float64 {
+	return float64(e.Kills+e.Assists) / float64(max(e.Deaths, 1))
+}
+
+// AvgScore returns the average score of the scored games.
+func (e *leaderboardEntry) AvgScore() float64 {
+	return e.ScoreSum / float64(max(e.Scored, 1))
+}
+
+// ranked reports whether the entry has data for metric.
+func (e *leaderboardEntry) ranked(metric string) bool {
+	switch metric {
+	case metricRank:
+		return e.Rank != nil
+	case metricWinRate:
+		return e.Games >= leaderboardMinWinRate
+	case metricScore:
+		return e.Scored > 0
+	case metricLP:
+		return e.HasLP
+	default:
+		return e.Games > 0
+	}
+}
+
+// less reports whether e ranks above o on metric.
+func (e *leaderboardEntry) less(o *leaderboardEntry, metric string) bool {
+	switch metric {
+	case metricRank:
+		if e.Rank.Division() != o.Rank.Division() {
+			return e.Rank.Division() > o.Rank.Division()
+		}
+		if e.Rank.TotalLP() != o.Rank.TotalLP() {
+			return e.Rank.TotalLP() > o.Rank.TotalLP()
+		}
+	case metricWinRate:
+		if e.WinRate() != o.WinRate() {
+			return e.WinRate() > o.WinRate()
+		}
+	case metricScore:
+		if e.AvgScore() != o.AvgScore() {
+			return e.AvgScore() > o.AvgScore()
+		}
+	case metricKDA:
+		if e.KDA() != o.KDA() {
+			return e.KDA() > o.KDA()
+		}
+	case metricLP:
+		if e.LPGained != o.LPGained {
+			return e.LPGained > o.LPGained
+		}
+	}
+	if e.Games != o.Games {
+		return e.Games > o.Games
+	}
+	return e.Name < o.Name
+}
+
+// windowStart returns when a window began: Monday 00:00 for the week and
+// the 1st for the month, in now's location. It is zero for all time.
+func windowStart(window string, now time.Time) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch window {
+	case windowWeek:
+		return midnight.AddDate(0, 0, -(int(now.Weekday())+6)%7)
+	case windowMonth:
+		return midnight.AddDate(0, 0, 1-now.Day())
+	}
+	return time.Time{}
+}
+
 // handleLeaderboard handles the /leaderboard command.
-func (b *Bot) handleLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleLeaderboard(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	metric, queueType, window := metricRank, storage.QueueSolo, windowAll
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "metric":
+			metric = opt.StringValue()
+		case "queue":
+			queueType = opt.StringValue()
+		case "window":
+			window = opt.StringValue()
+		}
+	}
+
 	// Defer response (loading state)
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
@@ -23,155 +209,189 @@ func (b *Bot) handleLeaderboard(s *discordgo.Session, i *discordgo.InteractionCr
 	players := b.trackedPlayers.GetByChannel(i.ChannelID)
 
 	if len(players) == 0 {
-		embed := embeds.Warning("Chưa có người chơi nào được theo dõi trong kênh này.", "Sử dụng `/track` để thêm người chơi.")
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Embeds: &[]*discordgo.MessageEmbed{embed},
-		})
-		return
+		editEmbed(s, i, embeds.Warning("Chưa có người chơi nào được theo dõi trong kênh này.", "Sử dụng `/track` để thêm người chơi."))
+		return nil
 	}
 
-	// Debug: log tracked players
-	for _, p := range players {
-		log.Printf("Tracked player: Name=%s, PUUID=%s, ChannelID=%s", p.Name, p.PUUID, p.ChannelID)
-	}
-
-	// Fetch rank info for all players concurrently
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var rankInfos []*riot.PlayerRankInfo
+	slog.DebugContext(ctx, "Building leaderboard", "players", len(players), "metric", metric, "queue", queueType, "window", window)
 
+	// Weeks and months start at midnight in the guild's time zone, as recaps do
+	from := windowStart(window, time.Now().In(b.channelLocation(ctx, i.ChannelID)))
+	var entries []*leaderboardEntry
 	for _, player := range players {
-		// Skip if PUUID is empty
 		if player.PUUID == "" {
-			log.Printf("Skipping player %s: empty PUUID", player.Name)
 			continue
 		}
-
-		wg.Add(1)
-		go func(puuid, name string) {
-			defer wg.Done()
-
-			info, err := b.riotClient.GetPlayerRankInfo(puuid, name)
-			if err != nil {
-				log.Printf("Failed to get rank info for %s: %v", name, err)
-				return // Skip failed players
-			}
-
-			mu.Lock()
-			rankInfos = append(rankInfos, info)
-			mu.Unlock()
-		}(player.PUUID, player.Name)
-	}
-
-	wg.Wait()
-
-	if len(rankInfos) == 0 {
-		embed := embeds.Error("Không thể lấy thông tin xếp hạng. Vui lòng thử lại sau.", "")
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Embeds: &[]*discordgo.MessageEmbed{embed},
-		})
-		return
+		entry, err := b.leaderboardEntry(ctx, player, queueType, from, metric == metricRank)
+		if err != nil {
+			slog.WarnContext(ctx, "Reading leaderboard data failed", "player", player.Name, "error", err)
+			continue
+		}
+		if entry.ranked(metric) {
+			entries = append(entries, entry)
+		}
 	}
 
-	// Sort by TierValue (highest first)
-	sort.Slice(rankInfos, func(i, j int) bool {
-		return rankInfos[i].TierValue > rankInfos[j].TierValue
-	})
-
 	// Get channel name
 	channelName := "this channel"
 	if channel, err := s.Channel(i.ChannelID); err == nil {
 		channelName = "#" + channel.Name
 	}
 
-	// Build embed
-	embed := buildLeaderboardEmbed(rankInfos, channelName)
+	if len(entries) == 0 {
+		editEmbed(s, i, embeds.Warning(
+			fmt.Sprintf("Chưa có dữ liệu **%s** cho %s.", strings.ToLower(leaderboardMetricNames[metric]), strings.ToLower(leaderboardWindowNames[window])),
+			"Bot chỉ tính các trận xếp hạng của người chơi đang được theo dõi, kể từ khi theo dõi.",
+		))
+		return nil
+	}
 
-	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{embed},
-	})
-}
+	sort.SliceStable(entries, func(a, c int) bool { return entries[a].less(entries[c], metric) })
 
-// buildLeaderboardEmbed creates the leaderboard embed.
-func buildLeaderboardEmbed(players []*riot.PlayerRankInfo, channelName string) *discordgo.MessageEmbed {
-	embed := &discordgo.MessageEmbed{
-		Title: "🏆 BẢNG XẾP HẠNG",
-		Color: 0xF1C40F, // Gold color
-	}
+	embed := buildLeaderboardEmbed(entries, metric, queueType, window, channelName)
 
-	if len(players) == 0 {
-		embed.Description = "Không có dữ liệu"
-		embed.Footer = &discordgo.MessageEmbedFooter{
-			Text: "📊 Cache 10 phút",
-		}
-		return embed
+	chartFrom := from
+	if window == windowAll {
+		chartFrom = time.Now().AddDate(0, 0, -leaderboardChartFallback)
 	}
+	chart := lpChart(ctx, "LP - "+channelName, b.leaderboardSeries(entries, queueType, chartFrom))
 
-	var sb strings.Builder
+	return editEmbedWithChart(s, i, embed, chart)
+}
 
-	// Track queue types for footer
-	queueTypes := make(map[string]bool)
+// leaderboardEntry reads a player's stats in one queue since from. With
+// needRank and no snapshot yet, the current rank comes from the (cached)
+// league entries instead.
+func (b *Bot) leaderboardEntry(ctx context.Context, player *storage.TrackedPlayer, queueType string, from time.Time, needRank bool) (*leaderboardEntry, error) {
+	entry := &leaderboardEntry{Name: player.Name, PUUID: player.PUUID}
 
-	for idx, p := range players {
-		if idx >= 10 { // Max 10 players
-			break
+	records, err := b.history.ByPlayer(player.PUUID, storage.HistoryQuery{From: from, QueueID: rankQueueID(queueType)})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		entry.Games++
+		if r.Win {
+			entry.Wins++
 		}
+		entry.Kills += r.Kills
+		entry.Deaths += r.Deaths
+		entry.Assists += r.Assists
+		if r.ScoreSource != "" {
+			entry.ScoreSum += r.Score
+			entry.Scored++
+		}
+	}
 
-		// Medal/number
-		medal := getLeaderboardMedal(idx)
+	snapshots, err := b.history.Ranks(player.PUUID, queueType, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) > 0 {
+		entry.Rank = &snapshots[0]
+		entry.LPGained, entry.HasLP = lpSince(snapshots, from)
+	} else if needRank {
+		leagues, err := b.riotClient.GetLeagueEntriesByPUUID(ctx, player.PUUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range leagues {
+			if e.QueueType == queueType {
+				entry.Rank = &storage.RankSnapshot{
+					PUUID: player.PUUID, QueueType: queueType,
+					Tier: e.Tier, Rank: e.Rank, LP: e.LeaguePoints, Wins: e.Wins, Losses: e.Losses,
+				}
+			}
+		}
+	}
+	return entry, nil
+}
 
-		// Rank display
-		rankStr := formatRank(p.Tier, p.Rank, p.LP)
+// lpSince returns the LP gained since from, measured from the last snapshot
+// before it (or the first one after) to the latest. snapshots are newest
+// first; it reports false when there aren't two to compare.
+func lpSince(snapshots []storage.RankSnapshot, from time.Time) (int, bool) {
+	if len(snapshots) == 0 {
+		return 0, false
+	}
+	base := 0
+	for n := range snapshots {
+		base = n
+		if snapshots[n].TakenAt.Before(from) {
+			break
+		}
+	}
+	if base == 0 {
+		return 0, false
+	}
+	return storage.RankChange{Before: &snapshots[base], After: &snapshots[0]}.LPDelta(), true
+}
 
-		// Queue type indicator
-		queueIcon := ""
-		if p.QueueType == "RANKED_FLEX_SR" {
-			queueIcon = " 👥"
-			queueTypes["flex"] = true
-		} else if p.QueueType == "RANKED_SOLO_5x5" {
-			queueTypes["solo"] = true
+// rankQueueID returns the queue ID of a league queue type.
+func rankQueueID(queueType string) int {
+	for id, t := range rankQueues {
+		if t == queueType {
+			return id
 		}
+	}
+	return 0
+}
 
-		// Winrate
-		wrStr := ""
-		if p.TotalGames > 0 {
-			wrStr = fmt.Sprintf("%.1f%%", p.WinRate)
-		} else {
-			wrStr = "-"
+// leaderboardSeries returns the LP since from of the top players.
+func (b *Bot) leaderboardSeries(entries []*leaderboardEntry, queueType string, from time.Time) []charts.Series {
+	var series []charts.Series
+	for _, e := range entries[:min(len(entries), leaderboardChartPlayers)] {
+		snapshots, err := b.history.Ranks(e.PUUID, queueType, from, time.Time{})
+		if err != nil || len(snapshots) == 0 {
+			continue
 		}
+		series = append(series, charts.Series{Name: e.Name, Points: lpPoints(snapshots)})
+	}
+	return series
+}
 
-		// Hot streak indicator
-		streakIcon := ""
-		if p.HotStreak {
-			streakIcon = " 🔥"
+// buildLeaderboardEmbed creates the leaderboard embed from sorted entries.
+func buildLeaderboardEmbed(entries []*leaderboardEntry, metric, queueType, window, channelName string) *discordgo.MessageEmbed {
+	var sb strings.Builder
+	for idx, e := range entries {
+		if idx == leaderboardSize {
+			break
 		}
 
 		// Format: 🥇 **Player#Tag**
-		// ┗ Diamond II 75LP • 58.2% (142G) 🔥 👥
-		sb.WriteString(fmt.Sprintf("%s **%s**\n", medal, p.Name))
-		sb.WriteString(fmt.Sprintf("┗ %s • `%s` (%dG)%s%s\n\n", rankStr, wrStr, p.TotalGames, streakIcon, queueIcon))
+		// ┗ Diamond II 75LP • `58.2%` (142G)
+		fmt.Fprintf(&sb, "%s **%s**\n┗ ", getLeaderboardMedal(idx), e.Name)
+		switch metric {
+		case metricRank:
+			r := e.Rank
+			fmt.Fprintf(&sb, "%s • `%.1f%%` (%dG)", formatRank(r.Tier, r.Rank, r.LP), float64(r.Wins)/float64(max(r.Games(), 1))*100, r.Games())
+		case metricWinRate:
+			fmt.Fprintf(&sb, "`%.1f%%` • %dT - %dB", e.WinRate(), e.Wins, e.Games-e.Wins)
+		case metricGames:
+			fmt.Fprintf(&sb, "**%d** trận • %dT - %dB", e.Games, e.Wins, e.Games-e.Wins)
+		case metricScore:
+			fmt.Fprintf(&sb, "Điểm TB **%.1f** (%d trận)", e.AvgScore(), e.Scored)
+		case metricKDA:
+			fmt.Fprintf(&sb, "KDA **%.2f** (%.1f/%.1f/%.1f)", e.KDA(),
+				float64(e.Kills)/float64(e.Games), float64(e.Deaths)/float64(e.Games), float64(e.Assists)/float64(e.Games))
+		case metricLP:
+			fmt.Fprintf(&sb, "**%s** → %s", formatLPDelta(e.LPGained), formatRank(e.Rank.Tier, e.Rank.Rank, e.Rank.LP))
+		}
+		sb.WriteString("\n\n")
 	}
 
-	embed.Description = sb.String()
-
-	// Build footer based on queue types found
-	footerText := "📊 "
-	if queueTypes["solo"] && queueTypes["flex"] {
-		footerText += "Solo/Duo & Flex"
-	} else if queueTypes["flex"] {
-		footerText += "Ranked Flex 👥"
-	} else if queueTypes["solo"] {
-		footerText += "Ranked Solo/Duo"
-	} else {
-		footerText += "Ranked"
+	queue := "Ranked Solo/Duo"
+	if queueType == storage.QueueFlex {
+		queue = "Ranked Flex 👥"
 	}
-	footerText += " • Cache 10 phút"
-
-	embed.Footer = &discordgo.MessageEmbedFooter{
-		Text: footerText,
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🏆 BẢNG XẾP HẠNG - %s", strings.ToUpper(leaderboardMetricNames[metric])),
+		Description: sb.String(),
+		Color:       0xF1C40F, // Gold color
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("📊 %s • %s • %s", queue, leaderboardWindowNames[window], channelName),
+		},
 	}
-
-	return embed
 }
 
 // formatRank formats tier/rank/LP into display string.
@@ -180,10 +400,6 @@ func formatRank(tier, rank string, lp int) string {
 		return "Unranked"
 	}
 
-	if tier == "N/A" {
-		return "Chưa xác định"
-	}
-
 	// Format tier name (capitalize first letter only)
 	tierDisplay := strings.Title(strings.ToLower(tier))
 
@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/charts"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/storage"
+)
+
+// lpHistoryLines caps the games listed by /lp.
+const lpHistoryLines = 15
+
+// lpCommand defines /lp.
+func lpCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "lp",
+		Description: "Xem biến động LP của người chơi đang theo dõi",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "riot_id",
+				Description: "Tên người chơi (VD: Faker#KR1)",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "days",
+				Description: "Khoảng thời gian (mặc định 30 ngày)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "7 ngày", Value: 7},
+					{Name: "30 ngày", Value: 30},
+					{Name: "90 ngày", Value: 90},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "queue",
+				Description: "Hàng chờ (mặc định đơn/đôi)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Xếp hạng đơn/đôi", Value: storage.QueueSolo},
+					{Name: "Xếp hạng linh hoạt", Value: storage.QueueFlex},
+				},
+			},
+		},
+	}
+}
+
+// handleLP handles the /lp command.
+func (b *Bot) handleLP(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	var riotID string
+	days, queueType := 30, storage.QueueSolo
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "riot_id":
+			riotID = opt.StringValue()
+		case "days":
+			days = int(opt.IntValue())
+		case "queue":
+			queueType = opt.StringValue()
+		}
+	}
+
+	gameName, tagLine, err := parseRiotID(riotID)
+	if err != nil {
+		b.respondInvalidRiotID(s, i)
+		return err
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	puuid, err := b.riotClient.GetPUUIDByRiotID(ctx, gameName, tagLine)
+	if err != nil || puuid == "" {
+		editEmbed(s, i, embeds.Error(fmt.Sprintf("Không tìm thấy người chơi **%s**. Kiểm tra lại tên và tag.", riotID), ""))
+		return fmt.Errorf("%w: player %s: %v", ErrNotFound, riotID, err)
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+	snapshots, err := b.history.Ranks(puuid, queueType, from, time.Time{})
+	if err != nil {
+		editEmbed(s, i, embeds.Error("Không thể đọc lịch sử LP. Vui lòng thử lại sau.", ""))
+		return err
+	}
+	if len(snapshots) == 0 {
+		editEmbed(s, i, embeds.Warning(
+			fmt.Sprintf("Chưa có dữ liệu LP của **%s** trong %d ngày qua.", riotID, days),
+			"Bot chỉ ghi lại LP của người chơi đang được theo dõi, sau mỗi trận xếp hạng.",
+		))
+		return nil
+	}
+
+	// The last snapshot before the period is the baseline of the first game in it
+	var baseline *storage.RankSnapshot
+	if earlier, err := b.history.Ranks(puuid, queueType, time.Time{}, from); err == nil && len(earlier) > 0 {
+		baseline = &earlier[0]
+	}
+
+	embed := buildLPEmbed(riotID, days, snapshots, baseline, now)
+	points := lpPoints(snapshots)
+	if baseline != nil {
+		points = append(lpPoints([]storage.RankSnapshot{*baseline}), points...)
+	}
+	file := lpChart(ctx, fmt.Sprintf("LP - %s (%d ngày)", riotID, days), []charts.Series{{Name: riotID, Points: points}})
+	editEmbedWithChart(s, i, embed, file)
+	return nil
+}
+
+// lpPoints turns snapshots, newest first, into chart points, oldest first.
+func lpPoints(snapshots []storage.RankSnapshot) []charts.Point {
+	points := make([]charts.Point, len(snapshots))
+	for n, snap := range snapshots {
+		points[len(snapshots)-1-n] = charts.Point{At: snap.TakenAt, LP: snap.TotalLP()}
+	}
+	return points
+}
+
+// lpChart renders an LP chart as an attachment, or returns nil if it
+// can't be drawn.
+func lpChart(ctx context.Context, title string, series []charts.Series) *discordgo.File {
+	png, err := charts.LP(title, series)
+	if err != nil {
+		slog.DebugContext(ctx, "No LP chart", "title", title, "error", err)
+		return nil
+	}
+	return &discordgo.File{Name: "lp.png", ContentType: "image/png", Reader: bytes.NewReader(png)}
+}
+
+// editEmbedWithChart edits the deferred response to embed, showing chart
+// as its image when there is one.
+func editEmbedWithChart(s Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed, chart *discordgo.File) error {
+	edit := &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{embed}}
+	if chart != nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + chart.Name}
+		edit.Files = []*discordgo.File{chart}
+	}
+	_, err := s.InteractionResponseEdit(i.Interaction, edit)
+	return err
+}
+
+// buildLPEmbed creates the /lp embed from snapshots taken in the period,
+// newest first. baseline is the snapshot before the period, if any.
+func buildLPEmbed(riotID string, days int, snapshots []storage.RankSnapshot, baseline *storage.RankSnapshot, now time.Time) *discordgo.MessageEmbed {
+	latest := &snapshots[0]
+	first := baseline
+	if first == nil {
+		first = &snapshots[len(snapshots)-1]
+	}
+	net := storage.RankChange{Before: first, After: latest}
+	wins, losses := latest.Wins-first.Wins, latest.Losses-first.Losses
+
+	color := embeds.ColorWin
+	if net.LPDelta() < 0 {
+		color = embeds.ColorLose
+	}
+
+	var description strings.Builder
+	fmt.Fprintf(&description, "Hiện tại: **%s**\n", formatRank(latest.Tier, latest.Rank, latest.LP))
+	fmt.Fprintf(&description, "%d ngày qua: **%s** (%dT - %dB)", days, formatLPDelta(net.LPDelta()), wins, losses)
+	if net.NewTier() {
+		fmt.Fprintf(&description, "\n🎉 Đã lên **%s**", strings.Title(strings.ToLower(latest.Tier)))
+	}
+
+	var games strings.Builder
+	for n := range snapshots {
+		if n == lpHistoryLines {
+			fmt.Fprintf(&games, "… và %d trận khác", len(snapshots)-n)
+			break
+		}
+		change := storage.RankChange{After: &snapshots[n]}
+		if n+1 < len(snapshots) {
+			change.Before = &snapshots[n+1]
+		} else {
+			change.Before = baseline
+		}
+
+		delta := "`   —`"
+		if change.Before != nil {
+			delta = fmt.Sprintf("`%+4d`", change.LPDelta())
+		}
+		marker := ""
+		switch {
+		case change.Promoted():
+			marker = " ⬆️"
+		case change.Demoted():
+			marker = " ⬇️"
+		}
+		fmt.Fprintf(&games, "%s %s • %s%s\n", delta, formatRank(change.After.Tier, change.After.Rank, change.After.LP), embeds.TimeAgo(change.After.TakenAt, now), marker)
+	}
+
+	queue := "Đơn/Đôi"
+	if latest.QueueType == storage.QueueFlex {
+		queue = "Linh hoạt"
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📈 LP - %s (%s)", riotID, queue),
+		Description: description.String(),
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{{
+			Name:  fmt.Sprintf("🎮 %d trận gần nhất", min(len(snapshots), lpHistoryLines)),
+			Value: games.String(),
+		}},
+	}
+}
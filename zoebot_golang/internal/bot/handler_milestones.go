@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/storage"
+)
+
+// milestoneNames are the Vietnamese names of the milestone kinds.
+var milestoneNames = map[string]string{
+	storage.MilestonePromotion:  "Thăng/rớt hạng",
+	storage.MilestonePlacements: "Xong phân hạng",
+	storage.MilestonePeak:       "Đỉnh cao mùa giải",
+	storage.MilestoneStreak:     "Chuỗi thắng/thua 5+ trận",
+}
+
+// milestonesCommand defines /milestones.
+func milestonesCommand() *discordgo.ApplicationCommand {
+	permissions, dmAllowed := guildManagerPermissions()
+	kinds := make([]*discordgo.ApplicationCommandOptionChoice, len(storage.MilestoneKinds))
+	for n, kind := range storage.MilestoneKinds {
+		kinds[n] = &discordgo.ApplicationCommandOptionChoice{Name: milestoneNames[kind], Value: kind}
+	}
+	return &discordgo.ApplicationCommand{
+		Name:                     "milestones",
+		Description:              "Cài đặt thông báo cột mốc rank trong server",
+		DefaultMemberPermissions: permissions,
+		DMPermission:             dmAllowed,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Xem các loại cột mốc đang bật",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Bật hoặc tắt một loại cột mốc",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "type",
+						Description: "Loại cột mốc",
+						Required:    true,
+						Choices:     kinds,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Bật hay tắt",
+						Required:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleMilestones handles the /milestones command.
+func (b *Bot) handleMilestones(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	if !isGuildManager(i) {
+		respondEphemeral(s, i, embeds.Error("Bạn cần quyền **Quản lý máy chủ** để dùng lệnh này.", ""))
+		return errNotGuildManager
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, embeds.Error("Lệnh này chỉ dùng được trong server.", ""))
+		return fmt.Errorf("%w: /milestones outside a guild", ErrInvalidInput)
+	}
+
+	settings, err := b.guildSettings.Get(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, embeds.Error("Không thể đọc cài đặt của server. Vui lòng thử lại sau.", ""))
+		return err
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	if sub.Name == "set" {
+		var kind string
+		var enabled bool
+		for _, opt := range sub.Options {
+			switch opt.Name {
+			case "type":
+				kind = opt.StringValue()
+			case "enabled":
+				enabled = opt.BoolValue()
+			}
+		}
+		if _, ok := milestoneNames[kind]; !ok {
+			respondEphemeral(s, i, embeds.Error(fmt.Sprintf("Không có loại cột mốc **%s**.", kind), ""))
+			return fmt.Errorf("%w: milestone kind %q", ErrInvalidInput, kind)
+		}
+
+		settings, err = b.guildSettings.Update(i.GuildID, func(g *storage.GuildSettings) { g.SetMilestone(kind, enabled) })
+		if err != nil {
+			respondEphemeral(s, i, embeds.Error("Không thể lưu cài đặt. Vui lòng thử lại sau.", ""))
+			return err
+		}
+	}
+
+	return respondEphemeral(s, i, buildMilestonesEmbed(settings))
+}
+
+// buildMilestonesEmbed lists which milestones a guild announces.
+func buildMilestonesEmbed(settings *storage.GuildSettings) *discordgo.MessageEmbed {
+	var sb strings.Builder
+	for _, kind := range storage.MilestoneKinds {
+		state := "✅"
+		if !settings.MilestoneEnabled(kind) {
+			state = "❌"
+		}
+		fmt.Fprintf(&sb, "%s %s\n", state, milestoneNames[kind])
+	}
+	sb.WriteString("\nDùng `/milestones set` để bật hoặc tắt từng loại.")
+	return &discordgo.MessageEmbed{
+		Title:       "🎖️ CỘT MỐC RANK",
+		Description: sb.String(),
+		Color:       embeds.ColorInfo,
+	}
+}
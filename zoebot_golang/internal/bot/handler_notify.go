@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/storage"
+)
+
+// notifyModeNames are the Vietnamese names of the notification modes.
+var notifyModeNames = map[string]string{
+	storage.NotifyBoth:      "Từng trận + tổng kết phiên",
+	storage.NotifyGames:     "Chỉ từng trận",
+	storage.NotifySummaries: "Chỉ tổng kết phiên",
+}
+
+// notifyCommand defines /notify.
+func notifyCommand() *discordgo.ApplicationCommand {
+	permissions, dmAllowed := guildManagerPermissions()
+	modes := make([]*discordgo.ApplicationCommandOptionChoice, len(storage.NotifyModes))
+	for n, mode := range storage.NotifyModes {
+		modes[n] = &discordgo.ApplicationCommandOptionChoice{Name: notifyModeNames[mode], Value: mode}
+	}
+	return &discordgo.ApplicationCommand{
+		Name:                     "notify",
+		Description:              "Chọn cách bot thông báo trận đấu trong server",
+		DefaultMemberPermissions: permissions,
+		DMPermission:             dmAllowed,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "mode",
+			Description: "Kiểu thông báo (bỏ trống để xem cài đặt hiện tại)",
+			Choices:     modes,
+		}},
+	}
+}
+
+// handleNotify handles the /notify command.
+func (b *Bot) handleNotify(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	if !isGuildManager(i) {
+		respondEphemeral(s, i, embeds.Error("Bạn cần quyền **Quản lý máy chủ** để dùng lệnh này.", ""))
+		return errNotGuildManager
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, embeds.Error("Lệnh này chỉ dùng được trong server.", ""))
+		return fmt.Errorf("%w: /notify outside a guild", ErrInvalidInput)
+	}
+
+	settings, err := b.guildSettings.Get(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, embeds.Error("Không thể đọc cài đặt của server. Vui lòng thử lại sau.", ""))
+		return err
+	}
+
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		mode := options[0].StringValue()
+		if _, ok := notifyModeNames[mode]; !ok {
+			respondEphemeral(s, i, embeds.Error(fmt.Sprintf("Không có kiểu thông báo **%s**.", mode), ""))
+			return fmt.Errorf("%w: notify mode %q", ErrInvalidInput, mode)
+		}
+		if mode == storage.NotifyBoth {
+			mode = ""
+		}
+		settings, err = b.guildSettings.Update(i.GuildID, func(g *storage.GuildSettings) { g.Notify = mode })
+		if err != nil {
+			respondEphemeral(s, i, embeds.Error("Không thể lưu cài đặt. Vui lòng thử lại sau.", ""))
+			return err
+		}
+	}
+
+	return respondEphemeral(s, i, buildNotifyEmbed(settings, b.config().Session.Gap.Minutes()))
+}
+
+// buildNotifyEmbed lists the notification modes, marking the guild's.
+func buildNotifyEmbed(settings *storage.GuildSettings, gapMinutes float64) *discordgo.MessageEmbed {
+	var sb strings.Builder
+	for _, mode := range storage.NotifyModes {
+		state := "⚪"
+		if mode == settings.NotifyMode() {
+			state = "🔘"
+		}
+		fmt.Fprintf(&sb, "%s %s\n", state, notifyModeNames[mode])
+	}
+	fmt.Fprintf(&sb, "\nCác trận cách nhau dưới **%.0f phút** tính là một phiên; bot tổng kết phiên khi người chơi nghỉ đủ lâu.", gapMinutes)
+	return &discordgo.MessageEmbed{
+		Title:       "🔔 THÔNG BÁO TRẬN ĐẤU",
+		Description: sb.String(),
+		Color:       embeds.ColorInfo,
+	}
+}
@@ -0,0 +1,270 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/storage"
+)
+
+const (
+	profileMasteries = 3  // top champions shown on /profile
+	profileFormGames = 20 // games in the recent form line
+)
+
+// playerProfile is everything /profile shows. Parts that couldn't be
+// fetched are left empty.
+type playerProfile struct {
+	RiotID       string
+	Summoner     *riot.SummonerDTO
+	Solo, Flex   *riot.LeagueEntryDTO
+	Masteries    []riot.ChampionMasteryDTO
+	MasteryScore int
+	Form         recentForm
+}
+
+// recentForm summarises a player's last games.
+type recentForm struct {
+	Results   string // "WLW…", newest first
+	Wins      int
+	Games     int
+	Kills     int
+	Deaths    int
+	Assists   int
+	Champions []championCount // most played first
+}
+
+// championCount is how often a champion was played.
+type championCount struct {
+	Champion string
+	Games    int
+}
+
+// KDA returns (kills + assists) / deaths over all games.
+func (f recentForm) KDA() float64 {
+	return float64(f.Kills+f.Assists) / float64(max(f.Deaths, 1))
+}
+
+// profileCommand defines /profile.
+func profileCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "profile",
+		Description: "Xem hồ sơ người chơi: rank, thông thạo, phong độ gần đây",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "riot_id",
+				Description: "Tên người chơi (VD: Faker#KR1)",
+				Required:    true,
+			},
+		},
+	}
+}
+
+// handleProfile handles the /profile command.
+func (b *Bot) handleProfile(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	riotID := i.ApplicationCommandData().Options[0].StringValue()
+
+	gameName, tagLine, err := parseRiotID(riotID)
+	if err != nil {
+		b.respondInvalidRiotID(s, i)
+		return err
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	puuid, err := b.riotClient.GetPUUIDByRiotID(ctx, gameName, tagLine)
+	if err != nil || puuid == "" {
+		editEmbed(s, i, embeds.Error(fmt.Sprintf("Không tìm thấy người chơi **%s**. Kiểm tra lại tên và tag.", riotID), ""))
+		return fmt.Errorf("%w: player %s: %v", ErrNotFound, riotID, err)
+	}
+
+	profile := b.fetchProfile(ctx, riotID, puuid)
+	editEmbed(s, i, b.buildProfileEmbed(profile))
+	return nil
+}
+
+// fetchProfile gathers a player's profile. Each part is fetched
+// concurrently; failures are logged and leave that part empty.
+func (b *Bot) fetchProfile(ctx context.Context, riotID, puuid string) *playerProfile {
+	profile := &playerProfile{RiotID: riotID}
+	var wg sync.WaitGroup
+
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		summoner, err := b.riotClient.GetSummonerByPUUID(ctx, puuid)
+		if err != nil {
+			slog.WarnContext(ctx, "Getting summoner failed", "puuid", puuid, "error", err)
+			return
+		}
+		profile.Summoner = summoner
+	}()
+	go func() {
+		defer wg.Done()
+		entries, err := b.riotClient.GetLeagueEntriesByPUUID(ctx, puuid)
+		if err != nil {
+			slog.WarnContext(ctx, "Getting league entries failed", "puuid", puuid, "error", err)
+			return
+		}
+		for n := range entries {
+			switch entries[n].QueueType {
+			case "RANKED_SOLO_5x5":
+				profile.Solo = &entries[n]
+			case "RANKED_FLEX_SR":
+				profile.Flex = &entries[n]
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		masteries, err := b.riotClient.GetTopMasteries(ctx, puuid, profileMasteries)
+		if err != nil {
+			slog.WarnContext(ctx, "Getting masteries failed", "puuid", puuid, "error", err)
+			return
+		}
+		profile.Masteries = masteries
+		if score, err := b.riotClient.GetMasteryScore(ctx, puuid); err == nil {
+			profile.MasteryScore = score
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		matchIDs, err := b.riotClient.GetMatchIDs(ctx, puuid, 0, profileFormGames, 0)
+		if err != nil {
+			slog.WarnContext(ctx, "Getting recent matches failed", "puuid", puuid, "error", err)
+			return
+		}
+		profile.Form = summarizeForm(b.historyRecords(ctx, puuid, matchIDs))
+	}()
+
+	wg.Wait()
+	return profile
+}
+
+// summarizeForm builds the recent form line from records, newest first.
+func summarizeForm(records []storage.MatchRecord) recentForm {
+	var form recentForm
+	var results strings.Builder
+	played := make(map[string]int)
+	for _, r := range records {
+		if r.Win {
+			results.WriteString("W")
+			form.Wins++
+		} else {
+			results.WriteString("L")
+		}
+		form.Games++
+		form.Kills += r.Kills
+		form.Deaths += r.Deaths
+		form.Assists += r.Assists
+		played[r.Champion]++
+	}
+	form.Results = results.String()
+
+	for champion, games := range played {
+		form.Champions = append(form.Champions, championCount{Champion: champion, Games: games})
+	}
+	sort.Slice(form.Champions, func(a, c int) bool {
+		if form.Champions[a].Games != form.Champions[c].Games {
+			return form.Champions[a].Games > form.Champions[c].Games
+		}
+		return form.Champions[a].Champion < form.Champions[c].Champion
+	})
+	return form
+}
+
+// buildProfileEmbed creates the /profile embed.
+func (b *Bot) buildProfileEmbed(p *playerProfile) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("👤 HỒ SƠ - %s", p.RiotID),
+		Color:  embeds.ColorInfo,
+		Fields: make([]*discordgo.MessageEmbedField, 0, 4),
+	}
+	if p.Summoner != nil {
+		embed.Description = fmt.Sprintf("Cấp **%d**", p.Summoner.SummonerLevel)
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{
+			URL: fmt.Sprintf("https://ddragon.leagueoflegends.com/cdn/%s/img/profileicon/%d.png", b.gameData.DDragonVersion(), p.Summoner.ProfileIconID),
+		}
+	}
+
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{Name: "🏆 Đơn/Đôi", Value: formatLeagueEntry(p.Solo), Inline: true},
+		&discordgo.MessageEmbedField{Name: "👥 Linh hoạt", Value: formatLeagueEntry(p.Flex), Inline: true},
+	)
+
+	if len(p.Masteries) > 0 {
+		var sb strings.Builder
+		for n, m := range p.Masteries {
+			name := b.gameData.ChampionName(m.ChampionID)
+			if name == "" {
+				name = fmt.Sprintf("Tướng #%d", m.ChampionID)
+			}
+			fmt.Fprintf(&sb, "%s **%s** • Cấp %d • %s điểm\n", getLeaderboardMedal(n), name, m.ChampionLevel, formatPoints(m.ChampionPoints))
+		}
+		title := "⭐ Thông thạo"
+		if p.MasteryScore > 0 {
+			title = fmt.Sprintf("⭐ Thông thạo (tổng cấp %d)", p.MasteryScore)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: title, Value: sb.String()})
+	}
+
+	if f := p.Form; f.Games > 0 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "`%s`\n", f.Results)
+		fmt.Fprintf(&sb, "%dT - %dB (%.0f%%) • KDA **%.2f** (%.1f/%.1f/%.1f)\n", f.Wins, f.Games-f.Wins, float64(f.Wins)/float64(f.Games)*100,
+			f.KDA(), float64(f.Kills)/float64(f.Games), float64(f.Deaths)/float64(f.Games), float64(f.Assists)/float64(f.Games))
+		var champions []string
+		for n, c := range f.Champions {
+			if n == 3 {
+				break
+			}
+			champions = append(champions, fmt.Sprintf("%s (%d)", c.Champion, c.Games))
+		}
+		fmt.Fprintf(&sb, "Hay chơi: %s", strings.Join(champions, ", "))
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("📈 Phong độ %d trận gần nhất", f.Games),
+			Value: sb.String(),
+		})
+	}
+
+	return embed
+}
+
+// formatLeagueEntry formats one ranked queue for /profile.
+func formatLeagueEntry(e *riot.LeagueEntryDTO) string {
+	if e == nil {
+		return "Unranked"
+	}
+	games := e.Wins + e.Losses
+	winRate := 0.0
+	if games > 0 {
+		winRate = float64(e.Wins) / float64(games) * 100
+	}
+	streak := ""
+	if e.HotStreak {
+		streak = " 🔥"
+	}
+	return fmt.Sprintf("%s\n%dT - %dB (%.1f%%)%s", formatRank(e.Tier, e.Rank, e.LeaguePoints), e.Wins, e.Losses, winRate, streak)
+}
+
+// formatPoints shortens mastery points: 512340 -> "512.3K".
+func formatPoints(points int) string {
+	switch {
+	case points >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(points)/1_000_000)
+	case points >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(points)/1_000)
+	default:
+		return fmt.Sprintf("%d", points)
+	}
+}
@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/schedule"
+	"github.com/zoebot/internal/storage"
+)
+
+// recapDefault resets a recap setting to the configured one.
+const recapDefault = "default"
+
+// recapCommand defines /recap.
+func recapCommand() *discordgo.ApplicationCommand {
+	permissions, dmAllowed := guildManagerPermissions()
+	return &discordgo.ApplicationCommand{
+		Name:                     "recap",
+		Description:              "Cài đặt bản tổng kết định kỳ của kênh",
+		DefaultMemberPermissions: permissions,
+		DMPermission:             dmAllowed,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Xem lịch tổng kết của kênh này",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "schedule",
+				Description: "Đặt lịch tổng kết cho kênh này",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "cron",
+					Description: "Cron (phút giờ ngày tháng thứ, VD: 0 20 * * 0), off hoặc default",
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "timezone",
+				Description: "Đặt múi giờ của lịch tổng kết trong server",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "Múi giờ IANA (VD: Asia/Ho_Chi_Minh) hoặc default",
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "now",
+				Description: "Đăng tổng kết của kỳ vừa qua ngay bây giờ",
+			},
+		},
+	}
+}
+
+// handleRecap handles the /recap command.
+func (b *Bot) handleRecap(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	if !isGuildManager(i) {
+		respondEphemeral(s, i, embeds.Error("Bạn cần quyền **Quản lý máy chủ** để dùng lệnh này.", ""))
+		return errNotGuildManager
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, embeds.Error("Lệnh này chỉ dùng được trong server.", ""))
+		return fmt.Errorf("%w: /recap outside a guild", ErrInvalidInput)
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	value := ""
+	if len(sub.Options) > 0 {
+		value = strings.TrimSpace(sub.Options[0].StringValue())
+	}
+
+	switch sub.Name {
+	case "schedule", "timezone":
+		if strings.EqualFold(value, recapDefault) {
+			value = ""
+		}
+		var change func(g *storage.GuildSettings)
+		if sub.Name == "schedule" {
+			if strings.EqualFold(value, config.RecapOff) {
+				value = config.RecapOff
+			} else if value != "" {
+				if _, err := schedule.Parse(value); err != nil {
+					respondEphemeral(s, i, embeds.Error(fmt.Sprintf("Lịch `%s` không hợp lệ.", value),
+						"Dùng cron 5 trường: phút giờ ngày tháng thứ (VD: `0 20 * * 0` là 20:00 Chủ nhật), `@daily`, `@weekly`, `off` hoặc `default`."))
+					return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+				}
+			}
+			change = func(g *storage.GuildSettings) { g.SetRecapSchedule(i.ChannelID, value) }
+		} else {
+			if value != "" {
+				if _, err := time.LoadLocation(value); err != nil {
+					respondEphemeral(s, i, embeds.Error(fmt.Sprintf("Không có múi giờ `%s`.", value), "Dùng tên IANA, VD: `Asia/Ho_Chi_Minh`, `Asia/Tokyo`, `Europe/London`."))
+					return fmt.Errorf("%w: time zone %q", ErrInvalidInput, value)
+				}
+			}
+			change = func(g *storage.GuildSettings) { g.Timezone = value }
+		}
+		if _, err := b.guildSettings.Update(i.GuildID, change); err != nil {
+			respondEphemeral(s, i, embeds.Error("Không thể lưu cài đặt. Vui lòng thử lại sau.", ""))
+			return err
+		}
+
+	case "now":
+		return b.handleRecapNow(ctx, s, i)
+	}
+
+	rs, err := b.recapScheduleOf(i.ChannelID)
+	if err != nil {
+		respondEphemeral(s, i, embeds.Error("Không thể đọc lịch tổng kết. Vui lòng thử lại sau.", ""))
+		return err
+	}
+	return respondEphemeral(s, i, buildRecapScheduleEmbed(rs, time.Now()))
+}
+
+// handleRecapNow posts the channel's recap of the last schedule period,
+// ending now.
+func (b *Bot) handleRecapNow(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	rs, err := b.recapScheduleOf(i.ChannelID)
+	if err != nil {
+		editEmbed(s, i, embeds.Error("Không thể đọc lịch tổng kết. Vui lòng thử lại sau.", ""))
+		return err
+	}
+	now := time.Now().In(rs.Location)
+	period := 7 * 24 * time.Hour
+	if rs.Cron != nil {
+		if last := rs.Cron.Prev(now); !last.IsZero() {
+			if before := rs.Cron.Prev(last); !before.IsZero() {
+				period = last.Sub(before)
+			}
+		}
+	}
+
+	err = b.postRecap(ctx, i.ChannelID, now.Add(-period), now, rs.Location)
+	switch {
+	case errors.Is(err, errNoRecapGames):
+		editEmbed(s, i, embeds.Warning("Chưa có trận nào để tổng kết trong kỳ này.", "Bot chỉ tính các trận của người chơi đang được theo dõi trong kênh."))
+		return nil
+	case err != nil:
+		editEmbed(s, i, embeds.Error("Không thể đăng bản tổng kết. Vui lòng thử lại sau.", ""))
+		return err
+	}
+	editEmbed(s, i, embeds.Success("Đã đăng bản tổng kết.", ""))
+	return nil
+}
+
+// buildRecapScheduleEmbed describes a channel's recap schedule.
+func buildRecapScheduleEmbed(rs *recapSchedule, now time.Time) *discordgo.MessageEmbed {
+	var sb strings.Builder
+	source := "mặc định"
+	if rs.Custom {
+		source = "riêng của kênh"
+	}
+	if rs.Cron == nil {
+		fmt.Fprintf(&sb, "Lịch: **tắt** (%s)\n", source)
+	} else {
+		fmt.Fprintf(&sb, "Lịch: `%s` (%s)\n", rs.Spec, source)
+	}
+	fmt.Fprintf(&sb, "Múi giờ: **%s**\n", rs.Location)
+	if rs.Cron != nil {
+		if next := rs.Cron.Next(now.In(rs.Location)); !next.IsZero() {
+			fmt.Fprintf(&sb, "Lần tới: **%s** (<t:%d:R>)\n", next.Format("15:04 02/01/2006"), next.Unix())
+		}
+	}
+	sb.WriteString("\nDùng `/recap schedule` và `/recap timezone` để đổi, `/recap now` để đăng ngay.")
+	return &discordgo.MessageEmbed{
+		Title:       "📅 TỔNG KẾT ĐỊNH KỲ",
+		Description: sb.String(),
+		Color:       embeds.ColorInfo,
+	}
+}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/storage"
+)
+
+// errNotLinkedUser is returned when someone changes the tilt alerts of a
+// player linked to another Discord user.
+var errNotLinkedUser = fmt.Errorf("%w: player is linked to another user", ErrForbidden)
+
+// tiltCommand defines /tilt.
+func tiltCommand() *discordgo.ApplicationCommand {
+	riotID := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "riot_id",
+		Description: "Riot ID đang được theo dõi trong kênh (VD: Faker#KR1)",
+		Required:    true,
+	}
+	return &discordgo.ApplicationCommand{
+		Name:        "tilt",
+		Description: "Cài đặt nhắc nhở nghỉ ngơi khi đang tilt",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "link",
+				Description: "Gửi nhắc nhở của người chơi này qua tin nhắn riêng (cần quyền Quản lý máy chủ)",
+				Options: []*discordgo.ApplicationCommandOption{
+					riotID,
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Người nhận nhắc nhở (mặc định là bạn)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "unlink",
+				Description: "Gửi nhắc nhở của người chơi này vào kênh như trước",
+				Options:     []*discordgo.ApplicationCommandOption{riotID},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "alerts",
+				Description: "Bật hoặc tắt nhắc nhở cho người chơi này",
+				Options: []*discordgo.ApplicationCommandOption{
+					riotID,
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Bật hay tắt",
+						Required:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleTilt handles the /tilt command. Only guild managers link players
+// to a Discord user, so nobody gets another player's alerts by claiming
+// them; a linked player's settings belong to the linked user and guild
+// managers.
+func (b *Bot) handleTilt(ctx context.Context, s Session, i *discordgo.InteractionCreate) error {
+	sub := i.ApplicationCommandData().Options[0]
+	var riotID, linkUserID string
+	enabled := true
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "riot_id":
+			riotID = strings.TrimSpace(opt.StringValue())
+		case "user":
+			linkUserID = opt.UserValue(nil).ID
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	player := findChannelPlayer(b.trackedPlayers.GetByChannel(i.ChannelID), riotID)
+	if player == nil {
+		respondEphemeral(s, i, embeds.Error(fmt.Sprintf("**%s** không được theo dõi trong kênh này.", riotID), ""))
+		return fmt.Errorf("%w: player %s is not tracked in the channel", ErrNotFound, riotID)
+	}
+
+	userID := interactionUserID(i)
+	if sub.Name == "link" && !isGuildManager(i) {
+		respondEphemeral(s, i, embeds.Error("Bạn cần quyền **Quản lý máy chủ** để liên kết người chơi.",
+			"Nhờ quản lý server liên kết giúp bạn."))
+		return errNotGuildManager
+	}
+	if player.DiscordID != "" && player.DiscordID != userID && !isGuildManager(i) {
+		respondEphemeral(s, i, embeds.Error(fmt.Sprintf("**%s** đã được liên kết với <@%s>.", player.Name, player.DiscordID),
+			"Chỉ người đó hoặc quản lý server mới đổi được cài đặt này."))
+		return errNotLinkedUser
+	}
+
+	discordID, off := player.DiscordID, player.TiltOff
+	switch sub.Name {
+	case "link":
+		discordID = userID
+		if linkUserID != "" {
+			discordID = linkUserID
+		}
+	case "unlink":
+		discordID = ""
+	case "alerts":
+		off = !enabled
+	}
+	if err := b.trackedPlayers.UpdateTilt(player.PUUID, discordID, off); err != nil {
+		respondEphemeral(s, i, embeds.Error("Không thể lưu cài đặt. Vui lòng thử lại sau.", ""))
+		return err
+	}
+
+	updated := *player
+	updated.DiscordID, updated.TiltOff = discordID, off
+	return respondEphemeral(s, i, buildTiltSettingsEmbed(&updated, b.config().Tilt.Cooldown.Hours()))
+}
+
+// findChannelPlayer returns the player of players named riotID, ignoring
+// case, or nil.
+func findChannelPlayer(players []*storage.TrackedPlayer, riotID string) *storage.TrackedPlayer {
+	for _, p := range players {
+		if strings.EqualFold(p.Name, riotID) {
+			return p
+		}
+	}
+	return nil
+}
+
+// buildTiltSettingsEmbed describes a player's tilt alert settings.
+func buildTiltSettingsEmbed(player *storage.TrackedPlayer, cooldownHours float64) *discordgo.MessageEmbed {
+	var sb strings.Builder
+	if player.TiltOff {
+		sb.WriteString("Nhắc nhở: **tắt**\n")
+	} else {
+		sb.WriteString("Nhắc nhở: **bật**\n")
+	}
+	if player.DiscordID != "" {
+		fmt.Fprintf(&sb, "Gửi tới: tin nhắn riêng của <@%s>\n", player.DiscordID)
+	} else {
+		sb.WriteString("Gửi tới: kênh đang theo dõi\n")
+	}
+	fmt.Fprintf(&sb, "\nZoe nhắc khi thua %d trận liền, chết hoặc điểm tệ dần qua %d trận, hay chơi sau nửa đêm; tối đa một lần mỗi **%.0f giờ**.",
+		tiltLossStreak, tiltTrendGames, cooldownHours)
+	return &discordgo.MessageEmbed{
+		Title:       "🌱 NHẮC NHỞ TILT - " + player.Name,
+		Description: sb.String(),
+		Color:       embeds.ColorInfo,
+	}
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zoebot/pkg/healthcheck"
+)
+
+// RegisterHealthChecks wires the bot's dependencies into the healthcheck server.
+func (b *Bot) RegisterHealthChecks(srv *healthcheck.Server) {
+	srv.AddCheck("discord", true, b.checkGateway)
+	srv.AddCheck("storage", true, b.checkStorage)
+	srv.AddCheck("riot", true, b.checkRiot)
+	srv.AddCheck("ai", false, b.checkAI)
+	srv.AddCheck("poll", true, b.checkPoll)
+}
+
+// checkGateway reports the Discord gateway connection and heartbeat latency.
+func (b *Bot) checkGateway() healthcheck.Result {
+	connected := b.connected.Load()
+	res := healthcheck.Result{
+		OK: connected,
+		Details: map[string]interface{}{
+			"connected":  connected,
+			"latency_ms": b.session.HeartbeatLatency().Milliseconds(),
+		},
+	}
+	if !connected {
+		res.Message = "gateway disconnected"
+	}
+	return res
+}
+
+// checkStorage reports whether the storage backend is usable. Running
+// memory-only is fine when chosen, but not when Redis silently fell back.
+func (b *Bot) checkStorage() healthcheck.Result {
+	details := map[string]interface{}{"backend": b.store.Name()}
+	if err := b.store.Ping(); err != nil {
+		return healthcheck.Result{OK: false, Message: err.Error(), Details: details}
+	}
+	if b.store.Name() == "memory" {
+		return healthcheck.Result{OK: true, Message: "memory only", Details: details}
+	}
+	return healthcheck.Result{OK: true, Details: details}
+}
+
+// checkRiot reports the last Riot API call result. An auth failure means
+// the API key expired, which the bot can't recover from on its own.
+func (b *Bot) checkRiot() healthcheck.Result {
+	stats := b.riotClient.CallStats()
+	res := healthcheck.Result{OK: true, Details: stats.Details()}
+	if isAuthFailure(stats.LastStatus) {
+		res.OK = false
+		res.Message = "API key rejected"
+	}
+	return res
+}
+
+// checkAI reports the last AI API call result.
+func (b *Bot) checkAI() healthcheck.Result {
+	stats := b.aiClient.CallStats()
+	res := healthcheck.Result{OK: true, Details: stats.Details()}
+	if isAuthFailure(stats.LastStatus) {
+		res.OK = false
+		res.Message = "API key rejected"
+	}
+	return res
+}
+
+// checkPoll reports how far behind the match poll loop is, along with
+// the number of tracked players.
+func (b *Bot) checkPoll() healthcheck.Result {
+	details := map[string]interface{}{
+		"tracked_players": b.trackedPlayers.Count(),
+	}
+
+	last := b.lastPollAt.Load()
+	if last == 0 {
+		return healthcheck.Result{OK: true, Message: "not started", Details: details}
+	}
+
+	lag := time.Since(time.Unix(0, last))
+	details["last_poll_at"] = time.Unix(0, last).Format(time.RFC3339)
+	details["last_poll_duration_ms"] = time.Duration(b.lastPollTook.Load()).Milliseconds()
+	details["lag_seconds"] = int64(lag.Seconds())
+
+	// A poll runs every interval and is capped just under it, so being
+	// more than a few intervals behind means the loop is stuck.
+	if lag > 3*b.config().Poll.Interval {
+		return healthcheck.Result{OK: false, Message: "poll loop stalled", Details: details}
+	}
+	return healthcheck.Result{OK: true, Details: details}
+}
+
+// isAuthFailure reports whether an HTTP status means the credentials were rejected.
+func isAuthFailure(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
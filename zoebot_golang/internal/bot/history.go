@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/storage"
+)
+
+// recordMatch stores the match in the history for the player it was
+// analyzed for and for every tracked player in it, on either team. result
+// may be nil when the analysis failed; the stats are recorded unscored.
+func (b *Bot) recordMatch(ctx context.Context, puuid string, match *riot.MatchResponse, result *ai.AnalysisResult) {
+	scores := make(map[string]ai.PlayerAnalysis) // champion -> analysis
+	source := ""
+	if result != nil {
+		for _, p := range result.Players {
+			scores[p.Champion] = p
+			source = "ai"
+			if p.Comment == ai.RulesComment {
+				source = "rules"
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range match.Info.Participants {
+		if p.PUUID == "" || seen[p.PUUID] {
+			continue
+		}
+		seen[p.PUUID] = true
+
+		tracked, isTracked := b.trackedPlayers.Get(p.PUUID)
+		if p.PUUID != puuid && !isTracked {
+			continue
+		}
+
+		record := participantRecord(match, p.PUUID)
+		if isTracked {
+			record.RiotID = tracked.Name
+			record.ChannelID = tracked.ChannelID
+			record.GuildID = b.guildOf(tracked.ChannelID)
+		}
+		if analysis, ok := scores[p.ChampionName]; ok {
+			record.Score = analysis.Score
+			record.ScoreSource = source
+		}
+
+		if err := b.history.Add(record); err != nil {
+			slog.WarnContext(ctx, "Recording match failed", "match_id", match.Metadata.MatchID, "puuid", p.PUUID, "error", err)
+		}
+	}
+}
+
+// participantRecord builds the history record of one player straight from
+// the match details. It returns nil if the player isn't in the match.
+func participantRecord(match *riot.MatchResponse, puuid string) *storage.MatchRecord {
+	for _, p := range match.Info.Participants {
+		if p.PUUID != puuid {
+			continue
+		}
+		playedAt := time.Now().UTC()
+		if match.Info.GameEndTimestamp > 0 {
+			playedAt = time.UnixMilli(match.Info.GameEndTimestamp).UTC()
+		}
+		return &storage.MatchRecord{
+			MatchID:           match.Metadata.MatchID,
+			PUUID:             p.PUUID,
+			RiotID:            p.RiotIDGameName,
+			QueueID:           match.Info.QueueID,
+			GameMode:          match.Info.GameMode,
+			Patch:             riot.Patch(match.Info.GameVersion),
+			PlayedAt:          playedAt,
+			Duration:          time.Duration(match.Info.GameDuration) * time.Second,
+			Win:               p.Win,
+			Champion:          p.ChampionName,
+			Position:          p.TeamPosition,
+			Kills:             p.Kills,
+			Deaths:            p.Deaths,
+			Assists:           p.Assists,
+			CS:                p.TotalMinionsKilled + p.NeutralMinionsKilled,
+			Gold:              p.GoldEarned,
+			Damage:            p.TotalDamageDealtToChampions,
+			DamageShare:       math.Round(p.Challenges.TeamDamagePercentage*1000) / 10,
+			KillParticipation: math.Round(p.Challenges.KillParticipation*1000) / 10,
+			VisionScore:       p.VisionScore,
+		}
+	}
+	return nil
+}
+
+// guildOf returns the guild of a channel, or "" for DMs and unknown
+// channels. Lookups are cached; channels don't move between guilds.
+func (b *Bot) guildOf(channelID string) string {
+	if guildID, ok := b.guilds.Load(channelID); ok {
+		return guildID.(string)
+	}
+	channel, err := b.session.Channel(channelID)
+	if err != nil {
+		return ""
+	}
+	b.guilds.Store(channelID, channel.GuildID)
+	return channel.GuildID
+}
+
+// MatchHistory returns a player's recorded matches, newest first.
+func (b *Bot) MatchHistory(puuid string, q storage.HistoryQuery) ([]storage.MatchRecord, error) {
+	return b.history.ByPlayer(puuid, q)
+}
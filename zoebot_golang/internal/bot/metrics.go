@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/zoebot/pkg/metrics"
+)
+
+var (
+	commandsTotal = metrics.NewCounterVec(
+		"zoebot_commands_total",
+		"Slash command and component invocations by name and outcome.",
+		"command", "outcome",
+	)
+	pollDuration = metrics.NewHistogramVec(
+		"zoebot_poll_duration_seconds",
+		"Duration of a full match poll over all tracked players.",
+		[]float64{1, 5, 10, 20, 30, 45, 55, 60},
+	)
+	playersChecked = metrics.NewCounterVec(
+		"zoebot_poll_players_checked_total",
+		"Tracked players checked for new matches.",
+	)
+	notificationsSent = metrics.NewCounterVec(
+		"zoebot_notifications_sent_total",
+		"New match notifications posted to channels.",
+	)
+)
+
+// Errors returned by handlers and Bot operations to classify the outcome.
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// outcomeOf maps a handler result to a metric label.
+func outcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrInvalidInput):
+		return "invalid"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	default:
+		return "error"
+	}
+}
+
+// runCommand runs a handler, recovering panics, and records its outcome.
+func (b *Bot) runCommand(ctx context.Context, name string, handler func() error) {
+	start := time.Now()
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		outcome := outcomeOf(err)
+		commandsTotal.Inc(name, outcome)
+
+		attrs := []any{"command", name, "outcome", outcome, "took", time.Since(start)}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		if outcome == "error" {
+			slog.ErrorContext(ctx, "Command failed", attrs...)
+		} else {
+			slog.InfoContext(ctx, "Command handled", attrs...)
+		}
+	}()
+	err = handler()
+}
+
+// componentName derives a stable metric name from a component custom ID
+// (e.g. "detail_VN2_123_puuid" -> "component:detail").
+func componentName(customID string) string {
+	prefix, _, _ := strings.Cut(customID, "_")
+	return "component:" + prefix
+}
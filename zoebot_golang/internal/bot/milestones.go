@@ -0,0 +1,201 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/storage"
+)
+
+const (
+	placementGames = 5 // ranked games before a player gets a rank
+	streakLength   = 5 // games in a row that make a streak worth announcing
+)
+
+// Placement results at or above strongPlacement are respected, those below
+// weakPlacement mocked.
+var (
+	strongPlacement = (&storage.RankSnapshot{Tier: "DIAMOND", Rank: "IV"}).Division()
+	weakPlacement   = (&storage.RankSnapshot{Tier: "GOLD", Rank: "IV"}).Division()
+)
+
+// milestone is one rank milestone a game led to, with Zoe's comment on it.
+type milestone struct {
+	Kind string // storage.Milestone*
+	Line string
+	Good bool // worth celebrating rather than mocking
+}
+
+// rankMilestones returns the milestones a fresh snapshot reached. earlier
+// are the player's previous snapshots in the same queue, newest first.
+func rankMilestones(name string, change *storage.RankChange, earlier []storage.RankSnapshot) []milestone {
+	after := change.After
+	season := seasonSnapshots(after, earlier)
+	rank := formatRank(after.Tier, after.Rank, after.LP)
+
+	var found []milestone
+	if len(season) == 0 {
+		if after.Games() <= placementGames {
+			found = append(found, placementsMilestone(name, after, rank))
+		}
+		return found
+	}
+
+	// Compare with the last snapshot of this season only; a reset isn't a
+	// demotion
+	inSeason := storage.RankChange{Before: &season[0], After: after}
+	tier := strings.Title(strings.ToLower(after.Tier))
+	switch {
+	case inSeason.NewTier():
+		found = append(found, milestone{storage.MilestonePromotion, fmt.Sprintf("🎉 **%s** lên **%s** rồi kìa! Leo được tới đây thì ta công nhận… một chút xíu thôi ✨", name, tier), true})
+	case inSeason.Promoted():
+		found = append(found, milestone{storage.MilestonePromotion, fmt.Sprintf("⬆️ **%s** thăng lên **%s**. Tiến bộ đấy, nhưng đừng vội vênh mặt nha~", name, rank), true})
+	case inSeason.Demoted() && after.Tier != season[0].Tier:
+		found = append(found, milestone{storage.MilestonePromotion, fmt.Sprintf("💀 **%s** rớt khỏi %s, giờ ở **%s**. Ta xem mà muốn độn thổ giùm luôn á!", name, strings.Title(strings.ToLower(season[0].Tier)), rank), false})
+	case inSeason.Demoted():
+		found = append(found, milestone{storage.MilestonePromotion, fmt.Sprintf("⬇️ **%s** tụt xuống **%s**. Tay run hay não lag vậy cưng? 🙄", name, rank), false})
+	}
+
+	peak := 0
+	for _, s := range season {
+		peak = max(peak, s.Division())
+	}
+	if after.Division() > peak {
+		found = append(found, milestone{storage.MilestonePeak, fmt.Sprintf("🏔️ **%s** chạm đỉnh mới của mùa này: **%s**! Chụp màn hình lại đi, chắc không giữ được lâu đâu 😏", name, rank), true})
+	}
+
+	if line, good, ok := streakMilestone(name, after, season); ok {
+		found = append(found, milestone{storage.MilestoneStreak, line, good})
+	}
+	return found
+}
+
+// seasonSnapshots returns the leading part of earlier (newest first) from
+// the same season as after. Wins and losses only grow within a season, so
+// a snapshot with more games than the one after it came before a reset.
+func seasonSnapshots(after *storage.RankSnapshot, earlier []storage.RankSnapshot) []storage.RankSnapshot {
+	games := after.Games()
+	for n, s := range earlier {
+		if s.Games() >= games {
+			return earlier[:n]
+		}
+		games = s.Games()
+	}
+	return earlier
+}
+
+// placementsMilestone comments on a player's rank after placements.
+func placementsMilestone(name string, after *storage.RankSnapshot, rank string) milestone {
+	line := fmt.Sprintf("📋 **%s** xong phân hạng: **%s**. ", name, rank)
+	switch {
+	case after.Division() >= strongPlacement:
+		return milestone{storage.MilestonePlacements, line + "Được đấy, ta tôn trọng kẻ mạnh 🙇", true}
+	case after.Division() < weakPlacement:
+		return milestone{storage.MilestonePlacements, line + "Đánh phân hạng xong mà ra nông nỗi này hả? 🤡", false}
+	default:
+		return milestone{storage.MilestonePlacements, line + "Cũng tạm chấp nhận được~", true}
+	}
+}
+
+// streakMilestone reports a streak reaching streakLength games or a streak
+// at least that long ending with this game. Results come from the wins
+// between snapshots; a gap of more than one game ends the known run.
+func streakMilestone(name string, after *storage.RankSnapshot, season []storage.RankSnapshot) (string, bool, bool) {
+	var results []bool // newest first; true for a win
+	newer := after
+	for n := range season {
+		older := &season[n]
+		if newer.Games()-older.Games() != 1 {
+			break
+		}
+		results = append(results, newer.Wins > older.Wins)
+		newer = older
+	}
+	if len(results) == 0 {
+		return "", false, false
+	}
+
+	run := func(from int) int {
+		n := from
+		for n < len(results) && results[n] == results[from] {
+			n++
+		}
+		return n - from
+	}
+
+	won := results[0]
+	if current := run(0); current == streakLength {
+		if won {
+			return fmt.Sprintf("🔥 **%s** thắng **%d trận liên tiếp**! Gánh team hay được team gánh đây?", name, current), true, true
+		}
+		return fmt.Sprintf("🧊 **%s** thua **%d trận liên tiếp**. Tắt máy đi ngủ đi, ta năn nỉ đấy 😭", name, current), false, true
+	}
+	if len(results) > 1 && results[1] != won {
+		if ended := run(1); ended >= streakLength {
+			if won {
+				return fmt.Sprintf("🌤️ **%s** cuối cùng cũng thắng sau **%d trận thua**. Mặt trời mọc đằng tây rồi à?", name, ended), true, true
+			}
+			return fmt.Sprintf("💔 Chuỗi **%d trận thắng** của **%s** đứt rồi. Bay cao thì té đau thôi~", ended, name), false, true
+		}
+	}
+	return "", false, false
+}
+
+// announceMilestones posts the milestones of a fresh snapshot to the
+// player's channel, leaving out the kinds the guild turned off.
+func (b *Bot) announceMilestones(ctx context.Context, player *storage.TrackedPlayer, change *storage.RankChange) {
+	earlier, err := b.history.Ranks(change.After.PUUID, change.After.QueueType, time.Time{}, time.Time{})
+	if err != nil {
+		slog.WarnContext(ctx, "Reading rank snapshots failed", "player", player.Name, "error", err)
+		return
+	}
+	for n := range earlier {
+		if earlier[n].MatchID == change.After.MatchID {
+			earlier = append(earlier[:n:n], earlier[n+1:]...)
+			break
+		}
+	}
+
+	found := rankMilestones(player.Name, change, earlier)
+	if len(found) == 0 {
+		return
+	}
+	settings := &storage.GuildSettings{}
+	if guildID := b.guildOf(player.ChannelID); guildID != "" {
+		if settings, err = b.guildSettings.Get(guildID); err != nil {
+			slog.WarnContext(ctx, "Reading guild settings failed", "guild_id", guildID, "error", err)
+			return
+		}
+	}
+
+	var lines []string
+	color := embeds.ColorLose
+	for _, m := range found {
+		if !settings.MilestoneEnabled(m.Kind) {
+			continue
+		}
+		if len(lines) == 0 && m.Good {
+			color = embeds.ColorWin
+		}
+		lines = append(lines, m.Line)
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎖️ CỘT MỐC RANK",
+		Description: strings.Join(lines, "\n"),
+		Color:       color,
+	}
+	if _, err := b.session.ChannelMessageSendEmbed(player.ChannelID, embed); err != nil {
+		slog.WarnContext(ctx, "Sending milestones failed", "player", player.Name, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Announced rank milestones", "player", player.Name, "match_id", change.After.MatchID, "count", len(lines))
+}
@@ -0,0 +1,427 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/logging"
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/storage"
+)
+
+// Operations shared by slash commands and the admin API. They carry no
+// Discord interaction state, so every entry point runs the same code.
+
+// ErrAlreadyTracked is returned when a player is already tracked in the channel.
+var ErrAlreadyTracked = errors.New("already tracked")
+
+// errMatchUnavailable is returned when Riot has no details for a match.
+var errMatchUnavailable = errors.New("match details unavailable")
+
+// parseRiotID splits "Name#Tag" into its parts.
+func parseRiotID(riotID string) (string, string, error) {
+	gameName, tagLine, ok := strings.Cut(riotID, "#")
+	if !ok || gameName == "" || tagLine == "" {
+		return "", "", fmt.Errorf("%w: riot id %q", ErrInvalidInput, riotID)
+	}
+	return gameName, tagLine, nil
+}
+
+// Subscriptions returns every tracked player, sorted by name.
+func (b *Bot) Subscriptions() []storage.TrackedPlayer {
+	all := b.trackedPlayers.GetAll()
+	players := make([]storage.TrackedPlayer, 0, len(all))
+	for _, p := range all {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	return players
+}
+
+// TrackPlayer starts tracking riotID in channelID. The latest match is
+// recorded so only games played after this point are announced.
+func (b *Bot) TrackPlayer(ctx context.Context, riotID, channelID string) (*storage.TrackedPlayer, error) {
+	gameName, tagLine, err := parseRiotID(riotID)
+	if err != nil {
+		return nil, err
+	}
+
+	puuid, err := b.riotClient.GetPUUIDByRiotID(ctx, gameName, tagLine)
+	if err != nil || puuid == "" {
+		return nil, fmt.Errorf("%w: player %s: %v", ErrNotFound, riotID, err)
+	}
+
+	existing, ok := b.trackedPlayers.Get(puuid)
+	if ok && existing.ChannelID == channelID {
+		return existing, ErrAlreadyTracked
+	}
+
+	// Get latest match to initialize
+	matches, _ := b.riotClient.GetMatchIDsByPUUID(ctx, puuid, 1)
+	var lastMatchID string
+	if len(matches) > 0 {
+		lastMatchID = matches[0]
+	}
+
+	player := &storage.TrackedPlayer{
+		PUUID:       puuid,
+		LastMatchID: lastMatchID,
+		ChannelID:   channelID,
+		Name:        riotID,
+	}
+	if ok {
+		// Moving to another channel keeps the player's tilt alert settings
+		player.DiscordID, player.TiltOff = existing.DiscordID, existing.TiltOff
+	}
+	if err := b.trackedPlayers.Set(puuid, player); err != nil {
+		slog.WarnContext(ctx, "Saving tracked player failed", "riot_id", riotID, "error", err)
+	}
+
+	slog.InfoContext(ctx, "Tracked player", "riot_id", riotID, "channel_id", channelID)
+	return player, nil
+}
+
+// maxImportPlayers caps how many players one import can track, since each
+// one costs Riot API calls.
+const maxImportPlayers = 50
+
+// ImportResult sorts the Riot IDs of an import by outcome.
+type ImportResult struct {
+	Added          []string
+	AlreadyTracked []string
+	Failed         map[string]error // riot ID -> reason
+}
+
+// ImportPlayers tracks every riotID in channelID, skipping duplicates.
+// Failures of single players don't stop the import.
+func (b *Bot) ImportPlayers(ctx context.Context, riotIDs []string, channelID string) (*ImportResult, error) {
+	if len(riotIDs) > maxImportPlayers {
+		return nil, fmt.Errorf("%w: %d players, at most %d per import", ErrInvalidInput, len(riotIDs), maxImportPlayers)
+	}
+
+	result := &ImportResult{Failed: make(map[string]error)}
+	seen := make(map[string]bool, len(riotIDs))
+	for _, riotID := range riotIDs {
+		riotID = strings.TrimSpace(riotID)
+		if riotID == "" || seen[strings.ToLower(riotID)] {
+			continue
+		}
+		seen[strings.ToLower(riotID)] = true
+
+		_, err := b.TrackPlayer(ctx, riotID, channelID)
+		switch {
+		case err == nil:
+			result.Added = append(result.Added, riotID)
+		case errors.Is(err, ErrAlreadyTracked):
+			result.AlreadyTracked = append(result.AlreadyTracked, riotID)
+		default:
+			result.Failed[riotID] = err
+		}
+	}
+	slog.InfoContext(ctx, "Imported players", "channel_id", channelID,
+		"added", len(result.Added), "already_tracked", len(result.AlreadyTracked), "failed", len(result.Failed))
+	return result, nil
+}
+
+// UntrackPlayer stops tracking riotID, wherever it is tracked.
+func (b *Bot) UntrackPlayer(ctx context.Context, riotID string) (*storage.TrackedPlayer, error) {
+	gameName, tagLine, err := parseRiotID(riotID)
+	if err != nil {
+		return nil, err
+	}
+
+	puuid, err := b.riotClient.GetPUUIDByRiotID(ctx, gameName, tagLine)
+	if err != nil || puuid == "" {
+		return nil, fmt.Errorf("%w: player %s: %v", ErrNotFound, riotID, err)
+	}
+	return b.Unsubscribe(ctx, puuid)
+}
+
+// Unsubscribe stops tracking the player with the given PUUID.
+func (b *Bot) Unsubscribe(ctx context.Context, puuid string) (*storage.TrackedPlayer, error) {
+	player, ok := b.trackedPlayers.Get(puuid)
+	if !ok {
+		return nil, fmt.Errorf("%w: player is not tracked", ErrNotFound)
+	}
+
+	if err := b.trackedPlayers.Delete(puuid); err != nil {
+		slog.WarnContext(ctx, "Deleting tracked player failed", "riot_id", player.Name, "error", err)
+	}
+
+	slog.InfoContext(ctx, "Untracked player", "riot_id", player.Name)
+	return player, nil
+}
+
+// MoveSubscription changes the notification channel of a tracked player.
+func (b *Bot) MoveSubscription(ctx context.Context, puuid, channelID string) (*storage.TrackedPlayer, error) {
+	if _, ok := b.trackedPlayers.Get(puuid); !ok {
+		return nil, fmt.Errorf("%w: player is not tracked", ErrNotFound)
+	}
+	if _, err := b.session.Channel(channelID); err != nil {
+		return nil, fmt.Errorf("%w: channel %s: %v", ErrInvalidInput, channelID, err)
+	}
+
+	player, err := b.trackedPlayers.UpdateChannel(puuid, channelID)
+	if player == nil {
+		return nil, fmt.Errorf("%w: player is not tracked", ErrNotFound)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Saving tracked player failed", "riot_id", player.Name, "error", err)
+	}
+
+	slog.InfoContext(ctx, "Moved subscription", "riot_id", player.Name, "channel_id", channelID)
+	return player, nil
+}
+
+// ForcePoll checks a tracked player for a new match right away, outside
+// the poll schedule. It reports whether a new match was found.
+func (b *Bot) ForcePoll(ctx context.Context, puuid string) (bool, error) {
+	// checkPlayerMatch updates its player; like the poll loop, give it a
+	// copy taken under the store's lock and let the store update its own
+	player, ok := b.trackedPlayers.GetAll()[puuid]
+	if !ok {
+		return false, fmt.Errorf("%w: player is not tracked", ErrNotFound)
+	}
+	return b.checkPlayerMatch(ctx, puuid, &player), nil
+}
+
+// AnalyzeMatch fetches, parses and analyzes a match from the point of view
+// of puuid, caches the result for the detail buttons and records the match
+// in the history, unscored if the analysis fails. With an empty
+// puuid, the first tracked player in the match (or else the first
+// participant) is used.
+func (b *Bot) AnalyzeMatch(ctx context.Context, matchID, puuid string) (*ai.AnalysisResult, *riot.ParsedMatchData, error) {
+	matchDetails, err := b.riotClient.GetMatchDetails(ctx, matchID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %v", errMatchUnavailable, matchID, err)
+	}
+
+	if puuid == "" {
+		puuid = b.pickPerspective(matchDetails)
+	}
+
+	timeline, _ := b.riotClient.GetMatchTimeline(ctx, matchID)
+	matchData := b.riotClient.ParseMatchData(matchDetails, puuid, timeline)
+	if matchData == nil {
+		return nil, nil, fmt.Errorf("%w: player not in match %s", ErrNotFound, matchID)
+	}
+
+	result, err := b.aiClient.AnalyzeMatch(ctx, matchData)
+	if err != nil {
+		b.recordMatch(ctx, puuid, matchDetails, nil)
+		return nil, matchData, fmt.Errorf("analyze match %s: %w", matchID, err)
+	}
+
+	b.cacheAnalysis(matchID, result.Players, matchData)
+	b.recordMatch(ctx, puuid, matchDetails, result)
+	return result, matchData, nil
+}
+
+// pickPerspective chooses whose point of view to analyze a match from.
+func (b *Bot) pickPerspective(match *riot.MatchResponse) string {
+	for _, p := range match.Info.Participants {
+		if _, ok := b.trackedPlayers.Get(p.PUUID); ok {
+			return p.PUUID
+		}
+	}
+	if len(match.Info.Participants) > 0 {
+		return match.Info.Participants[0].PUUID
+	}
+	return ""
+}
+
+// FlushCache deletes a cache family, or a single key of it.
+func (b *Bot) FlushCache(ctx context.Context, family, key string) (int, error) {
+	prefix, ok := storage.CacheFamilies[family]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown cache family %q", ErrInvalidInput, family)
+	}
+	if key != "" && !strings.HasPrefix(key, prefix) {
+		return 0, fmt.Errorf("%w: key %q is not in cache family %q", ErrInvalidInput, key, family)
+	}
+
+	n, err := b.store.FlushCache(family, key)
+	if err != nil {
+		return n, err
+	}
+	slog.InfoContext(ctx, "Flushed cache", "family", family, "key", key, "deleted", n)
+	return n, nil
+}
+
+// NotificationChannels returns the distinct channels players are tracked in.
+func (b *Bot) NotificationChannels() []string {
+	seen := make(map[string]bool)
+	var channels []string
+	for _, p := range b.trackedPlayers.GetAll() {
+		if p.ChannelID != "" && !seen[p.ChannelID] {
+			seen[p.ChannelID] = true
+			channels = append(channels, p.ChannelID)
+		}
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// BroadcastResult reports where a broadcast was delivered.
+type BroadcastResult struct {
+	Sent   int      `json:"sent"`
+	Failed []string `json:"failed,omitempty"` // channel IDs
+}
+
+// Broadcast posts a notice to every notification channel.
+func (b *Bot) Broadcast(ctx context.Context, message string) (BroadcastResult, error) {
+	var res BroadcastResult
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return res, fmt.Errorf("%w: empty message", ErrInvalidInput)
+	}
+
+	embed := embeds.Warning(message, "📢 Thông báo từ ZoeBot")
+	for _, channelID := range b.NotificationChannels() {
+		if _, err := b.session.ChannelMessageSendEmbed(channelID, embed); err != nil {
+			slog.WarnContext(ctx, "Broadcast failed", "channel_id", channelID, "error", err)
+			res.Failed = append(res.Failed, channelID)
+			continue
+		}
+		res.Sent++
+	}
+
+	slog.InfoContext(ctx, "Broadcast sent", "sent", res.Sent, "failed", len(res.Failed))
+	return res, nil
+}
+
+// QueueState is a snapshot of the poll loop and in-memory queues.
+type QueueState struct {
+	TrackedPlayers  int       `json:"tracked_players"`
+	Polling         bool      `json:"polling"`
+	InFlight        int64     `json:"in_flight"`
+	LastPollAt      time.Time `json:"last_poll_at,omitempty"`
+	LastPollTook    string    `json:"last_poll_took,omitempty"`
+	NextPollAt      time.Time `json:"next_poll_at,omitempty"`
+	AnalyzedMatches int       `json:"analyzed_matches"`
+}
+
+// QueueState reports the poll loop state and in-memory queue sizes.
+func (b *Bot) QueueState() QueueState {
+	state := QueueState{
+		TrackedPlayers: b.trackedPlayers.Count(),
+		Polling:        b.polling.Load(),
+		InFlight:       b.pollInFlight.Load(),
+	}
+	if last := b.lastPollAt.Load(); last != 0 {
+		state.LastPollAt = time.Unix(0, last)
+		state.NextPollAt = state.LastPollAt.Add(b.config().Poll.Interval)
+		state.LastPollTook = time.Duration(b.lastPollTook.Load()).String()
+	}
+
+	b.analyzesMu.RLock()
+	state.AnalyzedMatches = len(b.analyzedMatches)
+	b.analyzesMu.RUnlock()
+
+	return state
+}
+
+// ReloadConfig re-reads the config file and environment and applies the
+// settings that are safe to change without reconnecting. An invalid
+// configuration is rejected as a whole.
+func (b *Bot) ReloadConfig(ctx context.Context) (*config.ReloadResult, error) {
+	next, res, err := b.config().Reload()
+	if err != nil {
+		slog.WarnContext(ctx, "Config reload rejected", "error", err)
+		return nil, err
+	}
+
+	b.cfg.Store(next)
+	b.aiClient.Apply(next.AI)
+	b.store.SetCacheTTLs(next.Cache.TTLs())
+	b.history.SetLimits(next.History)
+	if err := logging.SetLevel(next.Logging.Level); err != nil {
+		slog.WarnContext(ctx, "Applying log level failed", "error", err)
+	}
+
+	slog.InfoContext(ctx, "Config reloaded", "applied", res.Applied, "needs_restart", res.Restart)
+	return res, nil
+}
+
+// DataStatus lists the loaded game data and every synced version.
+type DataStatus struct {
+	data.StoreStatus
+	Synced []string `json:"synced"`
+}
+
+// Datasets reports the loaded game data and the synced versions on disk.
+func (b *Bot) Datasets() (DataStatus, error) {
+	synced, err := data.Versions(b.config().Data.Dir)
+	return DataStatus{StoreStatus: b.gameData.Status(), Synced: synced}, err
+}
+
+// ActivateDataset switches to a synced dataset version at runtime and
+// records it as current so it survives a restart. If any file fails to
+// load the previous data stays active.
+func (b *Bot) ActivateDataset(ctx context.Context, version string) (data.Summary, error) {
+	root := b.config().Data.Dir
+	ds, err := data.OpenDataset(root, version)
+	switch {
+	case errors.Is(err, data.ErrInvalidVersion):
+		return data.Summary{}, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	case errors.Is(err, os.ErrNotExist):
+		return data.Summary{}, fmt.Errorf("%w: version %s is not synced", ErrNotFound, version)
+	case err != nil:
+		return data.Summary{}, err
+	}
+
+	summary, err := b.gameData.Load(ds)
+	if err != nil {
+		slog.WarnContext(ctx, "Dataset activation failed, keeping the current one", "version", version, "error", err)
+		return summary, err
+	}
+	if err := data.SetCurrent(root, ds.Version); err != nil {
+		// Still active until restart (e.g. on a read-only filesystem)
+		slog.WarnContext(ctx, "Could not record the active dataset", "version", ds.Version, "error", err)
+	}
+	slog.InfoContext(ctx, "Activated game data", "version", ds.Version, "dir", ds.Dir)
+	return summary, nil
+}
+
+// ReloadReport describes what a static data reload loaded.
+type ReloadReport struct {
+	Data    data.Summary `json:"data"`
+	Prompts []string     `json:"prompt_overrides"`
+}
+
+// ReloadStaticData re-reads the active dataset and AI prompt overrides.
+// Anything that fails to load keeps its previous value.
+func (b *Bot) ReloadStaticData(ctx context.Context) (ReloadReport, error) {
+	var report ReloadReport
+	var errs []error
+
+	summary, err := b.gameData.Reload()
+	report.Data = summary
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	prompts, err := ai.LoadPrompts(b.config().Data.PromptsDir)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("prompts: %w", err))
+	}
+	report.Prompts = prompts
+
+	err = errors.Join(errs...)
+	if err != nil {
+		slog.WarnContext(ctx, "Static data reloaded with errors", "error", err)
+	} else {
+		slog.InfoContext(ctx, "Static data reloaded", "version", summary.Version, "prompts", prompts)
+	}
+	return report, err
+}
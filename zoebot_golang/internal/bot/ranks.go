@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zoebot/internal/storage"
+)
+
+// rankQueues maps ranked queue IDs to their league-v4 queue types.
+var rankQueues = map[int]string{
+	420: storage.QueueSolo,
+	440: storage.QueueFlex,
+}
+
+// rankResult is what snapshotRank did with a game.
+type rankResult int
+
+const (
+	rankSkipped  rankResult = iota // not a ranked game, placements, or given up on
+	rankTaken                      // snapshotted by this call
+	rankExisting                   // snapshotted earlier
+	rankPending                    // Riot hasn't counted the game yet; try again later
+)
+
+// maxPendingRanks caps how many games of a player wait for their snapshot.
+const maxPendingRanks = 3
+
+// snapshotRank stores a player's league entry after a ranked game and
+// returns the change since their previous snapshot. The change only has a
+// Before when exactly this game was counted since then; otherwise the LP
+// difference would include other games. A game is only snapshotted once.
+//
+// retry is set for games that were pending on an earlier poll. If other
+// games were counted since, the entry no longer belongs to this one and
+// it's given up on.
+func (b *Bot) snapshotRank(ctx context.Context, puuid, matchID string, retry bool) (*storage.RankChange, rankResult) {
+	if change, ok, err := b.history.RankChangeAt(puuid, matchID); err == nil && ok {
+		return change, rankExisting
+	}
+
+	match, err := b.riotClient.GetMatchDetails(ctx, matchID)
+	if err != nil {
+		return nil, rankPending
+	}
+	queueType, ok := rankQueues[match.Info.QueueID]
+	if !ok {
+		return nil, rankSkipped
+	}
+
+	entries, err := b.riotClient.RefreshLeagueEntries(ctx, puuid)
+	if err != nil {
+		slog.WarnContext(ctx, "Getting league entries failed", "puuid", puuid, "error", err)
+		return nil, rankPending
+	}
+	var after *storage.RankSnapshot
+	for _, e := range entries {
+		if e.QueueType == queueType {
+			after = &storage.RankSnapshot{
+				PUUID:     puuid,
+				QueueType: queueType,
+				MatchID:   matchID,
+				TakenAt:   time.Now().UTC(),
+				Tier:      e.Tier,
+				Rank:      e.Rank,
+				LP:        e.LeaguePoints,
+				Wins:      e.Wins,
+				Losses:    e.Losses,
+			}
+		}
+	}
+	if after == nil {
+		return nil, rankSkipped
+	}
+
+	change := &storage.RankChange{After: after}
+	if earlier, err := b.history.Ranks(puuid, queueType, time.Time{}, time.Time{}); err == nil && len(earlier) > 0 {
+		switch after.Games() - earlier[0].Games() {
+		case 0:
+			slog.DebugContext(ctx, "League entry not updated yet", "puuid", puuid, "match_id", matchID)
+			return nil, rankPending
+		case 1:
+			change.Before = &earlier[0]
+		default:
+			if retry {
+				slog.DebugContext(ctx, "League entry moved past the game", "puuid", puuid, "match_id", matchID)
+				return nil, rankSkipped
+			}
+		}
+	}
+
+	if err := b.history.AddRank(after); err != nil {
+		slog.WarnContext(ctx, "Saving rank snapshot failed", "puuid", puuid, "match_id", matchID, "error", err)
+	}
+	return change, rankTaken
+}
+
+// retryRankSnapshots tries again to snapshot the games of a player that
+// were pending on earlier polls. Their match was already posted, so only
+// the milestones are announced.
+func (b *Bot) retryRankSnapshots(ctx context.Context, puuid string, player *storage.TrackedPlayer) {
+	if len(player.PendingRanks) == 0 {
+		return
+	}
+	var pending []string
+	for _, matchID := range player.PendingRanks {
+		change, result := b.snapshotRank(ctx, puuid, matchID, true)
+		switch result {
+		case rankPending:
+			pending = append(pending, matchID)
+		case rankTaken:
+			b.announceMilestones(ctx, player, change)
+		}
+	}
+	b.setPendingRanks(ctx, puuid, player, pending)
+}
+
+// addPendingRank queues a game for retryRankSnapshots, dropping the
+// oldest ones past maxPendingRanks.
+func (b *Bot) addPendingRank(ctx context.Context, puuid string, player *storage.TrackedPlayer, matchID string) {
+	pending := append(slices.Clone(player.PendingRanks), matchID)
+	if len(pending) > maxPendingRanks {
+		pending = pending[len(pending)-maxPendingRanks:]
+	}
+	b.setPendingRanks(ctx, puuid, player, pending)
+}
+
+// setPendingRanks saves the games of a player waiting for their snapshot.
+func (b *Bot) setPendingRanks(ctx context.Context, puuid string, player *storage.TrackedPlayer, matchIDs []string) {
+	if slices.Equal(player.PendingRanks, matchIDs) {
+		return
+	}
+	player.PendingRanks = matchIDs
+	if err := b.trackedPlayers.UpdatePendingRanks(puuid, matchIDs); err != nil {
+		slog.WarnContext(ctx, "Saving pending rank snapshots failed", "player", player.Name, "error", err)
+	}
+}
+
+// formatLPDelta formats an LP change as "+21 LP" or "−18 LP".
+func formatLPDelta(delta int) string {
+	if delta < 0 {
+		return fmt.Sprintf("−%d LP", -delta)
+	}
+	return fmt.Sprintf("+%d LP", delta)
+}
+
+// rankChangeLine describes what a game did to a player's rank.
+func rankChangeLine(name string, c *storage.RankChange) string {
+	after := formatRank(c.After.Tier, c.After.Rank, c.After.LP)
+	if c.Before == nil {
+		return fmt.Sprintf("**%s**: %s", name, after)
+	}
+
+	line := fmt.Sprintf("**%s**: %s → %s", name, formatLPDelta(c.LPDelta()), after)
+	switch {
+	case c.NewTier():
+		line += fmt.Sprintf(" 🎉 Lên **%s**!", strings.Title(strings.ToLower(c.After.Tier)))
+	case c.Promoted():
+		line += " ⬆️ Thăng hạng!"
+	case c.Demoted():
+		line += " ⬇️ Rớt hạng"
+	}
+	return line
+}
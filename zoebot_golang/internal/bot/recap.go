@@ -0,0 +1,418 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/logging"
+	"github.com/zoebot/internal/schedule"
+	"github.com/zoebot/internal/storage"
+)
+
+const (
+	recapCheckInterval = 30 * time.Second // how often due recaps are looked for
+	recapPlayers       = 10               // players listed
+	recapChampions     = 3                // most played champions listed
+	recapMinStreak     = 3                // games in a row worth mentioning
+	recapLockTTL       = 10 * time.Minute // keeps other instances from posting the same recap
+)
+
+// errNoRecapGames means nobody in the channel played in the recap window.
+var errNoRecapGames = errors.New("no games in the recap window")
+
+// recapSchedule is when a channel's recaps are posted.
+type recapSchedule struct {
+	Spec     string         // cron expression or config.RecapOff
+	Cron     *schedule.Cron // nil when off
+	Location *time.Location
+	Custom   bool // the channel overrides the configured schedule
+}
+
+// recapScheduleOf returns a channel's recap schedule: the guild's settings
+// for the channel, else the configured default.
+func (b *Bot) recapScheduleOf(channelID string) (*recapSchedule, error) {
+	settings, err := b.channelSettings(channelID)
+	if err != nil {
+		return nil, err
+	}
+	rs := &recapSchedule{Spec: b.config().Recap.Schedule}
+	if spec := settings.RecapSchedule(channelID); spec != "" {
+		rs.Spec, rs.Custom = spec, true
+	}
+	if rs.Location, err = b.guildLocation(settings); err != nil {
+		return nil, err
+	}
+	if rs.Spec != config.RecapOff {
+		if rs.Cron, err = schedule.Parse(rs.Spec); err != nil {
+			return nil, err
+		}
+	}
+	return rs, nil
+}
+
+// channelSettings returns the settings of a channel's guild, or the
+// defaults for a channel outside a guild.
+func (b *Bot) channelSettings(channelID string) (*storage.GuildSettings, error) {
+	guildID := b.guildOf(channelID)
+	if guildID == "" {
+		return &storage.GuildSettings{}, nil
+	}
+	return b.guildSettings.Get(guildID)
+}
+
+// guildLocation returns the guild's time zone, or the configured one.
+func (b *Bot) guildLocation(settings *storage.GuildSettings) (*time.Location, error) {
+	timezone := settings.Timezone
+	if timezone == "" {
+		timezone = b.config().Recap.Timezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("time zone %q: %w", timezone, err)
+	}
+	return loc, nil
+}
+
+// channelLocation returns the time zone of a channel's guild. Channels whose
+// settings can't be read get the configured one.
+func (b *Bot) channelLocation(ctx context.Context, channelID string) *time.Location {
+	settings, err := b.channelSettings(channelID)
+	if err != nil {
+		slog.WarnContext(ctx, "Reading guild settings failed", "channel_id", channelID, "error", err)
+		settings = &storage.GuildSettings{}
+	}
+	loc, err := b.guildLocation(settings)
+	if err != nil {
+		slog.WarnContext(ctx, "Loading guild time zone failed", "channel_id", channelID, "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// runRecaps posts the channel recaps as their schedules come due, until
+// the bot stops.
+func (b *Bot) runRecaps() {
+	ticker := time.NewTicker(recapCheckInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-b.stopPolling:
+			return
+		case now := <-ticker.C:
+			b.postDueRecaps(last, now)
+			last = now
+		}
+	}
+}
+
+// postDueRecaps posts the recap of every channel whose schedule fired
+// after since and no later than now.
+func (b *Bot) postDueRecaps(since, now time.Time) {
+	channels := make(map[string]bool)
+	for _, p := range b.trackedPlayers.GetAll() {
+		channels[p.ChannelID] = true
+	}
+
+	for channelID := range channels {
+		rs, err := b.recapScheduleOf(channelID)
+		if err != nil {
+			slog.Warn("Reading recap schedule failed", "channel_id", channelID, "error", err)
+			continue
+		}
+		if rs.Cron == nil {
+			continue
+		}
+		at := rs.Cron.Next(since.In(rs.Location))
+		if at.IsZero() || at.After(now) {
+			continue
+		}
+
+		// Never unlocked: the lock outlives the check so no instance posts
+		// this recap again
+		if _, err := b.store.Backend().Lock("recap:"+channelID+":"+strconv.FormatInt(at.Unix(), 10), recapLockTTL); err != nil {
+			if !errors.Is(err, storage.ErrLocked) {
+				slog.Warn("Taking recap lock failed", "channel_id", channelID, "error", err)
+			}
+			continue
+		}
+
+		from := rs.Cron.Prev(at)
+		if from.IsZero() {
+			from = at.AddDate(0, 0, -7)
+		}
+		ctx := logging.NewCorrelationID(context.Background(), "recap")
+		if err := b.postRecap(ctx, channelID, from, at, rs.Location); err != nil {
+			if errors.Is(err, errNoRecapGames) {
+				slog.InfoContext(ctx, "Skipped recap without games", "channel_id", channelID)
+				continue
+			}
+			slog.WarnContext(ctx, "Posting recap failed", "channel_id", channelID, "error", err)
+		}
+	}
+}
+
+// recapPlayer is one player's games in a recap window.
+type recapPlayer struct {
+	Name       string
+	PUUID      string
+	Games      int
+	Wins       int
+	LPGained   int
+	HasLP      bool
+	WinStreak  int // longest in the window
+	LossStreak int
+}
+
+// recap is a channel's summary of one window.
+type recap struct {
+	From, To   time.Time
+	Players    []*recapPlayer // most games first
+	Best       *storage.MatchRecord
+	Worst      *storage.MatchRecord
+	Champions  []championCount // most played first
+	WinStreak  *recapPlayer    // longest win streak, if one is worth mentioning
+	LossStreak *recapPlayer
+}
+
+// buildRecap gathers the players' games from the history in [from, to).
+func (b *Bot) buildRecap(players []*storage.TrackedPlayer, from, to time.Time) (*recap, error) {
+	r := &recap{From: from, To: to}
+	champions := make(map[string]int)
+	for _, player := range players {
+		if player.PUUID == "" {
+			continue
+		}
+		records, err := b.history.ByPlayer(player.PUUID, storage.HistoryQuery{From: from, To: to})
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		p := &recapPlayer{Name: player.Name, PUUID: player.PUUID, Games: len(records)}
+		streak, won := 0, false
+		for n := len(records) - 1; n >= 0; n-- { // oldest first
+			rec := &records[n]
+			if rec.Win {
+				p.Wins++
+			}
+			if streak == 0 || rec.Win != won {
+				streak, won = 0, rec.Win
+			}
+			streak++
+			if won {
+				p.WinStreak = max(p.WinStreak, streak)
+			} else {
+				p.LossStreak = max(p.LossStreak, streak)
+			}
+
+			champions[rec.Champion]++
+			if rec.ScoreSource == "" {
+				continue
+			}
+			if r.Best == nil || rec.Score > r.Best.Score {
+				r.Best = rec
+			}
+			if r.Worst == nil || rec.Score < r.Worst.Score {
+				r.Worst = rec
+			}
+		}
+
+		snapshots, err := b.history.Ranks(player.PUUID, storage.QueueSolo, time.Time{}, to)
+		if err != nil {
+			return nil, err
+		}
+		p.LPGained, p.HasLP = lpSince(snapshots, from)
+		r.Players = append(r.Players, p)
+	}
+	if len(r.Players) == 0 {
+		return nil, errNoRecapGames
+	}
+
+	sort.SliceStable(r.Players, func(a, c int) bool {
+		if r.Players[a].Games != r.Players[c].Games {
+			return r.Players[a].Games > r.Players[c].Games
+		}
+		return r.Players[a].Name < r.Players[c].Name
+	})
+	for _, p := range r.Players {
+		if p.WinStreak >= recapMinStreak && (r.WinStreak == nil || p.WinStreak > r.WinStreak.WinStreak) {
+			r.WinStreak = p
+		}
+		if p.LossStreak >= recapMinStreak && (r.LossStreak == nil || p.LossStreak > r.LossStreak.LossStreak) {
+			r.LossStreak = p
+		}
+	}
+
+	for champion, games := range champions {
+		r.Champions = append(r.Champions, championCount{Champion: champion, Games: games})
+	}
+	sort.Slice(r.Champions, func(a, c int) bool {
+		if r.Champions[a].Games != r.Champions[c].Games {
+			return r.Champions[a].Games > r.Champions[c].Games
+		}
+		return r.Champions[a].Champion < r.Champions[c].Champion
+	})
+	r.Champions = r.Champions[:min(len(r.Champions), recapChampions)]
+	return r, nil
+}
+
+// contextData returns the recap for Zoe's summary and for replies to it.
+func (r *recap) contextData(channelName string) map[string]interface{} {
+	players := make([]map[string]interface{}, len(r.Players))
+	for n, p := range r.Players {
+		player := map[string]interface{}{
+			"name":             p.Name,
+			"games":            p.Games,
+			"wins":             p.Wins,
+			"losses":           p.Games - p.Wins,
+			"longest_win_run":  p.WinStreak,
+			"longest_loss_run": p.LossStreak,
+		}
+		if p.HasLP {
+			player["solo_lp_change"] = p.LPGained
+		}
+		players[n] = player
+	}
+	game := func(rec *storage.MatchRecord) map[string]interface{} {
+		if rec == nil {
+			return nil
+		}
+		return map[string]interface{}{
+			"player":   rec.RiotID,
+			"champion": rec.Champion,
+			"score":    rec.Score,
+			"kda":      fmt.Sprintf("%d/%d/%d", rec.Kills, rec.Deaths, rec.Assists),
+			"win":      rec.Win,
+		}
+	}
+	champions := make([]string, len(r.Champions))
+	for n, c := range r.Champions {
+		champions[n] = fmt.Sprintf("%s (%d)", c.Champion, c.Games)
+	}
+	return map[string]interface{}{
+		"channel":       channelName,
+		"from":          r.From.Format(time.RFC3339),
+		"to":            r.To.Format(time.RFC3339),
+		"players":       players,
+		"best_game":     game(r.Best),
+		"worst_game":    game(r.Worst),
+		"top_champions": champions,
+	}
+}
+
+// recapSummary asks Zoe to sum the recap up, falling back to a canned line.
+func (b *Bot) recapSummary(ctx context.Context, data map[string]interface{}) string {
+	summary, err := b.aiClient.ChatWithContext(ctx, "recap", data,
+		"Viết lời tổng kết cho cả kênh về khoảng thời gian này: ai gánh, ai tấu hài, ai leo rank, ai rớt rank.")
+	if err != nil || strings.TrimSpace(summary) == "" {
+		slog.WarnContext(ctx, "Recap summary failed", "error", err)
+		return "Lại một tuần nữa trôi qua, ta xem hết rồi đấy nhé. Số liệu ở dưới, tự soi mình đi~ 🔮"
+	}
+	return summary
+}
+
+// postRecap posts the recap of [from, to) to a channel, with Zoe's summary
+// and the LP chart of its most active players.
+func (b *Bot) postRecap(ctx context.Context, channelID string, from, to time.Time, loc *time.Location) error {
+	r, err := b.buildRecap(b.trackedPlayers.GetByChannel(channelID), from, to)
+	if err != nil {
+		return err
+	}
+
+	channelName := "this channel"
+	if channel, err := b.session.Channel(channelID); err == nil {
+		channelName = "#" + channel.Name
+	}
+	data := r.contextData(channelName)
+	embed := buildRecapEmbed(r, b.recapSummary(ctx, data), channelName, loc)
+
+	entries := make([]*leaderboardEntry, len(r.Players))
+	for n, p := range r.Players {
+		entries[n] = &leaderboardEntry{Name: p.Name, PUUID: p.PUUID}
+	}
+	message := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+	if chart := lpChart(ctx, "LP - "+channelName, b.leaderboardSeries(entries, storage.QueueSolo, from)); chart != nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + chart.Name}
+		message.Files = []*discordgo.File{chart}
+	}
+
+	msg, err := b.session.ChannelMessageSendComplex(channelID, message)
+	if err != nil {
+		return err
+	}
+	b.saveMessageContext(msg.ID, "recap", data)
+	slog.InfoContext(ctx, "Posted recap", "channel_id", channelID, "players", len(r.Players), "from", from, "to", to)
+	return nil
+}
+
+// buildRecapEmbed creates the recap embed.
+func buildRecapEmbed(r *recap, summary, channelName string, loc *time.Location) *discordgo.MessageEmbed {
+	var players strings.Builder
+	for n, p := range r.Players {
+		if n == recapPlayers {
+			fmt.Fprintf(&players, "… và %d người khác", len(r.Players)-n)
+			break
+		}
+		fmt.Fprintf(&players, "**%s** • %d trận (%dT - %dB)", p.Name, p.Games, p.Wins, p.Games-p.Wins)
+		if p.HasLP {
+			fmt.Fprintf(&players, " • %s", formatLPDelta(p.LPGained))
+		}
+		players.WriteString("\n")
+	}
+	fields := []*discordgo.MessageEmbedField{{Name: "🎮 Người chơi", Value: players.String()}}
+
+	game := func(rec *storage.MatchRecord) string {
+		result := "Thua"
+		if rec.Win {
+			result = "Thắng"
+		}
+		return fmt.Sprintf("**%s** - %s **%.1f** điểm (%d/%d/%d, %s) • %s",
+			rec.RiotID, rec.Champion, rec.Score, rec.Kills, rec.Deaths, rec.Assists, result, rec.PlayedAt.In(loc).Format("02/01"))
+	}
+	if r.Best != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "⭐ Trận hay nhất", Value: game(r.Best)})
+	}
+	if r.Worst != nil && r.Worst != r.Best {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "💀 Trận tệ nhất", Value: game(r.Worst)})
+	}
+
+	champions := make([]string, len(r.Champions))
+	for n, c := range r.Champions {
+		champions[n] = fmt.Sprintf("%s (%d)", c.Champion, c.Games)
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{Name: "🦸 Tướng được chơi nhiều", Value: strings.Join(champions, " • ")})
+
+	var streaks []string
+	if p := r.WinStreak; p != nil {
+		streaks = append(streaks, fmt.Sprintf("🔥 **%s** thắng **%d trận liên tiếp**", p.Name, p.WinStreak))
+	}
+	if p := r.LossStreak; p != nil {
+		streaks = append(streaks, fmt.Sprintf("🧊 **%s** thua **%d trận liên tiếp**", p.Name, p.LossStreak))
+	}
+	if len(streaks) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "📈 Chuỗi dài nhất", Value: strings.Join(streaks, "\n")})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "📅 TỔNG KẾT - " + channelName,
+		Description: summary,
+		Color:       embeds.ColorInfo,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("🗓️ %s - %s • %s", r.From.In(loc).Format("02/01 15:04"), r.To.In(loc).Format("02/01 15:04"), loc),
+		},
+	}
+}
@@ -0,0 +1,372 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/logging"
+	"github.com/zoebot/internal/storage"
+)
+
+const (
+	sessionLookback   = 24 * time.Hour // history read to find the latest session
+	sessionMaxIdle    = 6 * time.Hour  // sessions that ended longer ago aren't summed up, e.g. after downtime
+	sessionMarkTTL    = 48 * time.Hour // how long a summed up session is remembered
+	sessionEmbeds     = 10             // Discord's limit of embeds per message
+	tiltLossStreak    = 3              // losses in a row to end a session with
+	tiltScoreDrop     = 1.5            // score lost from the first half to the second
+	tiltDeathsPerGame = 8
+	tiltMarathon      = 8 // games in one session
+	tiltLPLoss        = 50
+)
+
+// gameSession is a run of one player's games with short breaks between.
+type gameSession struct {
+	Name      string
+	PUUID     string
+	Games     []storage.MatchRecord // oldest first
+	Wins      int
+	Deaths    int
+	ScoreSum  float64
+	Scored    int
+	LPGained  int
+	HasLP     bool
+	Champions []championCount // most played first
+}
+
+// lastSession returns the session the newest of records (newest first)
+// ends: the games starting less than gap after the previous one ended.
+func lastSession(records []storage.MatchRecord, gap time.Duration) []storage.MatchRecord {
+	if len(records) == 0 {
+		return nil
+	}
+	n := 1
+	for n < len(records) {
+		newer, older := &records[n-1], &records[n]
+		if newer.PlayedAt.Add(-newer.Duration).Sub(older.PlayedAt) >= gap {
+			break
+		}
+		n++
+	}
+	session := make([]storage.MatchRecord, n)
+	for k := range session {
+		session[k] = records[n-1-k]
+	}
+	return session
+}
+
+// newGameSession sums up a player's session. snapshots are the player's
+// solo queue snapshots, newest first.
+func newGameSession(player *storage.TrackedPlayer, games []storage.MatchRecord, snapshots []storage.RankSnapshot) *gameSession {
+	s := &gameSession{Name: player.Name, PUUID: player.PUUID, Games: games}
+	played := make(map[string]int)
+	for _, g := range games {
+		if g.Win {
+			s.Wins++
+		}
+		s.Deaths += g.Deaths
+		if g.ScoreSource != "" {
+			s.ScoreSum += g.Score
+			s.Scored++
+		}
+		played[g.Champion]++
+	}
+	for champion, n := range played {
+		s.Champions = append(s.Champions, championCount{Champion: champion, Games: n})
+	}
+	sort.Slice(s.Champions, func(a, c int) bool {
+		if s.Champions[a].Games != s.Champions[c].Games {
+			return s.Champions[a].Games > s.Champions[c].Games
+		}
+		return s.Champions[a].Champion < s.Champions[c].Champion
+	})
+
+	start := games[0].PlayedAt.Add(-games[0].Duration)
+	s.LPGained, s.HasLP = lpSince(snapshots, start)
+	return s
+}
+
+// Start returns when the first game began.
+func (s *gameSession) Start() time.Time {
+	return s.Games[0].PlayedAt.Add(-s.Games[0].Duration)
+}
+
+// End returns when the last game ended.
+func (s *gameSession) End() time.Time {
+	return s.Games[len(s.Games)-1].PlayedAt
+}
+
+// AvgScore returns the average score of the scored games.
+func (s *gameSession) AvgScore() float64 {
+	return s.ScoreSum / float64(max(s.Scored, 1))
+}
+
+// Tilt returns the signs the player was tilting, if any.
+func (s *gameSession) Tilt() []string {
+	var signs []string
+
+	if losses := lossStreak(s.Games); losses >= tiltLossStreak {
+		signs = append(signs, fmt.Sprintf("Thua **%d trận cuối** mà vẫn chưa chịu nghỉ", losses))
+	}
+
+	// Compare the scored games of each half of the session
+	var scores []float64
+	for _, g := range s.Games {
+		if g.ScoreSource != "" {
+			scores = append(scores, g.Score)
+		}
+	}
+	if len(scores) >= 4 {
+		half := len(scores) / 2
+		first, second := 0.0, 0.0
+		for n, score := range scores {
+			if n < half {
+				first += score
+			} else {
+				second += score
+			}
+		}
+		first, second = first/float64(half), second/float64(len(scores)-half)
+		if first-second >= tiltScoreDrop {
+			signs = append(signs, fmt.Sprintf("Điểm tụt dần: **%.1f** → **%.1f**", first, second))
+		}
+	}
+
+	if deaths := float64(s.Deaths) / float64(len(s.Games)); deaths >= tiltDeathsPerGame {
+		signs = append(signs, fmt.Sprintf("Chết trung bình **%.1f** lần/trận", deaths))
+	}
+	if len(s.Games) >= tiltMarathon {
+		signs = append(signs, fmt.Sprintf("Cày **%d trận** liền một mạch", len(s.Games)))
+	}
+	if s.HasLP && s.LPGained <= -tiltLPLoss {
+		signs = append(signs, fmt.Sprintf("Bay mất **%d LP**", -s.LPGained))
+	}
+	return signs
+}
+
+// sessionMarkKey holds the last match of a player's latest summed up
+// session.
+func sessionMarkKey(puuid string) string {
+	return "session:" + puuid
+}
+
+// claimKey stores value at key for ttl unless the key holds a value that
+// taken says rules it out, and reports whether it did. A lock makes sure
+// only one instance claims the key.
+func (b *Bot) claimKey(key, value string, ttl time.Duration, taken func(current string) bool) (bool, error) {
+	backend := b.store.Backend()
+	unlock, err := backend.Lock(key+":lock", time.Minute)
+	if errors.Is(err, storage.ErrLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	current, ok, err := backend.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if ok && taken(current) {
+		return false, nil
+	}
+	if err := backend.Set(key, value, ttl); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// notifyMode returns how a channel's guild wants games posted. Channels
+// outside a guild, or whose settings can't be read, get the default.
+func (b *Bot) notifyMode(ctx context.Context, channelID string) string {
+	settings, err := b.channelSettings(channelID)
+	if err != nil {
+		slog.WarnContext(ctx, "Reading guild settings failed", "channel_id", channelID, "error", err)
+		return storage.NotifyBoth
+	}
+	return settings.NotifyMode()
+}
+
+// postDueSessions sums up the sessions of players who have been idle for
+// the configured gap. Each channel gets one message with every player who
+// finished a session. With every game posted, one game isn't a session
+// worth summing up.
+func (b *Bot) postDueSessions(now time.Time) {
+	ctx := logging.NewCorrelationID(context.Background(), "sessions")
+	gap := b.config().Session.Gap
+
+	// Only players who played recently enough can have a session due
+	active, err := b.history.ActivePlayers(now.Add(-sessionMaxIdle))
+	if err != nil {
+		slog.WarnContext(ctx, "Reading active players failed", "error", err)
+		return
+	}
+
+	due := make(map[string][]*gameSession) // channel ID -> sessions
+	modes := make(map[string]string)       // channel ID -> notify mode
+	players := b.trackedPlayers.GetAll()
+	for _, puuid := range active {
+		player, ok := players[puuid]
+		if !ok {
+			continue
+		}
+		mode, ok := modes[player.ChannelID]
+		if !ok {
+			mode = b.notifyMode(ctx, player.ChannelID)
+			modes[player.ChannelID] = mode
+		}
+		if mode == storage.NotifyGames {
+			continue
+		}
+
+		records, err := b.history.ByPlayer(puuid, storage.HistoryQuery{From: now.Add(-sessionLookback)})
+		if err != nil {
+			slog.WarnContext(ctx, "Reading match history failed", "player", player.Name, "error", err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+		idle := now.Sub(records[0].PlayedAt)
+		if idle < gap || idle > sessionMaxIdle {
+			continue
+		}
+		games := lastSession(records, gap)
+		if len(games) < 2 && mode == storage.NotifyBoth {
+			continue
+		}
+
+		// Mark the session before posting it: a lost summary beats a double
+		last := games[len(games)-1].MatchID
+		claimed, err := b.claimKey(sessionMarkKey(puuid), last, sessionMarkTTL, func(marked string) bool { return marked == last })
+		if err != nil {
+			slog.WarnContext(ctx, "Marking session failed", "player", player.Name, "error", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		snapshots, err := b.history.Ranks(puuid, storage.QueueSolo, time.Time{}, time.Time{})
+		if err != nil {
+			slog.WarnContext(ctx, "Reading rank snapshots failed", "player", player.Name, "error", err)
+		}
+		due[player.ChannelID] = append(due[player.ChannelID], newGameSession(&player, games, snapshots))
+	}
+
+	for channelID, sessions := range due {
+		b.postSessions(ctx, channelID, sessions)
+	}
+}
+
+// postSessions posts the summaries of sessions to a channel.
+func (b *Bot) postSessions(ctx context.Context, channelID string, sessions []*gameSession) {
+	sort.Slice(sessions, func(a, c int) bool { return sessions[a].Name < sessions[c].Name })
+	for len(sessions) > 0 {
+		batch := sessions[:min(len(sessions), sessionEmbeds)]
+		sessions = sessions[len(batch):]
+
+		message := &discordgo.MessageSend{}
+		var data []map[string]interface{}
+		for _, s := range batch {
+			message.Embeds = append(message.Embeds, buildSessionEmbed(s))
+			data = append(data, s.contextData())
+		}
+		msg, err := b.session.ChannelMessageSendComplex(channelID, message)
+		if err != nil {
+			slog.WarnContext(ctx, "Sending session summary failed", "channel_id", channelID, "error", err)
+			return
+		}
+		b.saveMessageContext(msg.ID, "session", map[string]interface{}{"sessions": data})
+		slog.InfoContext(ctx, "Posted session summaries", "channel_id", channelID, "players", len(batch))
+	}
+}
+
+// contextData returns the session for replies to its summary.
+func (s *gameSession) contextData() map[string]interface{} {
+	games := make([]map[string]interface{}, len(s.Games))
+	for n, g := range s.Games {
+		game := map[string]interface{}{
+			"champion": g.Champion,
+			"win":      g.Win,
+			"kda":      fmt.Sprintf("%d/%d/%d", g.Kills, g.Deaths, g.Assists),
+		}
+		if g.ScoreSource != "" {
+			game["score"] = g.Score
+		}
+		games[n] = game
+	}
+	data := map[string]interface{}{
+		"player": s.Name,
+		"games":  games,
+		"wins":   s.Wins,
+		"losses": len(s.Games) - s.Wins,
+		"tilt":   s.Tilt(),
+	}
+	if s.HasLP {
+		data["solo_lp_change"] = s.LPGained
+	}
+	return data
+}
+
+// buildSessionEmbed creates the summary of one player's session.
+func buildSessionEmbed(s *gameSession) *discordgo.MessageEmbed {
+	var results strings.Builder
+	for _, g := range s.Games {
+		if g.Win {
+			results.WriteString("✅")
+		} else {
+			results.WriteString("❌")
+		}
+	}
+	losses := len(s.Games) - s.Wins
+
+	var description strings.Builder
+	fmt.Fprintf(&description, "%d trận từ <t:%d:t> đến <t:%d:t> (%s)\n%s",
+		len(s.Games), s.Start().Unix(), s.End().Unix(), formatSessionLength(s.End().Sub(s.Start())), results.String())
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "📊 Thành tích", Value: fmt.Sprintf("%dT - %dB (%.0f%%)", s.Wins, losses, float64(s.Wins)/float64(len(s.Games))*100), Inline: true},
+	}
+	if s.HasLP {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "📈 LP", Value: formatLPDelta(s.LPGained), Inline: true})
+	}
+	if s.Scored > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "⭐ Điểm TB", Value: fmt.Sprintf("%.1f", s.AvgScore()), Inline: true})
+	}
+	champions := make([]string, len(s.Champions))
+	for n, c := range s.Champions {
+		champions[n] = fmt.Sprintf("%s ×%d", c.Champion, c.Games)
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{Name: "🦸 Tướng", Value: strings.Join(champions, " • ")})
+	if tilt := s.Tilt(); len(tilt) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "⚠️ Dấu hiệu tilt", Value: "• " + strings.Join(tilt, "\n• ")})
+	}
+
+	color := embeds.ColorWin
+	if s.Wins < losses || (s.HasLP && s.LPGained < 0) {
+		color = embeds.ColorLose
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "🎮 TỔNG KẾT PHIÊN - " + s.Name,
+		Description: description.String(),
+		Color:       color,
+		Fields:      fields,
+	}
+}
+
+// formatSessionLength formats a session's length as hours and minutes.
+func formatSessionLength(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%d phút", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%d giờ %d phút", int(d.Hours()), int(d.Minutes())%60)
+}
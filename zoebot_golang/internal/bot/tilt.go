@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/embeds"
+	"github.com/zoebot/internal/logging"
+	"github.com/zoebot/internal/storage"
+)
+
+const (
+	tiltRecent      = 30 * time.Minute // only nudge players who may still be queueing
+	tiltTrendGames  = 3                // games in a row that each got worse
+	tiltTrendDeaths = 7                // deaths in the last game of a worsening run
+	tiltLateUntil   = 5                // games ending after midnight until this hour, in the guild's time zone
+)
+
+// tiltNudges are Zoe's lines for each kind of tilt, by the first one found.
+var tiltNudges = map[string]string{
+	"streak": "%s ơi, thua liền mấy trận rồi đó. Tắt game, ra ngoài chạm cỏ đi rồi hẵng quay lại nha~ 🌱",
+	"deaths": "%s ơi, càng chơi càng chết nhiều kìa. Nghỉ tay uống nước đi, Zoe giữ chỗ cho!",
+	"score":  "%s ơi, phong độ đang tụt dốc không phanh. Đi dạo một vòng cho đầu óc thoáng nha~",
+	"late":   "%s ơi, khuya lắm rồi đó! Đi ngủ đi, rank vẫn còn đó mai leo tiếp ✨",
+}
+
+// tiltSign is one reason a player looks tilted.
+type tiltSign struct {
+	Kind string
+	Text string
+}
+
+// lossStreak returns how many of games (oldest first) were lost in a row at
+// the end.
+func lossStreak(games []storage.MatchRecord) int {
+	losses := 0
+	for n := len(games) - 1; n >= 0 && !games[n].Win; n-- {
+		losses++
+	}
+	return losses
+}
+
+// tiltSigns returns why a session's games (oldest first) suggest the player
+// should take a break right now. loc is the guild's time zone.
+func tiltSigns(games []storage.MatchRecord, loc *time.Location) []tiltSign {
+	var signs []tiltSign
+	if losses := lossStreak(games); losses >= tiltLossStreak {
+		signs = append(signs, tiltSign{"streak", fmt.Sprintf("Thua **%d trận liên tiếp**", losses)})
+	}
+
+	if len(games) >= tiltTrendGames {
+		run := games[len(games)-tiltTrendGames:]
+		worse := true
+		deaths := make([]string, len(run))
+		for n, g := range run {
+			deaths[n] = fmt.Sprint(g.Deaths)
+			if n > 0 && g.Deaths <= run[n-1].Deaths {
+				worse = false
+			}
+		}
+		if worse && run[len(run)-1].Deaths >= tiltTrendDeaths {
+			signs = append(signs, tiltSign{"deaths", "Chết ngày càng nhiều: " + strings.Join(deaths, " → ")})
+		}
+	}
+
+	var scored []storage.MatchRecord
+	for _, g := range games {
+		if g.ScoreSource != "" {
+			scored = append(scored, g)
+		}
+	}
+	if len(scored) >= tiltTrendGames && scored[len(scored)-1].MatchID == games[len(games)-1].MatchID {
+		run := scored[len(scored)-tiltTrendGames:]
+		worse := true
+		scores := make([]string, len(run))
+		for n, g := range run {
+			scores[n] = fmt.Sprintf("%.1f", g.Score)
+			if n > 0 && g.Score >= run[n-1].Score {
+				worse = false
+			}
+		}
+		if worse && run[0].Score-run[len(run)-1].Score >= tiltScoreDrop {
+			signs = append(signs, tiltSign{"score", "Điểm tụt dần: " + strings.Join(scores, " → ")})
+		}
+	}
+
+	if at := games[len(games)-1].PlayedAt.In(loc); at.Hour() < tiltLateUntil {
+		signs = append(signs, tiltSign{"late", fmt.Sprintf("Vẫn còn cày lúc **%s**", at.Format("15:04"))})
+	}
+	return signs
+}
+
+// tiltAlertKey holds the match a player was last nudged after, for the
+// configured cooldown.
+func tiltAlertKey(puuid string) string {
+	return "tilt:" + puuid
+}
+
+// postTiltAlerts nudges players whose latest games look tilted to take a
+// break: by DM to their linked Discord user, or in their channel. Players
+// who turned alerts off are skipped, and each player is nudged at most once
+// per cooldown.
+func (b *Bot) postTiltAlerts(now time.Time) {
+	ctx := logging.NewCorrelationID(context.Background(), "tilt")
+	cfg := b.config()
+
+	// Only players who just played can be on a tilted run
+	active, err := b.history.ActivePlayers(now.Add(-tiltRecent))
+	if err != nil {
+		slog.WarnContext(ctx, "Reading active players failed", "error", err)
+		return
+	}
+
+	locations := make(map[string]*time.Location) // channel ID -> guild time zone
+	players := b.trackedPlayers.GetAll()
+	for _, puuid := range active {
+		player, ok := players[puuid]
+		if !ok || player.TiltOff {
+			continue
+		}
+		records, err := b.history.ByPlayer(puuid, storage.HistoryQuery{From: now.Add(-sessionLookback)})
+		if err != nil {
+			slog.WarnContext(ctx, "Reading match history failed", "player", player.Name, "error", err)
+			continue
+		}
+		if len(records) == 0 || now.Sub(records[0].PlayedAt) > tiltRecent {
+			continue
+		}
+
+		loc, ok := locations[player.ChannelID]
+		if !ok {
+			loc = b.channelLocation(ctx, player.ChannelID)
+			locations[player.ChannelID] = loc
+		}
+		signs := tiltSigns(lastSession(records, cfg.Session.Gap), loc)
+		if len(signs) == 0 {
+			continue
+		}
+
+		claimed, err := b.claimKey(tiltAlertKey(puuid), records[0].MatchID, cfg.Tilt.Cooldown, func(string) bool { return true })
+		if err != nil {
+			slog.WarnContext(ctx, "Marking tilt alert failed", "player", player.Name, "error", err)
+		}
+		if !claimed {
+			continue
+		}
+		b.sendTiltAlert(ctx, &player, signs)
+	}
+}
+
+// sendTiltAlert sends a player's nudge to their linked Discord user, or to
+// their channel when there is none or the DM can't be sent.
+func (b *Bot) sendTiltAlert(ctx context.Context, player *storage.TrackedPlayer, signs []tiltSign) {
+	embed := buildTiltEmbed(player, signs)
+	if player.DiscordID != "" {
+		dm, err := b.session.UserChannelCreate(player.DiscordID)
+		if err == nil {
+			_, err = b.session.ChannelMessageSendEmbed(dm.ID, embed)
+		}
+		if err == nil {
+			slog.InfoContext(ctx, "Sent tilt alert", "player", player.Name, "user_id", player.DiscordID)
+			return
+		}
+		slog.WarnContext(ctx, "Sending tilt DM failed, posting in channel", "player", player.Name, "user_id", player.DiscordID, "error", err)
+	}
+
+	if _, err := b.session.ChannelMessageSendEmbed(player.ChannelID, embed); err != nil {
+		slog.WarnContext(ctx, "Sending tilt alert failed", "player", player.Name, "channel_id", player.ChannelID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Posted tilt alert", "player", player.Name, "channel_id", player.ChannelID)
+}
+
+// buildTiltEmbed creates the nudge for a tilted player.
+func buildTiltEmbed(player *storage.TrackedPlayer, signs []tiltSign) *discordgo.MessageEmbed {
+	reasons := make([]string, len(signs))
+	for n, sign := range signs {
+		reasons[n] = "• " + sign.Text
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "🌱 ĐI CHẠM CỎ ĐI",
+		Description: fmt.Sprintf(tiltNudges[signs[0].Kind], "**"+player.Name+"**") + "\n\n" + strings.Join(reasons, "\n"),
+		Color:       embeds.ColorWarning,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Không muốn nhận nhắc nhở? Dùng /tilt alerts"},
+	}
+}
@@ -0,0 +1,151 @@
+package charts
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontStyle picks one of the bundled faces.
+type fontStyle int
+
+const (
+	fontLabel fontStyle = iota
+	fontTitle
+)
+
+// Bundled fonts, parsed once. Faces aren't safe for concurrent use, so
+// each canvas makes its own.
+var (
+	regularFont = mustParse(goregular.TTF)
+	boldFont    = mustParse(gobold.TTF)
+)
+
+func mustParse(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic("charts: parsing bundled font: " + err.Error())
+	}
+	return f
+}
+
+// canvas is an image with the drawing primitives the charts need. Lines
+// and shapes are drawn with integer math only, so output doesn't depend
+// on the platform.
+type canvas struct {
+	img   *image.RGBA
+	faces map[fontStyle]font.Face
+}
+
+func newCanvas(width, height int, bg color.RGBA) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	return &canvas{
+		img: img,
+		faces: map[fontStyle]font.Face{
+			fontLabel: mustFace(regularFont, 12),
+			fontTitle: mustFace(boldFont, 16),
+		},
+	}
+}
+
+func mustFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		panic("charts: creating font face: " + err.Error())
+	}
+	return face
+}
+
+// fill paints a rectangle.
+func (c *canvas) fill(r image.Rectangle, col color.RGBA) {
+	draw.Draw(c.img, r, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// hline draws a one pixel horizontal line from x0 to x1.
+func (c *canvas) hline(x0, x1, y int, col color.RGBA) {
+	c.fill(image.Rect(x0, y, x1, y+1), col)
+}
+
+// vline draws a one pixel vertical line from y0 to y1.
+func (c *canvas) vline(x, y0, y1 int, col color.RGBA) {
+	c.fill(image.Rect(x, y0, x+1, y1), col)
+}
+
+// line draws a two pixel wide line with Bresenham's algorithm.
+func (c *canvas) line(x0, y0, x1, y1 int, col color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.fill(image.Rect(x0, y0, x0+2, y0+2), col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// dot marks a point with a small filled circle.
+func (c *canvas) dot(x, y int, col color.RGBA) {
+	const r = 3
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			if dx*dx+dy*dy <= r*r+1 {
+				c.img.SetRGBA(x+dx+1, y+dy+1, col)
+			}
+		}
+	}
+}
+
+// text draws s with its baseline at y and returns its width.
+func (c *canvas) text(x, y int, s string, col color.RGBA, style fontStyle) int {
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: c.faces[style],
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+	return (d.Dot.X - fixed.I(x)).Ceil()
+}
+
+// measure returns the width of s in pixels.
+func (c *canvas) measure(s string, style fontStyle) int {
+	return font.MeasureString(c.faces[style], s).Ceil()
+}
+
+// mix blends col over bg at the given opacity percentage.
+func mix(col, bg color.RGBA, percent int) color.RGBA {
+	blend := func(a, b uint8) uint8 {
+		return uint8((int(a)*percent + int(b)*(100-percent)) / 100)
+	}
+	return color.RGBA{blend(col.R, bg.R), blend(col.G, bg.G), blend(col.B, bg.B), 0xff}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,226 @@
+// Package charts renders PNG charts for ZoeBot embeds. Everything is drawn
+// in pure Go with the bundled Go fonts, so the same input always gives the
+// same image. The Go fonts lack some Vietnamese letters, which show as
+// boxes.
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+)
+
+// Chart size in pixels.
+const (
+	Width  = 800
+	Height = 400
+)
+
+// Plot area margins.
+const (
+	marginLeft   = 90 // tier labels
+	marginRight  = 20
+	marginTop    = 50 // title and legend
+	marginBottom = 30 // dates
+)
+
+// Point is one LP reading. LP is the position on the whole ladder, as
+// returned by storage.RankSnapshot.TotalLP: 100 per division from Iron IV.
+type Point struct {
+	At time.Time
+	LP int
+}
+
+// Series is one player's line, oldest point first.
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// tierBand is the part of the ladder a tier covers.
+type tierBand struct {
+	Name  string
+	Floor int // LP of division IV, 0 LP
+	Color color.RGBA
+}
+
+// tierBands are the tiers from lowest to highest. Master and above share
+// one floor, so they are one band.
+var tierBands = []tierBand{
+	{"Iron", 0, color.RGBA{0x5e, 0x51, 0x4c, 0xff}},
+	{"Bronze", 400, color.RGBA{0x8c, 0x52, 0x3a, 0xff}},
+	{"Silver", 800, color.RGBA{0x80, 0x98, 0xa6, 0xff}},
+	{"Gold", 1200, color.RGBA{0xc8, 0x9b, 0x3c, 0xff}},
+	{"Platinum", 1600, color.RGBA{0x4e, 0x99, 0x96, 0xff}},
+	{"Emerald", 2000, color.RGBA{0x2a, 0xa1, 0x5c, 0xff}},
+	{"Diamond", 2400, color.RGBA{0x57, 0x6b, 0xd6, 0xff}},
+	{"Master+", 2800, color.RGBA{0x9d, 0x4d, 0xc4, 0xff}},
+}
+
+// palette colors the series in order.
+var palette = []color.RGBA{
+	{0xf1, 0xc4, 0x0f, 0xff},
+	{0x3d, 0xd6, 0xf5, 0xff},
+	{0xff, 0x6b, 0x6b, 0xff},
+	{0x7c, 0xf5, 0x7c, 0xff},
+	{0xff, 0x9f, 0x43, 0xff},
+	{0xe0, 0x7b, 0xf5, 0xff},
+	{0xff, 0xff, 0xff, 0xff},
+	{0x9e, 0xa7, 0xff, 0xff},
+}
+
+var (
+	background = color.RGBA{0x2b, 0x2d, 0x31, 0xff}
+	textColor  = color.RGBA{0xdb, 0xde, 0xe1, 0xff}
+	mutedColor = color.RGBA{0x94, 0x9b, 0xa4, 0xff}
+)
+
+// LP renders LP over time for one or more players, over bands showing
+// the tiers. Series without points are left out; it fails if none remain.
+func LP(title string, series []Series) ([]byte, error) {
+	var drawn []Series
+	for _, s := range series {
+		if len(s.Points) > 0 {
+			drawn = append(drawn, s)
+		}
+	}
+	if len(drawn) == 0 {
+		return nil, fmt.Errorf("no LP points to draw")
+	}
+	if len(drawn) > len(palette) {
+		drawn = drawn[:len(palette)]
+	}
+
+	c := newCanvas(Width, Height, background)
+	plot := image.Rect(marginLeft, marginTop, Width-marginRight, Height-marginBottom)
+	x, y := axes(drawn, plot)
+
+	drawBands(c, plot, y)
+	drawDates(c, plot, x)
+
+	for n, s := range drawn {
+		line := palette[n]
+		for k := 1; k < len(s.Points); k++ {
+			a, b := s.Points[k-1], s.Points[k]
+			c.line(x.pixel(a.At), y.pixel(a.LP), x.pixel(b.At), y.pixel(b.LP), line)
+		}
+		for _, p := range s.Points {
+			c.dot(x.pixel(p.At), y.pixel(p.LP), line)
+		}
+	}
+
+	c.text(marginLeft, 22, title, textColor, fontTitle)
+	legendX := marginLeft
+	for n, s := range drawn {
+		c.fill(image.Rect(legendX, 33, legendX+10, 43), palette[n])
+		legendX += 14 + c.text(legendX+14, 43, s.Name, textColor, fontLabel) + 16
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// timeAxis maps times onto the plot's x pixels.
+type timeAxis struct {
+	from, to time.Time
+	left     int
+	width    int
+}
+
+func (a timeAxis) pixel(t time.Time) int {
+	span := a.to.Sub(a.from)
+	return a.left + int(int64(a.width)*int64(t.Sub(a.from))/int64(span))
+}
+
+// lpAxis maps ladder LP onto the plot's y pixels.
+type lpAxis struct {
+	low, high int
+	bottom    int
+	height    int
+}
+
+func (a lpAxis) pixel(lp int) int {
+	return a.bottom - a.height*(lp-a.low)/(a.high-a.low)
+}
+
+// axes fits both axes to the points, with some room around them. The LP
+// axis always shows at least one division.
+func axes(series []Series, plot image.Rectangle) (timeAxis, lpAxis) {
+	first := series[0].Points[0]
+	from, to := first.At, first.At
+	low, high := first.LP, first.LP
+	for _, s := range series {
+		for _, p := range s.Points {
+			if p.At.Before(from) {
+				from = p.At
+			}
+			if p.At.After(to) {
+				to = p.At
+			}
+			low, high = min(low, p.LP), max(high, p.LP)
+		}
+	}
+	if !to.After(from) {
+		from, to = from.Add(-12*time.Hour), to.Add(12*time.Hour)
+	}
+	pad := to.Sub(from) / 40
+	from, to = from.Add(-pad), to.Add(pad)
+
+	low, high = max(low-50, 0), high+50
+	if high-low < 200 {
+		mid := (low + high) / 2
+		low, high = max(mid-100, 0), max(mid-100, 0)+200
+	}
+
+	return timeAxis{from: from, to: to, left: plot.Min.X, width: plot.Dx()},
+		lpAxis{low: low, high: high, bottom: plot.Max.Y, height: plot.Dy()}
+}
+
+// drawBands shades each tier in the plot and labels it, with thin lines
+// between divisions when they are far enough apart to read.
+func drawBands(c *canvas, plot image.Rectangle, y lpAxis) {
+	for n, band := range tierBands {
+		top := y.high
+		if n+1 < len(tierBands) {
+			top = min(top, tierBands[n+1].Floor)
+		}
+		floor := max(band.Floor, y.low)
+		if floor >= top {
+			continue
+		}
+		area := image.Rect(plot.Min.X, y.pixel(top), plot.Max.X, y.pixel(floor))
+		c.fill(area, mix(band.Color, background, 40))
+		c.fill(image.Rect(plot.Min.X, area.Min.Y, plot.Min.X+4, area.Max.Y), band.Color)
+
+		if area.Dy() >= 14 {
+			c.text(8, (area.Min.Y+area.Max.Y)/2+5, band.Name, band.Color, fontLabel)
+		}
+	}
+
+	if y.height*100/(y.high-y.low) >= 12 {
+		for lp := (y.low/100 + 1) * 100; lp < y.high; lp += 100 {
+			if lp < tierBands[len(tierBands)-1].Floor {
+				c.hline(plot.Min.X+4, plot.Max.X, y.pixel(lp), mix(textColor, background, 12))
+			}
+		}
+	}
+}
+
+// drawDates labels the time axis with up to five evenly spaced dates.
+func drawDates(c *canvas, plot image.Rectangle, x timeAxis) {
+	const ticks = 5
+	for n := range ticks {
+		at := x.from.Add(x.to.Sub(x.from) * time.Duration(n) / (ticks - 1))
+		label := at.UTC().Format("02/01")
+		px := x.pixel(at)
+		c.vline(px, plot.Max.Y, plot.Max.Y+4, mutedColor)
+		w := c.measure(label, fontLabel)
+		c.text(min(max(px-w/2, plot.Min.X), plot.Max.X-w), Height-8, label, mutedColor, fontLabel)
+	}
+}
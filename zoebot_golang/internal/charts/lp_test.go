@@ -0,0 +1,84 @@
+package charts
+
+import (
+	"bytes"
+	"flag"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+func TestLP(t *testing.T) {
+	start := time.Date(2026, 9, 1, 18, 0, 0, 0, time.UTC)
+	climb := func(lps ...int) []Point {
+		points := make([]Point, len(lps))
+		for n, lp := range lps {
+			points[n] = Point{At: start.Add(time.Duration(n) * 20 * time.Hour), LP: lp}
+		}
+		return points
+	}
+
+	tests := []struct {
+		name   string
+		title  string
+		series []Series
+	}{
+		{
+			name:   "single",
+			title:  "LP - Zoe#VN2",
+			series: []Series{{Name: "Zoe#VN2", Points: climb(1560, 1581, 1602, 1584, 1605, 1627, 1649, 1630, 1652)}},
+		},
+		{
+			name:  "several",
+			title: "LP - #general",
+			series: []Series{
+				{Name: "Zoe#VN2", Points: climb(1560, 1581, 1602, 1584, 1605, 1627)},
+				{Name: "Faker#KR1", Points: climb(2890, 2912, 2935, 2918)},
+				{Name: "Gà#VN2", Points: climb(780, 762, 741, 760, 738)},
+				{Name: "Empty#VN2"},
+			},
+		},
+		{
+			name:   "one_point",
+			title:  "LP - Zoe#VN2",
+			series: []Series{{Name: "Zoe#VN2", Points: climb(1210)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LP(tt.title, tt.series)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(bytes.NewReader(got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+				t.Errorf("size = %v, want %dx%d", b, Width, Height)
+			}
+
+			golden := filepath.Join("testdata", "lp_"+tt.name+".png")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("chart differs from %s; run with -update and check the image if the change is intended", golden)
+			}
+		})
+	}
+
+	if _, err := LP("LP", []Series{{Name: "Empty#VN2"}}); err == nil {
+		t.Error("LP drew a chart without points")
+	}
+}
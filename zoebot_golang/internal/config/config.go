@@ -1,163 +1,440 @@
 // Package config provides configuration management for ZoeBot.
+//
+// Settings are layered: built-in defaults, then an optional YAML config
+// file, then environment variables (which always win, so existing .env
+// deployments keep working).
 package config
 
 import (
-	"encoding/json"
+	"bytes"
 	"errors"
-	"log"
-	"net/http"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// DefaultFile is the config file looked up in the working directory when
+// no path is given.
+const DefaultFile = "zoebot.yaml"
+
 // Config holds all configuration values for the application.
 type Config struct {
-	// Discord
-	DiscordToken string
+	Discord DiscordConfig `yaml:"discord"`
+	Riot    RiotConfig    `yaml:"riot"`
+	AI      AIConfig      `yaml:"ai"`
+	Redis   RedisConfig   `yaml:"redis"`
+	Storage StorageConfig `yaml:"storage"`
+	Poll    PollConfig    `yaml:"poll"`
+	Cache   CacheConfig   `yaml:"cache"`
+	History HistoryConfig `yaml:"history"`
+	Session SessionConfig `yaml:"session"`
+	Recap   RecapConfig   `yaml:"recap"`
+	Tilt    TiltConfig    `yaml:"tilt"`
+	Health  HealthConfig  `yaml:"health"`
+	Admin   AdminConfig   `yaml:"admin"`
+	Logging LoggingConfig `yaml:"logging"`
+	Data    DataConfig    `yaml:"data"`
+
+	// path is the config file this was loaded from ("" if none).
+	path string
+}
 
-	// Riot API
-	RiotAPIKey          string
-	RiotBaseURLAccount  string
-	RiotBaseURLMatch    string
-	RiotBaseURLPlatform string // For summoner/league APIs (vn2.api.riotgames.com)
+// DiscordConfig configures the Discord gateway connection.
+type DiscordConfig struct {
+	Token string `yaml:"token"`
+}
 
-	// AI / LLM API
-	AIAPIKey string
-	AIAPIURL string
-	AIModel  string
+// RiotConfig configures the Riot API client.
+type RiotConfig struct {
+	APIKey          string        `yaml:"api_key"`
+	BaseURLAccount  string        `yaml:"base_url_account"`  // account-v1 (asia.api.riotgames.com)
+	BaseURLMatch    string        `yaml:"base_url_match"`    // match-v5 (sea.api.riotgames.com)
+	BaseURLPlatform string        `yaml:"base_url_platform"` // summoner/league (vn2.api.riotgames.com)
+	Timeout         time.Duration `yaml:"timeout"`
+}
 
-	// Redis
-	RedisURL               string
-	RedisKeyTrackedPlayers string
+// AIConfig configures the AI / LLM API client.
+type AIConfig struct {
+	APIKey          string        `yaml:"api_key"`
+	APIURL          string        `yaml:"api_url"`
+	Model           string        `yaml:"model"`
+	Timeout         time.Duration `yaml:"timeout"`
+	Temperature     float64       `yaml:"temperature"`      // match analysis
+	MaxTokens       int           `yaml:"max_tokens"`       // match analysis
+	ChatTemperature float64       `yaml:"chat_temperature"` // reply chat
+	ChatMaxTokens   int           `yaml:"chat_max_tokens"`  // reply chat
+}
 
-	// Data Dragon
-	DDragonVersion         string
-	DDragonChampionIconURL string
+// RedisConfig configures the Redis storage backend.
+type RedisConfig struct {
+	URL               string `yaml:"url"`
+	KeyPlayers        string `yaml:"key_players"`         // player set; each player is a hash at <key>:<puuid>
+	KeyTrackedPlayers string `yaml:"key_tracked_players"` // legacy JSON blob, imported once on startup
+	KeyHistory        string `yaml:"key_history"`         // prefix of the match history collections
+	KeyGuilds         string `yaml:"key_guilds"`          // per-guild settings, one record per guild
+}
 
-	// Paths
-	DataDir string
+// StorageConfig selects where tracked players, caches and contexts are kept.
+type StorageConfig struct {
+	Backend string `yaml:"backend"` // auto, redis, bolt, memory; auto: redis if redis.url is set, else bolt
+	Path    string `yaml:"path"`    // bolt database file; defaults to <data.dir>/zoebot.db
 }
 
-// Load reads configuration from environment variables.
-func Load() (*Config, error) {
-	// Try to load .env file (ignore error if not exists)
-	_ = godotenv.Load()
+// PollConfig configures the new match poll loop.
+type PollConfig struct {
+	Interval        time.Duration `yaml:"interval"`         // time between polls
+	Timeout         time.Duration `yaml:"timeout"`          // cap on a single poll
+	Concurrency     int           `yaml:"concurrency"`      // players checked in parallel
+	RequestInterval time.Duration `yaml:"request_interval"` // spacing between player checks
+}
+
+// CacheConfig holds cache TTLs per key family. Zero means no expiry.
+type CacheConfig struct {
+	PUUID    time.Duration `yaml:"puuid"`
+	Summoner time.Duration `yaml:"summoner"`
+	League   time.Duration `yaml:"league"`
+	Mastery  time.Duration `yaml:"mastery"`
+	Counter  time.Duration `yaml:"counter"`
+	Build    time.Duration `yaml:"build"`
+	Analysis time.Duration `yaml:"analysis"` // analyses behind the detail buttons
+	Context  time.Duration `yaml:"context"`  // reply chat contexts
+	Match    time.Duration `yaml:"match"`    // raw match details from Riot
+	Pages    time.Duration `yaml:"pages"`    // state behind paginated messages
+}
 
-	// Fetch latest Data Dragon version dynamically (fallback to env/default)
-	ddragonVersion := getEnvOrDefault("DDRAGON_VERSION", "")
-	if ddragonVersion == "" {
-		ddragonVersion = fetchLatestDDragonVersion()
+// TTLs returns the TTL of each cache family, keyed by family name.
+func (c CacheConfig) TTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		"puuid":    c.PUUID,
+		"summoner": c.Summoner,
+		"league":   c.League,
+		"mastery":  c.Mastery,
+		"counter":  c.Counter,
+		"build":    c.Build,
+		"analysis": c.Analysis,
+		"context":  c.Context,
+		"match":    c.Match,
+		"pages":    c.Pages,
 	}
+}
+
+// HistoryConfig limits the stored match history and LP snapshots. Older
+// entries are pruned as new ones are recorded.
+type HistoryConfig struct {
+	MaxMatches int           `yaml:"max_matches"` // kept per player
+	Retention  time.Duration `yaml:"retention"`   // matches older than this are dropped; 0 keeps them
+}
 
-	cfg := &Config{
-		// Discord
-		DiscordToken: os.Getenv("DISCORD_TOKEN"),
+// SessionConfig configures gaming sessions: games less than Gap apart are
+// one session, summed up once the player has been idle for Gap.
+type SessionConfig struct {
+	Gap time.Duration `yaml:"gap"`
+}
 
-		// Riot API
-		RiotAPIKey:          os.Getenv("RIOT_API_KEY"),
-		RiotBaseURLAccount:  getEnvOrDefault("RIOT_BASE_URL_ACCOUNT", "https://asia.api.riotgames.com"),
-		RiotBaseURLMatch:    getEnvOrDefault("RIOT_BASE_URL_MATCH", "https://sea.api.riotgames.com"),
-		RiotBaseURLPlatform: getEnvOrDefault("RIOT_BASE_URL_PLATFORM", "https://vn2.api.riotgames.com"),
+// RecapConfig sets the default schedule of the channel recaps. Guilds can
+// override both with /recap.
+type RecapConfig struct {
+	Schedule string `yaml:"schedule"` // cron expression; "off" disables recaps by default
+	Timezone string `yaml:"timezone"` // IANA name the schedule and late night tilt alerts are read in
+}
 
-		// AI / LLM API
-		AIAPIKey: os.Getenv("CLIPROXY_API_KEY"),
-		AIAPIURL: os.Getenv("CLIPROXY_API_URL"),
-		AIModel:  os.Getenv("CLIPROXY_MODEL"),
+// TiltConfig configures the alerts sent to players who look tilted.
+type TiltConfig struct {
+	Cooldown time.Duration `yaml:"cooldown"` // least time between two alerts for a player
+}
 
-		// Redis
-		RedisURL:               os.Getenv("REDIS_URL"),
-		RedisKeyTrackedPlayers: getEnvOrDefault("REDIS_KEY_TRACKED_PLAYERS", "zoebot:tracked_players"),
+// RecapOff is the recap schedule that turns recaps off.
+const RecapOff = "off"
 
-		// Data Dragon
-		DDragonVersion:         ddragonVersion,
-		DDragonChampionIconURL: "", // Will be set below
+// HealthConfig configures the healthcheck / metrics / admin HTTP server.
+type HealthConfig struct {
+	Addr string `yaml:"addr"`
+}
 
-		// Paths
-		DataDir: getEnvOrDefault("DATA_DIR", "data"),
-	}
+// AdminConfig configures operator access.
+type AdminConfig struct {
+	Token    string   `yaml:"token"`     // admin API bearer token (disabled when empty)
+	OwnerIDs []string `yaml:"owner_ids"` // Discord user IDs allowed to use /admin
+}
 
-	// Build champion icon URL template
-	cfg.DDragonChampionIconURL = "https://ddragon.leagueoflegends.com/cdn/" + cfg.DDragonVersion + "/img/champion/%s.png"
+// LoggingConfig configures log output.
+type LoggingConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn, error
+	Format string `yaml:"format"` // text, json
+}
 
-	log.Printf("Using Data Dragon version: %s", cfg.DDragonVersion)
+// DataConfig configures static game data.
+type DataConfig struct {
+	Dir            string `yaml:"dir"`
+	PromptsDir     string `yaml:"prompts_dir"`     // optional prompt overrides (system.txt, chat.txt)
+	DDragonVersion string `yaml:"ddragon_version"` // pin a synced dataset; empty: the active one
+}
 
-	return cfg, nil
+// Defaults returns the built-in configuration.
+func Defaults() *Config {
+	return &Config{
+		Riot: RiotConfig{
+			BaseURLAccount:  "https://asia.api.riotgames.com",
+			BaseURLMatch:    "https://sea.api.riotgames.com",
+			BaseURLPlatform: "https://vn2.api.riotgames.com",
+			Timeout:         15 * time.Second,
+		},
+		AI: AIConfig{
+			Timeout:         90 * time.Second,
+			Temperature:     0.7,
+			MaxTokens:       20000,
+			ChatTemperature: 0.8,
+			ChatMaxTokens:   500,
+		},
+		Redis: RedisConfig{
+			KeyPlayers:        "zoebot:players",
+			KeyTrackedPlayers: "zoebot:tracked_players",
+			KeyHistory:        "zoebot:history",
+			KeyGuilds:         "zoebot:guilds",
+		},
+		Storage: StorageConfig{
+			Backend: "auto",
+		},
+		Poll: PollConfig{
+			Interval:        1 * time.Minute,
+			Timeout:         55 * time.Second,
+			Concurrency:     5,
+			RequestInterval: 100 * time.Millisecond, // 10 req/sec, well under Riot's 20/sec
+		},
+		Cache: CacheConfig{
+			PUUID:    0, // Riot IDs rarely change hands
+			Summoner: 1 * time.Hour,
+			League:   10 * time.Minute,
+			Mastery:  1 * time.Hour,
+			Counter:  24 * time.Hour,
+			Build:    10 * time.Minute,
+			Analysis: 24 * time.Hour,
+			Context:  24 * time.Hour,
+			Match:    24 * time.Hour, // finished matches never change
+			Pages:    1 * time.Hour,
+		},
+		History: HistoryConfig{
+			MaxMatches: 500,
+			Retention:  180 * 24 * time.Hour,
+		},
+		Session: SessionConfig{
+			Gap: 45 * time.Minute,
+		},
+		Recap: RecapConfig{
+			Schedule: "0 20 * * 0", // Sunday evening
+			Timezone: "Asia/Ho_Chi_Minh",
+		},
+		Tilt: TiltConfig{
+			Cooldown: 3 * time.Hour,
+		},
+		Health: HealthConfig{
+			Addr: ":8080",
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Data: DataConfig{
+			Dir: "data",
+		},
+	}
 }
 
-// Validate checks if all required configuration values are set.
-func (c *Config) Validate() error {
-	var errs []string
+// Load builds the configuration from defaults, the config file at path and
+// environment variables. With an empty path, CONFIG_FILE or DefaultFile is
+// used if it exists. An explicitly given file must exist.
+func Load(path string) (*Config, error) {
+	// Try to load .env file (ignore error if not exists)
+	_ = godotenv.Load()
+
+	cfg := Defaults()
 
-	if c.DiscordToken == "" {
-		errs = append(errs, "DISCORD_TOKEN is missing")
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("CONFIG_FILE")
+		explicit = path != ""
+	}
+	if path == "" {
+		path = DefaultFile
 	}
 
-	if c.RiotAPIKey == "" {
-		errs = append(errs, "RIOT_API_KEY is missing")
+	if err := cfg.loadFile(path); err != nil {
+		if explicit || !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	} else {
+		cfg.path = path
 	}
 
-	if c.AIAPIKey == "" {
-		errs = append(errs, "CLIPROXY_API_KEY is missing")
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
 	}
 
-	if len(errs) > 0 {
-		log.Println("Config errors:")
-		for _, e := range errs {
-			log.Printf("  - %s", e)
-		}
-		return errors.New("configuration validation failed")
+	cfg.Data.Dir = resolveDataDir(cfg.Data.Dir)
+	if cfg.Data.PromptsDir == "" {
+		cfg.Data.PromptsDir = filepath.Join(cfg.Data.Dir, "prompts")
+	}
+	if cfg.Storage.Path == "" {
+		cfg.Storage.Path = filepath.Join(cfg.Data.Dir, "zoebot.db")
 	}
 
-	return nil
+	return cfg, nil
 }
 
-// ChampionDataPath returns the full path to champion.json
-func (c *Config) ChampionDataPath() string {
-	return filepath.Join(c.DataDir, "champion.json")
-}
+// loadFile overlays the YAML file at path onto c.
+func (c *Config) loadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
 
-// getEnvOrDefault returns the environment variable value or a default.
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
 	}
-	return defaultValue
+	return nil
 }
 
-// fetchLatestDDragonVersion fetches the latest Data Dragon version from Riot API.
-// Returns a fallback version if the fetch fails.
-func fetchLatestDDragonVersion() string {
-	const (
-		versionsURL     = "https://ddragon.leagueoflegends.com/api/versions.json"
-		fallbackVersion = "15.1.1"
-	)
+// Path returns the config file in use, or "" when running on env only.
+func (c *Config) Path() string {
+	return c.path
+}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(versionsURL)
-	if err != nil {
-		log.Printf("Failed to fetch DDragon versions: %v, using fallback %s", err, fallbackVersion)
-		return fallbackVersion
+// applyEnv overlays environment variables onto c.
+func (c *Config) applyEnv() error {
+	var errs []error
+	str := func(dst *string, key string) {
+		if v := os.Getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	dur := func(dst *time.Duration, key string) {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid duration %q", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+	num := func(dst *int, key string) {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid number %q", key, v))
+				return
+			}
+			*dst = n
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("DDragon API returned %d, using fallback %s", resp.StatusCode, fallbackVersion)
-		return fallbackVersion
+	// Discord
+	str(&c.Discord.Token, "DISCORD_TOKEN")
+
+	// Riot API
+	str(&c.Riot.APIKey, "RIOT_API_KEY")
+	str(&c.Riot.BaseURLAccount, "RIOT_BASE_URL_ACCOUNT")
+	str(&c.Riot.BaseURLMatch, "RIOT_BASE_URL_MATCH")
+	str(&c.Riot.BaseURLPlatform, "RIOT_BASE_URL_PLATFORM")
+
+	// AI / LLM API
+	str(&c.AI.APIKey, "CLIPROXY_API_KEY")
+	str(&c.AI.APIURL, "CLIPROXY_API_URL")
+	str(&c.AI.Model, "CLIPROXY_MODEL")
+
+	// Redis
+	str(&c.Redis.URL, "REDIS_URL")
+	str(&c.Redis.KeyPlayers, "REDIS_KEY_PLAYERS")
+	str(&c.Redis.KeyTrackedPlayers, "REDIS_KEY_TRACKED_PLAYERS")
+	str(&c.Redis.KeyHistory, "REDIS_KEY_HISTORY")
+	str(&c.Redis.KeyGuilds, "REDIS_KEY_GUILDS")
+
+	// Storage
+	str(&c.Storage.Backend, "STORAGE_BACKEND")
+	str(&c.Storage.Path, "STORAGE_PATH")
+
+	// Poll
+	dur(&c.Poll.Interval, "POLL_INTERVAL")
+	num(&c.Poll.Concurrency, "POLL_CONCURRENCY")
+
+	// History
+	num(&c.History.MaxMatches, "HISTORY_MAX_MATCHES")
+	dur(&c.History.Retention, "HISTORY_RETENTION")
+
+	// Sessions
+	dur(&c.Session.Gap, "SESSION_GAP")
+
+	// Recaps
+	str(&c.Recap.Schedule, "RECAP_SCHEDULE")
+	str(&c.Recap.Timezone, "RECAP_TIMEZONE")
+
+	// Tilt alerts
+	dur(&c.Tilt.Cooldown, "TILT_COOLDOWN")
+
+	// Healthcheck server
+	str(&c.Health.Addr, "HEALTH_ADDR")
+
+	// Admin
+	str(&c.Admin.Token, "ADMIN_TOKEN")
+	if v := os.Getenv("OWNER_IDS"); v != "" {
+		c.Admin.OwnerIDs = splitList(v)
 	}
 
-	var versions []string
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-		log.Printf("Failed to parse DDragon versions: %v, using fallback %s", err, fallbackVersion)
-		return fallbackVersion
+	// Logging
+	str(&c.Logging.Level, "LOG_LEVEL")
+	str(&c.Logging.Format, "LOG_FORMAT")
+
+	// Data
+	str(&c.Data.Dir, "DATA_DIR")
+	str(&c.Data.PromptsDir, "PROMPTS_DIR")
+	str(&c.Data.DDragonVersion, "DDRAGON_VERSION")
+
+	return errors.Join(errs...)
+}
+
+// resolveDataDir prefers the data directory next to the executable (as
+// shipped in the Docker image) and falls back to the configured one.
+func resolveDataDir(configured string) string {
+	execPath, err := os.Executable()
+	if err != nil {
+		return configured
 	}
+	dir := filepath.Join(filepath.Dir(execPath), "data")
+	// Either the checked-in snapshot or a synced dataset
+	for _, marker := range []string{"item.json", "current"} {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return dir
+		}
+	}
+	return configured
+}
 
-	if len(versions) == 0 {
-		log.Printf("DDragon versions list is empty, using fallback %s", fallbackVersion)
-		return fallbackVersion
+// IsOwner reports whether the Discord user is a configured bot owner.
+func (c *Config) IsOwner(userID string) bool {
+	for _, id := range c.Admin.OwnerIDs {
+		if id == userID {
+			return true
+		}
 	}
+	return false
+}
 
-	return versions[0] // First version is the latest
+// splitList splits a comma-separated value, dropping empty entries.
+func splitList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
 }
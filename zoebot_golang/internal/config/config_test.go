@@ -0,0 +1,346 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envKeys are the variables Load reads, cleared for each test so the
+// environment running the tests can't leak in.
+var envKeys = []string{
+	"CONFIG_FILE", "DISCORD_TOKEN", "RIOT_API_KEY", "CLIPROXY_API_KEY", "CLIPROXY_API_URL", "CLIPROXY_MODEL",
+	"REDIS_URL", "STORAGE_BACKEND", "STORAGE_PATH", "POLL_INTERVAL", "POLL_CONCURRENCY",
+	"HISTORY_MAX_MATCHES", "HISTORY_RETENTION", "SESSION_GAP", "RECAP_SCHEDULE", "RECAP_TIMEZONE",
+	"TILT_COOLDOWN", "HEALTH_ADDR", "ADMIN_TOKEN", "OWNER_IDS", "LOG_LEVEL", "LOG_FORMAT",
+	"DATA_DIR", "PROMPTS_DIR", "DDRAGON_VERSION",
+}
+
+// cleanEnv runs the test in an empty directory with no config variables set.
+func cleanEnv(t *testing.T) string {
+	t.Helper()
+	for _, key := range envKeys {
+		t.Setenv(key, "")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // zoebot.yaml in the working directory, if set
+		env     map[string]string
+		check   func(t *testing.T, c *Config)
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, c *Config) {
+				if c.Path() != "" || c.Poll.Interval != time.Minute || c.Health.Addr != ":8080" || c.Recap.Schedule != "0 20 * * 0" {
+					t.Errorf("defaults not applied: %+v", c)
+				}
+				if c.Storage.Path != filepath.Join("data", "zoebot.db") || c.Data.PromptsDir != filepath.Join("data", "prompts") {
+					t.Errorf("derived paths = %q, %q", c.Storage.Path, c.Data.PromptsDir)
+				}
+			},
+		},
+		{
+			name: "file over defaults",
+			file: "poll:\n  interval: 2m\nhistory:\n  retention: 720h\ndata:\n  dir: /srv/zoebot\n",
+			check: func(t *testing.T, c *Config) {
+				if c.Path() != DefaultFile || c.Poll.Interval != 2*time.Minute || c.History.Retention != 720*time.Hour {
+					t.Errorf("file not applied: path %q, poll %s, retention %s", c.Path(), c.Poll.Interval, c.History.Retention)
+				}
+				if c.Poll.Concurrency != 5 || c.History.MaxMatches != 500 {
+					t.Errorf("settings missing from the file lost their defaults: %+v %+v", c.Poll, c.History)
+				}
+				if c.Storage.Path != filepath.Join("/srv/zoebot", "zoebot.db") {
+					t.Errorf("storage.path = %q, want it under data.dir", c.Storage.Path)
+				}
+			},
+		},
+		{
+			name: "env over file",
+			file: "poll:\n  interval: 2m\n  concurrency: 3\nadmin:\n  owner_ids: [\"1\"]\n",
+			env:  map[string]string{"POLL_INTERVAL": "3m", "OWNER_IDS": "10, 20,,30", "STORAGE_PATH": "/tmp/z.db"},
+			check: func(t *testing.T, c *Config) {
+				if c.Poll.Interval != 3*time.Minute || c.Poll.Concurrency != 3 {
+					t.Errorf("poll = %+v, want interval from env and concurrency from file", c.Poll)
+				}
+				if !slices.Equal(c.Admin.OwnerIDs, []string{"10", "20", "30"}) {
+					t.Errorf("owner ids = %q", c.Admin.OwnerIDs)
+				}
+				if c.Storage.Path != "/tmp/z.db" {
+					t.Errorf("storage.path = %q", c.Storage.Path)
+				}
+			},
+		},
+		{
+			name: "CONFIG_FILE",
+			env:  map[string]string{"CONFIG_FILE": "other.yaml"},
+			// other.yaml is written by the test below
+			check: func(t *testing.T, c *Config) {
+				if c.Path() != "other.yaml" || c.Tilt.Cooldown != time.Hour {
+					t.Errorf("path %q, tilt %s", c.Path(), c.Tilt.Cooldown)
+				}
+			},
+		},
+		{
+			name:    "unknown field",
+			file:    "poll:\n  intervall: 2m\n",
+			wantErr: "field intervall not found",
+		},
+		{
+			name:    "wrong type",
+			file:    "poll:\n  concurrency: many\n",
+			wantErr: "config file zoebot.yaml",
+		},
+		{
+			name:    "explicit file missing",
+			env:     map[string]string{"CONFIG_FILE": "missing.yaml"},
+			wantErr: "missing.yaml",
+		},
+		{
+			name:    "invalid env values",
+			env:     map[string]string{"POLL_INTERVAL": "soon", "HISTORY_MAX_MATCHES": "lots"},
+			wantErr: "POLL_INTERVAL: invalid duration \"soon\"\nHISTORY_MAX_MATCHES: invalid number \"lots\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := cleanEnv(t)
+			if tt.file != "" {
+				writeFile(t, filepath.Join(dir, DefaultFile), tt.file)
+			}
+			writeFile(t, filepath.Join(dir, "other.yaml"), "tilt:\n  cooldown: 1h\n")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			c, err := Load("")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+// validConfig returns defaults with the required credentials set.
+func validConfig() *Config {
+	c := Defaults()
+	c.Discord.Token = "discord"
+	c.Riot.APIKey = "riot"
+	c.AI.APIKey = "ai"
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("defaults with credentials: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"discord token", func(c *Config) { c.Discord.Token = " " }, "discord.token (DISCORD_TOKEN) is missing"},
+		{"riot key", func(c *Config) { c.Riot.APIKey = "" }, "riot.api_key (RIOT_API_KEY) is missing"},
+		{"ai key", func(c *Config) { c.AI.APIKey = "" }, "ai.api_key (CLIPROXY_API_KEY) is missing"},
+		{"riot url", func(c *Config) { c.Riot.BaseURLMatch = "sea.api.riotgames.com" }, "riot.base_url_match must be an http(s) URL"},
+		{"riot timeout", func(c *Config) { c.Riot.Timeout = 2 * time.Minute }, "riot.timeout must be between 1s and 1m0s"},
+		{"ai url", func(c *Config) { c.AI.APIURL = "ftp://ai" }, "ai.api_url must be an http(s) URL"},
+		{"ai timeout", func(c *Config) { c.AI.Timeout = time.Second }, "ai.timeout must be between"},
+		{"ai temperature", func(c *Config) { c.AI.Temperature = 2.5 }, "ai.temperature must be between 0 and 2, got 2.5"},
+		{"ai max tokens", func(c *Config) { c.AI.MaxTokens = 0 }, "ai.max_tokens must be between 1 and 100000"},
+		{"chat temperature", func(c *Config) { c.AI.ChatTemperature = -1 }, "ai.chat_temperature must be between"},
+		{"chat max tokens", func(c *Config) { c.AI.ChatMaxTokens = 5000 }, "ai.chat_max_tokens must be between 1 and 4000"},
+		{"redis url", func(c *Config) { c.Redis.URL = "http://localhost:6379" }, "redis.url must be a redis:// or rediss:// URL"},
+		{"redis key", func(c *Config) { c.Redis.KeyHistory = "" }, "redis.key_history is missing"},
+		{"redis players keys", func(c *Config) { c.Redis.KeyTrackedPlayers = c.Redis.KeyPlayers }, "redis.key_players must differ from redis.key_tracked_players"},
+		{"redis history key", func(c *Config) { c.Redis.KeyHistory = c.Redis.KeyPlayers }, "redis.key_history must differ"},
+		{"redis guilds key", func(c *Config) { c.Redis.KeyGuilds = c.Redis.KeyHistory }, "redis.key_guilds must differ"},
+		{"storage backend", func(c *Config) { c.Storage.Backend = "sqlite" }, "storage.backend (STORAGE_BACKEND) must be one of auto, redis, bolt, memory"},
+		{"redis backend without url", func(c *Config) { c.Storage.Backend = "redis" }, "storage.backend redis needs redis.url"},
+		{"poll interval", func(c *Config) { c.Poll.Interval = 10 * time.Second; c.Poll.Timeout = 5 * time.Second }, "poll.interval must be between 30s and 1h0m0s"},
+		{"poll timeout", func(c *Config) { c.Poll.Timeout = c.Poll.Interval }, "poll.timeout must be positive and shorter than poll.interval"},
+		{"poll concurrency", func(c *Config) { c.Poll.Concurrency = 21 }, "poll.concurrency must be between 1 and 20"},
+		{"request interval", func(c *Config) { c.Poll.RequestInterval = time.Millisecond }, "poll.request_interval must be between"},
+		{"cache ttl", func(c *Config) { c.Cache.League = -time.Minute }, "cache.league must not be negative"},
+		{"history matches", func(c *Config) { c.History.MaxMatches = 10 }, "history.max_matches (HISTORY_MAX_MATCHES) must be between 20 and 5000"},
+		{"history retention", func(c *Config) { c.History.Retention = 24 * time.Hour }, "history.retention (HISTORY_RETENTION) must be 0 or at least 168h"},
+		{"session gap", func(c *Config) { c.Session.Gap = 5 * time.Minute }, "session.gap (SESSION_GAP) must be between"},
+		{"recap schedule", func(c *Config) { c.Recap.Schedule = "every sunday" }, "recap.schedule (RECAP_SCHEDULE) must be a cron expression"},
+		{"recap timezone", func(c *Config) { c.Recap.Timezone = "Mars/Olympus" }, "recap.timezone (RECAP_TIMEZONE) must be an IANA time zone"},
+		{"empty recap timezone", func(c *Config) { c.Recap.Timezone = "" }, "recap.timezone (RECAP_TIMEZONE) must be an IANA time zone"},
+		{"tilt cooldown", func(c *Config) { c.Tilt.Cooldown = time.Minute }, "tilt.cooldown (TILT_COOLDOWN) must be between"},
+		{"health addr", func(c *Config) { c.Health.Addr = "8080" }, "health.addr must be host:port"},
+		{"health port", func(c *Config) { c.Health.Addr = ":70000" }, "health.addr port must be 1-65535"},
+		{"admin token", func(c *Config) { c.Admin.Token = "short" }, "admin.token (ADMIN_TOKEN) must be at least 16 characters"},
+		{"owner ids", func(c *Config) { c.Admin.OwnerIDs = []string{"123", "@zoe"} }, "admin.owner_ids must be Discord user IDs, got \"@zoe\""},
+		{"log level", func(c *Config) { c.Logging.Level = "verbose" }, "logging.level (LOG_LEVEL) must be one of debug, info, warn, error"},
+		{"log format", func(c *Config) { c.Logging.Format = "xml" }, "logging.format (LOG_FORMAT) must be one of text, json"},
+		{"data dir", func(c *Config) { c.Data.Dir = "" }, "data.dir (DATA_DIR) is missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+			var verr *ValidationError
+			if err := c.Validate(); !errors.As(err, &verr) {
+				t.Fatalf("err = %v, want a ValidationError", err)
+			}
+			if len(verr.Problems) != 1 || !strings.Contains(verr.Problems[0], tt.want) {
+				t.Errorf("problems = %q, want one containing %q", verr.Problems, tt.want)
+			}
+		})
+	}
+
+	// Every problem is reported at once
+	c := validConfig()
+	c.Discord.Token = ""
+	c.Poll.Concurrency = 0
+	c.Logging.Level = "loud"
+	var verr *ValidationError
+	if err := c.Validate(); !errors.As(err, &verr) || len(verr.Problems) != 3 {
+		t.Fatalf("err = %v, want three problems", err)
+	}
+	if msg := verr.Error(); !strings.HasPrefix(msg, "invalid configuration: ") || strings.Count(msg, "; ") != 2 {
+		t.Errorf("Error() = %q", msg)
+	}
+}
+
+// leafSettings returns the yaml path of every setting in Config.
+func leafSettings(prefix string, t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == t.PkgPath() {
+			names = append(names, leafSettings(name, field.Type)...)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestSafeSettings(t *testing.T) {
+	leaves := leafSettings("", reflect.TypeOf(Config{}))
+	for name := range safeSettings {
+		if !slices.Contains(leaves, name) {
+			t.Errorf("safe setting %q is not a config setting", name)
+		}
+	}
+
+	// Credentials, endpoints, storage and listeners need a restart
+	for name, safe := range map[string]bool{
+		"discord.token":          false,
+		"riot.api_key":           false,
+		"riot.base_url_match":    false,
+		"ai.api_key":             false,
+		"ai.api_url":             false,
+		"ai.timeout":             false,
+		"redis.url":              false,
+		"redis.key_history":      false,
+		"storage.backend":        false,
+		"storage.path":           false,
+		"health.addr":            false,
+		"admin.token":            false,
+		"logging.format":         false,
+		"data.dir":               false,
+		"ai.model":               true,
+		"ai.temperature":         true,
+		"poll.interval":          true,
+		"poll.concurrency":       true,
+		"cache.league":           true,
+		"history.retention":      true,
+		"session.gap":            true,
+		"recap.schedule":         true,
+		"recap.timezone":         true,
+		"tilt.cooldown":          true,
+		"admin.owner_ids":        true,
+		"logging.level":          true,
+		"poll.request_interval":  true,
+		"ai.chat_max_tokens":     true,
+		"history.max_matches":    true,
+		"cache.pages":            true,
+		"riot.base_url_platform": false,
+	} {
+		if !slices.Contains(leaves, name) {
+			t.Errorf("%q is not a config setting", name)
+		}
+		if safeSettings[name] != safe {
+			t.Errorf("%q safe = %v, want %v", name, safeSettings[name], safe)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := cleanEnv(t)
+	path := filepath.Join(dir, "zoebot.yaml")
+	t.Setenv("DISCORD_TOKEN", "discord")
+	t.Setenv("RIOT_API_KEY", "riot")
+	t.Setenv("CLIPROXY_API_KEY", "ai")
+	writeFile(t, path, "poll:\n  interval: 2m\nhealth:\n  addr: \":8080\"\n")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, path, "poll:\n  interval: 5m\n  concurrency: 8\nhealth:\n  addr: \":9090\"\nadmin:\n  owner_ids: [\"42\"]\n")
+	t.Setenv("REDIS_URL", "redis://localhost:6379")
+	next, res, err := c.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"admin.owner_ids", "poll.concurrency", "poll.interval"}; !slices.Equal(res.Applied, want) {
+		t.Errorf("applied = %q, want %q", res.Applied, want)
+	}
+	if want := []string{"health.addr", "redis.url"}; !slices.Equal(res.Restart, want) {
+		t.Errorf("restart = %q, want %q", res.Restart, want)
+	}
+	if next.Poll.Interval != 5*time.Minute || next.Poll.Concurrency != 8 || !slices.Equal(next.Admin.OwnerIDs, []string{"42"}) {
+		t.Errorf("safe settings not applied: %+v %+v", next.Poll, next.Admin)
+	}
+	if next.Health.Addr != ":8080" || next.Redis.URL != "" {
+		t.Errorf("restart-only settings changed at runtime: %q %q", next.Health.Addr, next.Redis.URL)
+	}
+	if c.Poll.Interval != 2*time.Minute || next.Path() != path {
+		t.Errorf("reload modified the running config or lost its path")
+	}
+
+	// An invalid file leaves everything as it was
+	writeFile(t, path, "poll:\n  interval: 5s\n")
+	if next, res, err := c.Reload(); err == nil || next != nil || res != nil {
+		t.Errorf("invalid reload = %v, %v, %v; want only an error", next, res, err)
+	}
+}
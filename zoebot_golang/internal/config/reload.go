@@ -0,0 +1,98 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// safeSettings are the settings that can change at runtime. Everything
+// else (credentials, endpoints, the gateway, listeners) needs a restart.
+var safeSettings = map[string]bool{
+	"ai.model":              true,
+	"ai.temperature":        true,
+	"ai.max_tokens":         true,
+	"ai.chat_temperature":   true,
+	"ai.chat_max_tokens":    true,
+	"poll.interval":         true,
+	"poll.timeout":          true,
+	"poll.concurrency":      true,
+	"poll.request_interval": true,
+	"cache.puuid":           true,
+	"cache.summoner":        true,
+	"cache.league":          true,
+	"cache.mastery":         true,
+	"cache.counter":         true,
+	"cache.build":           true,
+	"cache.analysis":        true,
+	"cache.context":         true,
+	"cache.match":           true,
+	"cache.pages":           true,
+	"history.max_matches":   true,
+	"history.retention":     true,
+	"session.gap":           true,
+	"recap.schedule":        true,
+	"recap.timezone":        true,
+	"tilt.cooldown":         true,
+	"admin.owner_ids":       true,
+	"logging.level":         true,
+}
+
+// ReloadResult describes what a reload changed.
+type ReloadResult struct {
+	Applied []string `json:"applied"`           // safe settings now in effect
+	Restart []string `json:"restart,omitempty"` // changed settings that need a restart
+}
+
+// Reload loads the configuration again from the same file and environment
+// and validates it. The returned config equals c except for the safe
+// settings that changed; c itself is not modified. Nothing is returned
+// when the new configuration is invalid.
+func (c *Config) Reload() (*Config, *ReloadResult, error) {
+	next, err := Load(c.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	merged := *c
+	res := &ReloadResult{}
+	diffSettings("", reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next).Elem(), func(name string, dst, src reflect.Value) {
+		if safeSettings[name] {
+			dst.Set(src)
+			res.Applied = append(res.Applied, name)
+		} else {
+			res.Restart = append(res.Restart, name)
+		}
+	})
+	sort.Strings(res.Applied)
+	sort.Strings(res.Restart)
+	return &merged, res, nil
+}
+
+// diffSettings walks two configs and calls changed for every leaf setting
+// (named by its yaml path, e.g. "poll.interval") whose value differs.
+func diffSettings(prefix string, dst, src reflect.Value, changed func(name string, dst, src reflect.Value)) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		d, s := dst.Field(i), src.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == t.PkgPath() {
+			diffSettings(name, d, s, changed)
+			continue
+		}
+		if !reflect.DeepEqual(d.Interface(), s.Interface()) {
+			changed(name, d, s)
+		}
+	}
+}
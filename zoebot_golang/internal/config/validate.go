@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zoebot/internal/schedule"
+)
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that required values are set and every value is in range.
+// It returns a *ValidationError naming each offending setting.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	// Required credentials
+	v.required("discord.token (DISCORD_TOKEN)", c.Discord.Token)
+	v.required("riot.api_key (RIOT_API_KEY)", c.Riot.APIKey)
+	v.required("ai.api_key (CLIPROXY_API_KEY)", c.AI.APIKey)
+
+	// Riot API
+	v.httpURL("riot.base_url_account", c.Riot.BaseURLAccount)
+	v.httpURL("riot.base_url_match", c.Riot.BaseURLMatch)
+	v.httpURL("riot.base_url_platform", c.Riot.BaseURLPlatform)
+	v.between("riot.timeout", c.Riot.Timeout, time.Second, time.Minute)
+
+	// AI
+	if c.AI.APIURL != "" {
+		v.httpURL("ai.api_url", c.AI.APIURL)
+	}
+	v.between("ai.timeout", c.AI.Timeout, 5*time.Second, 5*time.Minute)
+	v.floatRange("ai.temperature", c.AI.Temperature, 0, 2)
+	v.intRange("ai.max_tokens", c.AI.MaxTokens, 1, 100000)
+	v.floatRange("ai.chat_temperature", c.AI.ChatTemperature, 0, 2)
+	v.intRange("ai.chat_max_tokens", c.AI.ChatMaxTokens, 1, 4000)
+
+	// Redis
+	if c.Redis.URL != "" {
+		if u, err := url.Parse(c.Redis.URL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+			v.add("redis.url must be a redis:// or rediss:// URL")
+		}
+	}
+	v.required("redis.key_players", c.Redis.KeyPlayers)
+	v.required("redis.key_tracked_players", c.Redis.KeyTrackedPlayers)
+	v.required("redis.key_history", c.Redis.KeyHistory)
+	v.required("redis.key_guilds", c.Redis.KeyGuilds)
+	if c.Redis.KeyPlayers == c.Redis.KeyTrackedPlayers {
+		v.add("redis.key_players must differ from redis.key_tracked_players")
+	}
+	if c.Redis.KeyHistory == c.Redis.KeyPlayers || c.Redis.KeyHistory == c.Redis.KeyTrackedPlayers {
+		v.add("redis.key_history must differ from redis.key_players and redis.key_tracked_players")
+	}
+	if c.Redis.KeyGuilds == c.Redis.KeyPlayers || c.Redis.KeyGuilds == c.Redis.KeyTrackedPlayers || c.Redis.KeyGuilds == c.Redis.KeyHistory {
+		v.add("redis.key_guilds must differ from the other redis keys")
+	}
+
+	// Storage
+	v.oneOf("storage.backend (STORAGE_BACKEND)", c.Storage.Backend, "auto", "redis", "bolt", "memory")
+	if c.Storage.Backend == "redis" && c.Redis.URL == "" {
+		v.add("storage.backend redis needs redis.url (REDIS_URL)")
+	}
+
+	// Poll
+	v.between("poll.interval", c.Poll.Interval, 30*time.Second, time.Hour)
+	if c.Poll.Timeout <= 0 || c.Poll.Timeout >= c.Poll.Interval {
+		v.add(fmt.Sprintf("poll.timeout must be positive and shorter than poll.interval (%s), got %s", c.Poll.Interval, c.Poll.Timeout))
+	}
+	v.intRange("poll.concurrency", c.Poll.Concurrency, 1, 20)
+	// Riot's personal key limit is 20 req/sec; each check makes at least one call
+	v.between("poll.request_interval", c.Poll.RequestInterval, 50*time.Millisecond, 10*time.Second)
+
+	// Cache TTLs
+	for family, ttl := range c.Cache.TTLs() {
+		if ttl < 0 {
+			v.add(fmt.Sprintf("cache.%s must not be negative, got %s", family, ttl))
+		}
+	}
+
+	// History
+	v.intRange("history.max_matches (HISTORY_MAX_MATCHES)", c.History.MaxMatches, 20, 5000)
+	if c.History.Retention != 0 && c.History.Retention < 7*24*time.Hour {
+		v.add(fmt.Sprintf("history.retention (HISTORY_RETENTION) must be 0 or at least 168h, got %s", c.History.Retention))
+	}
+
+	// Sessions
+	v.between("session.gap (SESSION_GAP)", c.Session.Gap, 10*time.Minute, 6*time.Hour)
+
+	// Recaps
+	if c.Recap.Schedule != RecapOff {
+		if _, err := schedule.Parse(c.Recap.Schedule); err != nil {
+			v.add(fmt.Sprintf("recap.schedule (RECAP_SCHEDULE) must be a cron expression or %q: %v", RecapOff, err))
+		}
+	}
+	if _, err := time.LoadLocation(c.Recap.Timezone); err != nil || c.Recap.Timezone == "" {
+		v.add(fmt.Sprintf("recap.timezone (RECAP_TIMEZONE) must be an IANA time zone (e.g. \"Asia/Ho_Chi_Minh\"), got %q", c.Recap.Timezone))
+	}
+
+	// Tilt alerts
+	v.between("tilt.cooldown (TILT_COOLDOWN)", c.Tilt.Cooldown, 30*time.Minute, 7*24*time.Hour)
+
+	// Healthcheck server
+	if _, port, err := net.SplitHostPort(c.Health.Addr); err != nil {
+		v.add(fmt.Sprintf("health.addr must be host:port (e.g. \":8080\"), got %q", c.Health.Addr))
+	} else if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		v.add(fmt.Sprintf("health.addr port must be 1-65535, got %q", port))
+	}
+
+	// Admin
+	if c.Admin.Token != "" && len(c.Admin.Token) < 16 {
+		v.add("admin.token (ADMIN_TOKEN) must be at least 16 characters")
+	}
+	for _, id := range c.Admin.OwnerIDs {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			v.add(fmt.Sprintf("admin.owner_ids must be Discord user IDs, got %q", id))
+		}
+	}
+
+	// Logging
+	v.oneOf("logging.level (LOG_LEVEL)", strings.ToLower(c.Logging.Level), "debug", "info", "warn", "error")
+	v.oneOf("logging.format (LOG_FORMAT)", strings.ToLower(c.Logging.Format), "text", "json")
+
+	// Data
+	v.required("data.dir (DATA_DIR)", c.Data.Dir)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validator collects validation problems.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(problem string) {
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) required(name, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(name + " is missing")
+	}
+}
+
+func (v *validator) httpURL(name, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(fmt.Sprintf("%s must be an http(s) URL, got %q", name, value))
+	}
+}
+
+func (v *validator) between(name string, d, min, max time.Duration) {
+	if d < min || d > max {
+		v.add(fmt.Sprintf("%s must be between %s and %s, got %s", name, min, max, d))
+	}
+}
+
+func (v *validator) intRange(name string, n, min, max int) {
+	if n < min || n > max {
+		v.add(fmt.Sprintf("%s must be between %d and %d, got %d", name, min, max, n))
+	}
+}
+
+func (v *validator) floatRange(name string, f, min, max float64) {
+	if f < min || f > max {
+		v.add(fmt.Sprintf("%s must be between %g and %g, got %g", name, min, max, f))
+	}
+}
+
+func (v *validator) oneOf(name, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(fmt.Sprintf("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value))
+}
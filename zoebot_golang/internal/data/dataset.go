@@ -0,0 +1,207 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dataset layout inside the data directory:
+//
+//	data/
+//	  current                 active version (written by `zoebot data use`)
+//	  versions/16.1.1/        one directory per synced patch
+//	    manifest.json
+//	    item.json ...
+//
+// Data files placed directly in the data directory (the checked-in
+// snapshot) are still used when nothing has been synced.
+const (
+	VersionsDir  = "versions"
+	CurrentFile  = "current"
+	ManifestName = "manifest.json"
+
+	// ChampionStatsFile is Data Dragon's champion.json (tags and stats).
+	ChampionStatsFile = "champion.json"
+
+	// FallbackVersion is used when no dataset records its version.
+	FallbackVersion = "16.1.1"
+)
+
+// ErrInvalidVersion is returned for malformed dataset versions.
+var ErrInvalidVersion = errors.New("invalid version")
+
+// Manifest describes a synced dataset.
+type Manifest struct {
+	Version  string                  `json:"version"` // Data Dragon version, e.g. 16.1.1
+	Locale   string                  `json:"locale"`
+	SyncedAt time.Time               `json:"synced_at"`
+	Files    map[string]ManifestFile `json:"files"`
+}
+
+// ManifestFile records where a data file came from.
+type ManifestFile struct {
+	Source string `json:"source"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Dataset is a directory of static data files for one patch.
+type Dataset struct {
+	Version  string    `json:"version"`
+	Dir      string    `json:"dir"`
+	Manifest *Manifest `json:"manifest,omitempty"` // nil for the checked-in snapshot
+}
+
+// ResolveDataset finds the dataset to use under root without touching the
+// network. A pinned version must have been synced; otherwise the version
+// named in the current file is used, then the snapshot in root itself.
+func ResolveDataset(root, pinned string) (Dataset, error) {
+	if pinned != "" {
+		if ds, err := OpenDataset(root, pinned); err == nil {
+			return ds, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return Dataset{}, err
+		}
+		// Not synced: keep the snapshot's files but report the pinned version
+		return Dataset{Version: pinned, Dir: root}, nil
+	}
+
+	if version, err := CurrentVersion(root); err == nil && version != "" {
+		return OpenDataset(root, version)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Dataset{}, err
+	}
+
+	return Dataset{Version: snapshotVersion(root), Dir: root}, nil
+}
+
+// OpenDataset opens the synced dataset for version.
+func OpenDataset(root, version string) (Dataset, error) {
+	if !validVersion(version) {
+		return Dataset{}, fmt.Errorf("%w %q", ErrInvalidVersion, version)
+	}
+	dir := filepath.Join(root, VersionsDir, version)
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return Dataset{}, err
+	}
+	return Dataset{Version: manifest.Version, Dir: dir, Manifest: manifest}, nil
+}
+
+// ReadManifest reads the manifest of the dataset in dir.
+func ReadManifest(dir string) (*Manifest, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", dir, err)
+	}
+	return &m, nil
+}
+
+// Versions lists the synced dataset versions under root, newest first.
+func Versions(root string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, VersionsDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, e := range entries {
+		if !e.IsDir() || !validVersion(e.Name()) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, VersionsDir, e.Name(), ManifestName)); err == nil {
+			versions = append(versions, e.Name())
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) > 0
+	})
+	return versions, nil
+}
+
+// CurrentVersion returns the version named in root's current file.
+func CurrentVersion(root string) (string, error) {
+	raw, err := os.ReadFile(filepath.Join(root, CurrentFile))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// SetCurrent records version as the active dataset under root. The file is
+// replaced atomically so a crash never leaves it half written.
+func SetCurrent(root, version string) error {
+	if !validVersion(version) {
+		return fmt.Errorf("%w %q", ErrInvalidVersion, version)
+	}
+	tmp, err := os.CreateTemp(root, ".current-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(version + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(root, CurrentFile))
+}
+
+// snapshotVersion reads the version recorded in the snapshot's champion.json.
+func snapshotVersion(root string) string {
+	raw, err := os.ReadFile(filepath.Join(root, ChampionStatsFile))
+	if err != nil {
+		return FallbackVersion
+	}
+	var champions struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &champions); err != nil || champions.Version == "" {
+		return FallbackVersion
+	}
+	return champions.Version
+}
+
+// validVersion accepts Data Dragon versions like 16.1.1 and rejects
+// anything that could escape the versions directory.
+func validVersion(version string) bool {
+	if version == "" || len(version) > 32 {
+		return false
+	}
+	for _, r := range version {
+		if (r < '0' || r > '9') && r != '.' && r != '_' && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return version[0] != '.'
+}
+
+// compareVersions compares dotted versions numerically.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		var x, y int
+		fmt.Sscanf(as[i], "%d", &x)
+		fmt.Sscanf(bs[i], "%d", &y)
+		if x != y {
+			return x - y
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -5,9 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
-	"sync"
 )
 
 // ItemEntry represents a single item from the JSON array
@@ -36,24 +34,6 @@ type PerkStyle struct {
 	Name string `json:"name"`
 }
 
-var (
-	itemData     map[int]ItemEntry // id -> item
-	itemDataOnce sync.Once
-	itemDataErr  error
-
-	perkData     map[int]PerkData
-	perkDataOnce sync.Once
-	perkDataErr  error
-
-	perkStyleData     map[int]string // id -> name
-	perkStyleDataOnce sync.Once
-	perkStyleDataErr  error
-
-	championData     map[string]ChampionEntry // alias (lowercase) -> champion
-	championDataOnce sync.Once
-	championDataErr  error
-)
-
 // ChampionEntry represents a champion from champion-summary.json
 type ChampionEntry struct {
 	ID                 int      `json:"id"`
@@ -63,214 +43,144 @@ type ChampionEntry struct {
 	Roles              []string `json:"roles"`
 }
 
-// LoadItems loads item data from the JSON file (array format)
-func LoadItems(filePath string) (map[int]ItemEntry, error) {
-	itemDataOnce.Do(func() {
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			itemDataErr = err
-			return
-		}
-
-		var items []ItemEntry
-		if err := json.Unmarshal(data, &items); err != nil {
-			itemDataErr = err
-			return
-		}
-
-		itemData = make(map[int]ItemEntry)
-		for _, item := range items {
-			itemData[item.ID] = item
-		}
-	})
-
-	return itemData, itemDataErr
+// ChampionStats represents a champion from Data Dragon's champion.json
+type ChampionStats struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+	Info struct {
+		Attack  int `json:"attack"`
+		Defense int `json:"defense"`
+		Magic   int `json:"magic"`
+	} `json:"info"`
 }
 
-// LoadPerks loads perk/rune data from the JSON file
-func LoadPerks(filePath string) (map[int]PerkData, error) {
-	perkDataOnce.Do(func() {
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			perkDataErr = err
-			return
-		}
+// File names of the static data files inside the data directory.
+const (
+	ItemFile      = "item.json"
+	PerkFile      = "perk.json"
+	PerkStyleFile = "perk-style.json"
+	ChampionFile  = "champion-summary.json"
+)
 
-		var perks []PerkData
-		if err := json.Unmarshal(data, &perks); err != nil {
-			perkDataErr = err
-			return
-		}
+// readItems parses item data from the JSON file (array format)
+func readItems(filePath string) (map[int]ItemEntry, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-		perkData = make(map[int]PerkData)
-		for _, p := range perks {
-			perkData[p.ID] = p
-		}
-	})
+	var items []ItemEntry
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
 
-	return perkData, perkDataErr
+	loaded := make(map[int]ItemEntry, len(items))
+	for _, item := range items {
+		loaded[item.ID] = item
+	}
+
+	return loaded, nil
 }
 
-// GetItemName returns the Vietnamese name for an item ID
-func GetItemName(itemID string) string {
-	if itemData == nil {
-		return "Item #" + itemID
+// readPerks parses perk/rune data from the JSON file
+func readPerks(filePath string) (map[int]PerkData, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	// Convert string ID to int
-	id, err := strconv.Atoi(itemID)
-	if err != nil {
-		return "Item #" + itemID
+	var perks []PerkData
+	if err := json.Unmarshal(data, &perks); err != nil {
+		return nil, err
 	}
 
-	if item, ok := itemData[id]; ok {
-		return item.Name
+	loaded := make(map[int]PerkData, len(perks))
+	for _, p := range perks {
+		loaded[p.ID] = p
 	}
 
-	return "Item #" + itemID
+	return loaded, nil
 }
 
-// GetPerkName returns the Vietnamese name for a perk/rune ID
-func GetPerkName(perkID int) string {
-	if perkData == nil {
-		return ""
+// readPerkStyles parses perk style (rune tree) data from the JSON file
+func readPerkStyles(filePath string) (map[int]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	if perk, ok := perkData[perkID]; ok {
-		return perk.Name
+	var styleData PerkStyleData
+	if err := json.Unmarshal(data, &styleData); err != nil {
+		return nil, err
 	}
 
-	return ""
-}
-
-// GetItems returns all loaded items
-func GetItems() map[int]ItemEntry {
-	return itemData
-}
+	loaded := make(map[int]string, len(styleData.Styles))
+	for _, style := range styleData.Styles {
+		loaded[style.ID] = style.Name
+	}
 
-// GetPerks returns all loaded perks
-func GetPerks() map[int]PerkData {
-	return perkData
+	return loaded, nil
 }
 
-// LoadPerkStyles loads perk style (rune tree) data from the JSON file
-func LoadPerkStyles(filePath string) (map[int]string, error) {
-	perkStyleDataOnce.Do(func() {
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			perkStyleDataErr = err
-			return
-		}
+// readChampions parses champion data from the JSON file
+func readChampions(filePath string) (map[string]ChampionEntry, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-		var styleData PerkStyleData
-		if err := json.Unmarshal(data, &styleData); err != nil {
-			perkStyleDataErr = err
-			return
-		}
+	var champions []ChampionEntry
+	if err := json.Unmarshal(data, &champions); err != nil {
+		return nil, err
+	}
 
-		perkStyleData = make(map[int]string)
-		for _, style := range styleData.Styles {
-			perkStyleData[style.ID] = style.Name
+	loaded := make(map[string]ChampionEntry, len(champions))
+	for _, champ := range champions {
+		// Store by lowercase alias for easy lookup
+		key := strings.ToLower(champ.Alias)
+		loaded[key] = champ
+		// Also store by lowercase name
+		nameKey := strings.ToLower(champ.Name)
+		if nameKey != key {
+			loaded[nameKey] = champ
 		}
-	})
+	}
 
-	return perkStyleData, perkStyleDataErr
+	return loaded, nil
 }
 
-// GetPerkStyleName returns the Vietnamese name for a perk style/tree ID
-func GetPerkStyleName(styleID int) string {
-	if perkStyleData == nil {
-		return ""
+// readChampionStats parses Data Dragon champion data, keyed by champion ID
+// (e.g. "MonkeyKing")
+func readChampionStats(filePath string) (map[string]ChampionStats, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	if name, ok := perkStyleData[styleID]; ok {
-		return name
+	var file struct {
+		Data map[string]ChampionStats `json:"data"`
 	}
-
-	return ""
-}
-
-// GetPerkIconURL returns the CDN URL for a perk icon
-func GetPerkIconURL(perkID int) string {
-	if perkData == nil {
-		return ""
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
 	}
-
-	if perk, ok := perkData[perkID]; ok && perk.IconPath != "" {
-		// Convert path like "/lol-game-data/assets/v1/perk-images/Styles/..."
-		// to Community Dragon URL
-		iconPath := perk.IconPath
-		// Remove the prefix and convert to lowercase for CDN
-		iconPath = strings.TrimPrefix(iconPath, "/lol-game-data/assets/v1/")
-		return "https://raw.communitydragon.org/latest/plugins/rcp-be-lol-game-data/global/default/v1/" + strings.ToLower(iconPath)
+	if file.Data == nil {
+		return map[string]ChampionStats{}, nil
 	}
 
-	return ""
+	return file.Data, nil
 }
 
 // GetItemIconURL returns the Data Dragon URL for an item icon
 func GetItemIconURL(itemID string, version string) string {
 	if version == "" {
-		version = "14.24.1" // fallback version
+		version = FallbackVersion
 	}
 	return fmt.Sprintf("https://ddragon.leagueoflegends.com/cdn/%s/img/item/%s.png", version, itemID)
 }
 
-// LoadChampions loads champion data from the JSON file
-func LoadChampions(filePath string) (map[string]ChampionEntry, error) {
-	championDataOnce.Do(func() {
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			championDataErr = err
-			return
-		}
-
-		var champions []ChampionEntry
-		if err := json.Unmarshal(data, &champions); err != nil {
-			championDataErr = err
-			return
-		}
-
-		championData = make(map[string]ChampionEntry)
-		for _, champ := range champions {
-			// Store by lowercase alias for easy lookup
-			key := strings.ToLower(champ.Alias)
-			championData[key] = champ
-			// Also store by lowercase name
-			nameKey := strings.ToLower(champ.Name)
-			if nameKey != key {
-				championData[nameKey] = champ
-			}
-		}
-	})
-
-	return championData, championDataErr
-}
-
-// GetChampionIconURL returns the CDN URL for a champion icon
-func GetChampionIconURL(championName string) string {
-	if championData == nil {
-		return ""
-	}
-
-	// Normalize name for lookup
-	key := strings.ToLower(championName)
-	key = strings.ReplaceAll(key, " ", "")
-	key = strings.ReplaceAll(key, "'", "")
-
-	if champ, ok := championData[key]; ok && champ.SquarePortraitPath != "" {
-		// Convert path to Community Dragon URL
-		iconPath := champ.SquarePortraitPath
-		iconPath = strings.TrimPrefix(iconPath, "/lol-game-data/assets/v1/")
-		return "https://raw.communitydragon.org/latest/plugins/rcp-be-lol-game-data/global/default/v1/" + strings.ToLower(iconPath)
-	}
-
-	// Try original name
-	if champ, ok := championData[strings.ToLower(championName)]; ok && champ.SquarePortraitPath != "" {
-		iconPath := champ.SquarePortraitPath
-		iconPath = strings.TrimPrefix(iconPath, "/lol-game-data/assets/v1/")
-		return "https://raw.communitydragon.org/latest/plugins/rcp-be-lol-game-data/global/default/v1/" + strings.ToLower(iconPath)
-	}
-
-	return ""
+// communityDragonURL converts a game data asset path like
+// "/lol-game-data/assets/v1/perk-images/Styles/..." to a Community Dragon URL
+func communityDragonURL(assetPath string) string {
+	assetPath = strings.TrimPrefix(assetPath, "/lol-game-data/assets/v1/")
+	return "https://raw.communitydragon.org/latest/plugins/rcp-be-lol-game-data/global/default/v1/" + strings.ToLower(assetPath)
 }
@@ -0,0 +1,281 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store holds every static dataset behind one atomic pointer. Readers
+// always see a snapshot from a single dataset; Load builds the next
+// snapshot off to the side and swaps it in only if every file loaded,
+// except on the first load, where whatever parsed beats having nothing.
+type Store struct {
+	snap atomic.Pointer[snapshot]
+
+	mu       sync.Mutex // serializes loads
+	lastErr  error
+	lastLoad time.Time
+}
+
+// snapshot is one fully loaded dataset. It is never modified once stored.
+type snapshot struct {
+	dataset       Dataset
+	loadedAt      time.Time
+	items         map[int]ItemEntry        // id -> item
+	perks         map[int]PerkData         // id -> perk
+	perkStyles    map[int]string           // id -> name
+	champions     map[string]ChampionEntry // alias (lowercase) -> champion
+	championStats map[string]ChampionStats // Data Dragon id -> stats
+}
+
+// Summary reports how many entries of each kind were loaded.
+type Summary struct {
+	Version       string `json:"version"`
+	Items         int    `json:"items"`
+	Perks         int    `json:"perks"`
+	PerkStyles    int    `json:"perk_styles"`
+	Champions     int    `json:"champions"`
+	ChampionStats int    `json:"champion_stats"`
+}
+
+// LoadError lists the data files that failed to load.
+type LoadError struct {
+	Dir    string
+	Failed map[string]error // file name -> error
+}
+
+func (e *LoadError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Failed[name])
+	}
+	return fmt.Sprintf("load game data from %s: %s", e.Dir, strings.Join(parts, "; "))
+}
+
+// Unwrap returns the individual file errors.
+func (e *LoadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// NewStore returns an empty store. Lookups on it fall back to placeholders
+// until a dataset is loaded.
+func NewStore() *Store {
+	s := &Store{}
+	s.snap.Store(&snapshot{})
+	return s
+}
+
+// Load reads every file of ds and swaps the result in. If any file fails
+// a *LoadError names the failures and the current data stays in place,
+// unless nothing was loaded yet: then the files that parsed are used, and
+// lookups into the failed ones fall back to placeholders until a Reload
+// succeeds.
+func (s *Store) Load(ds Dataset) (Summary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err := readSnapshot(ds)
+	s.lastLoad = time.Now()
+	s.lastErr = err
+	if err != nil {
+		if s.snap.Load().dataset.Dir != "" {
+			return Summary{Version: ds.Version}, err
+		}
+		// Nothing loaded yet: keep what parsed and remember the dataset so
+		// Reload retries it
+		s.snap.Store(next)
+		return next.summary(), err
+	}
+
+	s.snap.Store(next)
+	return next.summary(), nil
+}
+
+// Reload reads the active dataset from disk again.
+func (s *Store) Reload() (Summary, error) {
+	return s.Load(s.Dataset())
+}
+
+// readSnapshot loads all files of ds. On failure it returns the files that
+// did load alongside the *LoadError; the failed datasets are left empty.
+func readSnapshot(ds Dataset) (*snapshot, error) {
+	failed := make(map[string]error)
+	note := func(name string, err error) {
+		if err != nil {
+			failed[name] = err
+		}
+	}
+
+	next := &snapshot{dataset: ds, loadedAt: time.Now()}
+	var err error
+	next.items, err = readItems(filepath.Join(ds.Dir, ItemFile))
+	note(ItemFile, err)
+	next.perks, err = readPerks(filepath.Join(ds.Dir, PerkFile))
+	note(PerkFile, err)
+	next.perkStyles, err = readPerkStyles(filepath.Join(ds.Dir, PerkStyleFile))
+	note(PerkStyleFile, err)
+	next.champions, err = readChampions(filepath.Join(ds.Dir, ChampionFile))
+	note(ChampionFile, err)
+	next.championStats, err = readChampionStats(filepath.Join(ds.Dir, ChampionStatsFile))
+	note(ChampionStatsFile, err)
+
+	if len(failed) > 0 {
+		return next, &LoadError{Dir: ds.Dir, Failed: failed}
+	}
+	return next, nil
+}
+
+// validate rejects a snapshot with an empty dataset.
+func (n *snapshot) validate() error {
+	var errs []error
+	for name, count := range map[string]int{
+		ItemFile:          len(n.items),
+		PerkFile:          len(n.perks),
+		PerkStyleFile:     len(n.perkStyles),
+		ChampionFile:      len(n.champions),
+		ChampionStatsFile: len(n.championStats),
+	} {
+		if count == 0 {
+			errs = append(errs, fmt.Errorf("%s: no entries", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *snapshot) summary() Summary {
+	return Summary{
+		Version:       n.dataset.Version,
+		Items:         len(n.items),
+		Perks:         len(n.perks),
+		PerkStyles:    len(n.perkStyles),
+		Champions:     len(n.champions),
+		ChampionStats: len(n.championStats),
+	}
+}
+
+// StoreStatus describes what the store holds and how the last load went.
+type StoreStatus struct {
+	Dataset   Dataset   `json:"dataset"`
+	Summary   Summary   `json:"summary"`
+	LoadedAt  time.Time `json:"loaded_at"`
+	LastLoad  time.Time `json:"last_load"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Status reports the active dataset and the result of the last load.
+func (s *Store) Status() StoreStatus {
+	snap := s.snap.Load()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := StoreStatus{
+		Dataset:  snap.dataset,
+		Summary:  snap.summary(),
+		LoadedAt: snap.loadedAt,
+		LastLoad: s.lastLoad,
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	return status
+}
+
+// Dataset returns the active dataset.
+func (s *Store) Dataset() Dataset {
+	return s.snap.Load().dataset
+}
+
+// DDragonVersion returns the Data Dragon version used for asset URLs.
+func (s *Store) DDragonVersion() string {
+	if v := s.snap.Load().dataset.Version; v != "" {
+		return v
+	}
+	return FallbackVersion
+}
+
+// ItemName returns the Vietnamese name for an item ID
+func (s *Store) ItemName(itemID string) string {
+	id, err := strconv.Atoi(itemID)
+	if err != nil {
+		return "Item #" + itemID
+	}
+
+	if item, ok := s.snap.Load().items[id]; ok {
+		return item.Name
+	}
+
+	return "Item #" + itemID
+}
+
+// PerkName returns the Vietnamese name for a perk/rune ID
+func (s *Store) PerkName(perkID int) string {
+	return s.snap.Load().perks[perkID].Name
+}
+
+// PerkStyleName returns the Vietnamese name for a perk style/tree ID
+func (s *Store) PerkStyleName(styleID int) string {
+	return s.snap.Load().perkStyles[styleID]
+}
+
+// PerkIconURL returns the CDN URL for a perk icon
+func (s *Store) PerkIconURL(perkID int) string {
+	if perk, ok := s.snap.Load().perks[perkID]; ok && perk.IconPath != "" {
+		return communityDragonURL(perk.IconPath)
+	}
+	return ""
+}
+
+// ChampionIconURL returns the CDN URL for a champion icon
+func (s *Store) ChampionIconURL(championName string) string {
+	champions := s.snap.Load().champions
+
+	// Normalize name for lookup
+	key := strings.ToLower(championName)
+	key = strings.ReplaceAll(key, " ", "")
+	key = strings.ReplaceAll(key, "'", "")
+
+	if champ, ok := champions[key]; ok && champ.SquarePortraitPath != "" {
+		return communityDragonURL(champ.SquarePortraitPath)
+	}
+
+	// Try original name
+	if champ, ok := champions[strings.ToLower(championName)]; ok && champ.SquarePortraitPath != "" {
+		return communityDragonURL(champ.SquarePortraitPath)
+	}
+
+	return ""
+}
+
+// ChampionName returns the name of a champion by its numeric key (as used
+// by champion-mastery-v4), or "" if it isn't in the dataset.
+func (s *Store) ChampionName(championKey int) string {
+	for _, champ := range s.snap.Load().champions {
+		if champ.ID == championKey {
+			return champ.Name
+		}
+	}
+	return ""
+}
+
+// ChampionStats returns Data Dragon tags and stats for a champion ID.
+func (s *Store) ChampionStats(championID string) (ChampionStats, bool) {
+	stats, ok := s.snap.Load().championStats[championID]
+	return stats, ok
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testFiles is a minimal dataset with one entry per file.
+var testFiles = map[string]string{
+	ItemFile:          `[{"id": 3089, "name": "Mũ Phù Thủy Rabadon"}]`,
+	PerkFile:          `[{"id": 8112, "name": "Sốc Điện", "iconPath": "/lol-game-data/assets/v1/perk-images/Electrocute.png"}]`,
+	PerkStyleFile:     `{"styles": [{"id": 8100, "name": "Áp Đảo"}]}`,
+	ChampionFile:      `[{"id": 142, "name": "Zoe", "alias": "Zoe", "squarePortraitPath": "/lol-game-data/assets/v1/champion-icons/142.png"}]`,
+	ChampionStatsFile: `{"data": {"Zoe": {"name": "Zoe", "tags": ["Mage"]}}}`,
+}
+
+// writeDataset writes testFiles to a new directory, replacing the files in
+// broken with invalid JSON.
+func writeDataset(t *testing.T, version string, broken ...string) Dataset {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range testFiles {
+		for _, b := range broken {
+			if name == b {
+				content = "{not json"
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Dataset{Version: version, Dir: dir}
+}
+
+func TestStoreLoad(t *testing.T) {
+	s := NewStore()
+	summary, err := s.Load(writeDataset(t, "16.1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Summary{Version: "16.1.1", Items: 1, Perks: 1, PerkStyles: 1, Champions: 1, ChampionStats: 1}
+	if summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	if s.ItemName("3089") != "Mũ Phù Thủy Rabadon" || s.PerkStyleName(8100) != "Áp Đảo" || s.ChampionName(142) != "Zoe" {
+		t.Error("lookups don't use the loaded data")
+	}
+	if status := s.Status(); status.LastError != "" || status.LoadedAt.IsZero() {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestStoreFirstLoadPartial(t *testing.T) {
+	s := NewStore()
+	ds := writeDataset(t, "16.1.1", ItemFile, ChampionStatsFile)
+
+	summary, err := s.Load(ds)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("err = %v, want a LoadError", err)
+	}
+	if len(loadErr.Failed) != 2 || loadErr.Failed[ItemFile] == nil || loadErr.Failed[ChampionStatsFile] == nil {
+		t.Errorf("failed = %v, want %s and %s", loadErr.Failed, ItemFile, ChampionStatsFile)
+	}
+
+	// The files that parsed are in use; the broken ones fall back
+	want := Summary{Version: "16.1.1", Perks: 1, PerkStyles: 1, Champions: 1}
+	if summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	if s.PerkName(8112) != "Sốc Điện" || s.ChampionIconURL("Zoe") == "" || s.DDragonVersion() != "16.1.1" {
+		t.Error("datasets that parsed were not installed")
+	}
+	if s.ItemName("3089") != "Item #3089" {
+		t.Errorf("item name = %q, want the placeholder", s.ItemName("3089"))
+	}
+	if _, ok := s.ChampionStats("Zoe"); ok {
+		t.Error("champion stats found from a file that failed")
+	}
+	if status := s.Status(); status.LastError == "" || status.Dataset.Dir != ds.Dir {
+		t.Errorf("status = %+v, want the error and the dataset", status)
+	}
+
+	// Reload retries the same dataset once the files are fixed
+	for _, name := range []string{ItemFile, ChampionStatsFile} {
+		if err := os.WriteFile(filepath.Join(ds.Dir, name), []byte(testFiles[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if s.ItemName("3089") != "Mũ Phù Thủy Rabadon" || s.Status().LastError != "" {
+		t.Error("reload did not install the fixed files")
+	}
+}
+
+func TestStoreReloadAllOrNothing(t *testing.T) {
+	s := NewStore()
+	if _, err := s.Load(writeDataset(t, "16.1.1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A broken file keeps every dataset of the current snapshot
+	next := writeDataset(t, "16.2.1", PerkFile)
+	summary, err := s.Load(next)
+	if err == nil {
+		t.Fatal("loading a broken dataset succeeded")
+	}
+	if summary.Version != "16.2.1" || summary.Items != 0 {
+		t.Errorf("summary = %+v, want only the version", summary)
+	}
+	if s.DDragonVersion() != "16.1.1" || s.PerkName(8112) != "Sốc Điện" || s.ItemName("3089") != "Mũ Phù Thủy Rabadon" {
+		t.Error("a failed load replaced the current data")
+	}
+
+	// Same for a reload of the active dataset
+	if err := os.WriteFile(filepath.Join(s.Dataset().Dir, ChampionFile), []byte("[{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Reload(); err == nil {
+		t.Fatal("reloading a broken file succeeded")
+	}
+	if s.ChampionName(142) != "Zoe" || s.Status().LastError == "" {
+		t.Error("a failed reload replaced the current data")
+	}
+}
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	ddragonVersionsURL = "https://ddragon.leagueoflegends.com/api/versions.json"
+	ddragonDataURL     = "https://ddragon.leagueoflegends.com/cdn/%s/data/%s/%s"
+	cdragonDataURL     = "https://raw.communitydragon.org/%s/plugins/rcp-be-lol-game-data/global/%s/v1/%s"
+
+	// DefaultLocale matches the Vietnamese names used in embeds.
+	DefaultLocale = "vi_VN"
+
+	maxFileSize = 64 << 20
+)
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	Version string // Data Dragon version; empty or "latest" for the newest
+	Locale  string // e.g. vi_VN
+	Force   bool   // replace an already synced version
+	Client  *http.Client
+}
+
+// Sync downloads the static data files for a patch into
+// root/versions/<version> and writes its manifest. Files are downloaded
+// and validated in a temporary directory first, so a failed sync never
+// leaves a partial dataset behind. It does not change the current version.
+func Sync(ctx context.Context, root string, opts SyncOptions) (*Manifest, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 2 * time.Minute}
+	}
+	if opts.Locale == "" {
+		opts.Locale = DefaultLocale
+	}
+	if opts.Version == "" || opts.Version == "latest" {
+		latest, err := LatestVersion(ctx, opts.Client)
+		if err != nil {
+			return nil, err
+		}
+		opts.Version = latest
+	}
+	if !validVersion(opts.Version) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidVersion, opts.Version)
+	}
+
+	versionsDir := filepath.Join(root, VersionsDir)
+	target := filepath.Join(versionsDir, opts.Version)
+	if _, err := os.Stat(target); err == nil && !opts.Force {
+		return nil, fmt.Errorf("version %s is already synced (use -force to replace it)", opts.Version)
+	}
+	if err := os.MkdirAll(versionsDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp(versionsDir, ".sync-"+opts.Version+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	manifest := &Manifest{
+		Version:  opts.Version,
+		Locale:   opts.Locale,
+		SyncedAt: time.Now().UTC(),
+		Files:    make(map[string]ManifestFile),
+	}
+	for name, source := range sources(opts.Version, opts.Locale) {
+		file, err := download(ctx, opts.Client, source, filepath.Join(tmp, name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		manifest.Files[name] = file
+	}
+
+	if err := validateDataset(tmp); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ManifestName), raw, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp, 0o755); err != nil {
+		return nil, err
+	}
+
+	// Swap the new directory in; an existing one is moved aside first
+	// because rename can't replace a non-empty directory
+	if _, err := os.Stat(target); err == nil {
+		old := tmp + ".old"
+		if err := os.Rename(target, old); err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(old)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// sources maps each data file to its download URL. Community Dragon only
+// publishes major.minor patches and uses lowercase locales.
+func sources(version, locale string) map[string]string {
+	patch := version
+	if parts := strings.Split(version, "."); len(parts) >= 2 {
+		patch = parts[0] + "." + parts[1]
+	}
+	cdLocale := strings.ToLower(locale)
+
+	return map[string]string{
+		ItemFile:          fmt.Sprintf(cdragonDataURL, patch, cdLocale, "items.json"),
+		PerkFile:          fmt.Sprintf(cdragonDataURL, patch, cdLocale, "perks.json"),
+		PerkStyleFile:     fmt.Sprintf(cdragonDataURL, patch, cdLocale, "perkstyles.json"),
+		ChampionFile:      fmt.Sprintf(cdragonDataURL, patch, cdLocale, "champion-summary.json"),
+		ChampionStatsFile: fmt.Sprintf(ddragonDataURL, version, locale, "champion.json"),
+	}
+}
+
+// download fetches url into path and records its size and checksum.
+func download(ctx context.Context, client *http.Client, url, path string) (ManifestFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ManifestFile{}, fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("GET %s: %w", url, err)
+	}
+	if n > maxFileSize {
+		return ManifestFile{}, fmt.Errorf("GET %s: larger than %d bytes", url, maxFileSize)
+	}
+	if err := f.Close(); err != nil {
+		return ManifestFile{}, err
+	}
+
+	return ManifestFile{Source: url, Size: n, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// validateDataset checks that every file in dir parses and is non-empty.
+func validateDataset(dir string) error {
+	snap, err := readSnapshot(Dataset{Dir: dir})
+	if err != nil {
+		return err
+	}
+	return snap.validate()
+}
+
+// LatestVersion fetches the newest Data Dragon version.
+func LatestVersion(ctx context.Context, client *http.Client) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ddragonVersionsURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch DDragon versions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch DDragon versions: status %d", resp.StatusCode)
+	}
+
+	var versions []string
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return "", fmt.Errorf("parse DDragon versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return "", errors.New("DDragon versions list is empty")
+	}
+	return versions[0], nil
+}
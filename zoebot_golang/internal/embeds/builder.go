@@ -19,13 +19,10 @@ const (
 	ColorWarning = 0xFFFF00 // Yellow
 )
 
-// DDragonVersion is the Data Dragon version for assets.
-var DDragonVersion = "16.1.1"
-
 // GetChampionIcon returns the champion icon URL.
-func GetChampionIcon(championName string) string {
+func GetChampionIcon(gameData *data.Store, championName string) string {
 	// Try to get from loaded champion data first
-	if url := data.GetChampionIconURL(championName); url != "" {
+	if url := gameData.ChampionIconURL(championName); url != "" {
 		return url
 	}
 
@@ -50,7 +47,7 @@ func GetChampionIcon(championName string) string {
 		cleanName = strings.ReplaceAll(cleanName, "'", "")
 	}
 
-	return fmt.Sprintf("https://ddragon.leagueoflegends.com/cdn/%s/img/champion/%s.png", DDragonVersion, cleanName)
+	return fmt.Sprintf("https://ddragon.leagueoflegends.com/cdn/%s/img/champion/%s.png", gameData.DDragonVersion(), cleanName)
 }
 
 // GetPositionEmoji returns emoji for each position.
@@ -216,7 +213,7 @@ func CompactAnalysis(players []ai.PlayerAnalysis, matchData *riot.ParsedMatchDat
 }
 
 // PlayerAnalysisEmbed creates a detailed embed for a single player.
-func PlayerAnalysisEmbed(p ai.PlayerAnalysis, matchData *riot.ParsedMatchData) *discordgo.MessageEmbed {
+func PlayerAnalysisEmbed(gameData *data.Store, p ai.PlayerAnalysis, matchData *riot.ParsedMatchData) *discordgo.MessageEmbed {
 	color := ColorLose
 	if matchData.Win {
 		color = ColorWin
@@ -230,7 +227,7 @@ func PlayerAnalysisEmbed(p ai.PlayerAnalysis, matchData *riot.ParsedMatchData) *
 		Description: fmt.Sprintf("%s %s | **%.1f/10**", positionEmoji, p.PositionVN, p.Score),
 		Color:       color,
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: GetChampionIcon(p.Champion),
+			URL: GetChampionIcon(gameData, p.Champion),
 		},
 		Fields: make([]*discordgo.MessageEmbedField, 0),
 	}
@@ -0,0 +1,91 @@
+package embeds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/storage"
+)
+
+// queueNames are the display names of the common queues.
+var queueNames = map[int]string{
+	400:  "Thường (cấm chọn)",
+	420:  "Xếp hạng đơn/đôi",
+	430:  "Thường (chọn ẩn)",
+	440:  "Xếp hạng linh hoạt",
+	450:  "ARAM",
+	490:  "Thường (chơi nhanh)",
+	1700: "Arena",
+	1900: "URF",
+}
+
+// QueueName returns the display name of a queue, falling back to the game
+// mode for queues it doesn't know.
+func QueueName(queueID int, gameMode string) string {
+	if name, ok := queueNames[queueID]; ok {
+		return name
+	}
+	if gameMode != "" {
+		return gameMode
+	}
+	return fmt.Sprintf("Queue %d", queueID)
+}
+
+// TimeAgo formats how long before now t was.
+func TimeAgo(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "vừa xong"
+	case d < time.Hour:
+		return fmt.Sprintf("%d phút trước", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d giờ trước", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d ngày trước", int(d.Hours()/24))
+	}
+}
+
+// MatchHistory creates one page of a player's recent matches. page is
+// zero-based; pages is the total page count.
+func MatchHistory(riotID string, queueID int, records []storage.MatchRecord, page, pages int, now time.Time) *discordgo.MessageEmbed {
+	description := "Tất cả chế độ"
+	if queueID > 0 {
+		description = QueueName(queueID, "")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📜 LỊCH SỬ ĐẤU - %s", riotID),
+		Description: description,
+		Color:       ColorInfo,
+		Fields:      make([]*discordgo.MessageEmbedField, 0, len(records)),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Trang %d/%d", page+1, pages),
+		},
+	}
+
+	for _, r := range records {
+		result := "💀 Thua"
+		if r.Win {
+			result = "🏆 Thắng"
+		}
+
+		var lines []string
+		lines = append(lines, fmt.Sprintf("⚔️ **%d/%d/%d** • %d CS • %.1f%% sát thương", r.Kills, r.Deaths, r.Assists, r.CS, r.DamageShare))
+		lines = append(lines, fmt.Sprintf("⏱️ %d phút • %s • %s", int(r.Duration.Minutes()), QueueName(r.QueueID, r.GameMode), TimeAgo(r.PlayedAt, now)))
+		if r.Score > 0 {
+			lines = append(lines, fmt.Sprintf("%s %.1f/10", ai.GetScoreEmoji(r.Score), r.Score))
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s - %s %s", result, GetPositionEmoji(r.Position), r.Champion),
+			Value:  strings.Join(lines, "\n"),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
@@ -0,0 +1,71 @@
+package fakeriot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/services/riot"
+)
+
+// ChatAnswer is the stub AI's reply to chat questions.
+const ChatAnswer = "Zoe (giả lập) đã nhận câu hỏi, nhưng AI thật không được bật."
+
+// chatCompletions answers OpenAI-style chat requests. Analysis requests
+// (those with a response format) are scored by ai.ScoreMatch from the
+// lane matchups in the user prompt, so the bot parses a real result.
+func (s *Server) chatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req ai.ChatRequest
+	if err := decode(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	content := ChatAnswer
+	if req.ResponseFormat != nil {
+		result, err := scorePrompt(req.Messages)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		raw, err := json.Marshal(result)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		content = string(raw)
+	}
+
+	var resp ai.ChatResponse
+	resp.Choices = make([]struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}, 1)
+	resp.Choices[0].Message.Content = content
+	resp.Usage = &ai.Usage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// scorePrompt finds the lane matchups JSON in the last user message.
+func scorePrompt(messages []ai.ChatMessage) (*ai.AnalysisResult, error) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		_, rest, ok := strings.Cut(messages[i].Content, ai.LaneMatchupsHeader)
+		if !ok {
+			break
+		}
+
+		// The decoder stops at the end of the array; text follows it
+		var matchups []riot.LaneMatchup
+		if err := json.NewDecoder(strings.NewReader(rest)).Decode(&matchups); err != nil {
+			return nil, errors.New("lane matchups: " + err.Error())
+		}
+		return ai.ScoreMatch(&riot.ParsedMatchData{LaneMatchups: matchups}), nil
+	}
+	return nil, errors.New("no lane matchups in the user prompt")
+}
@@ -0,0 +1,404 @@
+package fakeriot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zoebot/internal/services/riot"
+)
+
+// MatchOptions describes a match generated by FinishMatch.
+type MatchOptions struct {
+	Win       bool     `json:"win"`
+	Position  string   `json:"position"` // TOP, JUNGLE, MIDDLE, BOTTOM or UTILITY; default MIDDLE
+	Champion  string   `json:"champion"` // default depends on the position
+	Kills     int      `json:"kills"`    // all of kills, deaths and assists zero: random
+	Deaths    int      `json:"deaths"`
+	Assists   int      `json:"assists"`
+	Duration  int      `json:"duration"`  // seconds; default 1800
+	QueueID   int      `json:"queue_id"`  // default 420 (ranked solo/duo)
+	Teammates []string `json:"teammates"` // PUUIDs on the player's team
+	Template  string   `json:"template"`  // clone this match instead of generating one
+}
+
+// gameVersion is the patch generated matches are played on.
+const gameVersion = "15.20.712.2345"
+
+// positions in the order Riot lists each team's participants.
+var positions = []string{"TOP", "JUNGLE", "MIDDLE", "BOTTOM", "UTILITY"}
+
+// defaultChampions picks champions per position, [ally, enemy].
+var defaultChampions = map[string][2]string{
+	"TOP":     {"Garen", "Darius"},
+	"JUNGLE":  {"LeeSin", "Vi"},
+	"MIDDLE":  {"Ahri", "Zoe"},
+	"BOTTOM":  {"Jinx", "Caitlyn"},
+	"UTILITY": {"Thresh", "Lulu"},
+}
+
+// matchDoc is the subset of match-v5 the fake generates.
+type matchDoc struct {
+	Metadata struct {
+		DataVersion  string   `json:"dataVersion"`
+		MatchID      string   `json:"matchId"`
+		Participants []string `json:"participants"`
+	} `json:"metadata"`
+	Info struct {
+		GameCreation       int64              `json:"gameCreation"`
+		GameStartTimestamp int64              `json:"gameStartTimestamp"`
+		GameEndTimestamp   int64              `json:"gameEndTimestamp"`
+		GameDuration       int64              `json:"gameDuration"`
+		GameID             int64              `json:"gameId"`
+		GameMode           string             `json:"gameMode"`
+		GameType           string             `json:"gameType"`
+		GameVersion        string             `json:"gameVersion"`
+		MapID              int                `json:"mapId"`
+		PlatformID         string             `json:"platformId"`
+		QueueID            int                `json:"queueId"`
+		Participants       []riot.Participant `json:"participants"`
+	} `json:"info"`
+}
+
+// FinishMatch records a match that ended just now for puuid, with a
+// timeline, and returns its ID. The match is newest in the player's
+// match ID list, which is what the poll loop looks for.
+func (s *Server) FinishMatch(puuid string, opts MatchOptions) (string, error) {
+	if puuid == "" {
+		return "", errors.New("puuid is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	gameID := int64(1_900_000_000 + s.seq)
+	id := "VN2_" + strconv.FormatInt(gameID, 10)
+	for s.matches[id] != nil {
+		gameID++
+		id = "VN2_" + strconv.FormatInt(gameID, 10)
+	}
+
+	var (
+		raw, timeline json.RawMessage
+		err           error
+	)
+	if opts.Template != "" {
+		raw, timeline, err = s.cloneMatch(opts.Template, puuid, id, gameID)
+	} else {
+		raw, timeline, err = s.generateMatch(puuid, opts, id, gameID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	m, err := parseMatch(raw)
+	if err != nil {
+		return "", err
+	}
+	s.matches[id] = m
+	if timeline != nil {
+		s.timelines[id] = timeline
+	}
+	return id, nil
+}
+
+// cloneMatch copies a stored match under a new ID and timestamps,
+// keeping every other field as it is.
+func (s *Server) cloneMatch(template, puuid, id string, gameID int64) (json.RawMessage, json.RawMessage, error) {
+	src, ok := s.matches[template]
+	if !ok {
+		return nil, nil, fmt.Errorf("template match %s not found", template)
+	}
+	found := false
+	for _, p := range src.puuids {
+		found = found || p == puuid
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("player %s is not in template match %s", puuid, template)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(src.raw, &doc); err != nil {
+		return nil, nil, err
+	}
+	metadata, _ := doc["metadata"].(map[string]interface{})
+	info, _ := doc["info"].(map[string]interface{})
+	if metadata == nil || info == nil {
+		return nil, nil, fmt.Errorf("template match %s has no metadata or info", template)
+	}
+
+	duration, _ := info["gameDuration"].(float64)
+	end := time.Now().UnixMilli()
+	metadata["matchId"] = id
+	info["gameId"] = gameID
+	info["gameEndTimestamp"] = end
+	info["gameStartTimestamp"] = end - int64(duration)*1000
+	info["gameCreation"] = end - int64(duration)*1000 - 60_000
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, s.timelines[template], nil
+}
+
+// generateMatch builds a plausible 5v5 match around puuid. Stats are
+// random but seeded by the game ID, so a fresh server replays the same
+// matches.
+func (s *Server) generateMatch(puuid string, opts MatchOptions, id string, gameID int64) (json.RawMessage, json.RawMessage, error) {
+	if opts.Position == "" {
+		opts.Position = "MIDDLE"
+	}
+	if _, ok := defaultChampions[opts.Position]; !ok {
+		return nil, nil, fmt.Errorf("unknown position %q", opts.Position)
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = 1800
+	}
+	if opts.QueueID == 0 {
+		opts.QueueID = 420
+	}
+	rng := rand.New(rand.NewPCG(uint64(gameID), 0))
+	minutes := float64(opts.Duration) / 60
+
+	// Seat the player at their position and teammates at the others
+	ally := make(map[string]string, len(positions))
+	ally[opts.Position] = puuid
+	mates := opts.Teammates
+	for _, pos := range positions {
+		if ally[pos] != "" {
+			continue
+		}
+		if len(mates) > 0 {
+			ally[pos], mates = mates[0], mates[1:]
+		}
+	}
+
+	var participants []riot.Participant
+	for team := 0; team < 2; team++ {
+		teamID := 100 + 100*team
+		win := opts.Win == (team == 0)
+		for i, pos := range positions {
+			pid := team*5 + i + 1
+			p := riot.Participant{
+				ParticipantID:      pid,
+				ChampionName:       defaultChampions[pos][team],
+				TeamID:             teamID,
+				TeamPosition:       pos,
+				IndividualPosition: pos,
+				Win:                win,
+			}
+			if team == 0 {
+				p.PUUID = ally[pos]
+			}
+			if p.PUUID == "" {
+				p.PUUID = fmt.Sprintf("fake-%s-%d", id, pid)
+				p.RiotIDGameName = fmt.Sprintf("Bot%d", pid)
+			} else if a, ok := s.accounts[p.PUUID]; ok {
+				p.RiotIDGameName = a.GameName
+			} else {
+				p.RiotIDGameName = p.PUUID
+			}
+			if p.PUUID == puuid && opts.Champion != "" {
+				p.ChampionName = opts.Champion
+			}
+			generateStats(rng, &p, minutes)
+			if p.PUUID == puuid && opts.Kills+opts.Deaths+opts.Assists > 0 {
+				p.Kills, p.Deaths, p.Assists = opts.Kills, opts.Deaths, opts.Assists
+			}
+			participants = append(participants, p)
+		}
+	}
+	fillChallenges(participants, minutes)
+
+	var doc matchDoc
+	end := time.Now().UnixMilli()
+	doc.Metadata.DataVersion = "2"
+	doc.Metadata.MatchID = id
+	doc.Info.GameEndTimestamp = end
+	doc.Info.GameStartTimestamp = end - int64(opts.Duration)*1000
+	doc.Info.GameCreation = doc.Info.GameStartTimestamp - 60_000
+	doc.Info.GameDuration = int64(opts.Duration)
+	doc.Info.GameID = gameID
+	doc.Info.GameMode = "CLASSIC"
+	doc.Info.GameType = "MATCHED_GAME"
+	doc.Info.GameVersion = gameVersion
+	doc.Info.MapID = 11
+	doc.Info.PlatformID = "VN2"
+	doc.Info.QueueID = opts.QueueID
+	doc.Info.Participants = participants
+	for _, p := range participants {
+		doc.Metadata.Participants = append(doc.Metadata.Participants, p.PUUID)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	timeline, err := json.Marshal(generateTimeline(rng, id, participants, opts.Duration))
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, timeline, nil
+}
+
+// generateStats fills in per-player totals typical for the position.
+func generateStats(rng *rand.Rand, p *riot.Participant, minutes float64) {
+	between := func(lo, hi float64) float64 { return lo + rng.Float64()*(hi-lo) }
+	winBonus := 1.0
+	if p.Win {
+		winBonus = 1.2
+	}
+
+	p.Kills = int(between(1, 9) * winBonus)
+	p.Deaths = int(between(1, 8) / winBonus)
+	p.Assists = int(between(2, 12) * winBonus)
+	p.ChampLevel = int(math.Min(18, minutes/2+between(-2, 2)))
+	p.TotalDamageDealtToChampions = int(between(650, 1000) * minutes * winBonus)
+	p.TotalDamageTaken = int(between(550, 900) * minutes)
+	p.DamageSelfMitigated = int(between(200, 800) * minutes)
+	p.TimeCCingOthers = int(between(5, 40))
+	p.DamageDealtToObjectives = int(between(100, 400) * minutes)
+	p.GoldEarned = int(between(330, 430) * minutes * winBonus)
+	p.VisionScore = int(between(0.5, 1) * minutes)
+	p.WardsPlaced = p.VisionScore / 2
+	p.WardsKilled = p.VisionScore / 6
+	p.TotalMinionsKilled = int(between(5.5, 8.5) * minutes)
+
+	switch p.TeamPosition {
+	case "JUNGLE":
+		p.TotalMinionsKilled = int(between(0.5, 1.5) * minutes)
+		p.NeutralMinionsKilled = int(between(4, 6) * minutes)
+		p.VisionScore = int(between(1, 1.6) * minutes)
+		p.Challenges.DragonTakedowns = int(between(0, 3))
+	case "UTILITY":
+		p.Kills /= 2
+		p.Assists += 5
+		p.TotalMinionsKilled = int(between(0.5, 1.5) * minutes)
+		p.TotalDamageDealtToChampions /= 2
+		p.GoldEarned = p.GoldEarned * 7 / 10
+		p.VisionScore = int(between(2, 3) * minutes)
+		p.WardsPlaced = p.VisionScore / 2
+		p.Challenges.ControlWardsPlaced = int(between(2, 6))
+	}
+	if p.TeamPosition != "JUNGLE" && p.TeamPosition != "UTILITY" {
+		p.Challenges.LaneMinionsFirst10Min = int(between(45, 80))
+	}
+	p.Challenges.TurretTakedowns = int(between(0, 3) * winBonus)
+}
+
+// fillChallenges derives the values that depend on kills and the team
+// totals, after FinishMatch has applied the requested K/D/A.
+func fillChallenges(participants []riot.Participant, minutes float64) {
+	type totals struct{ kills, damage, taken int }
+	teams := make(map[int]*totals)
+	for _, p := range participants {
+		t := teams[p.TeamID]
+		if t == nil {
+			t = &totals{}
+			teams[p.TeamID] = t
+		}
+		t.kills += p.Kills
+		t.damage += p.TotalDamageDealtToChampions
+		t.taken += p.TotalDamageTaken
+	}
+
+	for i := range participants {
+		p := &participants[i]
+		t := teams[p.TeamID]
+		p.LargestKillingSpree = p.Kills / 2
+		p.TotalTimeSpentDead = p.Deaths * 25
+
+		c := &p.Challenges
+		c.SoloKills = p.Kills / 3
+		c.Takedowns = p.Kills + p.Assists
+		c.KDA = float64(c.Takedowns) / math.Max(1, float64(p.Deaths))
+		if t.kills > 0 {
+			c.KillParticipation = math.Min(1, float64(c.Takedowns)/float64(t.kills))
+		}
+		if t.damage > 0 {
+			c.TeamDamagePercentage = float64(p.TotalDamageDealtToChampions) / float64(t.damage)
+		}
+		if t.taken > 0 {
+			c.DamageTakenOnTeamPct = float64(p.TotalDamageTaken) / float64(t.taken)
+		}
+		c.DamagePerMinute = float64(p.TotalDamageDealtToChampions) / minutes
+		c.GoldPerMinute = float64(p.GoldEarned) / minutes
+		c.VisionScorePerMinute = float64(p.VisionScore) / minutes
+	}
+}
+
+// generateTimeline spreads the match totals over one frame per minute and
+// turns every kill into a CHAMPION_KILL event.
+func generateTimeline(rng *rand.Rand, id string, participants []riot.Participant, duration int) map[string]interface{} {
+	durationMs := int64(duration) * 1000
+
+	// One frame per minute plus a last one at the end of the game
+	var stamps []int64
+	for ts := int64(0); ts < durationMs; ts += 60_000 {
+		stamps = append(stamps, ts)
+	}
+	stamps = append(stamps, durationMs)
+
+	frames := make([]riot.TimelineFrame, len(stamps))
+	for i, ts := range stamps {
+		progress := float64(ts) / float64(durationMs)
+
+		frames[i].Timestamp = ts
+		frames[i].Events = []riot.TimelineEvent{}
+		frames[i].ParticipantFrames = make(map[string]riot.ParticipantFrame, len(participants))
+		for _, p := range participants {
+			frames[i].ParticipantFrames[strconv.Itoa(p.ParticipantID)] = riot.ParticipantFrame{
+				ParticipantID:       p.ParticipantID,
+				TotalGold:           500 + int(float64(p.GoldEarned-500)*progress),
+				MinionsKilled:       int(float64(p.TotalMinionsKilled) * progress),
+				JungleMinionsKilled: int(float64(p.NeutralMinionsKilled) * progress),
+			}
+		}
+	}
+
+	// Kills land in the frame that ends after them
+	for _, killer := range participants {
+		var enemies, allies []int
+		for _, p := range participants {
+			switch {
+			case p.TeamID != killer.TeamID:
+				enemies = append(enemies, p.ParticipantID)
+			case p.ParticipantID != killer.ParticipantID:
+				allies = append(allies, p.ParticipantID)
+			}
+		}
+		for k := 0; k < killer.Kills; k++ {
+			ts := 90_000 + rng.Int64N(max(1, durationMs-90_000))
+			event := riot.TimelineEvent{
+				Type:                    "CHAMPION_KILL",
+				Timestamp:               ts,
+				KillerID:                killer.ParticipantID,
+				VictimID:                enemies[rng.IntN(len(enemies))],
+				AssistingParticipantIDs: []int{allies[rng.IntN(len(allies))]},
+				Bounty:                  300,
+			}
+			frame := &frames[min(len(frames)-1, int(ts/60_000)+1)]
+			frame.Events = append(frame.Events, event)
+		}
+	}
+
+	for i := range frames {
+		events := frames[i].Events
+		sort.Slice(events, func(a, b int) bool { return events[a].Timestamp < events[b].Timestamp })
+	}
+
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"dataVersion": "2", "matchId": id},
+		"info": map[string]interface{}{
+			"frameInterval": 60_000,
+			"frames":        frames,
+		},
+	}
+}
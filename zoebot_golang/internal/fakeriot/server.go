@@ -0,0 +1,685 @@
+// Package fakeriot is an in-memory stand-in for the Riot API and the AI
+// API, used for local development and tests.
+//
+// It serves the endpoints riot.Client calls from a fixtures directory:
+//
+//	accounts.json            [{"puuid", "gameName", "tagLine"}]
+//	matches/<id>.json        match-v5 match
+//	timelines/<id>.json      match-v5 timeline (optional)
+//	leagues/<puuid>.json     league-v4 entries (optional, unranked otherwise)
+//	summoners/<puuid>.json   summoner-v4 summoner (optional, generated otherwise)
+//	masteries/<puuid>.json   champion-mastery-v4 masteries (optional, none otherwise)
+//	active-games/<puuid>.json spectator-v5 game (optional, 404 otherwise)
+//
+// Tests script it through the Server methods; a running cmd/fakeriot is
+// scripted through the same operations under /_fake/:
+//
+//	GET    /_fake/requests               request log
+//	POST   /_fake/accounts               add {"puuid", "gameName", "tagLine"}
+//	POST   /_fake/matches                finish a match {"puuid", ...MatchOptions}
+//	PUT    /_fake/leagues/{puuid}        replace league entries
+//	PUT    /_fake/active-games/{puuid}   put a player in game
+//	DELETE /_fake/active-games/{puuid}   end the player's game
+//	POST   /_fake/faults                 inject {"path", "status", "count", "retry_after"}
+//	DELETE /_fake/faults                 clear faults
+//	POST   /_fake/reset                  reload the fixtures
+//
+// POST /v1/chat/completions is a stub AI: analysis requests are scored
+// with ai.ScoreMatch and chat requests get a canned answer.
+package fakeriot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zoebot/internal/services/riot"
+)
+
+// Fault makes matching requests fail.
+type Fault struct {
+	Path       string `json:"path"`        // path prefix; empty matches every API route
+	Status     int    `json:"status"`      // e.g. 429, 500, 503
+	Count      int    `json:"count"`       // requests to fail; 0 until cleared
+	RetryAfter int    `json:"retry_after"` // Retry-After seconds for 429
+}
+
+// Request is one entry of the request log.
+type Request struct {
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Status int       `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// Server is a fake Riot API. It is safe for concurrent use.
+type Server struct {
+	fixtures string
+	token    string // required X-Riot-Token; empty accepts any
+
+	mu          sync.Mutex
+	accounts    map[string]riot.AccountResponse // puuid -> account
+	matches     map[string]*storedMatch         // match id -> match
+	timelines   map[string]json.RawMessage      // match id -> timeline
+	leagues     map[string]json.RawMessage      // puuid -> league entries
+	summoners   map[string]json.RawMessage      // puuid -> summoner
+	masteries   map[string]json.RawMessage      // puuid -> champion masteries
+	activeGames map[string]json.RawMessage      // puuid -> spectator game
+	faults      []*Fault
+	requests    []Request
+	seq         int
+}
+
+// storedMatch is a match as served, plus what the match ID route needs.
+type storedMatch struct {
+	raw     json.RawMessage
+	puuids  []string
+	queueID int
+	created int64 // game creation, Unix ms
+}
+
+// New loads the fixtures in dir. An empty dir starts with no data.
+func New(dir string) (*Server, error) {
+	s := &Server{fixtures: dir}
+	if err := s.Reset(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// RequireToken makes Riot routes return 401 unless X-Riot-Token is token.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// Reset drops everything added at runtime and reloads the fixtures.
+func (s *Server) Reset() error {
+	accounts := make(map[string]riot.AccountResponse)
+	matches := make(map[string]*storedMatch)
+	timelines := make(map[string]json.RawMessage)
+	leagues := make(map[string]json.RawMessage)
+	summoners := make(map[string]json.RawMessage)
+	masteries := make(map[string]json.RawMessage)
+	activeGames := make(map[string]json.RawMessage)
+
+	if s.fixtures != "" {
+		var list []riot.AccountResponse
+		raw, err := os.ReadFile(filepath.Join(s.fixtures, "accounts.json"))
+		if err == nil {
+			err = json.Unmarshal(raw, &list)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("accounts.json: %w", err)
+		}
+		for _, a := range list {
+			accounts[a.PUUID] = a
+		}
+
+		err = readDir(filepath.Join(s.fixtures, "matches"), func(id string, raw json.RawMessage) error {
+			m, err := parseMatch(raw)
+			if err != nil {
+				return err
+			}
+			matches[id] = m
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for sub, into := range map[string]map[string]json.RawMessage{
+			"timelines":    timelines,
+			"leagues":      leagues,
+			"summoners":    summoners,
+			"masteries":    masteries,
+			"active-games": activeGames,
+		} {
+			err := readDir(filepath.Join(s.fixtures, sub), func(name string, raw json.RawMessage) error {
+				into[name] = raw
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts = accounts
+	s.matches = matches
+	s.timelines = timelines
+	s.leagues = leagues
+	s.summoners = summoners
+	s.masteries = masteries
+	s.activeGames = activeGames
+	s.faults = nil
+	s.requests = nil
+	return nil
+}
+
+// readDir calls fn with every *.json file in dir, keyed by file name
+// without the extension. A missing dir is empty.
+func readDir(dir string, fn func(name string, raw json.RawMessage) error) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(raw) {
+			return fmt.Errorf("%s: invalid JSON", path)
+		}
+		if err := fn(strings.TrimSuffix(filepath.Base(path), ".json"), raw); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// parseMatch reads the fields the match ID route filters on.
+func parseMatch(raw json.RawMessage) (*storedMatch, error) {
+	var head struct {
+		Info struct {
+			GameCreation int64 `json:"gameCreation"`
+			QueueID      int   `json:"queueId"`
+			Participants []struct {
+				PUUID string `json:"puuid"`
+			} `json:"participants"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, err
+	}
+
+	m := &storedMatch{raw: raw, queueID: head.Info.QueueID, created: head.Info.GameCreation}
+	for _, p := range head.Info.Participants {
+		m.puuids = append(m.puuids, p.PUUID)
+	}
+	return m, nil
+}
+
+// AddAccount adds or replaces an account.
+func (s *Server) AddAccount(a riot.AccountResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[a.PUUID] = a
+}
+
+// SetLeague replaces a player's league entries.
+func (s *Server) SetLeague(puuid string, entries []riot.LeagueEntryDTO) error {
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leagues[puuid] = raw
+	return nil
+}
+
+// SetActiveGame puts a player in game; a nil game ends it.
+func (s *Server) SetActiveGame(puuid string, game json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if game == nil {
+		delete(s.activeGames, puuid)
+		return
+	}
+	s.activeGames[puuid] = game
+}
+
+// InjectFault adds a fault. Faults are checked in the order added.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the request log, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Hits counts logged requests whose path starts with prefix.
+func (s *Server) Hits(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if strings.HasPrefix(r.Path, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// Handler returns the fake API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /riot/account/v1/accounts/by-riot-id/{gameName}/{tagLine}", s.accountByRiotID)
+	mux.HandleFunc("GET /riot/account/v1/accounts/by-puuid/{puuid}", s.accountByPUUID)
+	mux.HandleFunc("GET /lol/match/v5/matches/by-puuid/{puuid}/ids", s.matchIDs)
+	mux.HandleFunc("GET /lol/match/v5/matches/{matchId}", s.match)
+	mux.HandleFunc("GET /lol/match/v5/matches/{matchId}/timeline", s.timeline)
+	mux.HandleFunc("GET /lol/summoner/v4/summoners/by-puuid/{puuid}", s.summoner)
+	mux.HandleFunc("GET /lol/league/v4/entries/by-puuid/{puuid}", s.league)
+	mux.HandleFunc("GET /lol/champion-mastery/v4/champion-masteries/by-puuid/{puuid}/top", s.topMasteries)
+	mux.HandleFunc("GET /lol/champion-mastery/v4/scores/by-puuid/{puuid}", s.masteryScore)
+	mux.HandleFunc("GET /lol/spectator/v5/active-games/by-summoner/{puuid}", s.activeGame)
+	mux.HandleFunc("POST /v1/chat/completions", s.chatCompletions)
+
+	control := http.NewServeMux()
+	control.HandleFunc("GET /_fake/requests", s.ctlRequests)
+	control.HandleFunc("POST /_fake/accounts", s.ctlAddAccount)
+	control.HandleFunc("POST /_fake/matches", s.ctlFinishMatch)
+	control.HandleFunc("PUT /_fake/leagues/{puuid}", s.ctlSetLeague)
+	control.HandleFunc("PUT /_fake/active-games/{puuid}", s.ctlSetActiveGame)
+	control.HandleFunc("DELETE /_fake/active-games/{puuid}", s.ctlEndActiveGame)
+	control.HandleFunc("POST /_fake/faults", s.ctlInjectFault)
+	control.HandleFunc("DELETE /_fake/faults", s.ctlClearFaults)
+	control.HandleFunc("POST /_fake/reset", s.ctlReset)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/_fake/") {
+			control.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer s.logRequest(r, rec)
+
+		if status, retryAfter, ok := s.fault(r.URL.Path); ok {
+			if retryAfter > 0 {
+				rec.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			}
+			writeRiotError(rec, status, http.StatusText(status))
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/v1/") && !s.authorized(r) {
+			writeRiotError(rec, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		mux.ServeHTTP(rec, r)
+	})
+}
+
+// fault consumes the first fault matching path.
+func (s *Server) fault(path string) (status, retryAfter int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.faults {
+		if !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f.Status, f.RetryAfter, true
+	}
+	return 0, 0, false
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == "" {
+		return r.Header.Get("X-Riot-Token") != ""
+	}
+	return r.Header.Get("X-Riot-Token") == s.token
+}
+
+func (s *Server) logRequest(r *http.Request, rec *statusRecorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Status: rec.status,
+		At:     time.Now(),
+	})
+}
+
+// statusRecorder remembers the status code for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (s *Server) accountByRiotID(w http.ResponseWriter, r *http.Request) {
+	gameName, tagLine := r.PathValue("gameName"), r.PathValue("tagLine")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.accounts {
+		// Riot IDs are case-insensitive
+		if strings.EqualFold(a.GameName, gameName) && strings.EqualFold(a.TagLine, tagLine) {
+			writeJSON(w, http.StatusOK, a)
+			return
+		}
+	}
+	writeRiotError(w, http.StatusNotFound, "Data not found - No results found for player with riot id "+gameName+"#"+tagLine)
+}
+
+func (s *Server) accountByPUUID(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.accounts[r.PathValue("puuid")]; ok {
+		writeJSON(w, http.StatusOK, a)
+		return
+	}
+	writeRiotError(w, http.StatusNotFound, "Data not found - No results found for player with puuid")
+}
+
+// matchIDs lists the player's match IDs newest first, honoring the start,
+// count, queue, startTime and endTime parameters.
+func (s *Server) matchIDs(w http.ResponseWriter, r *http.Request) {
+	puuid := r.PathValue("puuid")
+	q := r.URL.Query()
+	start := queryInt(q.Get("start"), 0)
+	count := queryInt(q.Get("count"), 20)
+	if start < 0 || count < 0 || count > 100 {
+		writeRiotError(w, http.StatusBadRequest, "Bad request - start must be >= 0 and count between 0 and 100")
+		return
+	}
+	queue := queryInt(q.Get("queue"), 0)
+	startTime := int64(queryInt(q.Get("startTime"), 0)) * 1000
+	endTime := int64(queryInt(q.Get("endTime"), 0)) * 1000
+
+	type entry struct {
+		id      string
+		created int64
+	}
+	var found []entry
+
+	s.mu.Lock()
+	for id, m := range s.matches {
+		if queue != 0 && m.queueID != queue {
+			continue
+		}
+		if (startTime != 0 && m.created < startTime) || (endTime != 0 && m.created > endTime) {
+			continue
+		}
+		for _, p := range m.puuids {
+			if p == puuid {
+				found = append(found, entry{id: id, created: m.created})
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].created != found[j].created {
+			return found[i].created > found[j].created
+		}
+		return found[i].id > found[j].id
+	})
+
+	ids := []string{}
+	for i := start; i < len(found) && len(ids) < count; i++ {
+		ids = append(ids, found[i].id)
+	}
+	writeJSON(w, http.StatusOK, ids)
+}
+
+func (s *Server) match(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	m, ok := s.matches[r.PathValue("matchId")]
+	s.mu.Unlock()
+	if !ok {
+		writeRiotError(w, http.StatusNotFound, "Data not found - match file not found")
+		return
+	}
+	writeRaw(w, m.raw)
+}
+
+func (s *Server) timeline(w http.ResponseWriter, r *http.Request) {
+	s.serveRaw(w, s.timelines, r.PathValue("matchId"), "Data not found - timeline file not found")
+}
+
+func (s *Server) summoner(w http.ResponseWriter, r *http.Request) {
+	puuid := r.PathValue("puuid")
+
+	s.mu.Lock()
+	raw, ok := s.summoners[puuid]
+	_, known := s.accounts[puuid]
+	s.mu.Unlock()
+	if ok {
+		writeRaw(w, raw)
+		return
+	}
+	if !known {
+		writeRiotError(w, http.StatusNotFound, "Data not found - summoner not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, riot.SummonerDTO{
+		ID:            "summoner-" + puuid,
+		AccountID:     "account-" + puuid,
+		PUUID:         puuid,
+		ProfileIconID: 29,
+		SummonerLevel: 100,
+	})
+}
+
+func (s *Server) league(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	raw, ok := s.leagues[r.PathValue("puuid")]
+	s.mu.Unlock()
+	if !ok {
+		raw = json.RawMessage("[]")
+	}
+	writeRaw(w, raw)
+}
+
+// playerMasteries returns a player's masteries, best first.
+func (s *Server) playerMasteries(puuid string) ([]riot.ChampionMasteryDTO, error) {
+	s.mu.Lock()
+	raw, ok := s.masteries[puuid]
+	s.mu.Unlock()
+	if !ok {
+		return []riot.ChampionMasteryDTO{}, nil
+	}
+	var masteries []riot.ChampionMasteryDTO
+	if err := json.Unmarshal(raw, &masteries); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(masteries, func(i, j int) bool { return masteries[i].ChampionPoints > masteries[j].ChampionPoints })
+	return masteries, nil
+}
+
+func (s *Server) topMasteries(w http.ResponseWriter, r *http.Request) {
+	masteries, err := s.playerMasteries(r.PathValue("puuid"))
+	if err != nil {
+		writeRiotError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	count := queryInt(r.URL.Query().Get("count"), 3)
+	writeJSON(w, http.StatusOK, masteries[:min(count, len(masteries))])
+}
+
+func (s *Server) masteryScore(w http.ResponseWriter, r *http.Request) {
+	masteries, err := s.playerMasteries(r.PathValue("puuid"))
+	if err != nil {
+		writeRiotError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	score := 0
+	for _, m := range masteries {
+		score += m.ChampionLevel
+	}
+	writeJSON(w, http.StatusOK, score)
+}
+
+func (s *Server) activeGame(w http.ResponseWriter, r *http.Request) {
+	s.serveRaw(w, s.activeGames, r.PathValue("puuid"), "Data not found - spectator game info isn't found")
+}
+
+// serveRaw writes from[key] or a 404 with msg.
+func (s *Server) serveRaw(w http.ResponseWriter, from map[string]json.RawMessage, key, msg string) {
+	s.mu.Lock()
+	raw, ok := from[key]
+	s.mu.Unlock()
+	if !ok {
+		writeRiotError(w, http.StatusNotFound, msg)
+		return
+	}
+	writeRaw(w, raw)
+}
+
+func (s *Server) ctlRequests(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Requests())
+}
+
+func (s *Server) ctlAddAccount(w http.ResponseWriter, r *http.Request) {
+	var a riot.AccountResponse
+	if err := decode(r, &a); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if a.PUUID == "" || a.GameName == "" || a.TagLine == "" {
+		writeError(w, http.StatusBadRequest, errors.New("puuid, gameName and tagLine are required"))
+		return
+	}
+	s.AddAccount(a)
+	writeJSON(w, http.StatusCreated, a)
+}
+
+func (s *Server) ctlFinishMatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PUUID string `json:"puuid"`
+		MatchOptions
+	}
+	if err := decode(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := s.FinishMatch(req.PUUID, req.MatchOptions)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"match_id": id})
+}
+
+func (s *Server) ctlSetLeague(w http.ResponseWriter, r *http.Request) {
+	var entries []riot.LeagueEntryDTO
+	if err := decode(r, &entries); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.SetLeague(r.PathValue("puuid"), entries); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ctlSetActiveGame(w http.ResponseWriter, r *http.Request) {
+	var game json.RawMessage
+	if err := decode(r, &game); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.SetActiveGame(r.PathValue("puuid"), game)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ctlEndActiveGame(w http.ResponseWriter, r *http.Request) {
+	s.SetActiveGame(r.PathValue("puuid"), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ctlInjectFault(w http.ResponseWriter, r *http.Request) {
+	var f Fault
+	if err := decode(r, &f); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if f.Status < 400 || f.Status > 599 {
+		writeError(w, http.StatusBadRequest, errors.New("status must be a 4xx or 5xx code"))
+		return
+	}
+	s.InjectFault(f)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ctlClearFaults(w http.ResponseWriter, r *http.Request) {
+	s.ClearFaults()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ctlReset(w http.ResponseWriter, r *http.Request) {
+	if err := s.Reset(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func queryInt(v string, def int) int {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.New("invalid JSON body: " + err.Error())
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeRaw(w http.ResponseWriter, raw json.RawMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(raw)
+}
+
+// writeRiotError writes an error in the Riot API's format.
+func writeRiotError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]interface{}{
+		"status": map[string]interface{}{"message": msg, "status_code": status},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,257 @@
+package fakeriot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zoebot/internal/bot"
+	"github.com/zoebot/internal/bot/bottest"
+	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
+	"github.com/zoebot/internal/fakeriot"
+	"github.com/zoebot/internal/services/ai"
+	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/internal/storage"
+)
+
+const testPUUID = "fake-puuid-zoe"
+
+// testEnv is a bot whose Riot and AI clients talk to a fake server loaded
+// with testdata.
+type testEnv struct {
+	fake    *fakeriot.Server
+	bot     *bot.Bot
+	riot    *riot.Client
+	session *bottest.Session
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	fake, err := fakeriot.New("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(fake.Handler())
+	t.Cleanup(srv.Close)
+
+	cfg := config.Defaults()
+	cfg.Redis.URL = ""
+	cfg.Riot.APIKey = "fake-key"
+	cfg.Riot.BaseURLAccount = srv.URL
+	cfg.Riot.BaseURLMatch = srv.URL
+	cfg.Riot.BaseURLPlatform = srv.URL
+	cfg.AI.APIKey = "fake-key"
+	cfg.AI.APIURL = srv.URL + "/v1/chat/completions"
+
+	store := storage.NewStore(storage.NewMemoryBackend())
+	t.Cleanup(func() { store.Close() })
+	gameData := data.NewStore()
+	env := &testEnv{
+		fake:    fake,
+		riot:    riot.NewClient(cfg, store, gameData),
+		session: bottest.NewSession(),
+	}
+	env.bot = bot.NewWithDeps(cfg, gameData, bot.Deps{
+		Session: env.session,
+		Riot:    env.riot,
+		AI:      ai.NewClient(cfg),
+		Scraper: &bottest.Scraper{},
+		Store:   store,
+	})
+
+	player, err := env.bot.TrackPlayer(context.Background(), "Zoe#VN2", bottest.Channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.PUUID != testPUUID || player.LastMatchID != "VN2_1000000001" {
+		t.Fatalf("tracked %+v, want the fixture player at the fixture match", player)
+	}
+	return env
+}
+
+// poll runs the poll loop's check for the test player.
+func (env *testEnv) poll(t *testing.T) bool {
+	t.Helper()
+	found, err := env.bot.ForcePoll(context.Background(), testPUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+// lastStatus returns the status of the last logged request to path.
+func (env *testEnv) lastStatus(path string) int {
+	requests := env.fake.Requests()
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].Path == path {
+			return requests[i].Status
+		}
+	}
+	return 0
+}
+
+func TestPollFinishedMatch(t *testing.T) {
+	env := newTestEnv(t)
+	if env.poll(t) {
+		t.Fatal("found a new match before one was played")
+	}
+
+	matchID, err := env.fake.FinishMatch(testPUUID, fakeriot.MatchOptions{Win: true, Kills: 10, Deaths: 2, Assists: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !env.poll(t) {
+		t.Fatal("the finished match was not found")
+	}
+
+	if len(env.session.Sent) != 1 || env.session.Sent[0].ChannelID != bottest.Channel {
+		t.Fatalf("sent %+v, want one notification in the channel", env.session.Sent)
+	}
+	if len(env.session.Edited) != 1 || len(*env.session.Edited[0].Embeds) != 1 {
+		t.Fatalf("edits %+v, want the notification replaced by the analysis", env.session.Edited)
+	}
+	if components := *env.session.Edited[0].Components; len(components) == 0 {
+		t.Error("the analysis has no buttons")
+	}
+	if len(env.session.EmbedEdits) != 0 {
+		t.Errorf("analysis failed: %+v", env.session.EmbedEdits[0])
+	}
+	for _, path := range []string{"/lol/match/v5/matches/" + matchID, "/lol/match/v5/matches/" + matchID + "/timeline", "/v1/chat/completions"} {
+		if status := env.lastStatus(path); status != http.StatusOK {
+			t.Errorf("%s: status %d, want 200", path, status)
+		}
+	}
+
+	if env.poll(t) {
+		t.Error("the same match was found twice")
+	}
+}
+
+func TestPollRateLimited(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.fake.FinishMatch(testPUUID, fakeriot.MatchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	idsPath := "/lol/match/v5/matches/by-puuid/" + testPUUID + "/ids"
+	env.fake.InjectFault(fakeriot.Fault{Path: idsPath, Status: http.StatusTooManyRequests, Count: 1, RetryAfter: 1})
+
+	// The rate limited poll skips the player without losing the match
+	if env.poll(t) {
+		t.Fatal("a rate limited poll found a match")
+	}
+	if status := env.lastStatus(idsPath); status != http.StatusTooManyRequests {
+		t.Fatalf("match IDs status %d, want 429", status)
+	}
+	if stats := env.riot.CallStats(); stats.LastStatus != http.StatusTooManyRequests || stats.Errors != 1 {
+		t.Errorf("call stats = %+v, want the 429 recorded", stats)
+	}
+	if len(env.session.Sent) != 0 {
+		t.Errorf("sent %d messages after a 429", len(env.session.Sent))
+	}
+
+	// The next poll retries and finds it
+	if !env.poll(t) {
+		t.Fatal("the retry did not find the match")
+	}
+	if env.fake.Hits(idsPath) != 3 { // tracking, the 429 and the retry
+		t.Errorf("match IDs requested %d times, want 3", env.fake.Hits(idsPath))
+	}
+	if len(env.session.Sent) != 1 || len(env.session.Edited) != 1 {
+		t.Errorf("sent %d and edited %d messages, want the analysis once", len(env.session.Sent), len(env.session.Edited))
+	}
+}
+
+func TestPollServerErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault func(matchID string) fakeriot.Fault
+		// found is whether the poll with the fault notices the match
+		found bool
+	}{
+		{
+			name: "match IDs",
+			fault: func(string) fakeriot.Fault {
+				return fakeriot.Fault{Path: "/lol/match/v5/matches/by-puuid/", Status: http.StatusInternalServerError, Count: 1}
+			},
+		},
+		{
+			name: "match details",
+			fault: func(matchID string) fakeriot.Fault {
+				return fakeriot.Fault{Path: "/lol/match/v5/matches/" + matchID, Status: http.StatusServiceUnavailable}
+			},
+			found: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			matchID, err := env.fake.FinishMatch(testPUUID, fakeriot.MatchOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			env.fake.InjectFault(tt.fault(matchID))
+
+			if found := env.poll(t); found != tt.found {
+				t.Fatalf("poll = %v, want %v", found, tt.found)
+			}
+			env.fake.ClearFaults()
+			if stats := env.riot.CallStats(); stats.LastStatus < 500 || !strings.Contains(stats.LastError, "API error 5") {
+				t.Errorf("call stats = %+v, want the 5xx recorded", stats)
+			}
+
+			if !tt.found {
+				// Nothing was lost: the next poll handles the match
+				if !env.poll(t) || len(env.session.Edited) != 1 {
+					t.Errorf("the retry did not analyze the match: edited %d", len(env.session.Edited))
+				}
+				return
+			}
+
+			// The match was noticed but couldn't be fetched: the notification
+			// says so and the match isn't posted again
+			if len(env.session.Sent) != 1 || len(env.session.EmbedEdits) != 1 {
+				t.Fatalf("sent %d, error edits %d; want one notification turned into an error", len(env.session.Sent), len(env.session.EmbedEdits))
+			}
+			if desc := env.session.EmbedEdits[0].Description; !strings.Contains(desc, "Riot API") {
+				t.Errorf("error embed = %q", desc)
+			}
+			if env.poll(t) {
+				t.Error("the failed match was posted again")
+			}
+		})
+	}
+}
+
+func TestFaults(t *testing.T) {
+	fake, err := fakeriot.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.InjectFault(fakeriot.Fault{Path: "/lol/league/", Status: http.StatusTooManyRequests, Count: 2, RetryAfter: 3})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Riot-Token", "fake-key")
+		rec := httptest.NewRecorder()
+		fake.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	for n := 0; n < 2; n++ {
+		rec := get("/lol/league/v4/entries/by-puuid/p1")
+		if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "3" {
+			t.Fatalf("request %d: status %d, Retry-After %q; want 429 after 3s", n+1, rec.Code, rec.Header().Get("Retry-After"))
+		}
+	}
+	if rec := get("/lol/league/v4/entries/by-puuid/p1"); rec.Code != http.StatusOK {
+		t.Errorf("after the fault ran out: status %d, want 200", rec.Code)
+	}
+	if rec := get("/lol/summoner/v4/summoners/by-puuid/p1"); rec.Code != http.StatusNotFound {
+		t.Errorf("other routes: status %d, want 404 for an unknown player", rec.Code)
+	}
+	if hits := fake.Hits("/lol/league/"); hits != 3 {
+		t.Errorf("league requests logged = %d, want 3", hits)
+	}
+}
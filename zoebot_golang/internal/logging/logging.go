@@ -0,0 +1,161 @@
+// Package logging configures structured logging for ZoeBot.
+//
+// It wraps log/slog with a configurable level and format, carries a
+// correlation ID through contexts, and redacts sensitive values
+// (PUUIDs, API keys, tokens) from log fields.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Setup installs the default slog logger. level is one of debug, info,
+// warn or error; format is "text" or "json". The standard library log
+// package is routed through the same handler at info level.
+func Setup(w io.Writer, level, format string) (*slog.Logger, error) {
+	if err := SetLevel(level); err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       &currentLevel,
+		ReplaceAttr: redactAttr,
+	}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q (use text or json)", format)
+	}
+
+	logger := slog.New(&contextHandler{Handler: h})
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	return logger, nil
+}
+
+// currentLevel is the minimum level of the installed handler.
+var currentLevel slog.LevelVar
+
+// SetLevel changes the log level at runtime.
+func SetLevel(level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	currentLevel.Set(lvl)
+	return nil
+}
+
+// correlationKey is the context key for the correlation ID.
+type correlationKey struct{}
+
+// WithCorrelationID returns a context carrying id. Log calls made with
+// the *Context slog functions will include it as the "cid" attribute.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// NewCorrelationID returns a context carrying a fresh random ID with the
+// given prefix (e.g. "poll").
+func NewCorrelationID(ctx context.Context, prefix string) context.Context {
+	var b [4]byte
+	rand.Read(b[:])
+	return WithCorrelationID(ctx, prefix+"-"+hex.EncodeToString(b[:]))
+}
+
+// CorrelationID returns the correlation ID carried by ctx, if any.
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// contextHandler adds the correlation ID from the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		r.AddAttrs(slog.String("cid", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// secretKeys are attribute keys whose values are never logged.
+var secretKeys = map[string]bool{
+	"api_key":       true,
+	"apikey":        true,
+	"token":         true,
+	"authorization": true,
+	"password":      true,
+}
+
+var (
+	// riotKeyRegex matches Riot API keys embedded in free text (e.g. errors).
+	riotKeyRegex = regexp.MustCompile(`RGAPI-[0-9a-fA-F-]+`)
+	// puuidRegex matches PUUIDs (78 url-safe chars) embedded in URLs or errors.
+	puuidRegex = regexp.MustCompile(`[A-Za-z0-9_-]{70,}`)
+)
+
+// redactAttr hides secrets and shortens PUUIDs.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case secretKeys[key]:
+		return slog.String(a.Key, "[REDACTED]")
+	case key == "puuid":
+		return slog.String(a.Key, RedactPUUID(a.Value.String()))
+	}
+	if a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, RedactText(a.Value.String()))
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactText(err.Error()))
+		}
+	}
+	return a
+}
+
+// RedactText removes API keys and shortens PUUIDs found in free text.
+func RedactText(v string) string {
+	if strings.Contains(v, "RGAPI-") {
+		v = riotKeyRegex.ReplaceAllString(v, "RGAPI-[REDACTED]")
+	}
+	if len(v) >= 70 {
+		v = puuidRegex.ReplaceAllStringFunc(v, RedactPUUID)
+	}
+	return v
+}
+
+// RedactPUUID shortens a PUUID to a recognizable but non-identifying prefix.
+func RedactPUUID(puuid string) string {
+	if len(puuid) <= 8 {
+		return puuid
+	}
+	return puuid[:8] + "…"
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// testPUUID is the length of a real PUUID.
+var testPUUID = strings.Repeat("aB3_-", 15) + "xyz"
+
+// logged logs one attribute through the JSON handler and returns its value
+// as written.
+func logged(t *testing.T, attr slog.Attr) any {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(&contextHandler{Handler: slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactAttr})})
+	logger.Info("test", attr)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	return record[attr.Key]
+}
+
+func TestRedact(t *testing.T) {
+	if len(testPUUID) != 78 {
+		t.Fatalf("test PUUID has %d characters, want 78", len(testPUUID))
+	}
+	short := testPUUID[:8] + "…"
+
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want any
+	}{
+		{"api key", slog.String("api_key", "RGAPI-1234"), "[REDACTED]"},
+		{"secret key in another case", slog.String("Authorization", "Bot abc.def"), "[REDACTED]"},
+		{"upper case token", slog.String("TOKEN", "abc"), "[REDACTED]"},
+		{"non-string secret", slog.Int("password", 1234), "[REDACTED]"},
+		{"puuid key", slog.String("puuid", testPUUID), short},
+		{"puuid key in another case", slog.String("PUUID", testPUUID), short},
+		{"short puuid", slog.String("puuid", "p1"), "p1"},
+		{"Riot key in text", slog.String("detail", "request with RGAPI-0a1b2c3d-4e5f-6789-abcd-ef0123456789 failed"), "request with RGAPI-[REDACTED] failed"},
+		{"Riot key in an error", slog.Any("error", errors.New("GET /ids?api_key=RGAPI-deadbeef-0000: 403")), "GET /ids?api_key=RGAPI-[REDACTED]: 403"},
+		{"PUUID in a URL", slog.String("url", "https://asia.api.riotgames.com/lol/match/v5/matches/by-puuid/"+testPUUID+"/ids?count=1"), "https://asia.api.riotgames.com/lol/match/v5/matches/by-puuid/" + short + "/ids?count=1"},
+		{"PUUID in an error", slog.Any("error", errors.New("account "+testPUUID+": 404")), "account " + short + ": 404"},
+		{"short text", slog.String("player", "Zoe#VN2"), "Zoe#VN2"},
+		{"long text without a PUUID", slog.String("reply", strings.Repeat("chơi hay quá ", 10)), strings.Repeat("chơi hay quá ", 10)},
+		{"number", slog.Int("count", 3), 3.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := logged(t, tt.attr); got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.attr.Key, got, tt.want)
+			}
+		})
+	}
+
+	if got := RedactPUUID("12345678"); got != "12345678" {
+		t.Errorf("RedactPUUID of 8 characters = %q, want it untouched", got)
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	saved := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(saved) })
+
+	var buf bytes.Buffer
+	if _, err := Setup(&buf, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := NewCorrelationID(context.Background(), "poll")
+	id := CorrelationID(ctx)
+	if !strings.HasPrefix(id, "poll-") || len(id) != len("poll-")+8 {
+		t.Fatalf("correlation ID = %q, want poll- and 8 hex digits", id)
+	}
+	if other := CorrelationID(NewCorrelationID(context.Background(), "poll")); other == id {
+		t.Error("two correlation IDs are the same")
+	}
+
+	slog.InfoContext(ctx, "with", "puuid", testPUUID)
+	slog.With("player", "Zoe#VN2").InfoContext(ctx, "with attrs")
+	slog.Info("without")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("logged %d lines, want 3: %s", len(lines), buf.String())
+	}
+	for n, want := range []string{id, id, ""} {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(lines[n]), &record); err != nil {
+			t.Fatal(err)
+		}
+		if cid, _ := record["cid"].(string); cid != want {
+			t.Errorf("line %d cid = %q, want %q", n+1, cid, want)
+		}
+	}
+	if strings.Contains(buf.String(), testPUUID) {
+		t.Error("Setup's handler logged a full PUUID")
+	}
+
+	if _, err := Setup(&buf, "info", "xml"); err == nil {
+		t.Error("Setup accepted an unknown format")
+	}
+	if _, err := Setup(&buf, "loud", "text"); err == nil {
+		t.Error("Setup accepted an unknown level")
+	}
+}
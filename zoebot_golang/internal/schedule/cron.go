@@ -0,0 +1,216 @@
+// Package schedule parses cron expressions for ZoeBot's scheduled posts.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week. Fields take *, numbers, ranges (1-5), steps (*/15,
+// 1-30/2) and comma separated lists; months and weekdays also take
+// three-letter names. Weekday 0 and 7 are both Sunday. As in cron, when
+// both day fields are restricted a day matching either is enough.
+type Cron struct {
+	spec   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+// macros are the @ shorthands.
+var macros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// Parse parses a cron expression or one of @hourly, @daily, @weekly and
+// @monthly.
+func Parse(spec string) (*Cron, error) {
+	expr := strings.TrimSpace(spec)
+	if macro, ok := macros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: want 5 fields (minute hour day month weekday), got %d", spec, len(fields))
+	}
+
+	c := &Cron{spec: spec}
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: minute: %w", spec, err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: hour: %w", spec, err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: day of month: %w", spec, err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("cron %q: month: %w", spec, err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("cron %q: day of week: %w", spec, err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	c.anyDom = fields[2] == "*"
+	c.anyDow = fields[4] == "*"
+	return c, nil
+}
+
+// String returns the expression as given to Parse.
+func (c *Cron) String() string {
+	return c.spec
+}
+
+// parseField parses one field into a bitset of the values it allows.
+// names, if any, are accepted for min, min+1, …
+func parseField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = fieldValue(from, min, max, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = fieldValue(to, min, max, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = max // 5/15 means 5-max/15
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// fieldValue parses a number or name within [min, max].
+func fieldValue(s string, min, max int, names []string) (int, error) {
+	for n, name := range names {
+		if strings.EqualFold(s, name) {
+			return min + n, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid value %q (want %d-%d)", s, min, max)
+	}
+	return v, nil
+}
+
+// dayMatches reports whether t's day passes the day of month and day of
+// week fields.
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.anyDom && c.anyDow:
+		return true
+	case c.anyDom:
+		return dow
+	case c.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the first time after t the expression matches, in t's
+// location, or the zero time if it never does (e.g. "0 0 31 2 *").
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Matches reports whether the expression fires in t's minute.
+func (c *Cron) Matches(t time.Time) bool {
+	return c.month&(1<<int(t.Month())) != 0 && c.dayMatches(t) &&
+		c.hour&(1<<t.Hour()) != 0 && c.minute&(1<<t.Minute()) != 0
+}
+
+// Prev returns the last time before t the expression matched, in t's
+// location, or the zero time if it didn't in the five years before.
+func (c *Cron) Prev(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Add(-time.Nanosecond).Truncate(time.Minute)
+	limit := t.AddDate(-5, 0, 0)
+
+	for t.After(limit) {
+		if c.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if c.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+			continue
+		}
+		if c.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(-time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
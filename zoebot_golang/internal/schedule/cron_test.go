@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	saigon, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	// Wednesday
+	from := time.Date(2026, 10, 14, 9, 30, 0, 0, saigon)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 20 * * 0", time.Date(2026, 10, 18, 20, 0, 0, 0, saigon)},
+		{"@weekly", time.Date(2026, 10, 18, 0, 0, 0, 0, saigon)},
+		{"0 20 * * 7", time.Date(2026, 10, 18, 20, 0, 0, 0, saigon)},
+		{"0 20 * * sun", time.Date(2026, 10, 18, 20, 0, 0, 0, saigon)},
+		{"*/15 * * * *", time.Date(2026, 10, 14, 9, 45, 0, 0, saigon)},
+		{"30 9 * * *", time.Date(2026, 10, 15, 9, 30, 0, 0, saigon)},
+		{"0 8 1 * *", time.Date(2026, 11, 1, 8, 0, 0, 0, saigon)},
+		{"0 18 * * mon-fri", time.Date(2026, 10, 14, 18, 0, 0, 0, saigon)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, saigon)},
+		// Either day field is enough when both are set: the 20th or a Friday
+		{"0 12 20 * 5", time.Date(2026, 10, 16, 12, 0, 0, 0, saigon)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.spec, from, got, tt.want)
+		}
+		if tt.want.IsZero() {
+			if got := c.Prev(from); !got.IsZero() {
+				t.Errorf("%q.Prev(%s) = %s, want never", tt.spec, from, got)
+			}
+			continue
+		}
+		if !c.Matches(tt.want) || c.Matches(tt.want.Add(time.Minute*7)) {
+			t.Errorf("%q.Matches is inconsistent with Next", tt.spec)
+		}
+		if got := c.Prev(tt.want.Add(time.Minute)); !got.Equal(tt.want) {
+			t.Errorf("%q.Prev(%s) = %s, want %s", tt.spec, tt.want.Add(time.Minute), got, tt.want)
+		}
+		if prev := c.Prev(tt.want); !prev.Before(tt.want) || !c.Next(prev).Equal(tt.want) {
+			t.Errorf("%q.Prev(%s) = %s, which Next doesn't lead back from", tt.spec, tt.want, prev)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "0 0 * * funday"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) accepted an invalid expression", spec)
+		}
+	}
+}
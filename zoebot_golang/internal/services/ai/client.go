@@ -3,25 +3,44 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/zoebot/internal/config"
 	"github.com/zoebot/internal/services/riot"
+	"github.com/zoebot/pkg/healthcheck"
+	"github.com/zoebot/pkg/metrics"
+)
+
+var (
+	requestDuration = metrics.NewHistogramVec(
+		"zoebot_ai_request_duration_seconds",
+		"AI API request latency by request kind (analysis/chat) and result.",
+		[]float64{1, 2.5, 5, 10, 20, 30, 45, 60, 90},
+		"kind", "result",
+	)
+	tokensTotal = metrics.NewCounterVec(
+		"zoebot_ai_tokens_total",
+		"AI tokens consumed by request kind and token type (prompt/completion).",
+		"kind", "type",
+	)
 )
 
 // Client is a client for AI analysis API.
 type Client struct {
 	apiKey     string
 	apiURL     string
-	model      string
 	httpClient *http.Client
+	calls      healthcheck.CallTracker
+	settings   atomic.Pointer[config.AIConfig] // model and sampling, reloadable
 }
 
 // NewClient creates a new AI client.
@@ -34,24 +53,30 @@ func NewClient(cfg *config.Config) *Client {
 	}
 
 	c := &Client{
-		apiKey: cfg.AIAPIKey,
-		apiURL: cfg.AIAPIURL,
-		model:  cfg.AIModel,
+		apiKey: cfg.AI.APIKey,
+		apiURL: cfg.AI.APIURL,
 		httpClient: &http.Client{
-			Timeout:   90 * time.Second,
+			Timeout:   cfg.AI.Timeout,
 			Transport: transport,
 		},
 	}
+	c.Apply(cfg.AI)
 
 	if c.apiKey == "" {
-		log.Println("AI API Key missing")
+		slog.Warn("AI API key missing")
 	}
 
 	return c
 }
 
+// Apply updates the model and sampling settings used by later requests.
+// Credentials, URL and timeout are fixed at construction.
+func (c *Client) Apply(settings config.AIConfig) {
+	c.settings.Store(&settings)
+}
+
 // AnalyzeMatch analyzes match data and returns structured result.
-func (c *Client) AnalyzeMatch(matchData *riot.ParsedMatchData) (*AnalysisResult, error) {
+func (c *Client) AnalyzeMatch(ctx context.Context, matchData *riot.ParsedMatchData) (*AnalysisResult, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("API key not configured")
 	}
@@ -60,12 +85,27 @@ func (c *Client) AnalyzeMatch(matchData *riot.ParsedMatchData) (*AnalysisResult,
 		return nil, fmt.Errorf("invalid match data")
 	}
 
-	content, err := c.makeAPIRequest(matchData)
+	content, err := c.makeAPIRequest(ctx, matchData)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.parseResponse(content)
+	return c.parseResponse(ctx, content)
+}
+
+// UserPrompt returns the exact user prompt AnalyzeMatch sends for
+// matchData, for offline debugging.
+func (c *Client) UserPrompt(matchData *riot.ParsedMatchData) string {
+	return c.buildUserPrompt(matchData)
+}
+
+// LaneMatchupsHeader precedes the lane matchups JSON in the user prompt.
+const LaneMatchupsHeader = "SO SÁNH TỪNG LANE (Player vs Opponent):\n"
+
+// ActiveSystemPrompt returns the system prompt AnalyzeMatch currently
+// sends: the override from the prompts directory, or SystemPrompt.
+func ActiveSystemPrompt() string {
+	return systemPrompt()
 }
 
 // buildUserPrompt builds the user prompt from match data.
@@ -85,7 +125,7 @@ func (c *Client) buildUserPrompt(matchData *riot.ParsedMatchData) string {
 
 	// Lane matchups as JSON
 	matchupsJSON, _ := json.MarshalIndent(matchData.LaneMatchups, "", "  ")
-	sb.WriteString("SO SÁNH TỪNG LANE (Player vs Opponent):\n")
+	sb.WriteString(LaneMatchupsHeader)
 	sb.WriteString(string(matchupsJSON))
 
 	// Timeline insights
@@ -160,18 +200,19 @@ func (c *Client) buildTimelineText(timeline *riot.TimelineData) string {
 }
 
 // makeAPIRequest makes the API request to the AI service.
-func (c *Client) makeAPIRequest(matchData *riot.ParsedMatchData) (string, error) {
+func (c *Client) makeAPIRequest(ctx context.Context, matchData *riot.ParsedMatchData) (string, error) {
 	userPrompt := c.buildUserPrompt(matchData)
+	settings := c.settings.Load()
 
 	payload := ChatRequest{
-		Model: c.model,
+		Model: settings.Model,
 		Messages: []ChatMessage{
-			{Role: "system", Content: SystemPrompt},
+			{Role: "system", Content: systemPrompt()},
 			{Role: "user", Content: userPrompt},
 		},
 		Stream: false,
-		Temperature: 0.7,
-		MaxTokens:   20000,
+		Temperature: settings.Temperature,
+		MaxTokens:   settings.MaxTokens,
 		TopP:        1,
 		ResponseFormat: &ResponseFormat{
 			Type: "json_schema",
@@ -183,14 +224,44 @@ func (c *Client) makeAPIRequest(matchData *riot.ParsedMatchData) (string, error)
 		},
 	}
 
+	chatResp, err := c.sendChat(ctx, "analysis", payload)
+	if err != nil {
+		return "", err
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// sendChat posts a chat completion request and decodes the response.
+// Every call is recorded for the health status report and metrics;
+// kind labels the request ("analysis" or "chat").
+func (c *Client) sendChat(ctx context.Context, kind string, payload ChatRequest) (chatResp *ChatResponse, err error) {
+	start := time.Now()
+	defer func() {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		requestDuration.Observe(time.Since(start).Seconds(), kind, result)
+		slog.DebugContext(ctx, "AI request", "kind", kind, "result", result, "took", time.Since(start))
+		if chatResp != nil && chatResp.Usage != nil {
+			tokensTotal.Add(float64(chatResp.Usage.PromptTokens), kind, "prompt")
+			tokensTotal.Add(float64(chatResp.Usage.CompletionTokens), kind, "completion")
+		}
+	}()
+
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.apiURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
@@ -198,46 +269,56 @@ func (c *Client) makeAPIRequest(matchData *riot.ParsedMatchData) (string, error)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		err = fmt.Errorf("request failed: %w", err)
+		c.calls.Record(0, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		err = fmt.Errorf("failed to read response: %w", err)
+		c.calls.Record(resp.StatusCode, err)
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("AI API Error: %d - %s", resp.StatusCode, string(respBody))
-		return "", fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+		slog.WarnContext(ctx, "AI API error", "kind", kind, "status", resp.StatusCode, "body", truncate(string(respBody), 500))
+		err := fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+		c.calls.Record(resp.StatusCode, err)
+		return nil, err
 	}
 
-	var chatResp ChatResponse
-	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	var decoded ChatResponse
+	if err := json.Unmarshal(respBody, &decoded); err != nil {
+		err = fmt.Errorf("failed to parse response: %w", err)
+		c.calls.Record(resp.StatusCode, err)
+		return nil, err
 	}
 
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
-	}
+	c.calls.Record(resp.StatusCode, nil)
+	return &decoded, nil
+}
 
-	return chatResp.Choices[0].Message.Content, nil
+// CallStats returns a snapshot of AI API call results.
+func (c *Client) CallStats() healthcheck.CallSnapshot {
+	return c.calls.Snapshot()
 }
 
 // jsonBlockRegex matches ```json ... ``` code blocks
 var jsonBlockRegex = regexp.MustCompile("(?s)```json\\s*(.*?)\\s*```")
 
 // parseResponse parses the AI response content.
-func (c *Client) parseResponse(content string) (*AnalysisResult, error) {
+func (c *Client) parseResponse(ctx context.Context, content string) (*AnalysisResult, error) {
 	jsonStr := extractJSON(content)
 	if jsonStr == "" {
-		log.Printf("Failed to extract JSON from AI response: %s", content[:min(200, len(content))])
+		slog.WarnContext(ctx, "No JSON in AI response", "content", truncate(content, 200))
 		return nil, fmt.Errorf("no valid JSON found in AI response")
 	}
 
 	var result AnalysisResult
 	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		log.Printf("Failed to parse AI JSON: %v", err)
+		slog.WarnContext(ctx, "Parsing AI JSON failed", "error", err)
 		return nil, fmt.Errorf("failed to parse AI response: %w", err)
 	}
 
@@ -300,7 +381,7 @@ func GetScoreEmoji(score float64) string {
 }
 
 // ChatWithContext handles conversational AI chat with context from previous bot messages.
-func (c *Client) ChatWithContext(contextType string, contextData map[string]interface{}, question string) (string, error) {
+func (c *Client) ChatWithContext(ctx context.Context, contextType string, contextData map[string]interface{}, question string) (string, error) {
 	if c.apiKey == "" {
 		return "", fmt.Errorf("API key not configured")
 	}
@@ -321,49 +402,21 @@ CÂU HỎI CỦA USER:
 
 Trả lời ngắn gọn, súc tích (2-4 câu), giữ phong cách Zoe!`, contextType, string(contextJSON), question)
 
+	settings := c.settings.Load()
 	payload := ChatRequest{
-		Model: c.model,
+		Model: settings.Model,
 		Messages: []ChatMessage{
-			{Role: "system", Content: ChatSystemPrompt},
+			{Role: "system", Content: chatSystemPrompt()},
 			{Role: "user", Content: userPrompt},
 		},
-		Temperature: 0.8,
-		MaxTokens:   500,
+		Temperature: settings.ChatTemperature,
+		MaxTokens:   settings.ChatMaxTokens,
 		TopP:        1,
 	}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", c.apiURL, bytes.NewReader(body))
+	chatResp, err := c.sendChat(ctx, "chat", payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("AI Chat API Error: %d - %s", resp.StatusCode, string(respBody))
-		return "", fmt.Errorf("API error %d", resp.StatusCode)
-	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return "", err
 	}
 
 	if len(chatResp.Choices) == 0 {
@@ -379,3 +432,11 @@ Trả lời ngắn gọn, súc tích (2-4 câu), giữ phong cách Zoe!`, contex
 
 	return response, nil
 }
+
+// truncate shortens s to at most n bytes for logging.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -1,6 +1,15 @@
 // Package ai provides system prompts for AI analysis.
 package ai
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
 // SystemPrompt is the main system prompt for match analysis.
 const SystemPrompt = `Bạn là "Zoe Bot" - cô nàng pháp sư tinh nghịch 1000 tuổi. Phong cách: Đanh đá, xéo xắc, toxic cực mạnh với gà mờ nhưng tôn trọng kẻ mạnh.
 
@@ -73,6 +82,8 @@ const ChatSystemPrompt = `Bạn là "Zoe Bot" - cô nàng pháp sư tinh nghịc
 - "analysis": Dữ liệu phân tích trận đấu (players, scores, stats)
 - "build": Thông tin build tướng (runes, items)
 - "counter": Thông tin khắc chế tướng (matchups)
+- "recap": Tổng kết định kỳ của một kênh (số trận, LP, trận hay/tệ nhất, tướng, chuỗi thắng/thua)
+- "session": Tổng kết phiên chơi liên tục của người chơi (các trận, LP, dấu hiệu tilt)
 
 Trả lời câu hỏi của user dựa trên context. Không cần format JSON, chỉ cần text thường.`
 
@@ -153,3 +164,67 @@ var ResponseSchema = map[string]interface{}{
 		},
 	},
 }
+
+// Prompt override files, looked up in the prompts directory.
+const (
+	SystemPromptFile = "system.txt"
+	ChatPromptFile   = "chat.txt"
+)
+
+// promptSet holds the prompts currently in use.
+type promptSet struct {
+	system string
+	chat   string
+}
+
+var activePrompts atomic.Pointer[promptSet]
+
+func init() {
+	activePrompts.Store(&promptSet{system: SystemPrompt, chat: ChatSystemPrompt})
+}
+
+// LoadPrompts replaces the active prompts with the override files found in
+// dir. A missing file falls back to the built-in prompt, so an empty or
+// absent dir restores the defaults. It returns the overridden file names.
+func LoadPrompts(dir string) ([]string, error) {
+	next := &promptSet{system: SystemPrompt, chat: ChatSystemPrompt}
+	var overridden []string
+
+	for _, p := range []struct {
+		file string
+		dst  *string
+	}{
+		{SystemPromptFile, &next.system},
+		{ChatPromptFile, &next.chat},
+	} {
+		if dir == "" {
+			break
+		}
+		content, err := os.ReadFile(filepath.Join(dir, p.file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		text := strings.TrimSpace(string(content))
+		if text == "" {
+			return nil, fmt.Errorf("%s is empty", p.file)
+		}
+		*p.dst = text
+		overridden = append(overridden, p.file)
+	}
+
+	activePrompts.Store(next)
+	return overridden, nil
+}
+
+// systemPrompt returns the active match analysis prompt.
+func systemPrompt() string {
+	return activePrompts.Load().system
+}
+
+// chatSystemPrompt returns the active chat prompt.
+func chatSystemPrompt() string {
+	return activePrompts.Load().chat
+}
@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/zoebot/internal/services/riot"
+)
+
+// PositionNames maps Riot team positions to the Vietnamese names used in
+// analyses.
+var PositionNames = map[string]string{
+	"TOP":     "Đường trên",
+	"JUNGLE":  "Đi rừng",
+	"MIDDLE":  "Đường giữa",
+	"BOTTOM":  "Xạ thủ",
+	"UTILITY": "Hỗ trợ",
+}
+
+// RulesComment marks analyses produced by ScoreMatch.
+const RulesComment = "Chấm theo luật cố định, không dùng AI."
+
+// ScoreMatch scores the target player's team with fixed rules instead of
+// the AI. It is deterministic, which makes it a baseline to compare AI
+// scores against.
+func ScoreMatch(matchData *riot.ParsedMatchData) *AnalysisResult {
+	result := &AnalysisResult{}
+	if matchData == nil {
+		return result
+	}
+
+	for _, m := range matchData.LaneMatchups {
+		if m.Player == nil {
+			continue
+		}
+		result.Players = append(result.Players, scorePlayer(m.Player, m.Opponent))
+	}
+	return result
+}
+
+// factor is one scoring rule's contribution.
+type factor struct {
+	delta     float64
+	good, bad string
+}
+
+// scorePlayer scores one player against their lane opponent (may be nil).
+func scorePlayer(p, opp *riot.PlayerData) PlayerAnalysis {
+	var factors []factor
+	add := func(delta float64, good, bad string) {
+		factors = append(factors, factor{delta: delta, good: good, bad: bad})
+	}
+
+	role := p.TeamPosition
+	laner := role != "JUNGLE" && role != "UTILITY"
+
+	add(clamp((p.KDA-2.5)*0.6, -1.5, 1.5),
+		fmt.Sprintf("KDA %.1f (%d/%d/%d)", p.KDA, p.Kills, p.Deaths, p.Assists),
+		fmt.Sprintf("KDA chỉ %.1f (%d/%d/%d)", p.KDA, p.Kills, p.Deaths, p.Assists))
+
+	add(clamp((p.KillParticipation-50)/15, -1, 1),
+		fmt.Sprintf("tham gia %.0f%% số mạng của đội", p.KillParticipation),
+		fmt.Sprintf("chỉ tham gia %.0f%% số mạng của đội", p.KillParticipation))
+
+	if role != "UTILITY" {
+		add(clamp((p.TeamDamagePercentage-20)/8, -1, 1),
+			fmt.Sprintf("gây %.0f%% sát thương của đội", p.TeamDamagePercentage),
+			fmt.Sprintf("chỉ gây %.0f%% sát thương của đội", p.TeamDamagePercentage))
+	}
+
+	if laner {
+		add(clamp((p.CSPerMinute-6.5)/1.5, -1, 1),
+			fmt.Sprintf("farm tốt (%.1f CS/phút)", p.CSPerMinute),
+			fmt.Sprintf("farm yếu (%.1f CS/phút)", p.CSPerMinute))
+	}
+
+	if laner {
+		add(clamp((p.VisionScorePerMinute-0.8)/0.4, -0.5, 0.5),
+			fmt.Sprintf("kiểm soát tầm nhìn tốt (%.2f/phút)", p.VisionScorePerMinute),
+			fmt.Sprintf("ít cắm mắt (%.2f/phút)", p.VisionScorePerMinute))
+	} else {
+		add(clamp((p.VisionScorePerMinute-1.5)/0.5, -1, 1),
+			fmt.Sprintf("kiểm soát tầm nhìn tốt (%.2f/phút)", p.VisionScorePerMinute),
+			fmt.Sprintf("ít cắm mắt (%.2f/phút)", p.VisionScorePerMinute))
+	}
+
+	vsOpponent := ""
+	if opp != nil {
+		add(clamp((p.GoldPerMinute-opp.GoldPerMinute)/60, -1.5, 1.5),
+			fmt.Sprintf("hơn vàng %s (%.0f vs %.0f/phút)", opp.ChampionName, p.GoldPerMinute, opp.GoldPerMinute),
+			fmt.Sprintf("thua vàng %s (%.0f vs %.0f/phút)", opp.ChampionName, p.GoldPerMinute, opp.GoldPerMinute))
+
+		vsOpponent = fmt.Sprintf("vs %s: KDA %.1f vs %.1f, vàng %.0f vs %.0f/phút, sát thương %d vs %d",
+			opp.ChampionName, p.KDA, opp.KDA, p.GoldPerMinute, opp.GoldPerMinute,
+			p.TotalDamageDealtToChampions, opp.TotalDamageDealtToChampions)
+	}
+
+	score := 4.5
+	if p.Win {
+		score = 5.5
+	}
+	for _, f := range factors {
+		score += f.delta
+	}
+	score = math.Round(clamp(score, 1, 10)*2) / 2
+
+	// Strongest positive and negative rules explain the score
+	sort.SliceStable(factors, func(i, j int) bool { return factors[i].delta > factors[j].delta })
+	var highlight, weakness string
+	if f := factors[0]; f.delta > 0 {
+		highlight = f.good
+	}
+	if f := factors[len(factors)-1]; f.delta < 0 {
+		weakness = f.bad
+	}
+
+	position := PositionNames[role]
+	if position == "" {
+		position = role
+	}
+
+	return PlayerAnalysis{
+		Champion:   p.ChampionName,
+		PlayerName: p.RiotIDGameName,
+		PositionVN: position,
+		Score:      score,
+		VsOpponent: vsOpponent,
+		Highlight:  highlight,
+		Weakness:   weakness,
+		Comment:    RulesComment,
+	}
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
@@ -2,20 +2,37 @@
 package riot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/zoebot/internal/config"
+	"github.com/zoebot/internal/data"
 	"github.com/zoebot/internal/storage"
+	"github.com/zoebot/pkg/healthcheck"
+	"github.com/zoebot/pkg/metrics"
+)
+
+var (
+	requestsTotal = metrics.NewCounterVec(
+		"zoebot_riot_requests_total",
+		"Riot API requests by endpoint and HTTP status (\"error\" if no response).",
+		"endpoint", "status",
+	)
+	requestDuration = metrics.NewHistogramVec(
+		"zoebot_riot_request_duration_seconds",
+		"Riot API request latency by endpoint.",
+		metrics.DefaultBuckets,
+		"endpoint",
+	)
 )
 
 // Client is a client for Riot Games API.
@@ -25,13 +42,15 @@ type Client struct {
 	baseURLMatch    string
 	baseURLPlatform string // For summoner/league APIs
 	httpClient      *http.Client
-	championData    map[string]ChampionInfo
-	redisClient     *storage.RedisClient
+	gameData        *data.Store
+	store           *storage.Store // caches; nil disables caching
+	calls           healthcheck.CallTracker
 }
 
-// NewClient creates a new Riot API client.
+// NewClient creates a new Riot API client. Champion tags and stats come
+// from gameData.
 // Optimized: shorter timeout, connection reuse
-func NewClient(cfg *config.Config, redisClient *storage.RedisClient) *Client {
+func NewClient(cfg *config.Config, store *storage.Store, gameData *data.Store) *Client {
 	// Reuse connections for efficiency
 	transport := &http.Transport{
 		MaxIdleConns:        10,
@@ -41,81 +60,79 @@ func NewClient(cfg *config.Config, redisClient *storage.RedisClient) *Client {
 	}
 
 	c := &Client{
-		apiKey:          cfg.RiotAPIKey,
-		baseURLAccount:  cfg.RiotBaseURLAccount,
-		baseURLMatch:    cfg.RiotBaseURLMatch,
-		baseURLPlatform: cfg.RiotBaseURLPlatform,
+		apiKey:          cfg.Riot.APIKey,
+		baseURLAccount:  cfg.Riot.BaseURLAccount,
+		baseURLMatch:    cfg.Riot.BaseURLMatch,
+		baseURLPlatform: cfg.Riot.BaseURLPlatform,
 		httpClient: &http.Client{
-			Timeout:   15 * time.Second,
+			Timeout:   cfg.Riot.Timeout,
 			Transport: transport,
 		},
-		championData: make(map[string]ChampionInfo),
-		redisClient:  redisClient,
+		gameData: gameData,
+		store:    store,
 	}
 
-	// Load champion data
-	c.loadChampionData(cfg.ChampionDataPath())
-
 	return c
 }
 
-// loadChampionData loads champion data from JSON file.
-func (c *Client) loadChampionData(path string) {
-	file, err := os.Open(path)
-	if err != nil {
-		return
-	}
-	defer file.Close()
-
-	var data ChampionData
-	if err := json.NewDecoder(file).Decode(&data); err != nil {
-		return
-	}
-
-	c.championData = data.Data
-}
-
 // GetChampionInfo returns champion tags and stats.
 func (c *Client) GetChampionInfo(championName string) ([]string, int) {
-	if champ, ok := c.championData[championName]; ok {
+	if champ, ok := c.gameData.ChampionStats(championName); ok {
 		return champ.Tags, champ.Info.Defense
 	}
 	return []string{}, 5
 }
 
 // doRequest makes an HTTP request to Riot API.
-func (c *Client) doRequest(reqURL string) ([]byte, error) {
-	req, err := http.NewRequest("GET", reqURL, nil)
+// endpoint is a short label for metrics (e.g. "match", "league").
+func (c *Client) doRequest(ctx context.Context, endpoint, reqURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("X-Riot-Token", c.apiKey)
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
+	requestDuration.Observe(time.Since(start).Seconds(), endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		err = fmt.Errorf("request failed: %w", err)
+		c.calls.Record(0, err)
+		requestsTotal.Inc(endpoint, "error")
+		return nil, err
 	}
 	defer resp.Body.Close()
+	requestsTotal.Inc(endpoint, strconv.Itoa(resp.StatusCode))
+	slog.DebugContext(ctx, "Riot request", "endpoint", endpoint, "status", resp.StatusCode, "took", time.Since(start))
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		c.calls.Record(resp.StatusCode, err)
+		return nil, err
 	}
 
-	return io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	c.calls.Record(resp.StatusCode, err)
+	return body, err
+}
+
+// CallStats returns a snapshot of Riot API call results.
+func (c *Client) CallStats() healthcheck.CallSnapshot {
+	return c.calls.Snapshot()
 }
 
 // GetPUUIDByRiotID gets PUUID from Riot ID (Name#Tag).
 // Uses Redis cache to avoid repeated API calls.
-func (c *Client) GetPUUIDByRiotID(gameName, tagLine string) (string, error) {
+func (c *Client) GetPUUIDByRiotID(ctx context.Context, gameName, tagLine string) (string, error) {
 	// Create cache key (lowercase for consistency)
 	cacheKey := fmt.Sprintf("puuid:%s#%s", strings.ToLower(gameName), strings.ToLower(tagLine))
 
 	// Check cache first
-	if c.redisClient != nil {
-		if cached, err := c.redisClient.Get(cacheKey); err == nil && cached != "" {
-			log.Printf("PUUID cache hit for %s#%s", gameName, tagLine)
+	if c.store != nil {
+		if cached, ok := c.store.CacheGet("puuid", cacheKey); ok {
+			slog.DebugContext(ctx, "PUUID cache hit", "riot_id", gameName+"#"+tagLine)
 			return cached, nil
 		}
 	}
@@ -126,9 +143,9 @@ func (c *Client) GetPUUIDByRiotID(gameName, tagLine string) (string, error) {
 		url.PathEscape(tagLine),
 	)
 
-	body, err := c.doRequest(reqURL)
+	body, err := c.doRequest(ctx, "account", reqURL)
 	if err != nil {
-		log.Printf("Error fetching PUUID for %s#%s: %v", gameName, tagLine, err)
+		slog.WarnContext(ctx, "Fetching PUUID failed", "riot_id", gameName+"#"+tagLine, "error", err)
 		return "", err
 	}
 
@@ -137,20 +154,10 @@ func (c *Client) GetPUUIDByRiotID(gameName, tagLine string) (string, error) {
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// DEBUG LOGS
-	if c.redisClient == nil {
-		log.Println("DEBUG: redisClient is nil")
-	} else {
-		log.Printf("DEBUG: redisClient is set. Saving PUUID %s to key %s", resp.PUUID, cacheKey)
-		// Save to cache (permanent storage)
-		if resp.PUUID != "" {
-			if err := c.redisClient.Set(cacheKey, resp.PUUID); err != nil {
-				log.Printf("ERROR: Failed to cache PUUID: %v", err)
-			} else {
-				log.Printf("SUCCESS: PUUID cached for %s#%s", gameName, tagLine)
-			}
-		} else {
-			log.Println("DEBUG: PUUID is empty, skipping cache")
+	// Save to cache (permanent storage)
+	if c.store != nil && resp.PUUID != "" {
+		if err := c.store.CacheSet("puuid", cacheKey, resp.PUUID); err != nil {
+			slog.WarnContext(ctx, "Caching PUUID failed", "riot_id", gameName+"#"+tagLine, "error", err)
 		}
 	}
 
@@ -158,16 +165,26 @@ func (c *Client) GetPUUIDByRiotID(gameName, tagLine string) (string, error) {
 }
 
 // GetMatchIDsByPUUID gets list of recent match IDs.
-func (c *Client) GetMatchIDsByPUUID(puuid string, count int) ([]string, error) {
-	reqURL := fmt.Sprintf("%s/lol/match/v5/matches/by-puuid/%s/ids?start=0&count=%d",
+func (c *Client) GetMatchIDsByPUUID(ctx context.Context, puuid string, count int) ([]string, error) {
+	return c.GetMatchIDs(ctx, puuid, 0, count, 0)
+}
+
+// GetMatchIDs gets a page of match IDs, newest first, optionally limited to
+// one queue. queueID 0 means every queue.
+func (c *Client) GetMatchIDs(ctx context.Context, puuid string, start, count, queueID int) ([]string, error) {
+	reqURL := fmt.Sprintf("%s/lol/match/v5/matches/by-puuid/%s/ids?start=%d&count=%d",
 		c.baseURLMatch,
 		puuid,
+		start,
 		count,
 	)
+	if queueID > 0 {
+		reqURL += fmt.Sprintf("&queue=%d", queueID)
+	}
 
-	body, err := c.doRequest(reqURL)
+	body, err := c.doRequest(ctx, "match-ids", reqURL)
 	if err != nil {
-		log.Printf("Error fetching match IDs for %s: %v", puuid, err)
+		slog.WarnContext(ctx, "Fetching match IDs failed", "puuid", puuid, "error", err)
 		return nil, err
 	}
 
@@ -180,11 +197,11 @@ func (c *Client) GetMatchIDsByPUUID(puuid string, count int) ([]string, error) {
 }
 
 // GetSummonerByPUUID gets summoner data from PUUID.
-func (c *Client) GetSummonerByPUUID(puuid string) (*SummonerDTO, error) {
+func (c *Client) GetSummonerByPUUID(ctx context.Context, puuid string) (*SummonerDTO, error) {
 	// Check cache
 	cacheKey := fmt.Sprintf("summoner:%s", puuid)
-	if c.redisClient != nil {
-		if cached, err := c.redisClient.Get(cacheKey); err == nil && cached != "" {
+	if c.store != nil {
+		if cached, ok := c.store.CacheGet("summoner", cacheKey); ok {
 			var summoner SummonerDTO
 			if err := json.Unmarshal([]byte(cached), &summoner); err == nil {
 				return &summoner, nil
@@ -203,9 +220,7 @@ func (c *Client) GetSummonerByPUUID(puuid string) (*SummonerDTO, error) {
 		puuid,
 	)
 
-	log.Printf("Fetching summoner from: %s", reqURL)
-
-	body, err := c.doRequest(reqURL)
+	body, err := c.doRequest(ctx, "summoner", reqURL)
 	if err != nil {
 		return nil, err
 	}
@@ -215,29 +230,37 @@ func (c *Client) GetSummonerByPUUID(puuid string) (*SummonerDTO, error) {
 		return nil, fmt.Errorf("failed to parse summoner response: %w", err)
 	}
 
-	// Cache for 1 hour (summoner data rarely changes)
-	if c.redisClient != nil && summoner.ID != "" {
+	// Cache (summoner data rarely changes)
+	if c.store != nil && summoner.ID != "" {
 		data, _ := json.Marshal(summoner)
-		c.redisClient.Set(cacheKey, string(data))
+		c.store.CacheSet("summoner", cacheKey, string(data))
 	}
 
 	return &summoner, nil
 }
 
 // GetLeagueEntriesByPUUID gets ranked entries directly by PUUID.
-func (c *Client) GetLeagueEntriesByPUUID(puuid string) ([]LeagueEntryDTO, error) {
+func (c *Client) GetLeagueEntriesByPUUID(ctx context.Context, puuid string) ([]LeagueEntryDTO, error) {
 	// Check cache
 	cacheKey := fmt.Sprintf("league:puuid:%s", puuid)
-	if c.redisClient != nil {
-		if cached, err := c.redisClient.Get(cacheKey); err == nil && cached != "" {
+	if c.store != nil {
+		if cached, ok := c.store.CacheGet("league", cacheKey); ok {
 			var entries []LeagueEntryDTO
 			if err := json.Unmarshal([]byte(cached), &entries); err == nil {
-				log.Printf("League cache hit for PUUID %s", puuid[:8])
+				slog.DebugContext(ctx, "League cache hit", "puuid", puuid)
 				return entries, nil
 			}
 		}
 	}
 
+	return c.RefreshLeagueEntries(ctx, puuid)
+}
+
+// RefreshLeagueEntries gets ranked entries by PUUID, bypassing and then
+// updating the cache. Used right after a game, when the cached LP is stale.
+func (c *Client) RefreshLeagueEntries(ctx context.Context, puuid string) ([]LeagueEntryDTO, error) {
+	cacheKey := fmt.Sprintf("league:puuid:%s", puuid)
+
 	// Use platform URL
 	baseURL := c.baseURLPlatform
 	if baseURL == "" {
@@ -249,9 +272,7 @@ func (c *Client) GetLeagueEntriesByPUUID(puuid string) ([]LeagueEntryDTO, error)
 		puuid,
 	)
 
-	log.Printf("Fetching league entries by PUUID from: %s", reqURL)
-
-	body, err := c.doRequest(reqURL)
+	body, err := c.doRequest(ctx, "league", reqURL)
 	if err != nil {
 		return nil, err
 	}
@@ -261,140 +282,111 @@ func (c *Client) GetLeagueEntriesByPUUID(puuid string) ([]LeagueEntryDTO, error)
 		return nil, fmt.Errorf("failed to parse league entries: %w", err)
 	}
 
-	// Cache for 10 minutes
-	if c.redisClient != nil {
+	// Cache briefly, ranks change every game
+	if c.store != nil {
 		data, _ := json.Marshal(entries)
-		c.redisClient.Set(cacheKey, string(data))
+		c.store.CacheSet("league", cacheKey, string(data))
 	}
 
 	return entries, nil
 }
 
-// GetPlayerRankInfo gets complete rank info for a player.
-// Uses direct PUUID to League API endpoint.
-func (c *Client) GetPlayerRankInfo(puuid, name string) (*PlayerRankInfo, error) {
-	if puuid == "" {
-		return nil, fmt.Errorf("empty PUUID")
+// GetTopMasteries gets a player's highest champion masteries, best first.
+func (c *Client) GetTopMasteries(ctx context.Context, puuid string, count int) ([]ChampionMasteryDTO, error) {
+	// Check cache
+	cacheKey := fmt.Sprintf("mastery:top:%s:%d", puuid, count)
+	if c.store != nil {
+		if cached, ok := c.store.CacheGet("mastery", cacheKey); ok {
+			var masteries []ChampionMasteryDTO
+			if err := json.Unmarshal([]byte(cached), &masteries); err == nil {
+				return masteries, nil
+			}
+		}
 	}
 
-	// Use direct PUUID endpoint (no need for Summoner API)
-	entries, err := c.GetLeagueEntriesByPUUID(puuid)
-	if err != nil {
-		log.Printf("League API failed for %s: %v", name, err)
-		// Fallback to match history
-		return c.buildRankInfoFromMatches(puuid, name)
+	baseURL := c.baseURLPlatform
+	if baseURL == "" {
+		baseURL = "https://vn2.api.riotgames.com"
 	}
 
-	return c.buildRankInfoFromLeague(puuid, name, entries), nil
-}
-
-// buildRankInfoFromLeague builds rank info from league entries.
-func (c *Client) buildRankInfoFromLeague(puuid, name string, entries []LeagueEntryDTO) *PlayerRankInfo {
-	var soloEntry, flexEntry *LeagueEntryDTO
-	for i := range entries {
-		switch entries[i].QueueType {
-		case "RANKED_SOLO_5x5":
-			soloEntry = &entries[i]
-		case "RANKED_FLEX_SR":
-			flexEntry = &entries[i]
-		}
-	}
+	reqURL := fmt.Sprintf("%s/lol/champion-mastery/v4/champion-masteries/by-puuid/%s/top?count=%d",
+		baseURL,
+		puuid,
+		count,
+	)
 
-	// Prioritize solo queue, fallback to flex
-	entry := soloEntry
-	if entry == nil {
-		entry = flexEntry
+	body, err := c.doRequest(ctx, "mastery", reqURL)
+	if err != nil {
+		return nil, err
 	}
 
-	info := &PlayerRankInfo{
-		Name:  name,
-		PUUID: puuid,
+	var masteries []ChampionMasteryDTO
+	if err := json.Unmarshal(body, &masteries); err != nil {
+		return nil, fmt.Errorf("failed to parse mastery response: %w", err)
 	}
 
-	if entry == nil {
-		info.Tier = "UNRANKED"
-		info.QueueType = ""
-		info.TierValue = 0
-		return info
+	if c.store != nil {
+		data, _ := json.Marshal(masteries)
+		c.store.CacheSet("mastery", cacheKey, string(data))
 	}
 
-	info.Tier = entry.Tier
-	info.Rank = entry.Rank
-	info.LP = entry.LeaguePoints
-	info.Wins = entry.Wins
-	info.Losses = entry.Losses
-	info.TotalGames = entry.Wins + entry.Losses
-	info.HotStreak = entry.HotStreak
-	info.QueueType = entry.QueueType
-	info.TierValue = TierOrder[entry.Tier]*100 + RankOrder[entry.Rank]*10 + entry.LeaguePoints/10
+	return masteries, nil
+}
 
-	if info.TotalGames > 0 {
-		info.WinRate = float64(entry.Wins) / float64(info.TotalGames) * 100
+// GetMasteryScore gets the sum of a player's champion mastery levels.
+func (c *Client) GetMasteryScore(ctx context.Context, puuid string) (int, error) {
+	// Check cache
+	cacheKey := fmt.Sprintf("mastery:score:%s", puuid)
+	if c.store != nil {
+		if cached, ok := c.store.CacheGet("mastery", cacheKey); ok {
+			if score, err := strconv.Atoi(cached); err == nil {
+				return score, nil
+			}
+		}
 	}
 
-	return info
-}
+	baseURL := c.baseURLPlatform
+	if baseURL == "" {
+		baseURL = "https://vn2.api.riotgames.com"
+	}
 
-// buildRankInfoFromMatches calculates stats from recent match history.
-func (c *Client) buildRankInfoFromMatches(puuid, name string) (*PlayerRankInfo, error) {
-	// Get recent matches (last 20)
-	matchIDs, err := c.GetMatchIDsByPUUID(puuid, 20)
-	if err != nil || len(matchIDs) == 0 {
-		return &PlayerRankInfo{
-			Name:      name,
-			PUUID:     puuid,
-			Tier:      "UNRANKED",
-			TierValue: 0,
-		}, nil
-	}
-
-	wins := 0
-	totalGames := 0
-
-	// Check up to 10 matches for performance
-	checkCount := min(10, len(matchIDs))
-	for i := 0; i < checkCount; i++ {
-		match, err := c.GetMatchDetails(matchIDs[i])
-		if err != nil {
-			continue
-		}
+	reqURL := fmt.Sprintf("%s/lol/champion-mastery/v4/scores/by-puuid/%s", baseURL, puuid)
 
-		// Find player in match
-		for _, p := range match.Info.Participants {
-			if p.PUUID == puuid {
-				totalGames++
-				if p.Win {
-					wins++
-				}
-				break
-			}
-		}
+	body, err := c.doRequest(ctx, "mastery-score", reqURL)
+	if err != nil {
+		return 0, err
 	}
 
-	info := &PlayerRankInfo{
-		Name:       name,
-		PUUID:      puuid,
-		Tier:       "N/A", // Can't determine tier from matches
-		Wins:       wins,
-		Losses:     totalGames - wins,
-		TotalGames: totalGames,
-		TierValue:  wins * 10, // Sort by wins
+	var score int
+	if err := json.Unmarshal(body, &score); err != nil {
+		return 0, fmt.Errorf("failed to parse mastery score: %w", err)
 	}
 
-	if totalGames > 0 {
-		info.WinRate = float64(wins) / float64(totalGames) * 100
+	if c.store != nil {
+		c.store.CacheSet("mastery", cacheKey, strconv.Itoa(score))
 	}
 
-	return info, nil
+	return score, nil
 }
 
 // GetMatchDetails gets full details of a match.
-func (c *Client) GetMatchDetails(matchID string) (*MatchResponse, error) {
+func (c *Client) GetMatchDetails(ctx context.Context, matchID string) (*MatchResponse, error) {
+	// Check cache (finished matches never change)
+	cacheKey := fmt.Sprintf("match:%s", matchID)
+	if c.store != nil {
+		if cached, ok := c.store.CacheGet("match", cacheKey); ok {
+			var resp MatchResponse
+			if err := json.Unmarshal([]byte(cached), &resp); err == nil {
+				return &resp, nil
+			}
+		}
+	}
+
 	reqURL := fmt.Sprintf("%s/lol/match/v5/matches/%s", c.baseURLMatch, matchID)
 
-	body, err := c.doRequest(reqURL)
+	body, err := c.doRequest(ctx, "match", reqURL)
 	if err != nil {
-		log.Printf("Error fetching details for match %s: %v", matchID, err)
+		slog.WarnContext(ctx, "Fetching match details failed", "match_id", matchID, "error", err)
 		return nil, err
 	}
 
@@ -403,16 +395,21 @@ func (c *Client) GetMatchDetails(matchID string) (*MatchResponse, error) {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	if c.store != nil && resp.Metadata.MatchID != "" {
+		data, _ := json.Marshal(resp)
+		c.store.CacheSet("match", cacheKey, string(data))
+	}
+
 	return &resp, nil
 }
 
 // GetMatchTimeline gets timeline data for a match.
-func (c *Client) GetMatchTimeline(matchID string) (*TimelineResponse, error) {
+func (c *Client) GetMatchTimeline(ctx context.Context, matchID string) (*TimelineResponse, error) {
 	reqURL := fmt.Sprintf("%s/lol/match/v5/matches/%s/timeline", c.baseURLMatch, matchID)
 
-	body, err := c.doRequest(reqURL)
+	body, err := c.doRequest(ctx, "timeline", reqURL)
 	if err != nil {
-		log.Printf("Error fetching timeline for match %s: %v", matchID, err)
+		slog.WarnContext(ctx, "Fetching match timeline failed", "match_id", matchID, "error", err)
 		return nil, err
 	}
 
@@ -449,7 +446,7 @@ func (c *Client) ParseMatchData(match *MatchResponse, targetPUUID string, timeli
 	}
 
 	if targetTeamID == 0 {
-		log.Println("Target player not found in match participants")
+		slog.Warn("Target player not found in match participants", "match_id", match.Metadata.MatchID, "puuid", targetPUUID)
 		return nil
 	}
 
@@ -506,6 +503,9 @@ func (c *Client) ParseMatchData(match *MatchResponse, targetPUUID string, timeli
 		GameDuration:        gameDuration,
 		GameDurationMinutes: math.Round(gameDurationMinutes*10) / 10,
 		GameMode:            info.GameMode,
+		QueueID:             info.QueueID,
+		Patch:               Patch(info.GameVersion),
+		GameEndTimestamp:    info.GameEndTimestamp,
 		Win:                 win,
 		TargetPlayerName:    targetName,
 		Teammates:           teammates,
@@ -514,12 +514,23 @@ func (c *Client) ParseMatchData(match *MatchResponse, targetPUUID string, timeli
 	}
 }
 
+// Patch returns the major.minor part of a game version, e.g. "15.20" for
+// "15.20.712.2345".
+func Patch(gameVersion string) string {
+	parts := strings.SplitN(gameVersion, ".", 3)
+	if len(parts) < 2 {
+		return gameVersion
+	}
+	return parts[0] + "." + parts[1]
+}
+
 // extractPlayerData extracts player data from participant.
 func (c *Client) extractPlayerData(p Participant, gameDurationMinutes float64) PlayerData {
 	tags, defense := c.GetChampionInfo(p.ChampionName)
 	totalCS := p.TotalMinionsKilled + p.NeutralMinionsKilled
 
 	return PlayerData{
+		PUUID:              p.PUUID,
 		ChampionName:       p.ChampionName,
 		ChampionTags:       tags,
 		ChampionDefense:    defense,
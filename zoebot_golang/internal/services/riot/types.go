@@ -10,9 +10,12 @@ type AccountResponse struct {
 
 // MatchInfo represents the info section of a match response.
 type MatchInfo struct {
-	GameDuration int64         `json:"gameDuration"`
-	GameMode     string        `json:"gameMode"`
-	Participants []Participant `json:"participants"`
+	GameDuration     int64         `json:"gameDuration"`
+	GameEndTimestamp int64         `json:"gameEndTimestamp"` // unix millis
+	GameMode         string        `json:"gameMode"`
+	GameVersion      string        `json:"gameVersion"` // e.g. 15.20.712.2345
+	QueueID          int           `json:"queueId"`
+	Participants     []Participant `json:"participants"`
 }
 
 // MatchResponse represents the full match response from Riot API.
@@ -120,28 +123,15 @@ type ParticipantFrame struct {
 	JungleMinionsKilled int `json:"jungleMinionsKilled"`
 }
 
-// ChampionData represents champion data from Data Dragon.
-type ChampionData struct {
-	Data map[string]ChampionInfo `json:"data"`
-}
-
-// ChampionInfo represents info about a single champion.
-type ChampionInfo struct {
-	Name string   `json:"name"`
-	Tags []string `json:"tags"`
-	Info struct {
-		Attack  int `json:"attack"`
-		Defense int `json:"defense"`
-		Magic   int `json:"magic"`
-	} `json:"info"`
-}
-
 // ParsedMatchData represents processed match data for AI analysis.
 type ParsedMatchData struct {
 	MatchID             string          `json:"matchId"`
 	GameDuration        int64           `json:"gameDuration"`
 	GameDurationMinutes float64         `json:"gameDurationMinutes"`
 	GameMode            string          `json:"gameMode"`
+	QueueID             int             `json:"queueId"`
+	Patch               string          `json:"patch"`            // e.g. 15.20
+	GameEndTimestamp    int64           `json:"gameEndTimestamp"` // unix millis
 	Win                 bool            `json:"win"`
 	TargetPlayerName    string          `json:"target_player_name"`
 	Teammates           []PlayerData    `json:"teammates"`
@@ -151,6 +141,7 @@ type ParsedMatchData struct {
 
 // PlayerData represents processed player data.
 type PlayerData struct {
+	PUUID             string   `json:"puuid"`
 	ChampionName      string   `json:"championName"`
 	ChampionTags      []string `json:"championTags"`
 	ChampionDefense   int      `json:"championDefense"`
@@ -282,41 +273,11 @@ type LeagueEntryDTO struct {
 	Inactive     bool   `json:"inactive"`
 }
 
-// PlayerRankInfo represents processed rank info for leaderboard.
-type PlayerRankInfo struct {
-	Name       string  // Riot ID (Name#Tag)
-	PUUID      string
-	Tier       string  // DIAMOND, PLATINUM...
-	Rank       string  // I, II, III, IV
-	LP         int
-	Wins       int
-	Losses     int
-	WinRate    float64
-	TotalGames int
-	HotStreak  bool
-	TierValue  int    // For sorting: Challenger=9, Unranked=0
-	QueueType  string // RANKED_SOLO_5x5, RANKED_FLEX_SR
-}
-
-// TierOrder maps tier names to numeric values for sorting.
-var TierOrder = map[string]int{
-	"CHALLENGER":  10,
-	"GRANDMASTER": 9,
-	"MASTER":      8,
-	"DIAMOND":     7,
-	"EMERALD":     6,
-	"PLATINUM":    5,
-	"GOLD":        4,
-	"SILVER":      3,
-	"BRONZE":      2,
-	"IRON":        1,
-	"UNRANKED":    0,
-}
-
-// RankOrder maps rank to numeric value for sorting within tier.
-var RankOrder = map[string]int{
-	"I":   4,
-	"II":  3,
-	"III": 2,
-	"IV":  1,
+// ChampionMasteryDTO represents one champion's mastery from Riot API.
+type ChampionMasteryDTO struct {
+	PUUID          string `json:"puuid"`
+	ChampionID     int    `json:"championId"`
+	ChampionLevel  int    `json:"championLevel"`
+	ChampionPoints int    `json:"championPoints"`
+	LastPlayTime   int64  `json:"lastPlayTime"` // unix millis
 }
@@ -1,8 +1,10 @@
 package scraper
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strings"
@@ -11,34 +13,52 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/zoebot/internal/data"
 	"github.com/zoebot/internal/storage"
+	"github.com/zoebot/pkg/metrics"
 )
 
+var scrapesTotal = metrics.NewCounterVec(
+	"zoebot_scraper_requests_total",
+	"Scrape attempts by source site and result (success/failure).",
+	"source", "result",
+)
+
+// recordScrape counts a scrape attempt for the given source.
+func recordScrape(source string, err error) {
+	if err != nil {
+		scrapesTotal.Inc(source, "failure")
+		return
+	}
+	scrapesTotal.Inc(source, "success")
+}
+
 // Client is the scraper client.
 type Client struct {
 	httpClient *http.Client
-	redis      *storage.RedisClient
+	store      *storage.Store
+	gameData   *data.Store // rune names
 }
 
 // NewClient creates a new scraper client.
-func NewClient(redis *storage.RedisClient) *Client {
+func NewClient(store *storage.Store, gameData *data.Store) *Client {
 	return &Client{
 		httpClient: &http.Client{Timeout: 10 * time.Second},
-		redis:      redis,
+		store:      store,
+		gameData:   gameData,
 	}
 }
 
 // GetCounters returns counter data including best and worst picks.
-func (c *Client) GetCounters(champion, lane string) (*CounterData, error) {
+func (c *Client) GetCounters(ctx context.Context, champion, lane string) (*CounterData, error) {
 	// Normalize inputs
 	normChamp := normalizeChampionName(champion)
 	normLane := normalizeLane(lane)
 
-	// Redis Key: counter:v4:{champ}:{lane}
-	cacheKey := fmt.Sprintf("counter:v4:%s:%s", normChamp, normLane)
+	// Cache key: counter:{champ}:{lane}
+	cacheKey := fmt.Sprintf("counter:%s:%s", normChamp, normLane)
 
 	// 1. Check Cache
-	if c.redis != nil {
-		if val, err := c.redis.Get(cacheKey); err == nil && val != "" {
+	if c.store != nil {
+		if val, ok := c.store.CacheGet("counter", cacheKey); ok {
 			var data CounterData
 			if err := json.Unmarshal([]byte(val), &data); err == nil {
 				return &data, nil
@@ -49,23 +69,25 @@ func (c *Client) GetCounters(champion, lane string) (*CounterData, error) {
 	// 2. Scrape from CounterStats.net
 	url := fmt.Sprintf("https://counterstats.net/league-of-legends/%s", normChamp)
 
-	data, err := c.scrapeCounterStats(url, normLane)
+	data, err := c.scrapeCounterStats(ctx, url, normLane)
+	recordScrape("counterstats", err)
 	if err != nil {
+		slog.WarnContext(ctx, "Scraping counters failed", "champion", normChamp, "lane", normLane, "error", err)
 		return nil, err
 	}
 
 	// 3. Save to Cache
-	if c.redis != nil && (len(data.BestPicks) > 0 || len(data.WorstPicks) > 0) {
+	if c.store != nil && (len(data.BestPicks) > 0 || len(data.WorstPicks) > 0) {
 		jsonData, _ := json.Marshal(data)
-		c.redis.Set(cacheKey, string(jsonData))
+		c.store.CacheSet("counter", cacheKey, string(jsonData))
 	}
 
 	return data, nil
 }
 
 // scrapeCounterStats scrapes counter data from counterstats.net
-func (c *Client) scrapeCounterStats(url, lane string) (*CounterData, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) scrapeCounterStats(ctx context.Context, url, lane string) (*CounterData, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -350,7 +372,7 @@ func normalizeChampionNameForOPGG(name string) string {
 }
 
 // GetBuild scrapes champion build data from OP.GG.
-func (c *Client) GetBuild(champion, role string) (*BuildData, error) {
+func (c *Client) GetBuild(ctx context.Context, champion, role string) (*BuildData, error) {
 	normChamp := normalizeChampionNameForOPGG(champion)
 	normRole := normalizeLaneForOPGG(role)
 
@@ -358,12 +380,12 @@ func (c *Client) GetBuild(champion, role string) (*BuildData, error) {
 		return nil, fmt.Errorf("invalid role: %s (use: top, jungle, mid, adc, support)", role)
 	}
 
-	// Redis Key: build:v3:{champ}:{role} (v3 = fixed duplicates)
-	cacheKey := fmt.Sprintf("build:v3:%s:%s", normChamp, normRole)
+	// Cache key: build:{champ}:{role}
+	cacheKey := fmt.Sprintf("build:%s:%s", normChamp, normRole)
 
 	// 1. Check Cache
-	if c.redis != nil {
-		if val, err := c.redis.Get(cacheKey); err == nil && val != "" {
+	if c.store != nil {
+		if val, ok := c.store.CacheGet("build", cacheKey); ok {
 			var data BuildData
 			if err := json.Unmarshal([]byte(val), &data); err == nil {
 				return &data, nil
@@ -374,23 +396,25 @@ func (c *Client) GetBuild(champion, role string) (*BuildData, error) {
 	// 2. Scrape from OP.GG
 	url := fmt.Sprintf("https://www.op.gg/champions/%s/build/%s", normChamp, normRole)
 
-	data, err := c.scrapeOPGGBuild(url, champion, role)
+	data, err := c.scrapeOPGGBuild(ctx, url, champion, role)
+	recordScrape("opgg", err)
 	if err != nil {
+		slog.WarnContext(ctx, "Scraping build failed", "champion", normChamp, "role", normRole, "error", err)
 		return nil, err
 	}
 
-	// 3. Save to Cache (10 minutes)
-	if c.redis != nil && data != nil {
+	// 3. Save to Cache
+	if c.store != nil && data != nil {
 		jsonData, _ := json.Marshal(data)
-		c.redis.Set(cacheKey, string(jsonData))
+		c.store.CacheSet("build", cacheKey, string(jsonData))
 	}
 
 	return data, nil
 }
 
 // scrapeOPGGBuild scrapes build data from OP.GG page.
-func (c *Client) scrapeOPGGBuild(url, champion, role string) (*BuildData, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) scrapeOPGGBuild(ctx context.Context, url, champion, role string) (*BuildData, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -486,11 +510,11 @@ func (c *Client) extractRunes(doc *goquery.Document, result *BuildData) {
 		treeID := extractIDFromURL(src)
 		if treeID > 0 {
 			if result.PrimaryTree == "" {
-				if name := data.GetPerkStyleName(treeID); name != "" {
+				if name := c.gameData.PerkStyleName(treeID); name != "" {
 					result.PrimaryTree = name
 				}
 			} else if result.SecondaryTree == "" {
-				if name := data.GetPerkStyleName(treeID); name != "" {
+				if name := c.gameData.PerkStyleName(treeID); name != "" {
 					result.SecondaryTree = name
 				}
 			}
@@ -523,7 +547,7 @@ func (c *Client) extractRunes(doc *goquery.Document, result *BuildData) {
 		if i >= 4 {
 			break
 		}
-		if name := data.GetPerkName(id); name != "" {
+		if name := c.gameData.PerkName(id); name != "" {
 			result.PrimaryRunes = append(result.PrimaryRunes, name)
 			// Save keystone ID (first rune)
 			if i == 0 {
@@ -534,7 +558,7 @@ func (c *Client) extractRunes(doc *goquery.Document, result *BuildData) {
 
 	// Secondary runes: next 2
 	for i := 4; i < len(primaryRuneIDs) && i < 6; i++ {
-		if name := data.GetPerkName(primaryRuneIDs[i]); name != "" {
+		if name := c.gameData.PerkName(primaryRuneIDs[i]); name != "" {
 			secondaryRuneIDs = append(secondaryRuneIDs, primaryRuneIDs[i])
 			result.SecondaryRunes = append(result.SecondaryRunes, name)
 		}
@@ -545,7 +569,7 @@ func (c *Client) extractRunes(doc *goquery.Document, result *BuildData) {
 		if i >= 3 {
 			break
 		}
-		if name := data.GetPerkName(id); name != "" {
+		if name := c.gameData.PerkName(id); name != "" {
 			result.StatShards = append(result.StatShards, name)
 		}
 	}
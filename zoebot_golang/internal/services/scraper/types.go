@@ -1,5 +1,7 @@
 package scraper
 
+import "context"
+
 // CounterStats represents data for a single counter matchup.
 type CounterStats struct {
 	ChampionName string `json:"champion_name"`
@@ -14,9 +16,10 @@ type CounterData struct {
 	Lane       string          `json:"lane"`
 }
 
-// Scraper defines the interface for fetching counter data.
+// Scraper defines the interface for fetching counter and build data.
 type Scraper interface {
-	GetCounters(champion, lane string) (*CounterData, error)
+	GetCounters(ctx context.Context, champion, lane string) (*CounterData, error)
+	GetBuild(ctx context.Context, champion, role string) (*BuildData, error)
 }
 
 // BuildData represents champion build information from OP.GG.
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/zoebot/internal/config"
+)
+
+// Backend is a persistence engine. It stores two kinds of data: plain
+// string values under flat keys, optionally expiring (caches, analyses,
+// reply contexts), and records, small field maps grouped in a named
+// collection (one record per tracked player).
+//
+// Values that are missing and values that have expired look the same.
+type Backend interface {
+	// Name identifies the backend: "redis", "bolt" or "memory".
+	Name() string
+	Ping() error
+	Close() error
+
+	// Get returns the value at key and whether it exists.
+	Get(key string) (string, bool, error)
+	// Set stores value at key. A zero ttl means no expiry.
+	Set(key, value string, ttl time.Duration) error
+	// Delete removes keys and returns how many existed.
+	Delete(keys ...string) (int, error)
+	// DeletePrefix removes every key starting with prefix and returns how
+	// many there were.
+	DeletePrefix(prefix string) (int, error)
+	// Keys returns the keys starting with prefix, in no particular order.
+	Keys(prefix string) ([]string, error)
+
+	// Lock takes the named lock for at most ttl, returning ErrLocked if
+	// it is held elsewhere. Redis locks are shared by every instance; the
+	// file and memory backends only ever have one process.
+	Lock(name string, ttl time.Duration) (unlock func(), err error)
+
+	// Records returns every record of a collection by ID.
+	Records(collection string) (map[string]map[string]string, error)
+	// GetRecord returns one record of a collection and whether it exists.
+	GetRecord(collection, id string) (map[string]string, bool, error)
+	// GetRecords returns the records of a collection with the given IDs,
+	// leaving out the ones that don't exist.
+	GetRecords(collection string, ids []string) (map[string]map[string]string, error)
+	// PutRecord creates or replaces a record.
+	PutRecord(collection, id string, fields map[string]string) error
+	// CreateRecord stores a record unless one exists already, and reports
+	// whether it did.
+	CreateRecord(collection, id string, fields map[string]string) (bool, error)
+	// UpdateRecord sets fields of an existing record, leaving the others
+	// alone, and reports whether the record existed.
+	UpdateRecord(collection, id string, fields map[string]string) (bool, error)
+	// DeleteRecord removes a record.
+	DeleteRecord(collection, id string) error
+
+	// IndexAdd puts id in a time index with score (Unix seconds), moving it
+	// if it is there already. Indexes order the records of a collection
+	// without reading them; they are kept apart from the collection, so
+	// deleting a record leaves its index entry to IndexRemove.
+	IndexAdd(index, id string, score int64) error
+	// IndexRange returns the entries of an index scored in [min, max],
+	// highest score first (ties by ID, descending). A positive limit
+	// returns at most that many.
+	IndexRange(index string, min, max int64, limit int) ([]IndexEntry, error)
+	// IndexRemove removes ids from an index.
+	IndexRemove(index string, ids ...string) error
+}
+
+// IndexEntry is one entry of a time index.
+type IndexEntry struct {
+	ID    string
+	Score int64
+}
+
+// ErrLocked is returned by Backend.Lock when the lock is taken.
+var ErrLocked = errors.New("lock is held")
+
+// Open opens the backend selected by cfg.Storage.Backend. "auto" uses
+// Redis when a URL is configured and the bolt file otherwise. If Redis
+// can't be reached the store falls back to memory and Ping reports why.
+func Open(cfg *config.Config) (*Store, error) {
+	name := cfg.Storage.Backend
+	if name == "auto" {
+		name = "bolt"
+		if cfg.Redis.URL != "" {
+			name = "redis"
+		}
+	}
+
+	var store *Store
+	switch name {
+	case "redis":
+		backend, err := NewRedisBackend(cfg.Redis.URL)
+		if err != nil {
+			slog.Error("Redis connection failed, using memory only", "error", err)
+			store = NewStore(NewMemoryBackend())
+			store.degraded = fmt.Errorf("redis unavailable: %w", err)
+		} else {
+			store = NewStore(backend)
+		}
+	case "bolt":
+		backend, err := NewBoltBackend(cfg.Storage.Path)
+		if err != nil {
+			return nil, err
+		}
+		store = NewStore(backend)
+	case "memory":
+		store = NewStore(NewMemoryBackend())
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+
+	store.SetCacheTTLs(cfg.Cache.TTLs())
+	slog.Info("Storage opened", "backend", store.Name())
+	return store, nil
+}
@@ -0,0 +1,650 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zoebot/internal/config"
+)
+
+// backends returns a fresh instance of every backend that runs without a
+// server.
+func backends(t *testing.T) map[string]Backend {
+	t.Helper()
+	bolt, err := NewBoltBackend(filepath.Join(t.TempDir(), "zoebot.db"))
+	if err != nil {
+		t.Fatalf("NewBoltBackend: %v", err)
+	}
+	all := map[string]Backend{
+		"memory": NewMemoryBackend(),
+		"bolt":   bolt,
+	}
+	for _, b := range all {
+		t.Cleanup(func() { b.Close() })
+	}
+	return all
+}
+
+func TestBackendValues(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, ok, err := b.Get("missing"); ok || err != nil {
+				t.Fatalf("Get(missing) = %v, %v; want not found", ok, err)
+			}
+
+			b.Set("counter:zoe", "a", 0)
+			b.Set("counter:lux", "b", 0)
+			b.Set("build:zoe", "c", 0)
+			b.Set("puuid:gone", "d", time.Nanosecond)
+			time.Sleep(time.Millisecond)
+
+			if v, ok, _ := b.Get("counter:zoe"); !ok || v != "a" {
+				t.Errorf("Get(counter:zoe) = %q, %v; want a", v, ok)
+			}
+			if _, ok, _ := b.Get("puuid:gone"); ok {
+				t.Error("expired value still readable")
+			}
+			if n, _ := b.Delete("puuid:gone"); n != 0 {
+				t.Errorf("Delete(expired) = %d; want 0", n)
+			}
+
+			n, err := b.DeletePrefix("counter:")
+			if err != nil || n != 2 {
+				t.Fatalf("DeletePrefix = %d, %v; want 2", n, err)
+			}
+			if _, ok, _ := b.Get("build:zoe"); !ok {
+				t.Error("DeletePrefix removed a key outside the prefix")
+			}
+			if n, _ := b.Delete("build:zoe", "missing"); n != 1 {
+				t.Errorf("Delete = %d; want 1", n)
+			}
+		})
+	}
+}
+
+func TestBackendRecords(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if ok, _ := b.UpdateRecord("players", "p1", map[string]string{"name": "x"}); ok {
+				t.Error("UpdateRecord created a missing record")
+			}
+			if ok, err := b.CreateRecord("players", "p1", map[string]string{"name": "Zoe", "lane": "mid"}); !ok || err != nil {
+				t.Fatalf("CreateRecord = %v, %v", ok, err)
+			}
+			if ok, _ := b.CreateRecord("players", "p1", map[string]string{"name": "other"}); ok {
+				t.Error("CreateRecord overwrote an existing record")
+			}
+			if ok, _ := b.UpdateRecord("players", "p1", map[string]string{"name": "Zoe2"}); !ok {
+				t.Error("UpdateRecord missed an existing record")
+			}
+			b.PutRecord("players", "p2", map[string]string{"name": "Lux"})
+
+			records, err := b.Records("players")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 || records["p1"]["name"] != "Zoe2" || records["p1"]["lane"] != "mid" || records["p2"]["name"] != "Lux" {
+				t.Errorf("Records = %v", records)
+			}
+			if fields, ok, err := b.GetRecord("players", "p2"); !ok || err != nil || fields["name"] != "Lux" {
+				t.Errorf("GetRecord(p2) = %v, %v, %v", fields, ok, err)
+			}
+			if _, ok, _ := b.GetRecord("players", "p3"); ok {
+				t.Error("GetRecord found a missing record")
+			}
+			if records, _ := b.GetRecords("players", []string{"p2", "p3", "p1"}); len(records) != 2 || records["p1"]["name"] != "Zoe2" {
+				t.Errorf("GetRecords = %v; want p1 and p2", records)
+			}
+
+			b.DeleteRecord("players", "p1")
+			if records, _ := b.Records("players"); len(records) != 1 {
+				t.Errorf("after DeleteRecord, Records = %v", records)
+			}
+			if records, _ := b.Records("other"); len(records) != 0 {
+				t.Errorf("Records(other) = %v; want empty", records)
+			}
+		})
+	}
+}
+
+func TestBackendIndex(t *testing.T) {
+	ids := func(entries []IndexEntry) []string {
+		out := make([]string, len(entries))
+		for n, e := range entries {
+			out[n] = e.ID
+		}
+		return out
+	}
+	equal := func(a, b []string) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for n := range a {
+			if a[n] != b[n] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for id, score := range map[string]int64{"m1": 100, "m2": 200, "m3": 200, "m4": 300, "old": -50} {
+				if err := b.IndexAdd("played", id, score); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b.IndexAdd("other", "x", 150)
+
+			all, err := b.IndexRange("played", math.MinInt64, math.MaxInt64, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"m4", "m3", "m2", "m1", "old"}; !equal(ids(all), want) || all[0].Score != 300 || all[4].Score != -50 {
+				t.Errorf("IndexRange = %+v; want %v, highest first", all, want)
+			}
+
+			for _, tt := range []struct {
+				min, max int64
+				limit    int
+				want     []string
+			}{
+				{100, 200, 0, []string{"m3", "m2", "m1"}},
+				{150, 250, 0, []string{"m3", "m2"}},
+				{0, math.MaxInt64, 2, []string{"m4", "m3"}},
+				{301, math.MaxInt64, 0, nil},
+				{math.MinInt64, 0, 0, []string{"old"}},
+			} {
+				got, err := b.IndexRange("played", tt.min, tt.max, tt.limit)
+				if err != nil || !equal(ids(got), tt.want) {
+					t.Errorf("IndexRange(%d, %d, %d) = %v, %v; want %v", tt.min, tt.max, tt.limit, ids(got), err, tt.want)
+				}
+			}
+
+			// Adding again moves the entry
+			b.IndexAdd("played", "m1", 400)
+			if got, _ := b.IndexRange("played", 0, math.MaxInt64, 1); len(got) != 1 || got[0].ID != "m1" || got[0].Score != 400 {
+				t.Errorf("after moving m1, newest = %+v", got)
+			}
+			if err := b.IndexRemove("played", "m1", "m4", "missing"); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := b.IndexRange("played", math.MinInt64, math.MaxInt64, 0); !equal(ids(got), []string{"m3", "m2", "old"}) {
+				t.Errorf("after IndexRemove = %v", ids(got))
+			}
+			if got, _ := b.IndexRange("missing", math.MinInt64, math.MaxInt64, 0); len(got) != 0 {
+				t.Errorf("missing index = %v", got)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	keys := config.Defaults().Redis
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			b.Set(keys.KeyTrackedPlayers, `{
+				"puuid-1": {"puuid": "puuid-1", "name": "Zoe#VN2", "channel_id": "c1", "last_match_id": "VN2_1"},
+				"puuid-2": {"name": "Lux#VN2", "channel_id": "c2"},
+				"Ahri#VN2": {"name": "Ahri#VN2", "channel_id": "c3"}
+			}`, 0)
+
+			b.Set("counter:v4:zoe:mid", "old", 0)
+			b.Set("build:v3:zoe:mid", "old", 0)
+			b.Set("counter:vayne:top", "new", 0)
+
+			// History stored before the time indexes
+			playedAt := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+			b.PutRecord(keys.KeyHistory+":player:puuid-1", "VN2_1", encodeMatch(&MatchRecord{MatchID: "VN2_1", PUUID: "puuid-1", PlayedAt: playedAt}))
+			b.PutRecord(keys.KeyHistory+":players", "puuid-1", indexFields(playedAt))
+			b.PutRecord(keys.KeyHistory+":ranks:puuid-1", "VN2_1", encodeRank(&RankSnapshot{PUUID: "puuid-1", QueueType: QueueSolo, MatchID: "VN2_1", TakenAt: playedAt, Tier: "GOLD", Rank: "II"}))
+			b.PutRecord(keys.KeyHistory+":ranked", "puuid-1", indexFields(playedAt))
+
+			// A dry run reports the changes without making them
+			results, err := Migrate(NewStore(b), keys, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 3 || len(results[0].Changes) != 4 || len(results[1].Changes) != 1 || len(results[2].Changes) != 2 {
+				t.Fatalf("dry run results = %+v", results)
+			}
+			if version, _ := SchemaVersion(b); version != 0 {
+				t.Fatalf("dry run set schema version %d", version)
+			}
+			if _, ok, _ := b.Get(keys.KeyTrackedPlayers); !ok {
+				t.Fatal("dry run moved the legacy key")
+			}
+
+			if _, err := Migrate(NewStore(b), keys, false); err != nil {
+				t.Fatal(err)
+			}
+			if version, _ := SchemaVersion(b); version != LatestSchema() {
+				t.Errorf("schema version = %d; want %d", version, LatestSchema())
+			}
+			if results, err := Migrate(NewStore(b), keys, false); err != nil || len(results) != 0 {
+				t.Errorf("second Migrate = %+v, %v; want nothing to do", results, err)
+			}
+			if keys, _ := b.Keys("counter:"); len(keys) != 1 || keys[0] != "counter:vayne:top" {
+				t.Errorf("counter keys after migration = %v", keys)
+			}
+			if _, ok, _ := b.Get("build:v3:zoe:mid"); ok {
+				t.Error("versioned build key not dropped")
+			}
+			history := NewHistoryStore(NewStore(b), keys, config.HistoryConfig{})
+			if got, _ := history.ByPlayer("puuid-1", HistoryQuery{From: playedAt}); len(got) != 1 || got[0].MatchID != "VN2_1" {
+				t.Errorf("indexed history = %+v", got)
+			}
+			if got, _ := history.Ranks("puuid-1", QueueSolo, time.Time{}, time.Time{}); len(got) != 1 || got[0].Tier != "GOLD" {
+				t.Errorf("indexed ranks = %+v", got)
+			}
+			if got, _ := b.IndexRange(keys.KeyHistory+":index:players", playedAt.Unix(), math.MaxInt64, 0); len(got) != 1 || got[0].ID != "puuid-1" {
+				t.Errorf("players index = %+v", got)
+			}
+
+			players := NewTrackedPlayersStore(NewStore(b), keys)
+			if err := players.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if players.Count() != 2 {
+				t.Fatalf("Count = %d; want 2 (the Riot-ID-keyed entry has no PUUID)", players.Count())
+			}
+			if p, _ := players.Get("puuid-2"); p == nil || p.PUUID != "puuid-2" || p.ChannelID != "c2" {
+				t.Errorf("puuid-2 = %+v", p)
+			}
+			if _, ok, _ := b.Get(keys.KeyTrackedPlayers); ok {
+				t.Error("legacy key not moved")
+			}
+			if _, ok, _ := b.Get(keys.KeyTrackedPlayers + ":migrated"); !ok {
+				t.Error("legacy backup missing")
+			}
+
+			players.UpdateLastMatch("puuid-1", "VN2_2")
+			players.UpdateTilt("puuid-1", "user-1", true)
+			players.Delete("puuid-2")
+			players.UpdateLastMatch("puuid-2", "VN2_9") // must not bring it back
+
+			reloaded := NewTrackedPlayersStore(NewStore(b), keys)
+			if err := reloaded.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if reloaded.Count() != 1 {
+				t.Errorf("reloaded Count = %d; want 1", reloaded.Count())
+			}
+			if p, _ := reloaded.Get("puuid-1"); p == nil || p.LastMatchID != "VN2_2" || p.DiscordID != "user-1" || !p.TiltOff {
+				t.Errorf("reloaded puuid-1 = %+v", p)
+			}
+		})
+	}
+}
+
+func TestBoltPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zoebot.db")
+	b, err := NewBoltBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Set("puuid:zoe#vn2", "puuid-1", 0)
+	b.PutRecord("players", "puuid-1", map[string]string{"name": "Zoe"})
+	b.Close()
+
+	b, err = NewBoltBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if v, ok, _ := b.Get("puuid:zoe#vn2"); !ok || v != "puuid-1" {
+		t.Errorf("Get after reopen = %q, %v", v, ok)
+	}
+	if records, _ := b.Records("players"); records["puuid-1"]["name"] != "Zoe" {
+		t.Errorf("Records after reopen = %v", records)
+	}
+}
+
+func TestBackendSweep(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			b.Set("a", "1", time.Nanosecond)
+			b.Set("b", "2", 0)
+			b.Set("c", "3", time.Nanosecond)
+			time.Sleep(time.Millisecond)
+
+			n, err := b.(interface{ sweep() (int, error) }).sweep()
+			if err != nil || n != 2 {
+				t.Errorf("sweep = %d, %v; want 2", n, err)
+			}
+			if _, ok, _ := b.Get("b"); !ok {
+				t.Error("sweep removed a live value")
+			}
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			unlock, err := b.Lock("lock", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.Lock("lock", time.Minute); !errors.Is(err, ErrLocked) {
+				t.Fatalf("second Lock err = %v; want ErrLocked", err)
+			}
+			unlock()
+			unlock, err = b.Lock("lock", time.Minute)
+			if err != nil {
+				t.Fatalf("Lock after unlock: %v", err)
+			}
+			unlock()
+		})
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	keys := config.Defaults().Redis
+	src := NewStore(NewMemoryBackend())
+	defer src.Close()
+	if _, err := Migrate(src, keys, false); err != nil {
+		t.Fatal(err)
+	}
+	players := NewTrackedPlayersStore(src, keys)
+	players.Set("puuid-1", &TrackedPlayer{PUUID: "puuid-1", Name: "Zoe#VN2", ChannelID: "c1", LastMatchID: "VN2_1"})
+	src.CacheSet("analysis", "analysis:m1", `{"match_id":"m1"}`)
+	src.CacheSet("counter", "counter:zoe:mid", "not backed up")
+	NewHistoryStore(src, keys, config.HistoryConfig{}).Add(&MatchRecord{
+		MatchID: "m1", PUUID: "puuid-1", GuildID: "g1", PlayedAt: time.Unix(1790000000, 0), Kills: 5,
+	})
+	NewHistoryStore(src, keys, config.HistoryConfig{}).AddRank(&RankSnapshot{
+		PUUID: "puuid-1", QueueType: QueueSolo, MatchID: "m1", TakenAt: time.Unix(1790000000, 0), Tier: "GOLD", Rank: "II", LP: 40,
+	})
+	NewGuildStore(src, keys).Put(&GuildSettings{GuildID: "g1", MilestonesOff: []string{MilestoneStreak}})
+
+	archive, err := Backup(src, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.SchemaVersion != LatestSchema() || len(archive.Records["players"]) != 1 || len(archive.Records["history"]) != 1 || len(archive.Records["ranks"]) != 1 || len(archive.Records["guilds"]) != 1 || len(archive.Values["analysis"]) != 1 {
+		t.Fatalf("archive = %+v", archive)
+	}
+	if _, ok := archive.Values["counter"]; ok {
+		t.Error("archive includes the counter cache")
+	}
+
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			dst := NewStore(b)
+			if _, err := Restore(dst, keys, archive, true); err != nil {
+				t.Fatal(err)
+			}
+			if records, _ := b.Records(keys.KeyPlayers); len(records) != 0 {
+				t.Fatal("dry run wrote records")
+			}
+
+			summary, err := Restore(dst, keys, archive, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.Records["players"] != 1 || summary.Values["analysis"] != 1 {
+				t.Errorf("summary = %+v", summary)
+			}
+			restored := NewTrackedPlayersStore(dst, keys)
+			if err := restored.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if p, _ := restored.Get("puuid-1"); p == nil || p.LastMatchID != "VN2_1" || p.ChannelID != "c1" {
+				t.Errorf("restored player = %+v", p)
+			}
+			history := NewHistoryStore(dst, keys, config.HistoryConfig{})
+			if matches, _ := history.ByGuild("g1", HistoryQuery{}); len(matches) != 1 || matches[0].Kills != 5 {
+				t.Errorf("restored guild history = %+v", matches)
+			}
+			if rank, ok, _ := history.RankAt("puuid-1", "m1"); !ok || rank.Tier != "GOLD" || rank.LP != 40 {
+				t.Errorf("restored rank = %+v", rank)
+			}
+			if g, _ := NewGuildStore(dst, keys).Get("g1"); g == nil || g.MilestoneEnabled(MilestoneStreak) {
+				t.Errorf("restored guild settings = %+v", g)
+			}
+			if v, ok := dst.CacheGet("analysis", "analysis:m1"); !ok || v != `{"match_id":"m1"}` {
+				t.Errorf("restored analysis = %q, %v", v, ok)
+			}
+			if version, _ := SchemaVersion(b); version != LatestSchema() {
+				t.Errorf("schema version = %d, want %d", version, LatestSchema())
+			}
+		})
+	}
+
+	bad := *archive
+	bad.SchemaVersion = LatestSchema() + 1
+	if _, err := Restore(src, keys, &bad, true); err == nil {
+		t.Error("Restore accepted an archive from a newer schema")
+	}
+	bad = *archive
+	bad.Values = map[string]map[string]string{"analysis": {"counter:zoe:mid": "x"}}
+	if _, err := Restore(src, keys, &bad, true); err == nil {
+		t.Error("Restore accepted a key outside its family")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	keys := config.Defaults().Redis
+	day := func(n int) time.Time { return time.Now().UTC().Truncate(time.Second).AddDate(0, 0, -n) }
+
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			h := NewHistoryStore(NewStore(b), keys, config.HistoryConfig{MaxMatches: 4, Retention: 30 * 24 * time.Hour})
+			add := func(matchID, puuid, guildID string, queueID, daysAgo int) {
+				t.Helper()
+				err := h.Add(&MatchRecord{MatchID: matchID, PUUID: puuid, GuildID: guildID, QueueID: queueID, PlayedAt: day(daysAgo), Win: true, DamageShare: 25.5})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			add("m1", "p1", "g1", 420, 10)
+			add("m2", "p1", "g1", 440, 5)
+			add("m3", "p1", "g2", 420, 1)
+			add("m4", "p2", "g1", 420, 2)
+
+			all, err := h.ByPlayer("p1", HistoryQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 3 || all[0].MatchID != "m3" || all[2].MatchID != "m1" {
+				t.Fatalf("ByPlayer = %+v, want m3, m2, m1", all)
+			}
+			if !all[0].Win || all[0].DamageShare != 25.5 || !all[0].PlayedAt.Equal(day(1)) {
+				t.Errorf("record did not round-trip: %+v", all[0])
+			}
+
+			if got, _ := h.ByPlayer("p1", HistoryQuery{From: day(6), To: day(1)}); len(got) != 1 || got[0].MatchID != "m2" {
+				t.Errorf("date range = %+v, want m2", got)
+			}
+			if got, _ := h.ByPlayer("p1", HistoryQuery{QueueID: 420, Limit: 1}); len(got) != 1 || got[0].MatchID != "m3" {
+				t.Errorf("queue and limit = %+v, want m3", got)
+			}
+			if got, _ := h.ByGuild("g1", HistoryQuery{}); len(got) != 3 || got[0].MatchID != "m4" {
+				t.Errorf("ByGuild = %+v, want m4, m2, m1", got)
+			}
+			if got, _ := h.ByGuild("g1", HistoryQuery{From: day(3)}); len(got) != 1 || got[0].MatchID != "m4" {
+				t.Errorf("ByGuild since 3 days = %+v, want m4", got)
+			}
+
+			// Too old, then over the count limit
+			add("m0", "p1", "g1", 420, 40)
+			if _, ok, _ := h.Get("p1", "m0"); ok {
+				t.Error("match older than the retention was kept")
+			}
+			add("m5", "p1", "g1", 420, 0)
+			add("m6", "p1", "g1", 420, 0)
+			if got, _ := h.ByPlayer("p1", HistoryQuery{}); len(got) != 4 || got[3].MatchID != "m2" {
+				t.Errorf("after pruning = %+v, want the 4 newest", got)
+			}
+			if players, _ := h.Players(); len(players) != 2 {
+				t.Errorf("Players = %v", players)
+			}
+			if r, ok, err := h.Get("p1", "m5"); !ok || err != nil || r.MatchID != "m5" || !r.PlayedAt.Equal(day(0)) {
+				t.Errorf("Get(m5) = %+v, %v, %v", r, ok, err)
+			}
+			if _, ok, _ := h.Get("p1", "m1"); ok {
+				t.Error("Get found a pruned match")
+			}
+			if got, err := h.ActivePlayers(day(1)); err != nil || !slices.Equal(got, []string{"p1"}) {
+				t.Errorf("ActivePlayers since a day = %v, %v; want p1", got, err)
+			}
+			if got, _ := h.ActivePlayers(day(2)); !slices.Equal(got, []string{"p1", "p2"}) {
+				t.Errorf("ActivePlayers since 2 days = %v; want p1, p2", got)
+			}
+			if got, err := h.GetMany("p1", []string{"m5", "m1", "m3", "m4"}); err != nil || len(got) != 2 || got["m5"] == nil || got["m3"].GuildID != "g2" {
+				t.Errorf("GetMany = %v, %v; want m5 and m3", got, err)
+			}
+
+			// The sweep expires players who stopped playing
+			h.AddRank(&RankSnapshot{PUUID: "p2", QueueType: QueueSolo, MatchID: "m4", TakenAt: day(2), Tier: "GOLD", Rank: "I"})
+			deleted, err := h.Sweep(time.Now().AddDate(0, 0, 29))
+			if err != nil || deleted != 4 { // p1's m2 and m3, p2's m4 and its snapshot
+				t.Errorf("Sweep = %d, %v; want 4", deleted, err)
+			}
+			if players, _ := h.Players(); len(players) != 1 || players[0] != "p1" {
+				t.Errorf("Players after the sweep = %v; want p1", players)
+			}
+			if got, _ := h.ByPlayer("p1", HistoryQuery{}); len(got) != 2 {
+				t.Errorf("p1 after the sweep = %+v; want m6, m5", got)
+			}
+			if got, _ := h.ByGuild("g1", HistoryQuery{}); len(got) != 2 {
+				t.Errorf("ByGuild after the sweep = %+v; want m6, m5", got)
+			}
+			if got, _ := h.Ranks("p2", QueueSolo, time.Time{}, time.Time{}); len(got) != 0 {
+				t.Errorf("p2 ranks after the sweep = %+v", got)
+			}
+		})
+	}
+}
+
+func TestRanks(t *testing.T) {
+	keys := config.Defaults().Redis
+	at := func(hoursAgo int) time.Time {
+		return time.Now().UTC().Truncate(time.Second).Add(-time.Duration(hoursAgo) * time.Hour)
+	}
+
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			h := NewHistoryStore(NewStore(b), keys, config.HistoryConfig{MaxMatches: 3})
+			add := func(matchID, queueType string, hoursAgo int, tier, rank string, lp, wins int) {
+				t.Helper()
+				err := h.AddRank(&RankSnapshot{PUUID: "p1", QueueType: queueType, MatchID: matchID, TakenAt: at(hoursAgo), Tier: tier, Rank: rank, LP: lp, Wins: wins})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			add("m1", QueueSolo, 5, "GOLD", "I", 90, 10)
+			add("m2", QueueFlex, 4, "SILVER", "II", 10, 3)
+			add("m3", QueueSolo, 3, "PLATINUM", "IV", 10, 11)
+			add("m4", QueueSolo, 2, "GOLD", "I", 75, 11)
+
+			if got, _ := h.Ranks("p1", QueueSolo, time.Time{}, time.Time{}); len(got) != 2 || got[0].MatchID != "m4" {
+				t.Fatalf("solo ranks = %+v, want m4, m3 (m1 pruned)", got)
+			}
+
+			promotion, ok, err := h.RankChangeAt("p1", "m3")
+			if err != nil || !ok {
+				t.Fatalf("RankChangeAt(m3) = %v, %v", ok, err)
+			}
+			if promotion.Before != nil {
+				t.Errorf("m3 has a previous snapshot after pruning: %+v", promotion.Before)
+			}
+
+			demotion, _, _ := h.RankChangeAt("p1", "m4")
+			if demotion.LPDelta() != -35 || !demotion.Demoted() || demotion.Promoted() || demotion.NewTier() {
+				t.Errorf("Plat IV 10 -> Gold I 75 = %+d LP, demoted %v; want -35, demoted", demotion.LPDelta(), demotion.Demoted())
+			}
+		})
+	}
+
+	up := RankChange{
+		Before: &RankSnapshot{Tier: "GOLD", Rank: "I", LP: 90},
+		After:  &RankSnapshot{Tier: "PLATINUM", Rank: "IV", LP: 10},
+	}
+	if up.LPDelta() != 20 || !up.Promoted() || !up.NewTier() {
+		t.Errorf("Gold I 90 -> Plat IV 10 = %+d LP, promoted %v, new tier %v", up.LPDelta(), up.Promoted(), up.NewTier())
+	}
+	apex := RankChange{
+		Before: &RankSnapshot{Tier: "MASTER", Rank: "I", LP: 180},
+		After:  &RankSnapshot{Tier: "MASTER", Rank: "I", LP: 158},
+	}
+	if apex.LPDelta() != -22 || apex.Demoted() {
+		t.Errorf("Master 180 -> 158 = %+d LP, demoted %v", apex.LPDelta(), apex.Demoted())
+	}
+}
+
+func TestGuildSettings(t *testing.T) {
+	keys := config.Defaults().Redis
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			guilds := NewGuildStore(NewStore(b), keys)
+			g, err := guilds.Get("g1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, kind := range MilestoneKinds {
+				if !g.MilestoneEnabled(kind) {
+					t.Errorf("new guild has %s milestones off", kind)
+				}
+			}
+
+			g.SetMilestone(MilestoneStreak, false)
+			g.SetMilestone(MilestonePeak, false)
+			g.SetMilestone(MilestonePeak, true)
+			if err := guilds.Put(g); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := guilds.Get("g1")
+			if got.MilestoneEnabled(MilestoneStreak) || !got.MilestoneEnabled(MilestonePeak) || len(got.MilestonesOff) != 1 {
+				t.Errorf("stored settings = %+v, want only streak off", got)
+			}
+			if other, _ := guilds.Get("g2"); !other.MilestoneEnabled(MilestoneStreak) {
+				t.Error("settings leaked to another guild")
+			}
+
+			if got.NotifyMode() != NotifyBoth {
+				t.Errorf("notify mode = %q, want %q by default", got.NotifyMode(), NotifyBoth)
+			}
+			got.Notify = NotifySummaries
+			got.Timezone = "Asia/Tokyo"
+			got.SetRecapSchedule("c1", "0 9 * * 1")
+			got.SetRecapSchedule("c2", "off")
+			if err := guilds.Put(got); err != nil {
+				t.Fatal(err)
+			}
+			got.SetRecapSchedule("c2", "")
+			if err := guilds.Put(got); err != nil {
+				t.Fatal(err)
+			}
+			got, _ = guilds.Get("g1")
+			if got.NotifyMode() != NotifySummaries || got.Timezone != "Asia/Tokyo" || got.RecapSchedule("c1") != "0 9 * * 1" || got.RecapSchedule("c2") != "" || len(got.Recaps) != 1 {
+				t.Errorf("stored recap settings = %+v, want Tokyo and one schedule for c1", got)
+			}
+
+			// Update writes only what changed: a command saving in the middle
+			// of another one's read-modify-write keeps its setting
+			updated, err := guilds.Update("g3", func(g *GuildSettings) {
+				if _, err := guilds.Update("g3", func(g *GuildSettings) { g.Timezone = "Asia/Tokyo" }); err != nil {
+					t.Fatal(err)
+				}
+				g.Notify = NotifyGames
+				g.SetRecapSchedule("c1", "@daily")
+			})
+			if err != nil || updated.GuildID != "g3" || updated.Notify != NotifyGames {
+				t.Fatalf("Update = %+v, %v", updated, err)
+			}
+			if _, err := guilds.Update("g3", func(g *GuildSettings) { g.SetRecapSchedule("c1", "") }); err != nil {
+				t.Fatal(err)
+			}
+			got, _ = guilds.Get("g3")
+			if got.Notify != NotifyGames || got.Timezone != "Asia/Tokyo" || len(got.Recaps) != 0 {
+				t.Errorf("settings after concurrent updates = %+v, want both kept and the schedule cleared", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zoebot/internal/config"
+)
+
+// BackupFormat is the version of the Archive layout. Restore refuses newer
+// archives.
+const BackupFormat = 1
+
+// Archive is a backup of the bot state, independent of the backend it came
+// from. The data itself is in the layout of SchemaVersion; Restore migrates
+// it after writing it back.
+type Archive struct {
+	Format        int       `json:"format"`
+	CreatedAt     time.Time `json:"created_at"`
+	Backend       string    `json:"backend"`
+	SchemaVersion int       `json:"schema_version"`
+
+	// Records by section name (e.g. "players"), then record ID
+	Records map[string]map[string]map[string]string `json:"records"`
+	// Values by cache family (e.g. "analysis"), then key
+	Values map[string]map[string]string `json:"values"`
+}
+
+// backupSection reads and writes one section of an archive.
+type backupSection struct {
+	read  func() (map[string]map[string]string, error)
+	write func(id string, fields map[string]string) error
+}
+
+// backupSections are the record sections of an archive, by name.
+func backupSections(store *Store, keys config.RedisConfig) map[string]backupSection {
+	backend := store.Backend()
+	history := NewHistoryStore(store, keys, config.HistoryConfig{})
+	guilds := NewGuildStore(store, keys)
+	return map[string]backupSection{
+		"players": {
+			read: func() (map[string]map[string]string, error) { return backend.Records(keys.KeyPlayers) },
+			write: func(id string, fields map[string]string) error {
+				return backend.PutRecord(keys.KeyPlayers, id, fields)
+			},
+		},
+		"history": {read: history.exportRecords, write: history.importRecord},
+		"ranks":   {read: history.exportRanks, write: history.importRank},
+		"guilds":  {read: guilds.exportGuilds, write: guilds.importGuild},
+	}
+}
+
+// backupFamilies are the key families worth keeping. The other caches are
+// refetched on demand and reply contexts are short-lived.
+var backupFamilies = []string{"analysis"}
+
+// Backup reads the bot state into an archive.
+func Backup(store *Store, keys config.RedisConfig) (*Archive, error) {
+	backend := store.Backend()
+	version, err := SchemaVersion(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &Archive{
+		Format:        BackupFormat,
+		CreatedAt:     time.Now().UTC(),
+		Backend:       backend.Name(),
+		SchemaVersion: version,
+		Records:       make(map[string]map[string]map[string]string),
+		Values:        make(map[string]map[string]string),
+	}
+
+	for name, section := range backupSections(store, keys) {
+		records, err := section.read()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		archive.Records[name] = records
+	}
+
+	for _, family := range backupFamilies {
+		keys, err := backend.Keys(CacheFamilies[family])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", family, err)
+		}
+		values := make(map[string]string, len(keys))
+		for _, key := range keys {
+			value, ok, err := backend.Get(key)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", family, err)
+			}
+			if ok { // not expired since Keys
+				values[key] = value
+			}
+		}
+		archive.Values[family] = values
+	}
+	return archive, nil
+}
+
+// RestoreSummary counts what Restore wrote, by section or family.
+type RestoreSummary struct {
+	Records    map[string]int
+	Values     map[string]int
+	Migrations []MigrationResult
+}
+
+// Restore writes an archive back and migrates it to the current schema.
+// Records and values in the archive replace those with the same ID or key;
+// anything else already stored is kept. Values get their family's current
+// TTL. With dryRun nothing is written.
+func Restore(store *Store, keys config.RedisConfig, archive *Archive, dryRun bool) (*RestoreSummary, error) {
+	if archive.Format < 1 || archive.Format > BackupFormat {
+		return nil, fmt.Errorf("unsupported backup format %d (this build reads up to %d)", archive.Format, BackupFormat)
+	}
+	if archive.SchemaVersion > LatestSchema() {
+		return nil, fmt.Errorf("backup schema version %d is newer than this build (%d)", archive.SchemaVersion, LatestSchema())
+	}
+
+	sections := backupSections(store, keys)
+	for section := range archive.Records {
+		if _, ok := sections[section]; !ok {
+			return nil, fmt.Errorf("unknown backup section %q", section)
+		}
+	}
+	for family, values := range archive.Values {
+		prefix, ok := CacheFamilies[family]
+		if !ok {
+			return nil, fmt.Errorf("unknown cache family %q", family)
+		}
+		for key := range values {
+			if !strings.HasPrefix(key, prefix) {
+				return nil, fmt.Errorf("key %q is not in cache family %q", key, family)
+			}
+		}
+	}
+
+	summary := &RestoreSummary{Records: make(map[string]int), Values: make(map[string]int)}
+	backend := store.Backend()
+	for section, records := range archive.Records {
+		summary.Records[section] = len(records)
+		if dryRun {
+			continue
+		}
+		for id, fields := range records {
+			if err := sections[section].write(id, fields); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", section, id, err)
+			}
+		}
+	}
+	for family, values := range archive.Values {
+		summary.Values[family] = len(values)
+		if dryRun {
+			continue
+		}
+		for key, value := range values {
+			if err := store.CacheSet(family, key, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	if dryRun {
+		return summary, nil
+	}
+
+	// The restored data is in the archive's layout; migrate it from there
+	if err := backend.Set(SchemaVersionKey, fmt.Sprint(archive.SchemaVersion), 0); err != nil {
+		return nil, err
+	}
+	migrations, err := Migrate(store, keys, false)
+	summary.Migrations = migrations
+	return summary, err
+}
@@ -0,0 +1,467 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// valuesBucket holds the plain key/value entries. Each collection gets its
+// own bucket, named "records:<collection>", and each time index two:
+// "index:<name>" keyed by score and ID, and "index-ids:<name>" mapping IDs
+// to their score.
+var valuesBucket = []byte("values")
+
+// sweepInterval is how often expired values are removed.
+const sweepInterval = 10 * time.Minute
+
+// BoltBackend stores data in a single bbolt file, for self-hosting without
+// Redis. Values are stored with their expiry time in front: 8 bytes of
+// big-endian Unix nanoseconds, zero for never. Records are JSON objects.
+type BoltBackend struct {
+	localLocks // the file is opened by one process at a time
+	db         *bolt.DB
+	stop       func()
+}
+
+// NewBoltBackend opens (or creates) the database file at path. It fails if
+// another process has the file open.
+func NewBoltBackend(path string) (*BoltBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(valuesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	b := &BoltBackend{db: db}
+	b.stop = startSweeper(b.sweep)
+	slog.Info("Bolt database opened", "path", path)
+	return b, nil
+}
+
+// Name implements Backend.
+func (b *BoltBackend) Name() string {
+	return "bolt"
+}
+
+// Ping implements Backend.
+func (b *BoltBackend) Ping() error {
+	return b.db.View(func(tx *bolt.Tx) error { return nil })
+}
+
+// Close stops the expiry sweep and closes the file.
+func (b *BoltBackend) Close() error {
+	b.stop()
+	return b.db.Close()
+}
+
+// encodeValue prefixes value with its expiry time.
+func encodeValue(value string, ttl time.Duration) []byte {
+	buf := make([]byte, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(buf, uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	copy(buf[8:], value)
+	return buf
+}
+
+// decodeValue splits a stored value into the value and whether it has
+// expired by now.
+func decodeValue(raw []byte, now time.Time) (string, bool) {
+	if len(raw) < 8 {
+		return "", true
+	}
+	expiresAt := int64(binary.BigEndian.Uint64(raw))
+	expired := expiresAt != 0 && now.UnixNano() >= expiresAt
+	return string(raw[8:]), expired
+}
+
+// sweep deletes expired values.
+func (b *BoltBackend) sweep() (int, error) {
+	deleted := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		c := tx.Bucket(valuesBucket).Cursor()
+		for k, v := c.First(); k != nil; {
+			if _, expired := decodeValue(v, now); expired {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				deleted++
+				// Seek past the deleted key; Next after Delete can skip one
+				k, v = c.Seek(bytes.Clone(k))
+				continue
+			}
+			k, v = c.Next()
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// Get implements Backend.
+func (b *BoltBackend) Get(key string) (string, bool, error) {
+	var (
+		value string
+		found bool
+	)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(valuesBucket).Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		var expired bool
+		value, expired = decodeValue(raw, time.Now())
+		found = !expired
+		return nil
+	})
+	if err != nil || !found {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Backend.
+func (b *BoltBackend) Set(key, value string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(valuesBucket).Put([]byte(key), encodeValue(value, ttl))
+	})
+}
+
+// Delete implements Backend.
+func (b *BoltBackend) Delete(keys ...string) (int, error) {
+	deleted := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(valuesBucket)
+		now := time.Now()
+		for _, key := range keys {
+			raw := bucket.Get([]byte(key))
+			if raw == nil {
+				continue
+			}
+			if _, expired := decodeValue(raw, now); !expired {
+				deleted++
+			}
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// DeletePrefix implements Backend.
+func (b *BoltBackend) DeletePrefix(prefix string) (int, error) {
+	deleted := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		p := []byte(prefix)
+		c := tx.Bucket(valuesBucket).Cursor()
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Seek(p) {
+			if _, expired := decodeValue(v, now); !expired {
+				deleted++
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// Keys implements Backend.
+func (b *BoltBackend) Keys(prefix string) ([]string, error) {
+	var keys []string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		now := time.Now()
+		p := []byte(prefix)
+		c := tx.Bucket(valuesBucket).Cursor()
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+			if _, expired := decodeValue(v, now); !expired {
+				keys = append(keys, string(k))
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// recordsBucket returns the bucket name of a collection.
+func recordsBucket(collection string) []byte {
+	return []byte("records:" + collection)
+}
+
+// Records implements Backend.
+func (b *BoltBackend) Records(collection string) (map[string]map[string]string, error) {
+	records := make(map[string]map[string]string)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket(collection))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var fields map[string]string
+			if err := json.Unmarshal(v, &fields); err != nil {
+				return fmt.Errorf("record %s/%s: %w", collection, k, err)
+			}
+			records[string(k)] = fields
+			return nil
+		})
+	})
+	return records, err
+}
+
+// GetRecord implements Backend.
+func (b *BoltBackend) GetRecord(collection, id string) (map[string]string, bool, error) {
+	records, err := b.GetRecords(collection, []string{id})
+	if err != nil {
+		return nil, false, err
+	}
+	fields, ok := records[id]
+	return fields, ok, nil
+}
+
+// GetRecords implements Backend.
+func (b *BoltBackend) GetRecords(collection string, ids []string) (map[string]map[string]string, error) {
+	records := make(map[string]map[string]string, len(ids))
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket(collection))
+		if bucket == nil {
+			return nil
+		}
+		for _, id := range ids {
+			raw := bucket.Get([]byte(id))
+			if raw == nil {
+				continue
+			}
+			var fields map[string]string
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				return fmt.Errorf("record %s/%s: %w", collection, id, err)
+			}
+			records[id] = fields
+		}
+		return nil
+	})
+	return records, err
+}
+
+// putRecord writes a record in tx, creating the collection bucket.
+func putRecord(tx *bolt.Tx, collection, id string, fields map[string]string) error {
+	bucket, err := tx.CreateBucketIfNotExists(recordsBucket(collection))
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(id), raw)
+}
+
+// PutRecord implements Backend.
+func (b *BoltBackend) PutRecord(collection, id string, fields map[string]string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return putRecord(tx, collection, id, fields)
+	})
+}
+
+// CreateRecord implements Backend.
+func (b *BoltBackend) CreateRecord(collection, id string, fields map[string]string) (bool, error) {
+	created := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(recordsBucket(collection)); bucket != nil && bucket.Get([]byte(id)) != nil {
+			return nil
+		}
+		created = true
+		return putRecord(tx, collection, id, fields)
+	})
+	return created && err == nil, err
+}
+
+// UpdateRecord implements Backend.
+func (b *BoltBackend) UpdateRecord(collection, id string, fields map[string]string) (bool, error) {
+	updated := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket(collection))
+		if bucket == nil {
+			return nil
+		}
+		raw := bucket.Get([]byte(id))
+		if raw == nil {
+			return nil
+		}
+		var record map[string]string
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("record %s/%s: %w", collection, id, err)
+		}
+		maps.Copy(record, fields)
+		updated = true
+		return putRecord(tx, collection, id, record)
+	})
+	return updated && err == nil, err
+}
+
+// DeleteRecord implements Backend.
+func (b *BoltBackend) DeleteRecord(collection, id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket(collection))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+// indexBuckets returns the bucket names of a time index: entries by score,
+// then scores by ID.
+func indexBuckets(index string) ([]byte, []byte) {
+	return []byte("index:" + index), []byte("index-ids:" + index)
+}
+
+// encodeScore returns score as 8 bytes that sort like the numbers do,
+// negative ones first.
+func encodeScore(score int64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(score)^(1<<63))
+	return buf
+}
+
+func decodeScore(raw []byte) int64 {
+	return int64(binary.BigEndian.Uint64(raw) ^ (1 << 63))
+}
+
+// indexKey is the key of an index entry: its score, then its ID.
+func indexKey(score int64, id string) []byte {
+	return append(encodeScore(score), id...)
+}
+
+// IndexAdd implements Backend.
+func (b *BoltBackend) IndexAdd(index, id string, score int64) error {
+	byScore, byID := indexBuckets(index)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		entries, err := tx.CreateBucketIfNotExists(byScore)
+		if err != nil {
+			return err
+		}
+		scores, err := tx.CreateBucketIfNotExists(byID)
+		if err != nil {
+			return err
+		}
+		if old := scores.Get([]byte(id)); old != nil {
+			if err := entries.Delete(indexKey(decodeScore(old), id)); err != nil {
+				return err
+			}
+		}
+		if err := entries.Put(indexKey(score, id), []byte{}); err != nil {
+			return err
+		}
+		return scores.Put([]byte(id), encodeScore(score))
+	})
+}
+
+// IndexRange implements Backend by walking the entries backwards from max.
+func (b *BoltBackend) IndexRange(index string, min, max int64, limit int) ([]IndexEntry, error) {
+	byScore, _ := indexBuckets(index)
+	var entries []IndexEntry
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(byScore)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+
+		// Start at the last entry scored max or less
+		var k []byte
+		if max == math.MaxInt64 {
+			k, _ = c.Last()
+		} else if k, _ = c.Seek(encodeScore(max + 1)); k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+
+		for ; k != nil; k, _ = c.Prev() {
+			score := decodeScore(k[:8])
+			if score < min {
+				break
+			}
+			entries = append(entries, IndexEntry{ID: string(k[8:]), Score: score})
+			if limit > 0 && len(entries) == limit {
+				break
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// IndexRemove implements Backend.
+func (b *BoltBackend) IndexRemove(index string, ids ...string) error {
+	byScore, byID := indexBuckets(index)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		entries, scores := tx.Bucket(byScore), tx.Bucket(byID)
+		if entries == nil || scores == nil {
+			return nil
+		}
+		for _, id := range ids {
+			score := scores.Get([]byte(id))
+			if score == nil {
+				continue
+			}
+			if err := entries.Delete(indexKey(decodeScore(score), id)); err != nil {
+				return err
+			}
+			if err := scores.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// startSweeper runs sweep every sweepInterval until the returned stop
+// function is called.
+func startSweeper(sweep func() (int, error)) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				n, err := sweep()
+				if err != nil {
+					slog.Warn("Sweeping expired values failed", "error", err)
+				} else if n > 0 {
+					slog.Debug("Swept expired values", "deleted", n)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zoebot/internal/config"
+)
+
+// Rank milestone kinds, announced when a tracked player reaches one and
+// toggled per guild.
+const (
+	MilestonePromotion  = "promotion"  // up or down a division or tier
+	MilestonePlacements = "placements" // placement games done
+	MilestonePeak       = "peak"       // highest rank this season
+	MilestoneStreak     = "streak"     // 5+ win or loss streak started or ended
+)
+
+// MilestoneKinds lists every milestone kind, in display order.
+var MilestoneKinds = []string{MilestonePromotion, MilestonePlacements, MilestonePeak, MilestoneStreak}
+
+// Match notification modes, set per guild.
+const (
+	NotifyBoth      = "both"     // every game and a summary of each session
+	NotifyGames     = "games"    // every game only
+	NotifySummaries = "sessions" // session summaries only
+)
+
+// NotifyModes lists every notification mode, in display order.
+var NotifyModes = []string{NotifyBoth, NotifyGames, NotifySummaries}
+
+// GuildSettings are a guild's preferences. The zero value is the default:
+// every game and session posted, every milestone announced and recaps on
+// the configured schedule.
+type GuildSettings struct {
+	GuildID       string            `json:"guild_id"`
+	Notify        string            `json:"notify"`         // Notify*; "" for NotifyBoth
+	MilestonesOff []string          `json:"milestones_off"` // milestone kinds not announced, sorted
+	Timezone      string            `json:"timezone"`       // IANA name; "" for the configured one
+	Recaps        map[string]string `json:"recaps"`         // channel ID -> recap schedule, "off" or a cron expression
+}
+
+// RecapSchedule returns a channel's recap schedule, or "" if it uses the
+// configured one.
+func (g *GuildSettings) RecapSchedule(channelID string) string {
+	return g.Recaps[channelID]
+}
+
+// SetRecapSchedule sets a channel's recap schedule; "" goes back to the
+// configured one.
+func (g *GuildSettings) SetRecapSchedule(channelID, spec string) {
+	if spec == "" {
+		delete(g.Recaps, channelID)
+		return
+	}
+	if g.Recaps == nil {
+		g.Recaps = make(map[string]string)
+	}
+	g.Recaps[channelID] = spec
+}
+
+// NotifyMode returns how the guild wants games posted.
+func (g *GuildSettings) NotifyMode() string {
+	if g.Notify == "" {
+		return NotifyBoth
+	}
+	return g.Notify
+}
+
+// MilestoneEnabled reports whether the guild wants milestones of kind.
+func (g *GuildSettings) MilestoneEnabled(kind string) bool {
+	for _, off := range g.MilestonesOff {
+		if off == kind {
+			return false
+		}
+	}
+	return true
+}
+
+// SetMilestone turns announcements of one milestone kind on or off.
+func (g *GuildSettings) SetMilestone(kind string, enabled bool) {
+	off := g.MilestonesOff[:0:0]
+	for _, k := range g.MilestonesOff {
+		if k != kind {
+			off = append(off, k)
+		}
+	}
+	if !enabled {
+		off = append(off, kind)
+		sort.Strings(off)
+	}
+	g.MilestonesOff = off
+}
+
+// GuildSchema is the version of the guild record layout, stored in each
+// record's "v" field.
+const GuildSchema = 1
+
+// GuildStore keeps per-guild settings, one record per guild keyed by guild
+// ID. Guilds without a record use the defaults.
+type GuildStore struct {
+	backend    Backend
+	collection string
+}
+
+// NewGuildStore creates a guild settings store.
+func NewGuildStore(store *Store, keys config.RedisConfig) *GuildStore {
+	return &GuildStore{
+		backend:    store.Backend(),
+		collection: keys.KeyGuilds,
+	}
+}
+
+// Get returns a guild's settings, or the defaults if it has none.
+func (s *GuildStore) Get(guildID string) (*GuildSettings, error) {
+	fields, ok, err := s.backend.GetRecord(s.collection, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &GuildSettings{GuildID: guildID}, nil
+	}
+	return decodeGuild(fields)
+}
+
+// Put stores a guild's settings.
+func (s *GuildStore) Put(g *GuildSettings) error {
+	if g.GuildID == "" {
+		return fmt.Errorf("guild settings need a guild id")
+	}
+	return s.backend.PutRecord(s.collection, g.GuildID, encodeGuild(g))
+}
+
+// Update applies change to a guild's stored settings and writes only the
+// fields it changed, so commands changing different settings of a guild
+// at the same time don't undo each other. It returns the updated settings.
+func (s *GuildStore) Update(guildID string, change func(g *GuildSettings)) (*GuildSettings, error) {
+	g, err := s.Get(guildID)
+	if err != nil {
+		return nil, err
+	}
+	before := encodeGuild(g)
+	change(g)
+	g.GuildID = guildID
+	after := encodeGuild(g)
+
+	changed := make(map[string]string)
+	for name, value := range after {
+		if before[name] != value {
+			changed[name] = value
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changed[name] = "" // a cleared recap schedule
+		}
+	}
+	if len(changed) == 0 {
+		return g, nil
+	}
+
+	created, err := s.backend.CreateRecord(s.collection, guildID, after)
+	if err != nil || created {
+		return g, err
+	}
+	_, err = s.backend.UpdateRecord(s.collection, guildID, changed)
+	return g, err
+}
+
+// exportGuilds returns every guild's settings for a backup.
+func (s *GuildStore) exportGuilds() (map[string]map[string]string, error) {
+	return s.backend.Records(s.collection)
+}
+
+// importGuild adds a guild's settings from a backup.
+func (s *GuildStore) importGuild(id string, fields map[string]string) error {
+	g, err := decodeGuild(fields)
+	if err != nil {
+		return err
+	}
+	if g.GuildID != id {
+		return fmt.Errorf("record does not match its id")
+	}
+	return s.Put(g)
+}
+
+// recapField prefixes the record fields holding channel recap schedules.
+const recapField = "recap:"
+
+// encodeGuild returns the record fields of a guild's settings.
+func encodeGuild(g *GuildSettings) map[string]string {
+	fields := map[string]string{
+		"v":              strconv.Itoa(GuildSchema),
+		"guild_id":       g.GuildID,
+		"notify":         g.Notify,
+		"milestones_off": strings.Join(g.MilestonesOff, ","),
+		"timezone":       g.Timezone,
+	}
+	for channelID, spec := range g.Recaps {
+		fields[recapField+channelID] = spec
+	}
+	return fields
+}
+
+// decodeGuild reads a guild's settings.
+func decodeGuild(fields map[string]string) (*GuildSettings, error) {
+	version, err := strconv.Atoi(fields["v"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema version %q", fields["v"])
+	}
+	if version > GuildSchema {
+		return nil, fmt.Errorf("schema version %d is newer than this build (%d)", version, GuildSchema)
+	}
+	g := &GuildSettings{GuildID: fields["guild_id"], Notify: fields["notify"]}
+	if off := fields["milestones_off"]; off != "" {
+		g.MilestonesOff = strings.Split(off, ",")
+	}
+	g.Timezone = fields["timezone"]
+	for name, spec := range fields {
+		if channelID, ok := strings.CutPrefix(name, recapField); ok && spec != "" {
+			g.SetRecapSchedule(channelID, spec)
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,545 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zoebot/internal/config"
+)
+
+// MatchRecord is one player's result in a processed match.
+type MatchRecord struct {
+	MatchID   string        `json:"match_id"`
+	PUUID     string        `json:"puuid"`
+	RiotID    string        `json:"riot_id"`              // in-game name at the time
+	GuildID   string        `json:"guild_id,omitempty"`   // guild the player was tracked in, if any
+	ChannelID string        `json:"channel_id,omitempty"` // channel the player was tracked in, if any
+	QueueID   int           `json:"queue_id"`
+	GameMode  string        `json:"game_mode"`
+	Patch     string        `json:"patch"`
+	PlayedAt  time.Time     `json:"played_at"` // game end
+	Duration  time.Duration `json:"duration"`
+
+	Win               bool    `json:"win"`
+	Champion          string  `json:"champion"`
+	Position          string  `json:"position"`
+	Kills             int     `json:"kills"`
+	Deaths            int     `json:"deaths"`
+	Assists           int     `json:"assists"`
+	CS                int     `json:"cs"`
+	Gold              int     `json:"gold"`
+	Damage            int     `json:"damage"`
+	DamageShare       float64 `json:"damage_share"`       // % of the team's damage to champions
+	KillParticipation float64 `json:"kill_participation"` // %
+	VisionScore       int     `json:"vision_score"`
+
+	Score       float64 `json:"score"`        // 0-10; 0 when the match wasn't scored
+	ScoreSource string  `json:"score_source"` // "ai", "rules" or "" when unscored
+}
+
+// HistorySchema is the version of the match record layout, stored in each
+// record's "v" field.
+const HistorySchema = 1
+
+// HistoryQuery filters match history. Zero fields don't filter.
+type HistoryQuery struct {
+	From    time.Time // played at or after
+	To      time.Time // played before
+	QueueID int
+	Limit   int // newest first
+}
+
+// matches reports whether r passes the time and queue filters.
+func (q HistoryQuery) matches(r *MatchRecord) bool {
+	if !q.From.IsZero() && r.PlayedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !r.PlayedAt.Before(q.To) {
+		return false
+	}
+	return q.QueueID == 0 || r.QueueID == q.QueueID
+}
+
+// HistoryStore keeps the match history. Each player's matches are a
+// collection (<prefix>:player:<puuid>, keyed by match ID) with a time index
+// (<prefix>:index:played:<puuid>), so a date range or the latest matches
+// read only the records they return. Two small collections list the
+// players with history (<prefix>:players, also indexed by last match at
+// <prefix>:index:players) and the players seen in each guild
+// (<prefix>:guild:<guild ID>), with the time of their last match.
+//
+// Limits are applied to a player's matches whenever one is added, and to
+// everyone's by Sweep.
+type HistoryStore struct {
+	backend Backend
+	prefix  string
+	limits  config.HistoryConfig
+	mu      sync.Mutex // guards limits; serializes pruning
+}
+
+// NewHistoryStore creates a history store. Zero limits keep everything.
+func NewHistoryStore(store *Store, keys config.RedisConfig, limits config.HistoryConfig) *HistoryStore {
+	return &HistoryStore{
+		backend: store.Backend(),
+		prefix:  keys.KeyHistory,
+		limits:  limits,
+	}
+}
+
+// SetLimits replaces the retention limits, e.g. after a config reload.
+func (h *HistoryStore) SetLimits(limits config.HistoryConfig) {
+	h.mu.Lock()
+	h.limits = limits
+	h.mu.Unlock()
+}
+
+func (h *HistoryStore) playerCollection(puuid string) string {
+	return h.prefix + ":player:" + puuid
+}
+
+func (h *HistoryStore) playersCollection() string {
+	return h.prefix + ":players"
+}
+
+func (h *HistoryStore) guildCollection(guildID string) string {
+	return h.prefix + ":guild:" + guildID
+}
+
+func (h *HistoryStore) playedIndex(puuid string) string {
+	return h.prefix + ":index:played:" + puuid
+}
+
+func (h *HistoryStore) playersIndex() string {
+	return h.prefix + ":index:players"
+}
+
+// scoreRange returns the Unix seconds an index holds for times in
+// [from, to). Zero times don't bound the range.
+func scoreRange(from, to time.Time) (int64, int64) {
+	lo, hi := int64(math.MinInt64), int64(math.MaxInt64)
+	if !from.IsZero() {
+		lo = from.Unix()
+		if from.Nanosecond() > 0 {
+			lo++
+		}
+	}
+	if !to.IsZero() {
+		hi = to.Unix()
+		if to.Nanosecond() == 0 {
+			hi--
+		}
+	}
+	return lo, hi
+}
+
+// allEntries returns every entry of an index, highest score first.
+func (h *HistoryStore) allEntries(index string) ([]IndexEntry, error) {
+	return h.backend.IndexRange(index, math.MinInt64, math.MaxInt64, 0)
+}
+
+// entryIDs returns the IDs of index entries, in order.
+func entryIDs(entries []IndexEntry) []string {
+	ids := make([]string, len(entries))
+	for n, e := range entries {
+		ids[n] = e.ID
+	}
+	return ids
+}
+
+// Add records a match, replacing an earlier record of the same match for
+// the player, and prunes the player's history to the limits.
+func (h *HistoryStore) Add(r *MatchRecord) error {
+	if r.PUUID == "" || r.MatchID == "" {
+		return fmt.Errorf("match record needs a puuid and a match id")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.backend.PutRecord(h.playerCollection(r.PUUID), r.MatchID, encodeMatch(r)); err != nil {
+		return err
+	}
+	if err := h.backend.IndexAdd(h.playedIndex(r.PUUID), r.MatchID, r.PlayedAt.Unix()); err != nil {
+		return err
+	}
+	entries, err := h.allEntries(h.playedIndex(r.PUUID))
+	if err != nil {
+		return err
+	}
+
+	// Index the newest match, which isn't r when older matches are restored
+	newest := entries[0].Score
+	if err := h.backend.PutRecord(h.playersCollection(), r.PUUID, indexFields(time.Unix(newest, 0))); err != nil {
+		return err
+	}
+	if err := h.backend.IndexAdd(h.playersIndex(), r.PUUID, newest); err != nil {
+		return err
+	}
+	if r.GuildID != "" {
+		member, ok, err := h.backend.GetRecord(h.guildCollection(r.GuildID), r.PUUID)
+		if err != nil {
+			return err
+		}
+		if last, err := strconv.ParseInt(member["last_played_at"], 10, 64); !ok || err != nil || r.PlayedAt.Unix() > last {
+			if err := h.backend.PutRecord(h.guildCollection(r.GuildID), r.PUUID, indexFields(r.PlayedAt)); err != nil {
+				return err
+			}
+		}
+	}
+
+	pruned, err := h.prune(h.playerCollection(r.PUUID), h.playedIndex(r.PUUID), entries, time.Now())
+	if pruned > 0 {
+		slog.Debug("Pruned match history", "puuid", r.PUUID, "deleted", pruned)
+	}
+	return err
+}
+
+// indexFields are the fields of a player's entry in an index.
+func indexFields(lastPlayedAt time.Time) map[string]string {
+	return map[string]string{"last_played_at": strconv.FormatInt(lastPlayedAt.Unix(), 10)}
+}
+
+// prune drops the records of a collection beyond the limits, given the
+// entries of its time index, highest score first. The caller holds h.mu.
+func (h *HistoryStore) prune(collection, index string, entries []IndexEntry, now time.Time) (int, error) {
+	var stale []string
+	for n, e := range entries {
+		if (h.limits.MaxMatches > 0 && n >= h.limits.MaxMatches) ||
+			(h.limits.Retention > 0 && now.Sub(time.Unix(e.Score, 0)) > h.limits.Retention) {
+			if err := h.backend.DeleteRecord(collection, e.ID); err != nil {
+				return len(stale), err
+			}
+			stale = append(stale, e.ID)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+	return len(stale), h.backend.IndexRemove(index, stale...)
+}
+
+// matches returns a player's matches played in [from, to), newest first.
+// A positive limit returns at most that many.
+func (h *HistoryStore) matches(puuid string, from, to time.Time, limit int) ([]MatchRecord, error) {
+	lo, hi := scoreRange(from, to)
+	entries, err := h.backend.IndexRange(h.playedIndex(puuid), lo, hi, limit)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	raw, err := h.backend.GetRecords(h.playerCollection(puuid), entryIDs(entries))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]MatchRecord, 0, len(entries))
+	for _, e := range entries {
+		fields, ok := raw[e.ID]
+		if !ok {
+			continue // pruned while we read
+		}
+		r, err := decodeMatch(fields)
+		if err != nil {
+			slog.Warn("Skipping match record", "puuid", puuid, "match_id", e.ID, "error", err)
+			continue
+		}
+		records = append(records, *r)
+	}
+	return records, nil
+}
+
+// ByPlayer returns a player's matches passing q, newest first.
+func (h *HistoryStore) ByPlayer(puuid string, q HistoryQuery) ([]MatchRecord, error) {
+	// The index can only apply the limit when every match in range counts
+	limit := 0
+	if q.QueueID == 0 {
+		limit = q.Limit
+	}
+	records, err := h.matches(puuid, q.From, q.To, limit)
+	if err != nil {
+		return nil, err
+	}
+	return filterMatches(records, q, ""), nil
+}
+
+// ByGuild returns the matches played by players while tracked in guildID
+// that pass q, newest first.
+func (h *HistoryStore) ByGuild(guildID string, q HistoryQuery) ([]MatchRecord, error) {
+	members, err := h.backend.Records(h.guildCollection(guildID))
+	if err != nil {
+		return nil, err
+	}
+
+	var all []MatchRecord
+	for puuid, fields := range members {
+		// Players without a match since q.From have nothing to add
+		if last, err := strconv.ParseInt(fields["last_played_at"], 10, 64); err == nil && !q.From.IsZero() && time.Unix(last, 0).Before(q.From) {
+			continue
+		}
+		records, err := h.matches(puuid, q.From, q.To, 0)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, records...)
+	}
+	sortNewestFirst(all)
+	return filterMatches(all, q, guildID), nil
+}
+
+// Get returns one match of a player.
+func (h *HistoryStore) Get(puuid, matchID string) (*MatchRecord, bool, error) {
+	fields, ok, err := h.backend.GetRecord(h.playerCollection(puuid), matchID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	r, err := decodeMatch(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	return r, true, nil
+}
+
+// GetMany returns the stored matches of a player among matchIDs, keyed by
+// match ID, in one read. Matches that aren't stored are left out.
+func (h *HistoryStore) GetMany(puuid string, matchIDs []string) (map[string]*MatchRecord, error) {
+	raw, err := h.backend.GetRecords(h.playerCollection(puuid), matchIDs)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]*MatchRecord, len(raw))
+	for matchID, fields := range raw {
+		r, err := decodeMatch(fields)
+		if err != nil {
+			slog.Warn("Skipping match record", "puuid", puuid, "match_id", matchID, "error", err)
+			continue
+		}
+		records[matchID] = r
+	}
+	return records, nil
+}
+
+// Sweep applies the retention limit to every player's matches and rank
+// snapshots, since Add and AddRank only prune the player they are adding
+// for. Players left with nothing are dropped from the indexes. It returns
+// how many records were deleted.
+func (h *HistoryStore) Sweep(now time.Time) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.limits.Retention <= 0 {
+		return 0, nil
+	}
+	deleted := 0
+	for _, set := range []struct {
+		list       string
+		listIndex  string // "" if the list has no time index
+		collection func(puuid string) string
+		index      func(puuid string) string
+	}{
+		{h.playersCollection(), h.playersIndex(), h.playerCollection, h.playedIndex},
+		{h.rankedCollection(), "", h.ranksCollection, h.takenIndex},
+	} {
+		players, err := h.backend.Records(set.list)
+		if err != nil {
+			return deleted, err
+		}
+		for puuid := range players {
+			entries, err := h.allEntries(set.index(puuid))
+			if err != nil {
+				return deleted, err
+			}
+			n, err := h.prune(set.collection(puuid), set.index(puuid), entries, now)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			if n < len(entries) {
+				continue
+			}
+			if err := h.backend.DeleteRecord(set.list, puuid); err != nil {
+				return deleted, err
+			}
+			if set.listIndex != "" {
+				if err := h.backend.IndexRemove(set.listIndex, puuid); err != nil {
+					return deleted, err
+				}
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// ActivePlayers returns the PUUIDs of the players whose latest stored
+// match was played at or after since, most recent first.
+func (h *HistoryStore) ActivePlayers(since time.Time) ([]string, error) {
+	lo, hi := scoreRange(since, time.Time{})
+	entries, err := h.backend.IndexRange(h.playersIndex(), lo, hi, 0)
+	if err != nil {
+		return nil, err
+	}
+	return entryIDs(entries), nil
+}
+
+// Players returns the PUUIDs of every player with stored matches.
+func (h *HistoryStore) Players() ([]string, error) {
+	index, err := h.backend.Records(h.playersCollection())
+	if err != nil {
+		return nil, err
+	}
+	puuids := make([]string, 0, len(index))
+	for puuid := range index {
+		puuids = append(puuids, puuid)
+	}
+	sort.Strings(puuids)
+	return puuids, nil
+}
+
+// exportRecords returns every match record for a backup, keyed by
+// <puuid>/<match ID>.
+func (h *HistoryStore) exportRecords() (map[string]map[string]string, error) {
+	puuids, err := h.Players()
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]map[string]string)
+	for _, puuid := range puuids {
+		records, err := h.backend.Records(h.playerCollection(puuid))
+		if err != nil {
+			return nil, err
+		}
+		for matchID, fields := range records {
+			all[puuid+"/"+matchID] = fields
+		}
+	}
+	return all, nil
+}
+
+// importRecord adds a match record from a backup, rebuilding the indexes.
+func (h *HistoryStore) importRecord(id string, fields map[string]string) error {
+	r, err := decodeMatch(fields)
+	if err != nil {
+		return err
+	}
+	if r.PUUID+"/"+r.MatchID != id {
+		return fmt.Errorf("record does not match its id")
+	}
+	return h.Add(r)
+}
+
+// sortNewestFirst orders records by time played, newest first.
+func sortNewestFirst(records []MatchRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].PlayedAt.Equal(records[j].PlayedAt) {
+			return records[i].PlayedAt.After(records[j].PlayedAt)
+		}
+		return records[i].MatchID > records[j].MatchID
+	})
+}
+
+// filterMatches applies q to records sorted newest first, keeping only
+// guildID's matches when it is set.
+func filterMatches(records []MatchRecord, q HistoryQuery, guildID string) []MatchRecord {
+	var out []MatchRecord
+	for _, r := range records {
+		if guildID != "" && r.GuildID != guildID {
+			continue
+		}
+		if !q.matches(&r) {
+			continue
+		}
+		out = append(out, r)
+		if q.Limit > 0 && len(out) == q.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// encodeMatch returns the record fields of a match.
+func encodeMatch(r *MatchRecord) map[string]string {
+	itoa := strconv.Itoa
+	ftoa := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	return map[string]string{
+		"v":                  itoa(HistorySchema),
+		"match_id":           r.MatchID,
+		"puuid":              r.PUUID,
+		"riot_id":            r.RiotID,
+		"guild_id":           r.GuildID,
+		"channel_id":         r.ChannelID,
+		"queue_id":           itoa(r.QueueID),
+		"game_mode":          r.GameMode,
+		"patch":              r.Patch,
+		"played_at":          strconv.FormatInt(r.PlayedAt.Unix(), 10),
+		"duration":           strconv.FormatInt(int64(r.Duration/time.Second), 10),
+		"win":                strconv.FormatBool(r.Win),
+		"champion":           r.Champion,
+		"position":           r.Position,
+		"kills":              itoa(r.Kills),
+		"deaths":             itoa(r.Deaths),
+		"assists":            itoa(r.Assists),
+		"cs":                 itoa(r.CS),
+		"gold":               itoa(r.Gold),
+		"damage":             itoa(r.Damage),
+		"damage_share":       ftoa(r.DamageShare),
+		"kill_participation": ftoa(r.KillParticipation),
+		"vision_score":       itoa(r.VisionScore),
+		"score":              ftoa(r.Score),
+		"score_source":       r.ScoreSource,
+	}
+}
+
+// decodeMatch reads a match record. Missing numeric fields read as zero.
+func decodeMatch(fields map[string]string) (*MatchRecord, error) {
+	version, err := strconv.Atoi(fields["v"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema version %q", fields["v"])
+	}
+	if version > HistorySchema {
+		return nil, fmt.Errorf("schema version %d is newer than this build (%d)", version, HistorySchema)
+	}
+
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(fields[key])
+		return n
+	}
+	atof := func(key string) float64 {
+		f, _ := strconv.ParseFloat(fields[key], 64)
+		return f
+	}
+	playedAt, err := strconv.ParseInt(fields["played_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid played_at %q", fields["played_at"])
+	}
+	win, _ := strconv.ParseBool(fields["win"])
+
+	return &MatchRecord{
+		MatchID:           fields["match_id"],
+		PUUID:             fields["puuid"],
+		RiotID:            fields["riot_id"],
+		GuildID:           fields["guild_id"],
+		ChannelID:         fields["channel_id"],
+		QueueID:           atoi("queue_id"),
+		GameMode:          fields["game_mode"],
+		Patch:             fields["patch"],
+		PlayedAt:          time.Unix(playedAt, 0).UTC(),
+		Duration:          time.Duration(atoi("duration")) * time.Second,
+		Win:               win,
+		Champion:          fields["champion"],
+		Position:          fields["position"],
+		Kills:             atoi("kills"),
+		Deaths:            atoi("deaths"),
+		Assists:           atoi("assists"),
+		CS:                atoi("cs"),
+		Gold:              atoi("gold"),
+		Damage:            atoi("damage"),
+		DamageShare:       atof("damage_share"),
+		KillParticipation: atof("kill_participation"),
+		VisionScore:       atoi("vision_score"),
+		Score:             atof("score"),
+		ScoreSource:       fields["score_source"],
+	}, nil
+}
@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryBackend keeps everything in process memory, so nothing survives a
+// restart. Expired values are dropped when read and on every sweep.
+type MemoryBackend struct {
+	localLocks
+	values  map[string]memoryValue
+	records map[string]map[string]map[string]string // collection -> id -> fields
+	indexes map[string]map[string]int64             // index -> id -> score
+	mu      sync.Mutex
+	stop    func()
+}
+
+type memoryValue struct {
+	value     string
+	expiresAt time.Time // zero: never
+}
+
+func (v memoryValue) expired(now time.Time) bool {
+	return !v.expiresAt.IsZero() && !now.Before(v.expiresAt)
+}
+
+// NewMemoryBackend creates an empty memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	m := &MemoryBackend{
+		values:  make(map[string]memoryValue),
+		records: make(map[string]map[string]map[string]string),
+		indexes: make(map[string]map[string]int64),
+	}
+	m.stop = startSweeper(m.sweep)
+	return m
+}
+
+// Name implements Backend.
+func (m *MemoryBackend) Name() string {
+	return "memory"
+}
+
+// Ping implements Backend.
+func (m *MemoryBackend) Ping() error {
+	return nil
+}
+
+// Close stops the expiry sweep.
+func (m *MemoryBackend) Close() error {
+	m.stop()
+	return nil
+}
+
+// sweep drops expired values.
+func (m *MemoryBackend) sweep() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	deleted := 0
+	for key, v := range m.values {
+		if v.expired(now) {
+			delete(m.values, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Get implements Backend.
+func (m *MemoryBackend) Get(key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.values[key]
+	if !ok {
+		return "", false, nil
+	}
+	if v.expired(time.Now()) {
+		delete(m.values, key)
+		return "", false, nil
+	}
+	return v.value, true, nil
+}
+
+// Set implements Backend.
+func (m *MemoryBackend) Set(key, value string, ttl time.Duration) error {
+	v := memoryValue{value: value}
+	if ttl > 0 {
+		v.expiresAt = time.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	m.values[key] = v
+	m.mu.Unlock()
+	return nil
+}
+
+// Delete implements Backend.
+func (m *MemoryBackend) Delete(keys ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	deleted := 0
+	for _, key := range keys {
+		if v, ok := m.values[key]; ok {
+			delete(m.values, key)
+			if !v.expired(now) {
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// DeletePrefix implements Backend.
+func (m *MemoryBackend) DeletePrefix(prefix string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	deleted := 0
+	for key, v := range m.values {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.values, key)
+			if !v.expired(now) {
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// Keys implements Backend.
+func (m *MemoryBackend) Keys(prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var keys []string
+	for key, v := range m.values {
+		if strings.HasPrefix(key, prefix) && !v.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Records implements Backend.
+func (m *MemoryBackend) Records(collection string) (map[string]map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := make(map[string]map[string]string, len(m.records[collection]))
+	for id, fields := range m.records[collection] {
+		records[id] = maps.Clone(fields)
+	}
+	return records, nil
+}
+
+// GetRecord implements Backend.
+func (m *MemoryBackend) GetRecord(collection, id string) (map[string]string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fields, ok := m.records[collection][id]
+	return maps.Clone(fields), ok, nil
+}
+
+// GetRecords implements Backend.
+func (m *MemoryBackend) GetRecords(collection string, ids []string) (map[string]map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := make(map[string]map[string]string, len(ids))
+	for _, id := range ids {
+		if fields, ok := m.records[collection][id]; ok {
+			records[id] = maps.Clone(fields)
+		}
+	}
+	return records, nil
+}
+
+// PutRecord implements Backend.
+func (m *MemoryBackend) PutRecord(collection, id string, fields map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.records[collection] == nil {
+		m.records[collection] = make(map[string]map[string]string)
+	}
+	m.records[collection][id] = maps.Clone(fields)
+	return nil
+}
+
+// CreateRecord implements Backend.
+func (m *MemoryBackend) CreateRecord(collection, id string, fields map[string]string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.records[collection][id]; ok {
+		return false, nil
+	}
+	if m.records[collection] == nil {
+		m.records[collection] = make(map[string]map[string]string)
+	}
+	m.records[collection][id] = maps.Clone(fields)
+	return true, nil
+}
+
+// UpdateRecord implements Backend.
+func (m *MemoryBackend) UpdateRecord(collection, id string, fields map[string]string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[collection][id]
+	if !ok {
+		return false, nil
+	}
+	maps.Copy(record, fields)
+	return true, nil
+}
+
+// DeleteRecord implements Backend.
+func (m *MemoryBackend) DeleteRecord(collection, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records[collection], id)
+	return nil
+}
+
+// IndexAdd implements Backend.
+func (m *MemoryBackend) IndexAdd(index, id string, score int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.indexes[index] == nil {
+		m.indexes[index] = make(map[string]int64)
+	}
+	m.indexes[index][id] = score
+	return nil
+}
+
+// IndexRange implements Backend by sorting the matching entries.
+func (m *MemoryBackend) IndexRange(index string, min, max int64, limit int) ([]IndexEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []IndexEntry
+	for id, score := range m.indexes[index] {
+		if score >= min && score <= max {
+			entries = append(entries, IndexEntry{ID: id, Score: score})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// IndexRemove implements Backend.
+func (m *MemoryBackend) IndexRemove(index string, ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		delete(m.indexes[index], id)
+	}
+	return nil
+}
+
+// localLocks implements Backend.Lock within one process.
+type localLocks struct {
+	mu   sync.Mutex
+	held map[string]time.Time // name -> expiry
+}
+
+// Lock implements Backend.
+func (l *localLocks) Lock(name string, ttl time.Duration) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if expiresAt, ok := l.held[name]; ok && now.Before(expiresAt) {
+		return nil, ErrLocked
+	}
+	if l.held == nil {
+		l.held = make(map[string]time.Time)
+	}
+	expiresAt := now.Add(ttl)
+	l.held[name] = expiresAt
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.held[name] == expiresAt {
+			delete(l.held, name)
+		}
+	}, nil
+}
@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zoebot/internal/config"
+)
+
+// SchemaVersionKey holds the version of the last migration applied.
+const SchemaVersionKey = "zoebot:schema_version"
+
+// migrateLock keeps two instances from migrating at once.
+const migrateLock = "zoebot:migrate:lock"
+
+// migrateLockTTL bounds how long a crashed instance can hold the lock.
+const migrateLockTTL = 5 * time.Minute
+
+// Migration is one step of the persisted data layout. Up must be
+// idempotent, since a crash can leave a migration half applied and it runs
+// again on the next start, and must not write anything when m.DryRun is
+// set. It describes what it does (or would do) with m.Change.
+//
+// When a stored format changes, add a migration to the end of migrations
+// rather than versioning keys by hand; cache families can simply be flushed.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(m *MigrationContext) error
+}
+
+// MigrationContext is passed to Migration.Up.
+type MigrationContext struct {
+	Backend Backend
+	Keys    config.RedisConfig
+	DryRun  bool
+	changes []string
+}
+
+// Change records a change made (or, in a dry run, planned) by a migration.
+func (m *MigrationContext) Change(format string, args ...interface{}) {
+	m.changes = append(m.changes, fmt.Sprintf(format, args...))
+}
+
+// MigrationResult describes one migration that ran.
+type MigrationResult struct {
+	Version int      `json:"version"`
+	Name    string   `json:"name"`
+	Changes []string `json:"changes"`
+}
+
+// migrations are applied in order; versions must be 1, 2, 3, ...
+var migrations = []Migration{
+	{Version: 1, Name: "import legacy tracked players blob", Up: importLegacyPlayers},
+	{Version: 2, Name: "drop hand-versioned cache keys", Up: dropVersionedCacheKeys},
+	{Version: 3, Name: "index match history and rank snapshots by time", Up: indexHistory},
+}
+
+// LatestSchema is the schema version this build migrates to.
+func LatestSchema() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the stored schema version, 0 for fresh storage.
+func SchemaVersion(backend Backend) (int, error) {
+	raw, ok, err := backend.Get(SchemaVersionKey)
+	if err != nil || !ok {
+		return 0, err
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", raw)
+	}
+	return version, nil
+}
+
+// Migrate applies the pending migrations in order, recording the schema
+// version after each one. It holds a lock while doing so; another instance
+// already migrating is waited for. With dryRun nothing is written or
+// locked and the results describe what would change.
+func Migrate(store *Store, keys config.RedisConfig, dryRun bool) ([]MigrationResult, error) {
+	backend := store.Backend()
+	if !dryRun {
+		unlock, err := waitLock(backend, migrateLock, migrateLockTTL)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	current, err := SchemaVersion(backend)
+	if err != nil {
+		return nil, err
+	}
+	if current > LatestSchema() {
+		return nil, fmt.Errorf("schema version %d is newer than this build (%d)", current, LatestSchema())
+	}
+
+	var results []MigrationResult
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		m := &MigrationContext{Backend: backend, Keys: keys, DryRun: dryRun}
+		if err := migration.Up(m); err != nil {
+			return results, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		results = append(results, MigrationResult{Version: migration.Version, Name: migration.Name, Changes: m.changes})
+		if dryRun {
+			continue
+		}
+		if err := backend.Set(SchemaVersionKey, strconv.Itoa(migration.Version), 0); err != nil {
+			return results, err
+		}
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name, "changes", len(m.changes))
+	}
+	return results, nil
+}
+
+// waitLock takes a lock, waiting up to ttl for the holder to release it.
+func waitLock(backend Backend, name string, ttl time.Duration) (func(), error) {
+	deadline := time.Now().Add(ttl)
+	for {
+		unlock, err := backend.Lock(name, ttl)
+		if !errors.Is(err, ErrLocked) {
+			return unlock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		slog.Info("Waiting for another instance to finish migrating")
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// importLegacyPlayers copies players from the JSON blob at
+// redis.key_tracked_players into records. Players that already have a
+// record are left alone. The oldest blobs were keyed by PUUID without a
+// "puuid" field, and before that by Riot ID, which can't be imported.
+// The blob is then moved to <key>:migrated as a backup.
+func importLegacyPlayers(m *MigrationContext) error {
+	raw, ok, err := m.Backend.Get(m.Keys.KeyTrackedPlayers)
+	if err != nil || !ok {
+		return err
+	}
+
+	var legacy map[string]*TrackedPlayer
+	if err := json.Unmarshal([]byte(raw), &legacy); err != nil {
+		return err
+	}
+
+	existing, err := m.Backend.Records(m.Keys.KeyPlayers)
+	if err != nil {
+		return err
+	}
+
+	for key, player := range legacy {
+		if player == nil {
+			continue
+		}
+		if player.PUUID == "" {
+			if strings.Contains(key, "#") {
+				m.Change("skip %s: keyed by Riot ID, no PUUID", key)
+				continue
+			}
+			player.PUUID = key
+		}
+		if _, ok := existing[player.PUUID]; ok {
+			continue
+		}
+
+		m.Change("import %s (%s)", player.Name, player.PUUID)
+		if m.DryRun {
+			continue
+		}
+		if _, err := m.Backend.CreateRecord(m.Keys.KeyPlayers, player.PUUID, encodePlayer(player)); err != nil {
+			return err
+		}
+	}
+
+	backup := m.Keys.KeyTrackedPlayers + ":migrated"
+	m.Change("move %s to %s", m.Keys.KeyTrackedPlayers, backup)
+	if m.DryRun {
+		return nil
+	}
+	if err := m.Backend.Set(backup, raw, 0); err != nil {
+		return err
+	}
+	_, err = m.Backend.Delete(m.Keys.KeyTrackedPlayers)
+	return err
+}
+
+// versionedCacheKey matches the cache keys that were versioned by hand,
+// e.g. counter:v4:zoe:mid.
+var versionedCacheKey = regexp.MustCompile(`^(counter|build):v\d+:`)
+
+// dropVersionedCacheKeys deletes counter and build entries cached under
+// their old hand-versioned keys. They would only sit there until expiry.
+func dropVersionedCacheKeys(m *MigrationContext) error {
+	var stale []string
+	for _, prefix := range []string{"counter:v", "build:v"} {
+		keys, err := m.Backend.Keys(prefix)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if versionedCacheKey.MatchString(key) {
+				stale = append(stale, key)
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	m.Change("delete %d cache entries with versioned keys", len(stale))
+	if m.DryRun {
+		return nil
+	}
+	for len(stale) > 0 {
+		n := min(len(stale), 100)
+		if _, err := m.Backend.Delete(stale[:n]...); err != nil {
+			return err
+		}
+		stale = stale[n:]
+	}
+	return nil
+}
+
+// indexHistory adds the matches and rank snapshots stored before the time
+// indexes existed to them, and the players to the index of last matches.
+// Records whose time can't be read are left out, as readers skip them.
+func indexHistory(m *MigrationContext) error {
+	h := &HistoryStore{backend: m.Backend, prefix: m.Keys.KeyHistory}
+	for _, set := range []struct {
+		list       string
+		listIndex  string // "" if the list has no time index
+		collection func(puuid string) string
+		index      func(puuid string) string
+		timeField  string
+	}{
+		{h.playersCollection(), h.playersIndex(), h.playerCollection, h.playedIndex, "played_at"},
+		{h.rankedCollection(), "", h.ranksCollection, h.takenIndex, "taken_at"},
+	} {
+		players, err := m.Backend.Records(set.list)
+		if err != nil {
+			return err
+		}
+		for puuid := range players {
+			records, err := m.Backend.Records(set.collection(puuid))
+			if err != nil {
+				return err
+			}
+			if len(records) == 0 {
+				continue
+			}
+
+			m.Change("index %d records of %s", len(records), set.collection(puuid))
+			newest, found := int64(0), false
+			for id, fields := range records {
+				at, err := strconv.ParseInt(fields[set.timeField], 10, 64)
+				if err != nil {
+					m.Change("skip %s/%s: invalid %s %q", set.collection(puuid), id, set.timeField, fields[set.timeField])
+					continue
+				}
+				if !found || at > newest {
+					newest, found = at, true
+				}
+				if m.DryRun {
+					continue
+				}
+				if err := m.Backend.IndexAdd(set.index(puuid), id, at); err != nil {
+					return err
+				}
+			}
+			if m.DryRun || set.listIndex == "" || !found {
+				continue
+			}
+			if err := m.Backend.IndexAdd(set.listIndex, puuid, newest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zoebot/internal/config"
+)
+
+// TrackedPlayer represents a player being tracked for match notifications.
+type TrackedPlayer struct {
+	PUUID       string `json:"puuid"`
+	LastMatchID string `json:"last_match_id"`
+	ChannelID   string `json:"channel_id"`
+	Name        string `json:"name"`
+	DiscordID   string `json:"discord_id,omitempty"` // linked Discord user, who gets tilt alerts by DM
+	TiltOff     bool   `json:"tilt_off,omitempty"`   // opted out of tilt alerts
+	// PendingRanks are ranked games whose league entry hadn't counted them
+	// yet, oldest first. The next poll tries to snapshot them again.
+	PendingRanks []string `json:"pending_ranks,omitempty"`
+}
+
+// PlayersSchema is the version of the player record layout, stored in
+// each record's "v" field. Bump it when the fields change and teach
+// decodePlayer to read the older layout.
+const PlayersSchema = 1
+
+// TrackedPlayersStore keeps the tracked players in memory and writes every
+// change straight through to the backend, one record per player, so a
+// change touches only that player and concurrent writers don't overwrite
+// each other's updates.
+type TrackedPlayersStore struct {
+	backend    Backend
+	collection string // one record per player, keyed by PUUID
+	players    map[string]*TrackedPlayer
+	mu         sync.RWMutex
+}
+
+// NewTrackedPlayersStore creates a new tracked players store.
+func NewTrackedPlayersStore(store *Store, keys config.RedisConfig) *TrackedPlayersStore {
+	return &TrackedPlayersStore{
+		backend:    store.Backend(),
+		collection: keys.KeyPlayers,
+		players:    make(map[string]*TrackedPlayer),
+	}
+}
+
+// Load reads every tracked player from the backend. Older layouts are
+// brought up to date by Migrate beforehand.
+func (s *TrackedPlayersStore) Load() error {
+	records, err := s.backend.Records(s.collection)
+	if err != nil {
+		return err
+	}
+
+	players := make(map[string]*TrackedPlayer, len(records))
+	for puuid, fields := range records {
+		player, err := decodePlayer(fields)
+		if err != nil {
+			slog.Warn("Skipping tracked player", "puuid", puuid, "error", err)
+			continue
+		}
+		players[puuid] = player
+	}
+
+	s.mu.Lock()
+	s.players = players
+	s.mu.Unlock()
+
+	slog.Info("Loaded tracked players", "count", len(players))
+	return nil
+}
+
+// encodePlayer returns the record fields of a player.
+func encodePlayer(p *TrackedPlayer) map[string]string {
+	return map[string]string{
+		"v":             strconv.Itoa(PlayersSchema),
+		"puuid":         p.PUUID,
+		"name":          p.Name,
+		"channel_id":    p.ChannelID,
+		"last_match_id": p.LastMatchID,
+		"discord_id":    p.DiscordID,
+		"tilt_off":      strconv.FormatBool(p.TiltOff),
+		"pending_ranks": strings.Join(p.PendingRanks, ","),
+	}
+}
+
+// decodePlayer reads a player record.
+func decodePlayer(fields map[string]string) (*TrackedPlayer, error) {
+	version, err := strconv.Atoi(fields["v"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema version %q", fields["v"])
+	}
+	if version > PlayersSchema {
+		return nil, fmt.Errorf("schema version %d is newer than this build (%d)", version, PlayersSchema)
+	}
+	return &TrackedPlayer{
+		PUUID:        fields["puuid"],
+		Name:         fields["name"],
+		ChannelID:    fields["channel_id"],
+		LastMatchID:  fields["last_match_id"],
+		DiscordID:    fields["discord_id"],
+		TiltOff:      fields["tilt_off"] == "true",
+		PendingRanks: splitList(fields["pending_ranks"]),
+	}, nil
+}
+
+// splitList reads a comma separated record field.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// Get returns a tracked player by PUUID.
+func (s *TrackedPlayersStore) Get(puuid string) (*TrackedPlayer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.players[puuid]
+	return p, ok
+}
+
+// Set adds or replaces a tracked player.
+func (s *TrackedPlayersStore) Set(puuid string, player *TrackedPlayer) error {
+	// Ensure PUUID is always set in struct
+	if player.PUUID == "" {
+		player.PUUID = puuid
+	}
+	s.mu.Lock()
+	s.players[puuid] = player
+	fields := encodePlayer(player)
+	s.mu.Unlock()
+
+	return s.backend.PutRecord(s.collection, puuid, fields)
+}
+
+// Delete removes a tracked player.
+func (s *TrackedPlayersStore) Delete(puuid string) error {
+	s.mu.Lock()
+	delete(s.players, puuid)
+	s.mu.Unlock()
+
+	return s.backend.DeleteRecord(s.collection, puuid)
+}
+
+// GetAll returns all tracked players as a copy map.
+// Returns copies of TrackedPlayer to prevent data races.
+func (s *TrackedPlayersStore) GetAll() map[string]TrackedPlayer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]TrackedPlayer, len(s.players))
+	for k, v := range s.players {
+		if v != nil {
+			result[k] = *v // Copy the struct value
+		}
+	}
+	return result
+}
+
+// GetByChannel returns all tracked players for a specific channel.
+func (s *TrackedPlayersStore) GetByChannel(channelID string) []*TrackedPlayer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*TrackedPlayer
+	for _, p := range s.players {
+		if p.ChannelID == channelID {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Count returns the number of tracked players.
+func (s *TrackedPlayersStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.players)
+}
+
+// UpdateLastMatch updates the last match ID for a player. Only that field
+// is written, and nothing is written if the player was untracked.
+func (s *TrackedPlayersStore) UpdateLastMatch(puuid, matchID string) error {
+	s.mu.Lock()
+	p, ok := s.players[puuid]
+	if ok {
+		p.LastMatchID = matchID
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	_, err := s.backend.UpdateRecord(s.collection, puuid, map[string]string{"last_match_id": matchID})
+	return err
+}
+
+// UpdateChannel moves a player's notifications to another channel and
+// returns a copy of the updated player. Only that field is written, and
+// nothing is written (and nil returned) if the player was untracked.
+func (s *TrackedPlayersStore) UpdateChannel(puuid, channelID string) (*TrackedPlayer, error) {
+	s.mu.Lock()
+	p, ok := s.players[puuid]
+	var updated TrackedPlayer
+	if ok {
+		p.ChannelID = channelID
+		updated = *p
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, nil
+	}
+	_, err := s.backend.UpdateRecord(s.collection, puuid, map[string]string{"channel_id": channelID})
+	return &updated, err
+}
+
+// UpdateTilt sets who a player's tilt alerts go to and whether they get
+// any. Only those fields are written, and nothing is written if the player
+// was untracked.
+func (s *TrackedPlayersStore) UpdateTilt(puuid, discordID string, off bool) error {
+	s.mu.Lock()
+	p, ok := s.players[puuid]
+	if ok {
+		p.DiscordID = discordID
+		p.TiltOff = off
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	_, err := s.backend.UpdateRecord(s.collection, puuid, map[string]string{
+		"discord_id": discordID,
+		"tilt_off":   strconv.FormatBool(off),
+	})
+	return err
+}
+
+// UpdatePendingRanks sets the ranked games of a player still waiting for
+// their snapshot. Only that field is written, and nothing is written if
+// the player was untracked.
+func (s *TrackedPlayersStore) UpdatePendingRanks(puuid string, matchIDs []string) error {
+	s.mu.Lock()
+	p, ok := s.players[puuid]
+	if ok {
+		p.PendingRanks = matchIDs
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	_, err := s.backend.UpdateRecord(s.collection, puuid, map[string]string{"pending_ranks": strings.Join(matchIDs, ",")})
+	return err
+}
@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Rank queues, as in league-v4 entries.
+const (
+	QueueSolo = "RANKED_SOLO_5x5"
+	QueueFlex = "RANKED_FLEX_SR"
+)
+
+// RankSnapshot is a player's league entry in one queue right after a game.
+type RankSnapshot struct {
+	PUUID     string    `json:"puuid"`
+	QueueType string    `json:"queue_type"` // QueueSolo or QueueFlex
+	MatchID   string    `json:"match_id"`   // the game that led to it
+	TakenAt   time.Time `json:"taken_at"`
+	Tier      string    `json:"tier"` // IRON … CHALLENGER
+	Rank      string    `json:"rank"` // I-IV, "I" from Master up
+	LP        int       `json:"lp"`
+	Wins      int       `json:"wins"`
+	Losses    int       `json:"losses"`
+}
+
+// Games returns the ranked games played in the season so far.
+func (s *RankSnapshot) Games() int {
+	return s.Wins + s.Losses
+}
+
+// rankTiers are the tiers from lowest to highest.
+var rankTiers = []string{"IRON", "BRONZE", "SILVER", "GOLD", "PLATINUM", "EMERALD", "DIAMOND", "MASTER", "GRANDMASTER", "CHALLENGER"}
+
+// rankDivisions are the divisions from lowest to highest.
+var rankDivisions = []string{"IV", "III", "II", "I"}
+
+// tierIndex returns the position of tier in rankTiers, or -1.
+func tierIndex(tier string) int {
+	for n, t := range rankTiers {
+		if t == tier {
+			return n
+		}
+	}
+	return -1
+}
+
+// Apex reports whether the snapshot is Master or above, where there are no
+// divisions and LP just keeps counting.
+func (s *RankSnapshot) Apex() bool {
+	return tierIndex(s.Tier) >= tierIndex("MASTER")
+}
+
+// TotalLP places the snapshot on one LP ladder: 100 LP per division from
+// Iron IV, with Master and above sharing Master I's floor.
+func (s *RankSnapshot) TotalLP() int {
+	if s.Apex() {
+		return tierIndex("MASTER")*400 + s.LP
+	}
+	division := 0
+	for n, d := range rankDivisions {
+		if d == s.Rank {
+			division = n
+		}
+	}
+	return max(tierIndex(s.Tier), 0)*400 + division*100 + s.LP
+}
+
+// Division returns the snapshot's step on the ladder ignoring LP: 0 for
+// Iron IV up to 27 for Diamond I, then one step per apex tier.
+func (s *RankSnapshot) Division() int {
+	if s.Apex() {
+		return tierIndex("MASTER")*4 + tierIndex(s.Tier) - tierIndex("MASTER")
+	}
+	return (s.TotalLP() - s.LP) / 100
+}
+
+// RankChange compares two snapshots of the same queue. Before is nil for a
+// player's first snapshot.
+type RankChange struct {
+	Before *RankSnapshot
+	After  *RankSnapshot
+}
+
+// LPDelta returns the LP won or lost, counting promotions and demotions.
+func (c RankChange) LPDelta() int {
+	if c.Before == nil {
+		return 0
+	}
+	return c.After.TotalLP() - c.Before.TotalLP()
+}
+
+// Promoted reports whether the player moved up a division or tier.
+func (c RankChange) Promoted() bool {
+	return c.Before != nil && c.divisionChanged() && c.LPDelta() > 0
+}
+
+// Demoted reports whether the player moved down a division or tier.
+func (c RankChange) Demoted() bool {
+	return c.Before != nil && c.divisionChanged() && c.LPDelta() < 0
+}
+
+// NewTier reports whether the player reached a tier above the previous one.
+func (c RankChange) NewTier() bool {
+	return c.Before != nil && tierIndex(c.After.Tier) > tierIndex(c.Before.Tier)
+}
+
+func (c RankChange) divisionChanged() bool {
+	if c.Before.Tier != c.After.Tier {
+		return true
+	}
+	return !c.After.Apex() && c.Before.Rank != c.After.Rank
+}
+
+func (h *HistoryStore) ranksCollection(puuid string) string {
+	return h.prefix + ":ranks:" + puuid
+}
+
+func (h *HistoryStore) rankedCollection() string {
+	return h.prefix + ":ranked"
+}
+
+func (h *HistoryStore) takenIndex(puuid string) string {
+	return h.prefix + ":index:taken:" + puuid
+}
+
+// AddRank records a rank snapshot, replacing an earlier snapshot for the
+// same game, and prunes the player's snapshots to the history limits.
+func (h *HistoryStore) AddRank(s *RankSnapshot) error {
+	if s.PUUID == "" || s.MatchID == "" || s.QueueType == "" {
+		return fmt.Errorf("rank snapshot needs a puuid, a match id and a queue")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.backend.PutRecord(h.ranksCollection(s.PUUID), s.MatchID, encodeRank(s)); err != nil {
+		return err
+	}
+	if err := h.backend.IndexAdd(h.takenIndex(s.PUUID), s.MatchID, s.TakenAt.Unix()); err != nil {
+		return err
+	}
+	if err := h.backend.PutRecord(h.rankedCollection(), s.PUUID, indexFields(s.TakenAt)); err != nil {
+		return err
+	}
+
+	entries, err := h.allEntries(h.takenIndex(s.PUUID))
+	if err != nil {
+		return err
+	}
+	_, err = h.prune(h.ranksCollection(s.PUUID), h.takenIndex(s.PUUID), entries, time.Now())
+	return err
+}
+
+// rankEntries returns the index entries of a player's snapshots taken in
+// [from, to), newest first.
+func (h *HistoryStore) rankEntries(puuid string, from, to time.Time) ([]IndexEntry, error) {
+	lo, hi := scoreRange(from, to)
+	return h.backend.IndexRange(h.takenIndex(puuid), lo, hi, 0)
+}
+
+// ranks returns the snapshots of a player for entries, in order.
+func (h *HistoryStore) ranks(puuid string, entries []IndexEntry) ([]RankSnapshot, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	raw, err := h.backend.GetRecords(h.ranksCollection(puuid), entryIDs(entries))
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]RankSnapshot, 0, len(entries))
+	for _, e := range entries {
+		fields, ok := raw[e.ID]
+		if !ok {
+			continue // pruned while we read
+		}
+		s, err := decodeRank(fields)
+		if err != nil {
+			slog.Warn("Skipping rank snapshot", "puuid", puuid, "match_id", e.ID, "error", err)
+			continue
+		}
+		snapshots = append(snapshots, *s)
+	}
+	return snapshots, nil
+}
+
+// Ranks returns a player's snapshots in one queue taken in [from, to),
+// newest first. Zero times don't filter.
+func (h *HistoryStore) Ranks(puuid, queueType string, from, to time.Time) ([]RankSnapshot, error) {
+	entries, err := h.rankEntries(puuid, from, to)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := h.ranks(puuid, entries)
+	if err != nil {
+		return nil, err
+	}
+	var out []RankSnapshot
+	for _, s := range snapshots {
+		if s.QueueType == queueType {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// RankAt returns the snapshot a game led to, if any.
+func (h *HistoryStore) RankAt(puuid, matchID string) (*RankSnapshot, bool, error) {
+	fields, ok, err := h.backend.GetRecord(h.ranksCollection(puuid), matchID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	s, err := decodeRank(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	return s, true, nil
+}
+
+// rankPage is how many earlier snapshots RankChangeAt reads at a time
+// looking for one in the same queue.
+const rankPage = 10
+
+// RankChangeAt returns the change a game made: its snapshot and the one
+// before it in the same queue.
+func (h *HistoryStore) RankChangeAt(puuid, matchID string) (*RankChange, bool, error) {
+	after, ok, err := h.RankAt(puuid, matchID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	change := &RankChange{After: after}
+
+	earlier, err := h.rankEntries(puuid, time.Time{}, after.TakenAt)
+	if err != nil {
+		return nil, false, err
+	}
+	for len(earlier) > 0 {
+		page := earlier[:min(len(earlier), rankPage)]
+		earlier = earlier[len(page):]
+		snapshots, err := h.ranks(puuid, page)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, s := range snapshots {
+			if s.QueueType == after.QueueType {
+				change.Before = &s
+				return change, true, nil
+			}
+		}
+	}
+	return change, true, nil
+}
+
+// exportRanks returns every rank snapshot for a backup, keyed by
+// <puuid>/<match ID>.
+func (h *HistoryStore) exportRanks() (map[string]map[string]string, error) {
+	index, err := h.backend.Records(h.rankedCollection())
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]map[string]string)
+	for puuid := range index {
+		records, err := h.backend.Records(h.ranksCollection(puuid))
+		if err != nil {
+			return nil, err
+		}
+		for matchID, fields := range records {
+			all[puuid+"/"+matchID] = fields
+		}
+	}
+	return all, nil
+}
+
+// importRank adds a rank snapshot from a backup.
+func (h *HistoryStore) importRank(id string, fields map[string]string) error {
+	s, err := decodeRank(fields)
+	if err != nil {
+		return err
+	}
+	if s.PUUID+"/"+s.MatchID != id {
+		return fmt.Errorf("record does not match its id")
+	}
+	return h.AddRank(s)
+}
+
+// encodeRank returns the record fields of a rank snapshot.
+func encodeRank(s *RankSnapshot) map[string]string {
+	return map[string]string{
+		"v":          strconv.Itoa(HistorySchema),
+		"puuid":      s.PUUID,
+		"queue_type": s.QueueType,
+		"match_id":   s.MatchID,
+		"taken_at":   strconv.FormatInt(s.TakenAt.Unix(), 10),
+		"tier":       s.Tier,
+		"rank":       s.Rank,
+		"lp":         strconv.Itoa(s.LP),
+		"wins":       strconv.Itoa(s.Wins),
+		"losses":     strconv.Itoa(s.Losses),
+	}
+}
+
+// decodeRank reads a rank snapshot.
+func decodeRank(fields map[string]string) (*RankSnapshot, error) {
+	version, err := strconv.Atoi(fields["v"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema version %q", fields["v"])
+	}
+	if version > HistorySchema {
+		return nil, fmt.Errorf("schema version %d is newer than this build (%d)", version, HistorySchema)
+	}
+	takenAt, err := strconv.ParseInt(fields["taken_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taken_at %q", fields["taken_at"])
+	}
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(fields[key])
+		return n
+	}
+	return &RankSnapshot{
+		PUUID:     fields["puuid"],
+		QueueType: fields["queue_type"],
+		MatchID:   fields["match_id"],
+		TakenAt:   time.Unix(takenAt, 0).UTC(),
+		Tier:      fields["tier"],
+		Rank:      fields["rank"],
+		LP:        atoi("lp"),
+		Wins:      atoi("wins"),
+		Losses:    atoi("losses"),
+	}, nil
+}
@@ -1,46 +1,62 @@
-// Package storage provides Redis persistence for ZoeBot.
 package storage
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"strings"
-	"sync"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
-	"github.com/zoebot/internal/config"
 )
 
-// TrackedPlayer represents a player being tracked for match notifications.
-type TrackedPlayer struct {
-	PUUID       string `json:"puuid"`
-	LastMatchID string `json:"last_match_id"`
-	ChannelID   string `json:"channel_id"`
-	Name        string `json:"name"`
+// createRecordScript stores a record hash unless one exists already.
+// KEYS: record hash, collection set. ARGV: id, then field/value pairs.
+var createRecordScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], unpack(ARGV, 2))
+redis.call('SADD', KEYS[2], ARGV[1])
+return 1
+`)
+
+// updateRecordScript sets fields of an existing record hash, so an update
+// racing a delete doesn't bring the record back.
+// KEYS: record hash. ARGV: field/value pairs.
+var updateRecordScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], unpack(ARGV))
+return 1
+`)
+
+// unlockScript deletes a lock only if it still holds our token, so a lock
+// that expired and was taken by someone else is left alone.
+// KEYS: lock. ARGV: token.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisBackend stores data in Redis. Keys are plain strings; a collection
+// is a set of record IDs at <collection>, with each record a hash at
+// <collection>:<id>. A time index is a sorted set at its name.
+type RedisBackend struct {
+	client *redis.Client
+	ctx    context.Context
 }
 
-// RedisClient wraps go-redis client.
-type RedisClient struct {
-	client  *redis.Client
-	enabled bool
-	ctx     context.Context
-}
-
-// NewRedisClient creates a new Redis client using go-redis.
-func NewRedisClient(cfg *config.Config) *RedisClient {
-	redisURL := cfg.RedisURL
-	if redisURL == "" {
-		log.Println("Redis not configured (REDIS_URL missing), using memory only")
-		return &RedisClient{enabled: false, ctx: context.Background()}
-	}
-
+// NewRedisBackend connects to the Redis at redisURL.
+func NewRedisBackend(redisURL string) (*RedisBackend, error) {
 	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
-		log.Printf("Failed to parse REDIS_URL: %v", err)
-		return &RedisClient{enabled: false, ctx: context.Background()}
+		return nil, err
 	}
 
 	// Optimize for serverless
@@ -55,190 +71,233 @@ func NewRedisClient(cfg *config.Config) *RedisClient {
 
 	// Test connection
 	if err := client.Ping(ctx).Err(); err != nil {
-		log.Printf("Redis connection failed: %v", err)
-		return &RedisClient{enabled: false, ctx: ctx}
+		client.Close()
+		return nil, err
 	}
 
-	log.Println("Redis connected successfully")
-	return &RedisClient{
-		client:  client,
-		enabled: true,
-		ctx:     ctx,
-	}
+	slog.Info("Redis connected")
+	return &RedisBackend{client: client, ctx: ctx}, nil
 }
 
-// Get retrieves a value from Redis.
-func (r *RedisClient) Get(key string) (string, error) {
-	if !r.enabled {
-		return "", nil
-	}
-	val, err := r.client.Get(r.ctx, key).Result()
-	if err == redis.Nil {
-		return "", nil
-	}
-	return val, err
+// Name implements Backend.
+func (r *RedisBackend) Name() string {
+	return "redis"
 }
 
-// Set stores a value in Redis (no expiration).
-func (r *RedisClient) Set(key string, value string) error {
-	if !r.enabled {
-		return nil
-	}
-	return r.client.Set(r.ctx, key, value, 0).Err()
+// Ping checks the Redis connection with a short timeout.
+func (r *RedisBackend) Ping() error {
+	ctx, cancel := context.WithTimeout(r.ctx, time.Second)
+	defer cancel()
+	return r.client.Ping(ctx).Err()
 }
 
-// Delete removes a key from Redis.
-func (r *RedisClient) Delete(key string) error {
-	if !r.enabled {
-		return nil
-	}
-	return r.client.Del(r.ctx, key).Err()
+// Close closes the connection pool.
+func (r *RedisBackend) Close() error {
+	return r.client.Close()
 }
 
-// TrackedPlayersStore manages tracked players persistence.
-type TrackedPlayersStore struct {
-	redis   *RedisClient
-	key     string
-	players map[string]*TrackedPlayer
-	mu      sync.RWMutex
+// Get implements Backend.
+func (r *RedisBackend) Get(key string) (string, bool, error) {
+	val, err := r.client.Get(r.ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return val, true, nil
 }
 
-// NewTrackedPlayersStore creates a new tracked players store.
-func NewTrackedPlayersStore(redis *RedisClient, key string) *TrackedPlayersStore {
-	return &TrackedPlayersStore{
-		redis:   redis,
-		key:     key,
-		players: make(map[string]*TrackedPlayer),
-	}
+// Set implements Backend.
+func (r *RedisBackend) Set(key, value string, ttl time.Duration) error {
+	return r.client.Set(r.ctx, key, value, ttl).Err()
 }
 
-// Load loads tracked players from Redis.
-func (s *TrackedPlayersStore) Load() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Delete implements Backend.
+func (r *RedisBackend) Delete(keys ...string) (int, error) {
+	n, err := r.client.Del(r.ctx, keys...).Result()
+	return int(n), err
+}
 
-	data, err := s.redis.Get(s.key)
-	if err != nil {
+// DeletePrefix deletes matching keys in batches as SCAN finds them.
+func (r *RedisBackend) DeletePrefix(prefix string) (int, error) {
+	deleted := 0
+	iter := r.client.Scan(r.ctx, 0, prefix+"*", 100).Iterator()
+	batch := make([]string, 0, 100)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := r.client.Del(r.ctx, batch...).Result()
+		deleted += int(n)
+		batch = batch[:0]
 		return err
 	}
-
-	if data == "" {
-		s.players = make(map[string]*TrackedPlayer)
-		return nil
+	for iter.Next(r.ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
 	}
-
-	var players map[string]*TrackedPlayer
-	if err := json.Unmarshal([]byte(data), &players); err != nil {
-		return err
+	if err := iter.Err(); err != nil {
+		return deleted, err
 	}
+	return deleted, flush()
+}
 
-	// Migrate: ensure PUUID field is set from map key
-	needsSave := false
-	for puuid, player := range players {
-		if player.PUUID == "" && !strings.Contains(puuid, "#") {
-			player.PUUID = puuid
-			needsSave = true
-		}
+// Keys implements Backend with SCAN.
+func (r *RedisBackend) Keys(prefix string) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(r.ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(r.ctx) {
+		keys = append(keys, iter.Val())
 	}
+	return keys, iter.Err()
+}
 
-	s.players = players
-	log.Printf("Loaded %d players", len(s.players))
+// Lock implements Backend with SET NX and a random token.
+func (r *RedisBackend) Lock(name string, ttl time.Duration) (func(), error) {
+	var buf [16]byte
+	rand.Read(buf[:])
+	token := hex.EncodeToString(buf[:])
 
-	// Auto-save migrated data
-	if needsSave {
-		log.Println("Migrating old player data format...")
-		s.mu.Unlock()
-		s.Save()
-		s.mu.Lock()
+	ok, err := r.client.SetNX(r.ctx, name, token, ttl).Result()
+	if err != nil {
+		return nil, err
 	}
+	if !ok {
+		return nil, ErrLocked
+	}
+	return func() {
+		if err := unlockScript.Run(r.ctx, r.client, []string{name}, token).Err(); err != nil {
+			slog.Warn("Releasing lock failed", "lock", name, "error", err)
+		}
+	}, nil
+}
 
-	return nil
+// recordKey returns the hash key of a record.
+func recordKey(collection, id string) string {
+	return collection + ":" + id
 }
 
-// Save saves tracked players to Redis.
-func (s *TrackedPlayersStore) Save() error {
-	s.mu.RLock()
-	data, err := json.Marshal(s.players)
-	s.mu.RUnlock()
+// hashArgs flattens fields into HSET field/value arguments.
+func hashArgs(fields map[string]string) []interface{} {
+	args := make([]interface{}, 0, 2*len(fields))
+	for k, v := range fields {
+		args = append(args, k, v)
+	}
+	return args
+}
 
+// Records reads the collection set, then every record hash.
+func (r *RedisBackend) Records(collection string) (map[string]map[string]string, error) {
+	ids, err := r.client.SMembers(r.ctx, collection).Result()
 	if err != nil {
-		return fmt.Errorf("failed to marshal players: %w", err)
+		return nil, err
 	}
+	// Records deleted between SMEMBERS and HGETALL are left out
+	return r.GetRecords(collection, ids)
+}
 
-	if err := s.redis.Set(s.key, string(data)); err != nil {
-		return err
+// GetRecord implements Backend.
+func (r *RedisBackend) GetRecord(collection, id string) (map[string]string, bool, error) {
+	fields, err := r.client.HGetAll(r.ctx, recordKey(collection, id)).Result()
+	if err != nil || len(fields) == 0 {
+		return nil, false, err
 	}
-
-	return nil
+	return fields, true, nil
 }
 
-// Get returns a tracked player by PUUID.
-func (s *TrackedPlayersStore) Get(puuid string) (*TrackedPlayer, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	p, ok := s.players[puuid]
-	return p, ok
-}
+// GetRecords reads the record hashes in one pipeline.
+func (r *RedisBackend) GetRecords(collection string, ids []string) (map[string]map[string]string, error) {
+	records := make(map[string]map[string]string, len(ids))
+	if len(ids) == 0 {
+		return records, nil
+	}
 
-// Set adds or updates a tracked player.
-func (s *TrackedPlayersStore) Set(puuid string, player *TrackedPlayer) {
-	// Ensure PUUID is always set in struct
-	if player.PUUID == "" {
-		player.PUUID = puuid
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(r.ctx, recordKey(collection, id))
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, err
 	}
-	s.mu.Lock()
-	s.players[puuid] = player
-	s.mu.Unlock()
+
+	for i, cmd := range cmds {
+		if fields := cmd.Val(); len(fields) > 0 {
+			records[ids[i]] = fields
+		}
+	}
+	return records, nil
 }
 
-// Delete removes a tracked player.
-func (s *TrackedPlayersStore) Delete(puuid string) {
-	s.mu.Lock()
-	delete(s.players, puuid)
-	s.mu.Unlock()
+// PutRecord implements Backend.
+func (r *RedisBackend) PutRecord(collection, id string, fields map[string]string) error {
+	key := recordKey(collection, id)
+	pipe := r.client.TxPipeline()
+	pipe.Del(r.ctx, key)
+	pipe.HSet(r.ctx, key, hashArgs(fields)...)
+	pipe.SAdd(r.ctx, collection, id)
+	_, err := pipe.Exec(r.ctx)
+	return err
 }
 
-// GetAll returns all tracked players as a copy map.
-// Returns copies of TrackedPlayer to prevent data races.
-func (s *TrackedPlayersStore) GetAll() map[string]TrackedPlayer {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// CreateRecord implements Backend.
+func (r *RedisBackend) CreateRecord(collection, id string, fields map[string]string) (bool, error) {
+	args := append([]interface{}{id}, hashArgs(fields)...)
+	created, err := createRecordScript.Run(r.ctx, r.client, []string{recordKey(collection, id), collection}, args...).Int()
+	return created == 1, err
+}
 
-	result := make(map[string]TrackedPlayer, len(s.players))
-	for k, v := range s.players {
-		if v != nil {
-			result[k] = *v // Copy the struct value
-		}
-	}
-	return result
+// UpdateRecord implements Backend.
+func (r *RedisBackend) UpdateRecord(collection, id string, fields map[string]string) (bool, error) {
+	updated, err := updateRecordScript.Run(r.ctx, r.client, []string{recordKey(collection, id)}, hashArgs(fields)...).Int()
+	return updated == 1, err
 }
 
-// GetByChannel returns all tracked players for a specific channel.
-func (s *TrackedPlayersStore) GetByChannel(channelID string) []*TrackedPlayer {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// DeleteRecord implements Backend.
+func (r *RedisBackend) DeleteRecord(collection, id string) error {
+	pipe := r.client.TxPipeline()
+	pipe.Del(r.ctx, recordKey(collection, id))
+	pipe.SRem(r.ctx, collection, id)
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
 
-	var result []*TrackedPlayer
-	for _, p := range s.players {
-		if p.ChannelID == channelID {
-			result = append(result, p)
-		}
-	}
-	return result
+// IndexAdd implements Backend with ZADD.
+func (r *RedisBackend) IndexAdd(index, id string, score int64) error {
+	return r.client.ZAdd(r.ctx, index, redis.Z{Score: float64(score), Member: id}).Err()
 }
 
-// Count returns the number of tracked players.
-func (s *TrackedPlayersStore) Count() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return len(s.players)
+// IndexRange implements Backend with ZREVRANGEBYSCORE.
+func (r *RedisBackend) IndexRange(index string, min, max int64, limit int) ([]IndexEntry, error) {
+	zs, err := r.client.ZRevRangeByScoreWithScores(r.ctx, index, &redis.ZRangeBy{
+		Min:   strconv.FormatInt(min, 10),
+		Max:   strconv.FormatInt(max, 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]IndexEntry, len(zs))
+	for i, z := range zs {
+		id, _ := z.Member.(string)
+		entries[i] = IndexEntry{ID: id, Score: int64(z.Score)}
+	}
+	return entries, nil
 }
 
-// UpdateLastMatch updates the last match ID for a player.
-func (s *TrackedPlayersStore) UpdateLastMatch(puuid, matchID string) {
-	s.mu.Lock()
-	if p, ok := s.players[puuid]; ok {
-		p.LastMatchID = matchID
+// IndexRemove implements Backend with ZREM.
+func (r *RedisBackend) IndexRemove(index string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
 	}
-	s.mu.Unlock()
+	return r.client.ZRem(r.ctx, index, members...).Err()
 }
@@ -0,0 +1,113 @@
+// Package storage provides persistence for ZoeBot on Redis, an embedded
+// bolt file or memory.
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zoebot/pkg/metrics"
+)
+
+var cacheLookups = metrics.NewCounterVec(
+	"zoebot_cache_lookups_total",
+	"Cache lookups by key family and result (hit/miss).",
+	"family", "result",
+)
+
+// CacheFamilies maps cache family names to their key prefixes.
+var CacheFamilies = map[string]string{
+	"puuid":    "puuid:",
+	"summoner": "summoner:",
+	"league":   "league:puuid:",
+	"mastery":  "mastery:",
+	"counter":  "counter:",
+	"build":    "build:",
+	"analysis": "analysis:",
+	"context":  "context:",
+	"match":    "match:",
+	"pages":    "pages:",
+}
+
+// Store is the bot's view of its backend: cache entries with per-family
+// TTLs on top of the backend's keys and records.
+type Store struct {
+	backend  Backend
+	degraded error                                    // why the configured backend isn't in use
+	ttls     atomic.Pointer[map[string]time.Duration] // cache family -> TTL
+}
+
+// NewStore creates a store on backend. Cache entries don't expire until
+// SetCacheTTLs is called.
+func NewStore(backend Backend) *Store {
+	return &Store{backend: backend}
+}
+
+// Backend returns the underlying backend.
+func (s *Store) Backend() Backend {
+	return s.backend
+}
+
+// Name returns the backend name.
+func (s *Store) Name() string {
+	return s.backend.Name()
+}
+
+// Ping checks the backend. It fails while running on the memory fallback
+// for an unreachable Redis.
+func (s *Store) Ping() error {
+	if s.degraded != nil {
+		return s.degraded
+	}
+	return s.backend.Ping()
+}
+
+// Close closes the backend.
+func (s *Store) Close() error {
+	return s.backend.Close()
+}
+
+// CacheGet looks up a cache entry and records a hit or miss for its key
+// family (e.g. "puuid", "league", "counter"). Errors count as misses.
+func (s *Store) CacheGet(family, key string) (string, bool) {
+	val, ok, err := s.backend.Get(key)
+	if err != nil || !ok || val == "" {
+		cacheLookups.Inc(family, "miss")
+		return "", false
+	}
+	cacheLookups.Inc(family, "hit")
+	return val, true
+}
+
+// CacheSet stores a cache entry with its family's TTL.
+func (s *Store) CacheSet(family, key, value string) error {
+	var ttl time.Duration
+	if ttls := s.ttls.Load(); ttls != nil {
+		ttl = (*ttls)[family]
+	}
+	return s.backend.Set(key, value, ttl)
+}
+
+// SetCacheTTLs sets the TTL of each cache family for future CacheSet calls.
+func (s *Store) SetCacheTTLs(ttls map[string]time.Duration) {
+	s.ttls.Store(&ttls)
+}
+
+// FlushCache deletes cache entries of a family. With a key, only that entry
+// is deleted (the key must belong to the family); otherwise every entry with
+// the family prefix is. It returns the number of deleted keys.
+func (s *Store) FlushCache(family, key string) (int, error) {
+	prefix, ok := CacheFamilies[family]
+	if !ok {
+		return 0, fmt.Errorf("unknown cache family %q", family)
+	}
+	if key != "" && !strings.HasPrefix(key, prefix) {
+		return 0, fmt.Errorf("key %q is not in cache family %q", key, family)
+	}
+	if key != "" {
+		return s.backend.Delete(key)
+	}
+	return s.backend.DeletePrefix(prefix)
+}
@@ -3,41 +3,147 @@ package healthcheck
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Result is the outcome of a single dependency check.
+type Result struct {
+	OK      bool                   `json:"ok"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Check reports the current state of a dependency.
+// Checks must be cheap: they run on every probe request.
+type Check func() Result
+
+type namedCheck struct {
+	name     string
+	critical bool
+	check    Check
+}
+
 // Server is a minimal HTTP server for health checks.
 type Server struct {
-	server *http.Server
+	server    *http.Server
+	mux       *http.ServeMux
+	startedAt time.Time
+
+	mu     sync.RWMutex
+	checks []namedCheck
 }
 
 // New creates a new lightweight health check server.
+//
+// Endpoints:
+//   - /, /health, /livez: process is alive
+//   - /readyz: all critical checks pass (503 otherwise)
+//   - /status: JSON report of every registered check
 func New(addr string) *Server {
 	mux := http.NewServeMux()
 
-	// Minimal response, no allocations
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("ok"))
-	})
-
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("ok"))
-	})
-
-	return &Server{
+	s := &Server{
+		mux:       mux,
+		startedAt: time.Now(),
 		server: &http.Server{
 			Addr:              addr,
 			Handler:           mux,
 			ReadTimeout:       2 * time.Second,
-			WriteTimeout:      2 * time.Second,
+			WriteTimeout:      5 * time.Second,
 			IdleTimeout:       30 * time.Second,
 			ReadHeaderTimeout: 1 * time.Second,
 			MaxHeaderBytes:    1 << 10, // 1KB
 		},
 	}
+
+	// Minimal response, no allocations
+	alive := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}
+	mux.HandleFunc("/", alive)
+	mux.HandleFunc("/health", alive)
+	mux.HandleFunc("/livez", alive)
+
+	mux.HandleFunc("/readyz", s.handleReady)
+	mux.HandleFunc("/status", s.handleStatus)
+
+	return s
+}
+
+// AddCheck registers a dependency check. Critical checks gate /readyz;
+// non-critical checks only show up in /status.
+func (s *Server) AddCheck(name string, critical bool, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, namedCheck{name: name, critical: critical, check: check})
+}
+
+// Handle mounts an extra handler (e.g. /metrics) on the same listener.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// run executes all registered checks and returns their results by name,
+// plus the names of failing critical checks.
+func (s *Server) run() (map[string]Result, []string) {
+	s.mu.RLock()
+	checks := make([]namedCheck, len(s.checks))
+	copy(checks, s.checks)
+	s.mu.RUnlock()
+
+	results := make(map[string]Result, len(checks))
+	var failing []string
+	for _, c := range checks {
+		res := c.check()
+		results[c.name] = res
+		if c.critical && !res.OK {
+			failing = append(failing, c.name)
+		}
+	}
+	sort.Strings(failing)
+	return results, failing
+}
+
+// handleReady answers 200 when every critical check passes, 503 otherwise.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	_, failing := s.run()
+	if len(failing) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not ready: " + strings.Join(failing, ", ")))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ready"))
+}
+
+// statusResponse is the JSON body of /status.
+type statusResponse struct {
+	Ready         bool              `json:"ready"`
+	UptimeSeconds int64             `json:"uptime_seconds"`
+	Failing       []string          `json:"failing,omitempty"`
+	Checks        map[string]Result `json:"checks"`
+}
+
+// handleStatus writes a JSON report of every registered check.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	results, failing := s.run()
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(failing) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(statusResponse{
+		Ready:         len(failing) == 0,
+		UptimeSeconds: int64(time.Since(s.startedAt).Seconds()),
+		Failing:       failing,
+		Checks:        results,
+	})
 }
 
 // Start starts the health check server.
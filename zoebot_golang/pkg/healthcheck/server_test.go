@@ -0,0 +1,161 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func passing() Result { return Result{OK: true} }
+func failing() Result { return Result{OK: false, Message: "down"} }
+
+type testCheck struct {
+	name     string
+	critical bool
+	check    Check
+}
+
+// get requests path from s and returns the status and body.
+func get(t *testing.T, s *Server, path string) (int, string) {
+	t.Helper()
+	srv := httptest.NewServer(s.mux)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []testCheck
+		status int
+		body   string
+	}{
+		{
+			name:   "no checks",
+			status: http.StatusOK,
+			body:   "ready",
+		},
+		{
+			name: "all passing",
+			checks: []testCheck{
+				{"redis", true, passing},
+				{"discord", true, passing},
+				{"ai", false, passing},
+			},
+			status: http.StatusOK,
+			body:   "ready",
+		},
+		{
+			name: "non-critical failing",
+			checks: []testCheck{
+				{"redis", true, passing},
+				{"ai", false, failing},
+			},
+			status: http.StatusOK,
+			body:   "ready",
+		},
+		{
+			name: "critical failing",
+			checks: []testCheck{
+				{"riot", true, failing},
+				{"redis", true, failing},
+				{"discord", true, passing},
+				{"ai", false, failing},
+			},
+			status: http.StatusServiceUnavailable,
+			body:   "not ready: redis, riot",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(":0")
+			for _, c := range tt.checks {
+				s.AddCheck(c.name, c.critical, c.check)
+			}
+			status, body := get(t, s, "/readyz")
+			if status != tt.status || body != tt.body {
+				t.Errorf("/readyz = %d %q, want %d %q", status, body, tt.status, tt.body)
+			}
+			// Liveness doesn't depend on the checks
+			if status, _ := get(t, s, "/livez"); status != http.StatusOK {
+				t.Errorf("/livez = %d, want 200", status)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s := New(":0")
+	s.AddCheck("redis", true, failing)
+	s.AddCheck("ai", false, func() Result {
+		return Result{OK: true, Details: map[string]interface{}{"total": 3}}
+	})
+
+	status, body := get(t, s, "/status")
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", status)
+	}
+	var got statusResponse
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+	if got.Ready || len(got.Failing) != 1 || got.Failing[0] != "redis" || got.UptimeSeconds < 0 {
+		t.Errorf("status = %+v, want not ready with redis failing", got)
+	}
+	if redis := got.Checks["redis"]; redis.OK || redis.Message != "down" {
+		t.Errorf("redis check = %+v", redis)
+	}
+	if ai := got.Checks["ai"]; !ai.OK || ai.Details["total"] != 3.0 {
+		t.Errorf("ai check = %+v", ai)
+	}
+
+	// A passing report leaves out the failing list
+	s = New(":0")
+	s.AddCheck("ai", false, failing)
+	status, body = get(t, s, "/status")
+	if status != http.StatusOK || !strings.Contains(body, `"ready":true`) || strings.Contains(body, "failing") {
+		t.Errorf("/status = %d %s, want ready without failing checks", status, body)
+	}
+}
+
+func TestCallTracker(t *testing.T) {
+	var tracker CallTracker
+	if d := tracker.Snapshot().Details(); len(d) != 2 || d["total"] != uint64(0) {
+		t.Errorf("details before any call = %v, want only the counters", d)
+	}
+
+	tracker.Record(http.StatusOK, nil)
+	tracker.Record(http.StatusTooManyRequests, errors.New("rate limited"))
+	tracker.Record(0, errors.New(strings.Repeat("x", 300)))
+	snap := tracker.Snapshot()
+	if snap.Total != 3 || snap.Errors != 2 || snap.LastStatus != 0 || len(snap.LastError) != 200 || snap.LastAt.IsZero() {
+		t.Errorf("snapshot = %+v, want 3 calls, 2 errors and the last error cut to 200 bytes", snap)
+	}
+
+	// A success clears the last error but keeps the error count
+	tracker.Record(http.StatusOK, nil)
+	snap = tracker.Snapshot()
+	if snap.Errors != 2 || snap.LastError != "" || snap.LastStatus != http.StatusOK {
+		t.Errorf("snapshot after a success = %+v", snap)
+	}
+	d := snap.Details()
+	if d["total"] != uint64(4) || d["errors"] != uint64(2) || d["last_status"] != http.StatusOK || d["last_at"] == nil {
+		t.Errorf("details = %v", d)
+	}
+	if _, ok := d["last_error"]; ok {
+		t.Error("details report a cleared error")
+	}
+}
@@ -0,0 +1,76 @@
+package healthcheck
+
+import (
+	"sync"
+	"time"
+)
+
+// CallTracker records the outcome of calls to an external service
+// so that health checks can report on them without making requests.
+type CallTracker struct {
+	mu         sync.Mutex
+	lastAt     time.Time
+	lastStatus int
+	lastErr    string
+	total      uint64
+	errors     uint64
+}
+
+// CallSnapshot is a point-in-time copy of a CallTracker.
+type CallSnapshot struct {
+	LastAt     time.Time `json:"last_at,omitempty"`
+	LastStatus int       `json:"last_status,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+	Total      uint64    `json:"total"`
+	Errors     uint64    `json:"errors"`
+}
+
+// Record stores the result of a call. status is the HTTP status code
+// (0 if the request never got a response).
+func (t *CallTracker) Record(status int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastAt = time.Now()
+	t.lastStatus = status
+	t.total++
+	if err != nil {
+		t.errors++
+		msg := err.Error()
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		t.lastErr = msg
+	} else {
+		t.lastErr = ""
+	}
+}
+
+// Snapshot returns a copy of the current counters.
+func (t *CallTracker) Snapshot() CallSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return CallSnapshot{
+		LastAt:     t.lastAt,
+		LastStatus: t.lastStatus,
+		LastError:  t.lastErr,
+		Total:      t.total,
+		Errors:     t.errors,
+	}
+}
+
+// Details converts the snapshot into a Result details map.
+func (s CallSnapshot) Details() map[string]interface{} {
+	d := map[string]interface{}{
+		"total":  s.Total,
+		"errors": s.Errors,
+	}
+	if !s.LastAt.IsZero() {
+		d["last_at"] = s.LastAt.Format(time.RFC3339)
+		d["last_status"] = s.LastStatus
+	}
+	if s.LastError != "" {
+		d["last_error"] = s.LastError
+	}
+	return d
+}
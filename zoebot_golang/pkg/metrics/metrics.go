@@ -0,0 +1,140 @@
+// Package metrics provides a tiny Prometheus-compatible metrics registry.
+//
+// It implements just enough of the text exposition format (counters,
+// gauges and histograms with labels) to avoid pulling in the full
+// client library, which matters under the bot's 50MB memory limit.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are latency buckets (seconds) suited to HTTP calls.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// collector is anything the registry can write out.
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds a set of metrics.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// Default is the process-wide registry used by the New* helpers.
+var Default = &Registry{}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.collectors {
+		if existing.name() == c.name() {
+			panic("metrics: duplicate metric " + c.name())
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every metric in the Prometheus text format.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler serves the default registry.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Default.Write(w)
+	})
+}
+
+// desc is the shared name/help/label schema of a metric family.
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (d *desc) name() string { return d.metricName }
+
+func (d *desc) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, d.help, d.metricName, kind)
+}
+
+// key joins label values into a map key.
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.metricName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelString renders {a="x",b="y"} plus optional extra pairs.
+func (d *desc) labelString(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	n := 0
+	for i, l := range d.labels {
+		if n > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(l + `="` + escape(values[i]) + `"`)
+		n++
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if n > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(extra[i] + `="` + escape(extra[i+1]) + `"`)
+		n++
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func escape(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns series keys in a stable order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
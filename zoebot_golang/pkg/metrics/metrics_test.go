@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// useRegistry points the New* helpers at an empty registry for the test.
+func useRegistry(t *testing.T) {
+	t.Helper()
+	saved := Default
+	Default = &Registry{}
+	t.Cleanup(func() { Default = saved })
+}
+
+func TestHandler(t *testing.T) {
+	useRegistry(t)
+
+	// Registered out of order: families are written by name
+	requests := NewCounterVec("zoebot_requests_total", "Requests by route.", "route", "status")
+	latency := NewHistogramVec("zoebot_latency_seconds", "Request latency.", []float64{0.1, 1}, "route")
+	players := NewGaugeVec("zoebot_players", "Tracked players.")
+
+	requests.Inc("/match", "200")
+	requests.Add(2, "/match", "200")
+	requests.Inc("/account", "429")
+	requests.Add(-1, "/account", "429") // counters never go down
+	requests.Inc(`C:\path`, "line\n\"quoted\"")
+
+	players.Set(3)
+	players.Set(7)
+
+	latency.Observe(0.05, "/match")
+	latency.Observe(0.5, "/match")
+	latency.Observe(0.5, "/match")
+	latency.Observe(5, "/match")
+	latency.Observe(1, "/account")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	want := `# HELP zoebot_latency_seconds Request latency.
+# TYPE zoebot_latency_seconds histogram
+zoebot_latency_seconds_bucket{route="/account",le="0.1"} 0
+zoebot_latency_seconds_bucket{route="/account",le="1"} 1
+zoebot_latency_seconds_bucket{route="/account",le="+Inf"} 1
+zoebot_latency_seconds_sum{route="/account"} 1
+zoebot_latency_seconds_count{route="/account"} 1
+zoebot_latency_seconds_bucket{route="/match",le="0.1"} 1
+zoebot_latency_seconds_bucket{route="/match",le="1"} 3
+zoebot_latency_seconds_bucket{route="/match",le="+Inf"} 4
+zoebot_latency_seconds_sum{route="/match"} 6.05
+zoebot_latency_seconds_count{route="/match"} 4
+# HELP zoebot_players Tracked players.
+# TYPE zoebot_players gauge
+zoebot_players 7
+# HELP zoebot_requests_total Requests by route.
+# TYPE zoebot_requests_total counter
+zoebot_requests_total{route="/account",status="429"} 1
+zoebot_requests_total{route="/match",status="200"} 3
+zoebot_requests_total{route="C:\\path",status="line\n\"quoted\""} 1
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("/metrics =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegisterMisuse(t *testing.T) {
+	useRegistry(t)
+	counter := NewCounterVec("zoebot_errors_total", "Errors.", "kind")
+
+	assertPanics := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s did not panic", name)
+			}
+		}()
+		f()
+	}
+	assertPanics("duplicate metric", func() { NewGaugeVec("zoebot_errors_total", "Again.") })
+	assertPanics("wrong label count", func() { counter.Inc("a", "b") })
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// CounterVec is a monotonically increasing value per label set.
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+// NewCounterVec creates and registers a counter family.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		series: make(map[string]*counterSeries),
+	}
+	Default.register(c)
+	return c
+}
+
+// Inc adds 1 to the series with the given label values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v (which must be non-negative) to the series.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	k := c.key(values)
+	c.mu.Lock()
+	s, ok := c.series[k]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		c.series[k] = s
+	}
+	s.value += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, k := range sortedKeys(c.series) {
+		s := c.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelString(s.values), formatFloat(s.value))
+	}
+}
+
+// GaugeVec is a value that can go up and down per label set.
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+// NewGaugeVec creates and registers a gauge family.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		series: make(map[string]*counterSeries),
+	}
+	Default.register(g)
+	return g
+}
+
+// Set sets the series to v.
+func (g *GaugeVec) Set(v float64, values ...string) {
+	k := g.key(values)
+	g.mu.Lock()
+	s, ok := g.series[k]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		g.series[k] = s
+	}
+	s.value = v
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w, "gauge")
+	for _, k := range sortedKeys(g.series) {
+		s := g.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labelString(s.values), formatFloat(s.value))
+	}
+}
+
+// HistogramVec tracks the distribution of observed values per label set.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, non-cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates and registers a histogram family.
+// buckets must be sorted ascending; +Inf is implicit.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{metricName: name, help: help, labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	Default.register(h)
+	return h
+}
+
+// Observe records v in the series with the given label values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	k := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{
+			values: append([]string(nil), values...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[k] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	for _, k := range sortedKeys(h.series) {
+		s := h.series[k]
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(s.values, "le", formatFloat(b)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(s.values, "le", "+Inf"), s.count)
+		labels := h.labelString(s.values)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, labels, s.count)
+	}
+}
@@ -77,9 +77,8 @@ if [ ! -f ".env" ]; then
     echo "  - CLIPROXY_API_URL"
     echo "  - CLIPROXY_MODEL"
     echo ""
-    echo "Optional (for persistence):"
-    echo "  - UPSTASH_REDIS_REST_URL"
-    echo "  - UPSTASH_REDIS_REST_TOKEN"
+    echo "Optional:"
+    echo "  - REDIS_URL (otherwise state is kept in the zoebot-state volume)"
     echo ""
     echo -e "${NC}"
     echo "After editing .env, run:"
@@ -108,6 +107,10 @@ if docker compose ps | grep -q "running"; then
     echo "  docker compose down         # Stop"
     echo "  docker stats zoebot         # Resource usage"
     echo ""
+    echo "Backup and restore (stop the bot first when not using Redis):"
+    echo "  docker compose run --rm -v \"\$PWD:/backup\" zoebot backup -o /backup/zoebot-backup.json"
+    echo "  docker compose run --rm -v \"\$PWD:/backup\" zoebot restore /backup/zoebot-backup.json"
+    echo ""
     echo "Health check:"
     curl -s http://localhost:8080/health && echo ""
 else